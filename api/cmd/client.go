@@ -0,0 +1,392 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/apisix/manager-api/internal/client"
+)
+
+// clientOptions are the flags of the commands calling the admin API of a
+// running manager-api.
+type clientOptions struct {
+	server   string
+	token    string
+	username string
+	password string
+	output   string
+}
+
+func (o *clientOptions) addFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&o.server, "server", "", "address of the manager-api (default $MANAGER_API_SERVER or "+client.DefaultServer+")")
+	flags.StringVar(&o.token, "token", "", "token of the admin API (default $MANAGER_API_TOKEN)")
+	flags.StringVar(&o.username, "username", "", "user to log in as when no token is given (default $MANAGER_API_USERNAME)")
+	flags.StringVar(&o.password, "password", "", "password of the user (default $MANAGER_API_PASSWORD)")
+	flags.StringVarP(&o.output, "output", "o", client.FormatTable, "output format: table, json or yaml")
+}
+
+// client returns a client of the manager-api, which logs in with the
+// username and password when no token is given. The flags which are not set
+// are read from the environment, so that the token is not shown by the help
+// and the process list.
+func (o *clientOptions) client(ctx context.Context) (*client.Client, error) {
+	if err := client.CheckFormat(o.output); err != nil {
+		return nil, err
+	}
+	server := valueOr(o.server, os.Getenv("MANAGER_API_SERVER"), client.DefaultServer)
+	token := valueOr(o.token, os.Getenv("MANAGER_API_TOKEN"))
+	username := valueOr(o.username, os.Getenv("MANAGER_API_USERNAME"))
+
+	c := client.New(server, token)
+	if token == "" && username != "" {
+		password := valueOr(o.password, os.Getenv("MANAGER_API_PASSWORD"))
+		if err := c.Login(ctx, username, password); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// valueOr returns the first value which is not empty
+func valueOr(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func newClientCommands() []*cobra.Command {
+	opts := &clientOptions{}
+	var cmds []*cobra.Command
+	for _, res := range client.Resources {
+		cmds = append(cmds, newResourceCommand(opts, res))
+	}
+	cmds = append(cmds,
+		newExportCommand(opts),
+		newImportCommand(opts),
+		newDiffCommand(opts),
+	)
+	for _, cmd := range cmds {
+		opts.addFlags(cmd)
+		silence(cmd)
+	}
+	return cmds
+}
+
+// silence leaves the printing of errors to Execute, the errors are usually
+// returned by the admin API instead of caused by a wrong usage.
+func silence(cmd *cobra.Command) {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	for _, sub := range cmd.Commands() {
+		silence(sub)
+	}
+}
+
+func newResourceCommand(opts *clientOptions, res *client.Resource) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   res.Name,
+		Short: fmt.Sprintf("manage the %s of a running manager-api", res.Path),
+	}
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: fmt.Sprintf("list the %s", res.Path),
+		Args:  cobra.NoArgs,
+	}
+	filters := map[string]*string{}
+	for _, f := range res.Filters {
+		filters[f] = list.Flags().String(f, "", fmt.Sprintf("only list the %s matching the %s", res.Path, f))
+	}
+	list.RunE = func(cmd *cobra.Command, args []string) error {
+		c, err := opts.client(cmd.Context())
+		if err != nil {
+			return err
+		}
+		query := url.Values{}
+		for f, v := range filters {
+			if *v != "" {
+				query.Set(f, *v)
+			}
+		}
+		objs, err := c.List(cmd.Context(), res, query)
+		if err != nil {
+			return err
+		}
+		return client.Print(cmd.OutOrStdout(), opts.output, res, objs, false)
+	}
+
+	get := &cobra.Command{
+		Use:   "get ID...",
+		Short: fmt.Sprintf("show the %s with the ids", res.Path),
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			objs := make([]any, 0, len(args))
+			for _, id := range args {
+				obj, err := c.Get(cmd.Context(), res, id)
+				if err != nil {
+					return fmt.Errorf("get %s %s: %w", res.Name, id, err)
+				}
+				objs = append(objs, obj)
+			}
+			return client.Print(cmd.OutOrStdout(), opts.output, res, objs, true)
+		},
+	}
+
+	var file string
+	apply := &cobra.Command{
+		Use:   "apply -f FILE",
+		Short: fmt.Sprintf("create or update the %s of a YAML or JSON manifest", res.Path),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			objs, err := client.ReadManifest(file, cmd.InOrStdin(), res)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			applied := make([]any, 0, len(objs))
+			for i, obj := range objs {
+				ret, err := c.Apply(cmd.Context(), res, obj)
+				if err != nil {
+					return fmt.Errorf("apply %s %d of %d: %w", res.Name, i+1, len(objs), err)
+				}
+				applied = append(applied, ret)
+			}
+			return client.Print(cmd.OutOrStdout(), opts.output, res, applied, true)
+		},
+	}
+	addFileFlag(apply, &file, "manifest to apply, - reads it from stdin")
+
+	del := &cobra.Command{
+		Use:   "delete ID...",
+		Short: fmt.Sprintf("delete the %s with the ids", res.Path),
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if err := c.Delete(cmd.Context(), res, args); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "deleted %s %s\n", res.Name, strings.Join(args, ","))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(list, get, apply, del)
+	return cmd
+}
+
+func addFileFlag(cmd *cobra.Command, file *string, usage string) {
+	cmd.Flags().StringVarP(file, "filename", "f", "", usage)
+	_ = cmd.MarkFlagRequired("filename")
+}
+
+// filterOptions are the filters of an export or an import
+type filterOptions struct {
+	types string
+	ids   string
+	label string
+}
+
+func (f *filterOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.types, "types", "", "comma separated types of the objects, such as route,upstream")
+	cmd.Flags().StringVar(&f.ids, "ids", "", "comma separated ids of the objects")
+	cmd.Flags().StringVar(&f.label, "label", "", "label selector of the objects, such as env:prod")
+}
+
+func newExportCommand(opts *clientOptions) *cobra.Command {
+	var (
+		filter filterOptions
+		file   string
+		format string
+	)
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "export the configuration of a running manager-api",
+		Long: "Export the configuration of a running manager-api as a backup, which can be imported again.\n" +
+			"The objects used by the selected objects are exported with them.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			for k, v := range map[string]string{"types": filter.types, "ids": filter.ids, "label": filter.label, "format": format} {
+				if v != "" {
+					query.Set(k, v)
+				}
+			}
+
+			var w io.Writer = cmd.OutOrStdout()
+			if file != "" && file != "-" {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+			return c.Export(cmd.Context(), query, w)
+		},
+	}
+	filter.addFlags(cmd)
+	cmd.Flags().StringVarP(&file, "filename", "f", "-", "file to write the backup to, - writes it to stdout")
+	cmd.Flags().StringVar(&format, "format", "", "format of the backup: ndjson or tar, a single document by default")
+	return cmd
+}
+
+func newImportCommand(opts *clientOptions) *cobra.Command {
+	var (
+		filter filterOptions
+		file   string
+		format string
+		mode   string
+	)
+	cmd := &cobra.Command{
+		Use:   "import -f FILE",
+		Short: "import a backup into a running manager-api",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				data []byte
+				err  error
+			)
+			name := filepath.Base(file)
+			if file == "-" {
+				data, err = io.ReadAll(cmd.InOrStdin())
+				name = "backup"
+			} else {
+				data, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return err
+			}
+			if format != "" {
+				// the manager-api reads the format from the extension
+				name = strings.TrimSuffix(name, filepath.Ext(name)) + "." + format
+			}
+
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			fields := map[string]string{
+				"mode":  mode,
+				"types": filter.types,
+				"ids":   filter.ids,
+				"label": filter.label,
+			}
+			if client.IsStream(name) {
+				result, err := c.ImportStream(cmd.Context(), name, data, fields)
+				if result != nil {
+					if perr := client.PrintStreamResult(cmd.OutOrStdout(), opts.output, result); perr != nil {
+						return perr
+					}
+				}
+				return err
+			}
+			report, err := c.Import(cmd.Context(), name, data, fields)
+			if report != nil {
+				if perr := client.PrintImportReport(cmd.OutOrStdout(), opts.output, report); perr != nil {
+					return perr
+				}
+			}
+			return err
+		},
+	}
+	filter.addFlags(cmd)
+	addFileFlag(cmd, &file, "backup to import, - reads it from stdin")
+	cmd.Flags().StringVar(&format, "format", "", "format of the backup: ndjson or tar, read from the file extension by default")
+	cmd.Flags().StringVar(&mode, "mode", "", "handling of conflicting objects: return, skip, overwrite or remap (default return)")
+	return cmd
+}
+
+func newDiffCommand(opts *clientOptions) *cobra.Command {
+	var file string
+	names := make([]string, 0, len(client.Resources))
+	for _, res := range client.Resources {
+		names = append(names, res.Name)
+	}
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("diff {%s} -f FILE", strings.Join(names, "|")),
+		Short: "show the changes an apply of a manifest would make",
+		Long: "Show the changes an apply of a manifest would make to a running manager-api.\n" +
+			"The command fails when the manifest differs from the stored objects.",
+		Args:      cobra.ExactValidArgs(1),
+		ValidArgs: names,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var res *client.Resource
+			for _, r := range client.Resources {
+				if r.Name == args[0] {
+					res = r
+				}
+			}
+			objs, err := client.ReadManifest(file, cmd.InOrStdin(), res)
+			if err != nil {
+				return err
+			}
+			c, err := opts.client(cmd.Context())
+			if err != nil {
+				return err
+			}
+			changes, err := client.Diff(cmd.Context(), c, res, objs)
+			if err != nil {
+				return err
+			}
+
+			if opts.output == client.FormatTable {
+				client.PrintChanges(cmd.OutOrStdout(), changes)
+			} else if err := client.Encode(cmd.OutOrStdout(), opts.output, changes); err != nil {
+				return err
+			}
+			changed := 0
+			for _, ch := range changes {
+				if ch.Action != client.ActionUnchanged {
+					changed++
+				}
+			}
+			if changed > 0 {
+				return statusErrorf("%d of %d %s differ", changed, len(changes), res.Path)
+			}
+			return nil
+		},
+	}
+	addFileFlag(cmd, &file, "manifest to compare, - reads it from stdin")
+	return cmd
+}
@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/check"
+	"github.com/apisix/manager-api/internal/log"
+)
+
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "manage the configuration of manager-api",
+	}
+	cmd.AddCommand(newConfigCheckCommand())
+	return cmd
+}
+
+func newConfigCheckCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check",
+		Short: "check the configuration file, the schema and the connections to etcd, LDAP and OIDC",
+		// the failed checks are reported by the command itself
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// errors of the clients are logged to stderr, without creating
+			// the log files of the configuration
+			conf.ErrorLogPath = "/dev/stderr"
+			conf.ErrorLogLevel = "error"
+			log.InitLogger()
+
+			results := check.NewChecker().Run(conf.ConfigPath())
+			if failed := printResults(cmd.OutOrStdout(), results); failed > 0 {
+				return statusErrorf("%d of %d checks failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+}
+
+// printResults prints the results and returns the number of failed checks
+func printResults(w io.Writer, results []*check.Result) int {
+	failed := 0
+	for _, r := range results {
+		switch {
+		case r.Skipped != "":
+			fmt.Fprintf(w, "[SKIP] %s: %s\n", r.Name, r.Skipped)
+		case r.Failed():
+			failed++
+			fmt.Fprintf(w, "[FAIL] %s\n", r.Name)
+			for _, err := range r.Errors {
+				fmt.Fprintf(w, "       - %s\n", err)
+			}
+			fmt.Fprintf(w, "       hint: %s\n", r.Hint)
+		case len(r.Warnings) > 0:
+			fmt.Fprintf(w, "[WARN] %s\n", r.Name)
+			for _, err := range r.Warnings {
+				fmt.Fprintf(w, "       - %s\n", err)
+			}
+			fmt.Fprintf(w, "       hint: %s\n", r.Hint)
+		default:
+			fmt.Fprintf(w, "[OK]   %s\n", r.Name)
+		}
+	}
+	if failed == 0 {
+		fmt.Fprintln(w, "\nconfiguration is valid")
+	}
+	return failed
+}
@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -48,15 +49,35 @@ func init() {
 
 	rootCmd.AddCommand(
 		newVersionCommand(),
+		newConfigCommand(),
 	)
+	rootCmd.AddCommand(newClientCommands()...)
 }
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err)
+		var status *statusError
+		if errors.As(err, &status) {
+			os.Exit(1)
+		}
 	}
 }
 
+// statusError is returned by the commands whose result is checked by scripts,
+// like config check and diff, Execute exits with a non-zero status for it.
+type statusError struct {
+	msg string
+}
+
+func statusErrorf(format string, args ...any) error {
+	return &statusError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *statusError) Error() string {
+	return e.msg
+}
+
 func manageAPI() error {
 	conf.InitConf()
 	log.InitLogger()
@@ -76,18 +97,25 @@ func manageAPI() error {
 	// Signal received to the process externally.
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	select {
-	case sig := <-quit:
-		log.Infof("The Manager API server receive %s and start shutting down", sig.String())
-		stopEtcdConnectionChecker()
-		s.Stop()
-		log.Infof("See you next time!")
-	case err := <-errSig:
-		log.Errorf("The Manager API server start failed: %s", err.Error())
-		return err
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	for {
+		select {
+		case <-reload:
+			log.Infof("The Manager API server receive SIGHUP and reload the configuration")
+			s.Reload()
+		case sig := <-quit:
+			log.Infof("The Manager API server receive %s and start shutting down", sig.String())
+			stopEtcdConnectionChecker()
+			s.Stop()
+			log.Infof("See you next time!")
+			return nil
+		case err := <-errSig:
+			log.Errorf("The Manager API server start failed: %s", err.Error())
+			return err
+		}
 	}
-	return nil
 }
 
 func etcdConnectionChecker() context.CancelFunc {
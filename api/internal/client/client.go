@@ -0,0 +1,322 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/migrate"
+)
+
+// DefaultServer is the address of a manager-api listening with the default
+// configuration.
+const DefaultServer = "http://127.0.0.1:9000"
+
+// requestTimeout bounds the calls of the CRUD APIs, imports and exports take
+// as long as the configuration is large.
+const requestTimeout = 30 * time.Second
+
+// Client calls the admin API of a running manager-api.
+type Client struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+// New returns a client of the manager-api at the server address which
+// authenticates with the token, the token can be set later by Login.
+func New(server, token string) *Client {
+	return &Client{
+		server: strings.TrimSuffix(server, "/"),
+		token:  token,
+		http:   &http.Client{},
+	}
+}
+
+// Error is a request rejected by the manager-api, Data holds the data of the
+// response, such as the report of a failed import.
+type Error struct {
+	StatusCode int
+	Code       int
+	Message    string
+	Data       json.RawMessage
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("%s (status %d, code %d)", e.Message, e.StatusCode, e.Code)
+}
+
+// IsNotFound reports whether the error is a request for a missing object.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// response is the envelope of the responses of the admin API
+type response struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+type listData struct {
+	Rows      []json.RawMessage `json:"rows"`
+	TotalSize int               `json:"total_size"`
+}
+
+// Login exchanges the username and password for the token of the client.
+func (c *Client) Login(ctx context.Context, username, password string) error {
+	var session struct {
+		Token string `json:"token"`
+	}
+	input := map[string]string{"username": username, "password": password}
+	if err := c.call(ctx, http.MethodPost, "/apisix/admin/user/login", nil, input, &session); err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	c.token = session.Token
+	return nil
+}
+
+// List returns every object of the resource matching the query, the
+// parameters of the query are the filters of the list API of the resource.
+func (c *Client) List(ctx context.Context, res *Resource, query url.Values) ([]any, error) {
+	var data listData
+	if err := c.call(ctx, http.MethodGet, res.path(""), query, nil, &data); err != nil {
+		return nil, err
+	}
+	objs := make([]any, 0, len(data.Rows))
+	for _, row := range data.Rows {
+		obj := res.New()
+		if err := json.Unmarshal(row, obj); err != nil {
+			return nil, fmt.Errorf("decode %s: %w", res.Name, err)
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// Get returns the object of the resource with the id.
+func (c *Client) Get(ctx context.Context, res *Resource, id string) (any, error) {
+	obj := res.New()
+	if err := c.call(ctx, http.MethodGet, res.path(id), nil, nil, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Apply creates or updates the object with its id, an object without an id
+// is created with an id generated by the manager-api.
+func (c *Client) Apply(ctx context.Context, res *Resource, obj any) (any, error) {
+	method, path := http.MethodPost, res.path("")
+	if id := ID(obj); id != "" {
+		method, path = http.MethodPut, res.path(id)
+	}
+	ret := res.New()
+	if err := c.call(ctx, method, path, nil, obj, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Delete deletes the objects of the resource with the ids.
+func (c *Client) Delete(ctx context.Context, res *Resource, ids []string) error {
+	return c.call(ctx, http.MethodDelete, res.path(strings.Join(ids, ",")), nil, nil, nil)
+}
+
+// Export writes the backup of the objects selected by the query to w, the
+// query holds the filters and the format of the export API.
+func (c *Client) Export(ctx context.Context, query url.Values, w io.Writer) error {
+	resp, err := c.do(ctx, http.MethodGet, "/apisix/admin/migrate/export", query, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return decode(resp, nil)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// IsStream reports whether the manager-api imports the backup with the file
+// name as an NDJSON or tar stream, whose result is a migrate.StreamResult.
+func IsStream(name string) bool {
+	return strings.HasSuffix(name, ".ndjson") || strings.HasSuffix(name, ".tar")
+}
+
+// Import uploads the backup with the fields of the import API, such as the
+// conflict mode and the filters. The report is returned for a failed import
+// as well, when the manager-api produced one.
+func (c *Client) Import(ctx context.Context, name string, backup []byte, fields map[string]string) (*migrate.ImportReport, error) {
+	report := &migrate.ImportReport{}
+	ok, err := c.upload(ctx, name, backup, fields, report)
+	if !ok {
+		return nil, err
+	}
+	return report, err
+}
+
+// ImportStream uploads an NDJSON or tar backup, see IsStream, like Import.
+func (c *Client) ImportStream(ctx context.Context, name string, backup []byte, fields map[string]string) (*migrate.StreamResult, error) {
+	result := &migrate.StreamResult{}
+	ok, err := c.upload(ctx, name, backup, fields, result)
+	if !ok {
+		return nil, err
+	}
+	return result, err
+}
+
+// upload sends the backup to the import API and decodes the result into
+// out, reporting whether it did, failed imports have a result as well. The
+// manager-api reads the format of the backup from the extension of the name.
+func (c *Client) upload(ctx context.Context, name string, backup []byte, fields map[string]string, out any) (bool, error) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range fields {
+		if v == "" {
+			continue
+		}
+		if err := mw.WriteField(k, v); err != nil {
+			return false, err
+		}
+	}
+	fw, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		return false, err
+	}
+	if _, err := fw.Write(backup); err != nil {
+		return false, err
+	}
+	if err := mw.Close(); err != nil {
+		return false, err
+	}
+
+	err = c.send(ctx, http.MethodPost, "/apisix/admin/migrate/import", nil, requestBody{body, mw.FormDataContentType()}, out)
+	if e, ok := err.(*Error); ok && len(e.Data) > 0 && string(e.Data) != "null" {
+		return json.Unmarshal(e.Data, out) == nil, err
+	}
+	return err == nil, err
+}
+
+// requestBody is a body which is sent as is instead of encoded as JSON
+type requestBody struct {
+	io.Reader
+	contentType string
+}
+
+// call sends a request of the CRUD APIs, which is bounded by requestTimeout.
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, input, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	return c.send(ctx, method, path, query, input, out)
+}
+
+// send sends the request and decodes the data of the response into out,
+// input is encoded as JSON unless it is a requestBody.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, input, out any) error {
+	var (
+		body        io.Reader
+		contentType string
+	)
+	switch in := input.(type) {
+	case nil:
+	case requestBody:
+		body, contentType = in.Reader, in.contentType
+	default:
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body, contentType = bytes.NewReader(data), "application/json"
+	}
+
+	resp, err := c.do(ctx, method, path, query, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decode(resp, out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	u := c.server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+	return c.http.Do(req)
+}
+
+// decode decodes the envelope of the response, a response with a non-zero
+// code or an error status is returned as an *Error.
+func decode(resp *http.Response, out any) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	r := &response{}
+	if err := json.Unmarshal(data, r); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+		}
+		return fmt.Errorf("invalid response of %s: %w", resp.Request.URL.Path, err)
+	}
+	if r.Code != 0 || resp.StatusCode >= http.StatusBadRequest {
+		return &Error{StatusCode: resp.StatusCode, Code: r.Code, Message: r.Message, Data: r.Data}
+	}
+	if out == nil || len(r.Data) == 0 || string(r.Data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(r.Data, out)
+}
+
+// ID returns the id of an entity, an empty string when it has none.
+func ID(obj any) string {
+	info, ok := obj.(entity.GetBaseInfo)
+	if !ok || info.GetBaseInfo().ID == nil {
+		return ""
+	}
+	switch id := info.GetBaseInfo().ID.(type) {
+	case float64:
+		// ids decoded from JSON
+		return fmt.Sprintf("%.0f", id)
+	default:
+		return fmt.Sprint(id)
+	}
+}
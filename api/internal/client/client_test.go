@@ -0,0 +1,234 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+type request struct {
+	Method string
+	URL    string
+	Token  string
+	Body   string
+}
+
+// newServer returns a fake admin API which records the requests and answers
+// with the response of the path, requests of unknown paths get a 404.
+func newServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]request) {
+	var requests []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		requests = append(requests, request{
+			Method: r.Method,
+			URL:    r.URL.String(),
+			Token:  r.Header.Get("Authorization"),
+			Body:   string(body),
+		})
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			resp = `{"code":10001,"message":"data not found"}`
+		}
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestClient_Login(t *testing.T) {
+	srv, requests := newServer(t, map[string]string{
+		"POST /apisix/admin/user/login": `{"code":0,"data":{"token":"t0ken"}}`,
+		"GET /apisix/admin/routes/r1":   `{"code":0,"data":{"id":"r1","uri":"/a"}}`,
+	})
+
+	ctx := context.Background()
+	c := New(srv.URL+"/", "")
+	assert.NoError(t, c.Login(ctx, "admin", "admin"))
+	_, err := c.Get(ctx, Route, "r1")
+	assert.NoError(t, err)
+
+	assert.Equal(t, `{"password":"admin","username":"admin"}`, (*requests)[0].Body)
+	assert.Equal(t, "t0ken", (*requests)[1].Token)
+}
+
+func TestClient_ListAndGet(t *testing.T) {
+	srv, requests := newServer(t, map[string]string{
+		"GET /apisix/admin/routes":    `{"code":0,"data":{"rows":[{"id":"r1","uri":"/a","upstream_id":"u1","status":1},{"id":2,"uris":["/b","/c"]}],"total_size":2}}`,
+		"GET /apisix/admin/upstreams": `{"code":0,"data":{"rows":[{"id":"u1","type":"roundrobin","nodes":[{"host":"127.0.0.1","port":80,"weight":1}]}],"total_size":1}}`,
+	})
+	ctx := context.Background()
+	c := New(srv.URL, "token")
+
+	routes, err := c.List(ctx, Route, url.Values{"label": []string{"env:prod"}})
+	assert.NoError(t, err)
+	assert.Len(t, routes, 2)
+	assert.Equal(t, "/apisix/admin/routes?label=env%3Aprod", (*requests)[0].URL)
+	assert.Equal(t, "token", (*requests)[0].Token)
+	assert.Equal(t, []string{"r1", "-", "/a", "-", "upstream:u1", "enabled"}, Route.Row(routes[0]))
+	assert.Equal(t, []string{"2", "-", "/b,/c", "-", "-", "disabled"}, Route.Row(routes[1]))
+
+	upstreams, err := c.List(ctx, Upstream, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"u1", "-", "roundrobin", "127.0.0.1:80"}, Upstream.Row(upstreams[0]))
+
+	_, err = c.Get(ctx, Route, "missing")
+	assert.True(t, IsNotFound(err))
+	assert.EqualError(t, err, "data not found (status 404, code 10001)")
+}
+
+func TestClient_Apply(t *testing.T) {
+	srv, requests := newServer(t, map[string]string{
+		"PUT /apisix/admin/routes/r1": `{"code":0,"data":{"id":"r1","uri":"/a"}}`,
+		"POST /apisix/admin/routes":   `{"code":0,"data":{"id":"4711","uri":"/b"}}`,
+	})
+	ctx := context.Background()
+	c := New(srv.URL, "token")
+
+	ret, err := c.Apply(ctx, Route, &entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, URI: "/a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "r1", ID(ret))
+
+	ret, err = c.Apply(ctx, Route, &entity.Route{URI: "/b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "4711", ID(ret))
+
+	assert.Equal(t, "PUT", (*requests)[0].Method)
+	assert.Equal(t, "POST", (*requests)[1].Method)
+	assert.JSONEq(t, `{"id":null,"uri":"/b","name":"","status":0}`, (*requests)[1].Body)
+}
+
+func TestClient_Delete(t *testing.T) {
+	srv, requests := newServer(t, map[string]string{
+		"DELETE /apisix/admin/ssl/s1,s2": `{"code":0,"data":null}`,
+	})
+	ctx := context.Background()
+	c := New(srv.URL, "token")
+
+	assert.NoError(t, c.Delete(ctx, SSL, []string{"s1", "s2"}))
+	assert.Equal(t, "/apisix/admin/ssl/s1,s2", (*requests)[0].URL)
+}
+
+func TestClient_ExportImport(t *testing.T) {
+	var imported []byte
+	var fields url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/apisix/admin/migrate/export":
+			assert.Equal(t, "routes", r.URL.Query().Get("types"))
+			_, _ = w.Write([]byte("backup"))
+		case "/apisix/admin/migrate/import":
+			assert.NoError(t, r.ParseMultipartForm(1<<20))
+			fields = r.MultipartForm.Value
+			file, header, err := r.FormFile("file")
+			assert.NoError(t, err)
+			imported, _ = io.ReadAll(file)
+			if header.Filename == "backup.ndjson" {
+				_, _ = w.Write([]byte(`{"code":0,"data":{"created":2,"updated":1,"filtered":3}}`))
+				return
+			}
+			assert.Equal(t, "backup.bak", header.Filename)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"code":    20001,
+				"message": "import conflicts",
+				"data":    map[string]any{"total": 1, "failed": 1, "conflicts": []any{map[string]any{"type": "routes", "key": "r1"}}},
+			})
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	c := New(srv.URL, "token")
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, c.Export(ctx, url.Values{"types": []string{"routes"}}, out))
+	assert.Equal(t, "backup", out.String())
+
+	report, err := c.Import(ctx, "backup.bak", out.Bytes(), map[string]string{"mode": "skip", "ids": ""})
+	assert.EqualError(t, err, "import conflicts (status 200, code 20001)")
+	assert.Equal(t, "backup", string(imported))
+	assert.Equal(t, url.Values{"mode": []string{"skip"}}, fields)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, "r1", report.Conflicts[0].Key)
+
+	out.Reset()
+	assert.NoError(t, PrintImportReport(out, FormatTable, report))
+	assert.Equal(t, "TYPE     KEY   CONFLICT   WITH\nroutes   r1               -\n\n1 objects, 1 failed, 1 conflicts\n", out.String())
+
+	assert.True(t, IsStream("backup.ndjson"))
+	assert.False(t, IsStream("backup.bak"))
+	result, err := c.ImportStream(ctx, "backup.ndjson", []byte("{}"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, 3, result.Filtered)
+
+	out.Reset()
+	assert.NoError(t, PrintStreamResult(out, FormatTable, result))
+	assert.Equal(t, "2 created, 1 updated, 0 skipped, 3 filtered, 0 conflicts\n", out.String())
+}
+
+func TestDiff(t *testing.T) {
+	srv, _ := newServer(t, map[string]string{
+		"GET /apisix/admin/ssl/s1": `{"code":0,"data":{"id":"s1","snis":["a.com"],"status":1,"create_time":1}}`,
+		"GET /apisix/admin/ssl/s2": `{"code":0,"data":{"id":"s2","snis":["b.com"],"status":1}}`,
+	})
+	ctx := context.Background()
+	c := New(srv.URL, "token")
+
+	objs, err := DecodeManifest([]byte(`
+- id: s1
+  snis: [a.com]
+  key: secret
+  status: 1
+- id: s2
+  snis: [b.com, c.com]
+  status: 1
+- id: s3
+  snis: [d.com]
+- snis: [e.com]
+`), SSL)
+	assert.NoError(t, err)
+
+	changes, err := Diff(ctx, c, SSL, objs)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 4)
+	assert.Equal(t, ActionUnchanged, changes[0].Action)
+	assert.Equal(t, ActionUpdate, changes[1].Action)
+	assert.Equal(t, "snis", changes[1].Fields[0].Path)
+	assert.Equal(t, ActionCreate, changes[2].Action)
+	assert.Equal(t, ActionCreate, changes[3].Action)
+
+	out := &bytes.Buffer{}
+	PrintChanges(out, changes)
+	assert.Equal(t, `  ssl s1 (unchanged)
+~ ssl s2
+    snis: ["b.com"] -> ["b.com","c.com"]
++ ssl s3
++ ssl (new)
+`, out.String())
+}
@@ -0,0 +1,122 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/apisix/manager-api/internal/handler/data_loader/reconcile"
+)
+
+// Change actions of a diff.
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+)
+
+// Change is the difference between an object of a manifest and the stored
+// object with its id.
+type Change struct {
+	Type   string                `json:"type"`
+	ID     string                `json:"id,omitempty"`
+	Action string                `json:"action"`
+	Fields []reconcile.FieldDiff `json:"fields,omitempty"`
+}
+
+// Diff compares the objects of a manifest with the stored objects, objects
+// without an id or with an unknown id would be created by an apply.
+func Diff(ctx context.Context, c *Client, res *Resource, objs []any) ([]*Change, error) {
+	changes := make([]*Change, 0, len(objs))
+	for _, obj := range objs {
+		change := &Change{Type: res.Name, ID: ID(obj), Action: ActionCreate}
+		changes = append(changes, change)
+		if change.ID == "" {
+			continue
+		}
+
+		current, err := c.Get(ctx, res, change.ID)
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fields, err := reconcile.Diff(current, obj)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			// the ids only differ by their type, such as 1 and "1"
+			if f.Path != "id" && !hidden(res, f.Path) {
+				change.Fields = append(change.Fields, f)
+			}
+		}
+		change.Action = ActionUnchanged
+		if len(change.Fields) > 0 {
+			change.Action = ActionUpdate
+		}
+	}
+	return changes, nil
+}
+
+func hidden(res *Resource, path string) bool {
+	for _, h := range res.Hidden {
+		if h == path {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintChanges writes the changes in a readable form, with the changed
+// fields of the updated objects.
+func PrintChanges(w io.Writer, changes []*Change) {
+	for _, c := range changes {
+		id := c.ID
+		if id == "" {
+			id = "(new)"
+		}
+		switch c.Action {
+		case ActionCreate:
+			fmt.Fprintf(w, "+ %s %s\n", c.Type, id)
+		case ActionUpdate:
+			fmt.Fprintf(w, "~ %s %s\n", c.Type, id)
+			for _, f := range c.Fields {
+				fmt.Fprintf(w, "    %s: %s -> %s\n", f.Path, value(f.Before), value(f.After))
+			}
+		default:
+			fmt.Fprintf(w, "  %s %s (unchanged)\n", c.Type, id)
+		}
+	}
+}
+
+// value formats a field value as JSON, a missing value as <none>
+func value(v any) string {
+	if v == nil {
+		return "<none>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,105 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// ReadManifest reads the objects of the resource from a file, "-" reads them
+// from stdin.
+func ReadManifest(file string, stdin io.Reader, res *Resource) ([]any, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DecodeManifest(data, res)
+}
+
+// DecodeManifest decodes the objects of the resource from YAML or JSON. A
+// manifest holds an object or a list of them, several YAML documents
+// separated by "---" can be given at once.
+func DecodeManifest(data []byte, res *Resource) ([]any, error) {
+	var objs []any
+	for i, doc := range splitDocuments(data) {
+		js, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i+1, err)
+		}
+		js = bytes.TrimSpace(js)
+		if len(js) == 0 || string(js) == "null" {
+			continue
+		}
+
+		items := []json.RawMessage{js}
+		if js[0] == '[' {
+			if err := json.Unmarshal(js, &items); err != nil {
+				return nil, fmt.Errorf("document %d: %w", i+1, err)
+			}
+		}
+		for _, item := range items {
+			obj := res.New()
+			dec := json.NewDecoder(bytes.NewReader(item))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(obj); err != nil {
+				return nil, fmt.Errorf("document %d: invalid %s: %w", i+1, res.Name, err)
+			}
+			objs = append(objs, obj)
+		}
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("no %s found in the manifest", res.Name)
+	}
+	return objs, nil
+}
+
+// splitDocuments splits a YAML stream at the "---" separators
+func splitDocuments(data []byte) [][]byte {
+	var (
+		docs [][]byte
+		doc  bytes.Buffer
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "---") && strings.TrimSpace(line[3:]) == "" {
+			docs = append(docs, append([]byte(nil), doc.Bytes()...))
+			doc.Reset()
+			continue
+		}
+		doc.WriteString(line)
+		doc.WriteByte('\n')
+	}
+	return append(docs, doc.Bytes())
+}
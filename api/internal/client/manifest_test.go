@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package client
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+func TestDecodeManifest(t *testing.T) {
+	tests := []struct {
+		caseDesc string
+		manifest string
+		wantIDs  []string
+		wantErr  string
+	}{
+		{
+			caseDesc: "json object",
+			manifest: `{"id": "r1", "uri": "/a"}`,
+			wantIDs:  []string{"r1"},
+		},
+		{
+			caseDesc: "yaml list",
+			manifest: "- id: r1\n  uri: /a\n- id: r2\n  uri: /b\n",
+			wantIDs:  []string{"r1", "r2"},
+		},
+		{
+			caseDesc: "yaml documents",
+			manifest: "---\nid: r1\nuri: /a\n---\n# empty\n---\n- id: r2\n  uri: /b\n",
+			wantIDs:  []string{"r1", "r2"},
+		},
+		{
+			caseDesc: "unknown field",
+			manifest: "id: r1\nurl: /a\n",
+			wantErr:  `document 1: invalid route: json: unknown field "url"`,
+		},
+		{
+			caseDesc: "no objects",
+			manifest: "---\n",
+			wantErr:  "no route found in the manifest",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.caseDesc, func(t *testing.T) {
+			objs, err := DecodeManifest([]byte(tc.manifest), Route)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			var ids []string
+			for _, obj := range objs {
+				ids = append(ids, ID(obj))
+			}
+			assert.Equal(t, tc.wantIDs, ids)
+		})
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	objs, err := ReadManifest("-", strings.NewReader("id: u1\nnodes:\n  127.0.0.1:80: 1\n"), Upstream)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:80", Upstream.Row(objs[0])[3])
+
+	file := filepath.Join(t.TempDir(), "ssl.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`[{"id": "s1", "snis": ["a.com"]}]`), 0600))
+	objs, err = ReadManifest(file, nil, SSL)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.com"}, objs[0].(*entity.SSL).Snis)
+}
+
+func TestPrint(t *testing.T) {
+	routes := []any{&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, Name: "a", URI: "/a", Status: 1}}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, Print(out, FormatTable, Route, routes, false))
+	assert.Equal(t, "ID   NAME   URIS   HOSTS   UPSTREAM   STATUS\nr1   a      /a     -       -          enabled\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, Print(out, FormatYAML, Route, routes, true))
+	assert.Equal(t, "id: r1\nname: a\nstatus: 1\nuri: /a\n", out.String())
+
+	// the output of a get can be applied again
+	objs, err := DecodeManifest(out.Bytes(), Route)
+	assert.NoError(t, err)
+	assert.Equal(t, routes, objs)
+
+	out.Reset()
+	assert.NoError(t, Print(out, FormatJSON, Route, routes, false))
+	assert.JSONEq(t, `[{"id":"r1","name":"a","uri":"/a","status":1}]`, out.String())
+
+	assert.EqualError(t, CheckFormat("xml"), "invalid output format: xml, expected one of table, json, yaml")
+}
@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/apisix/manager-api/internal/core/migrate"
+)
+
+// Output formats of the commands.
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+// CheckFormat returns an error for an unknown output format.
+func CheckFormat(format string) error {
+	switch format {
+	case FormatTable, FormatJSON, FormatYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid output format: %s, expected one of table, json, yaml", format)
+}
+
+// Print writes the objects of the resource in the format, a single object is
+// written as is instead of as a list by JSON and YAML so that the output can
+// be applied again.
+func Print(w io.Writer, format string, res *Resource, objs []any, single bool) error {
+	if format == FormatTable {
+		rows := make([][]string, 0, len(objs))
+		for _, obj := range objs {
+			rows = append(rows, res.Row(obj))
+		}
+		return PrintTable(w, res.Columns, rows)
+	}
+
+	var v any = objs
+	if single && len(objs) == 1 {
+		v = objs[0]
+	}
+	return Encode(w, format, v)
+}
+
+// Encode writes the value as indented JSON or as YAML.
+func Encode(w io.Writer, format string, v any) error {
+	var (
+		data []byte
+		err  error
+	)
+	if format == FormatYAML {
+		data, err = yaml.Marshal(v)
+	} else {
+		data, err = json.MarshalIndent(v, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// PrintTable writes the rows aligned under the headers.
+func PrintTable(w io.Writer, headers []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// PrintImportReport writes the report of an import, the table format lists
+// the conflicts and the outcome of each object.
+func PrintImportReport(w io.Writer, format string, report *migrate.ImportReport) error {
+	if format != FormatTable {
+		return Encode(w, format, report)
+	}
+
+	if err := printConflicts(w, report.Conflicts); err != nil {
+		return err
+	}
+	if len(report.Items) > 0 {
+		rows := make([][]string, 0, len(report.Items))
+		for _, item := range report.Items {
+			rows = append(rows, []string{string(item.Type), item.Key, dash(item.NewKey), string(item.Status), dash(item.Error)})
+		}
+		if err := PrintTable(w, []string{"TYPE", "KEY", "NEW_KEY", "STATUS", "ERROR"}, rows); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%d objects, %d failed, %d conflicts\n", report.Total, report.Failed, len(report.Conflicts))
+	return nil
+}
+
+// PrintStreamResult writes the result of an NDJSON or tar import like
+// PrintImportReport.
+func PrintStreamResult(w io.Writer, format string, result *migrate.StreamResult) error {
+	if format != FormatTable {
+		return Encode(w, format, result)
+	}
+
+	if err := printConflicts(w, result.Conflicts); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%d created, %d updated, %d skipped, %d filtered, %d conflicts\n",
+		result.Created, result.Updated, result.Skipped, result.Filtered, len(result.Conflicts))
+	return nil
+}
+
+func printConflicts(w io.Writer, conflicts []migrate.Conflict) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	rows := make([][]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		rows = append(rows, []string{string(c.Type), c.Key, string(c.Reason), dash(c.With)})
+	}
+	if err := PrintTable(w, []string{"TYPE", "KEY", "CONFLICT", "WITH"}, rows); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
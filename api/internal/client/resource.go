@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+// Resource describes a resource of the admin API which can be managed by the
+// client.
+type Resource struct {
+	// Name is the singular name used by the commands
+	Name string
+	// Path is the name of the resource in the admin API
+	Path string
+	// Filters are the query parameters of the list API
+	Filters []string
+	// Hidden are the fields the admin API never returns, they are ignored
+	// when objects are compared.
+	Hidden []string
+	// New returns an empty entity of the resource
+	New func() any
+	// Columns are the headers of the table output, Row returns the cells
+	// of an object.
+	Columns []string
+	Row     func(obj any) []string
+}
+
+func (r *Resource) path(id string) string {
+	if id == "" {
+		return "/apisix/admin/" + r.Path
+	}
+	return "/apisix/admin/" + r.Path + "/" + id
+}
+
+var (
+	Route = &Resource{
+		Name:    "route",
+		Path:    "routes",
+		Filters: []string{"name", "uri", "host", "label", "status"},
+		New:     func() any { return &entity.Route{} },
+		Columns: []string{"ID", "NAME", "URIS", "HOSTS", "UPSTREAM", "STATUS"},
+		Row: func(obj any) []string {
+			r := obj.(*entity.Route)
+			return []string{
+				ID(r),
+				dash(r.Name),
+				join(r.URI, r.Uris),
+				join(r.Host, r.Hosts),
+				routeUpstream(r),
+				status(int(r.Status)),
+			}
+		},
+	}
+
+	Upstream = &Resource{
+		Name:    "upstream",
+		Path:    "upstreams",
+		Filters: []string{"name"},
+		New:     func() any { return &entity.Upstream{} },
+		Columns: []string{"ID", "NAME", "TYPE", "NODES"},
+		Row: func(obj any) []string {
+			u := obj.(*entity.Upstream)
+			return []string{ID(u), dash(u.Name), dash(u.Type), nodes(u.Nodes, u.ServiceName)}
+		},
+	}
+
+	SSL = &Resource{
+		Name:    "ssl",
+		Path:    "ssl",
+		Filters: []string{"sni"},
+		// the private keys are removed from the responses
+		Hidden:  []string{"key", "keys"},
+		New:     func() any { return &entity.SSL{} },
+		Columns: []string{"ID", "SNIS", "STATUS", "VALIDITY_END"},
+		Row: func(obj any) []string {
+			s := obj.(*entity.SSL)
+			end := "-"
+			if s.ValidityEnd > 0 {
+				end = time.Unix(s.ValidityEnd, 0).UTC().Format(time.RFC3339)
+			}
+			return []string{ID(s), join(s.Sni, s.Snis), status(s.Status), end}
+		},
+	}
+)
+
+// Resources are the resources managed by the client.
+var Resources = []*Resource{Route, Upstream, SSL}
+
+// dash fills the empty cells of a table
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func join(one string, many []string) string {
+	if len(many) == 0 {
+		return dash(one)
+	}
+	return strings.Join(many, ",")
+}
+
+// status formats the status of routes and SSL, 1 enables them
+func status(s int) string {
+	if s == 1 {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+func routeUpstream(r *entity.Route) string {
+	switch {
+	case r.UpstreamID != nil:
+		return fmt.Sprintf("upstream:%v", r.UpstreamID)
+	case r.Upstream != nil:
+		return nodes(r.Upstream.Nodes, r.Upstream.ServiceName)
+	case r.ServiceID != nil:
+		return fmt.Sprintf("service:%v", r.ServiceID)
+	}
+	return "-"
+}
+
+// nodes formats the nodes of an upstream, which are either a list of nodes
+// or a map of host:port to weight, an upstream using service discovery has
+// a service name instead.
+func nodes(n any, serviceName string) string {
+	var addrs []string
+	switch v := n.(type) {
+	case []any:
+		for _, node := range v {
+			if m, ok := node.(map[string]any); ok {
+				addrs = append(addrs, fmt.Sprintf("%v:%v", m["host"], m["port"]))
+			}
+		}
+	case map[string]any:
+		for addr := range v {
+			addrs = append(addrs, addr)
+		}
+		sort.Strings(addrs)
+	}
+	if len(addrs) == 0 {
+		if serviceName != "" {
+			return "service:" + serviceName
+		}
+		return "-"
+	}
+	return strings.Join(addrs, ",")
+}
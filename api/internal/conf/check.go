@@ -0,0 +1,317 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ghodss/yaml"
+	"github.com/tidwall/gjson"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// configSchema is the JSON schema of conf.yaml. Strings accept any scalar,
+// like the decoding of the configuration does.
+const configSchema = `{
+  "definitions": {
+    "str": {"type": ["string", "number", "boolean"]},
+    "port": {"type": "integer", "minimum": 1, "maximum": 65535},
+    "mtls": {
+      "type": "object",
+      "properties": {
+        "ca_file": {"$ref": "#/definitions/str"},
+        "cert_file": {"$ref": "#/definitions/str"},
+        "key_file": {"$ref": "#/definitions/str"}
+      },
+      "additionalProperties": false
+    }
+  },
+  "type": "object",
+  "properties": {
+    "conf": {
+      "type": "object",
+      "properties": {
+        "listen": {
+          "type": "object",
+          "properties": {"host": {"type": "string"}, "port": {"$ref": "#/definitions/port"}},
+          "additionalProperties": false
+        },
+        "ssl": {
+          "type": "object",
+          "properties": {
+            "host": {"type": "string"},
+            "port": {"$ref": "#/definitions/port"},
+            "cert": {"type": "string"},
+            "key": {"type": "string"}
+          },
+          "additionalProperties": false
+        },
+        "allow_list": {"type": "array", "items": {"type": "string"}},
+        "etcd": {
+          "type": "object",
+          "properties": {
+            "endpoints": {"type": "array", "items": {"type": "string"}, "minItems": 1},
+            "username": {"$ref": "#/definitions/str"},
+            "password": {"$ref": "#/definitions/str"},
+            "mtls": {"$ref": "#/definitions/mtls"},
+            "prefix": {"type": "string", "pattern": "^/"}
+          },
+          "additionalProperties": false
+        },
+        "log": {
+          "type": "object",
+          "properties": {
+            "error_log": {
+              "type": "object",
+              "properties": {
+                "level": {"enum": ["debug", "info", "warn", "error", "panic", "fatal"]},
+                "file_path": {"type": "string"}
+              },
+              "additionalProperties": false
+            },
+            "access_log": {
+              "type": "object",
+              "properties": {"file_path": {"type": "string"}},
+              "additionalProperties": false
+            }
+          },
+          "additionalProperties": false
+        },
+        "max_cpu": {"type": "integer"},
+        "security": {
+          "type": "object",
+          "properties": {
+            "access_control_allow_origin": {"$ref": "#/definitions/str"},
+            "access_control_allow_credentials": {"$ref": "#/definitions/str"},
+            "access_control_allow_headers": {"$ref": "#/definitions/str"},
+            "access_control-allow_methods": {"$ref": "#/definitions/str"},
+            "x_frame_options": {"$ref": "#/definitions/str"},
+            "content_security_policy": {"$ref": "#/definitions/str"}
+          },
+          "additionalProperties": false
+        }
+      },
+      "additionalProperties": false
+    },
+    "authentication": {
+      "type": "object",
+      "properties": {
+        "secret": {"$ref": "#/definitions/str"},
+        "expire_time": {"type": "integer", "minimum": 1},
+        "users": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "properties": {
+              "username": {"$ref": "#/definitions/str"},
+              "password": {"$ref": "#/definitions/str"}
+            },
+            "required": ["username", "password"],
+            "additionalProperties": false
+          }
+        }
+      },
+      "additionalProperties": false
+    },
+    "plugins": {"type": "array", "items": {"type": "string"}},
+    "oidc": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean"},
+        "expire_time": {"type": "integer", "minimum": 1},
+        "client_id": {"$ref": "#/definitions/str"},
+        "client_secret": {"$ref": "#/definitions/str"},
+        "auth_url": {"type": "string"},
+        "token_url": {"type": "string"},
+        "user_info_url": {"type": "string"},
+        "redirect_url": {"type": "string"},
+        "scope": {"type": "string"}
+      },
+      "additionalProperties": false
+    },
+    "ldap": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean"},
+        "host": {"type": "string"},
+        "base_dn": {"$ref": "#/definitions/str"},
+        "bind_dn": {"$ref": "#/definitions/str"},
+        "bind_password": {"$ref": "#/definitions/str"},
+        "filter": {"type": "string"},
+        "start_tls": {"type": "boolean"}
+      },
+      "additionalProperties": false
+    },
+    "backup": {
+      "type": "object",
+      "properties": {
+        "enabled": {"type": "boolean"},
+        "interval": {"type": "string"},
+        "target": {"enum": ["local", "s3"]},
+        "local": {
+          "type": "object",
+          "properties": {"dir": {"type": "string"}},
+          "additionalProperties": false
+        },
+        "s3": {
+          "type": "object",
+          "properties": {
+            "endpoint": {"type": "string"},
+            "region": {"type": "string"},
+            "bucket": {"type": "string"},
+            "prefix": {"type": "string"},
+            "access_key": {"$ref": "#/definitions/str"},
+            "secret_key": {"$ref": "#/definitions/str"}
+          },
+          "additionalProperties": false
+        },
+        "retention": {
+          "type": "object",
+          "properties": {
+            "max_count": {"type": "integer", "minimum": 0},
+            "max_age": {"type": "string"}
+          },
+          "additionalProperties": false
+        }
+      },
+      "additionalProperties": false
+    },
+    "clusters": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string", "minLength": 1},
+          "endpoints": {"type": "array", "items": {"type": "string"}, "minItems": 1},
+          "username": {"$ref": "#/definitions/str"},
+          "password": {"$ref": "#/definitions/str"},
+          "mtls": {"$ref": "#/definitions/mtls"},
+          "prefix": {"type": "string", "pattern": "^/"}
+        },
+        "required": ["name", "endpoints"],
+        "additionalProperties": false
+      }
+    }
+  },
+  "additionalProperties": false
+}`
+
+// ConfigPath returns the path of the config file manager-api reads
+func ConfigPath() string {
+	if ConfigFile != "" {
+		return ConfigFile
+	}
+	name := "conf.yaml"
+	if profile := os.Getenv("APISIX_PROFILE"); profile != "" {
+		name = "conf" + "-" + profile + ".yaml"
+	}
+	return filepath.Join(WorkDir, "conf", name)
+}
+
+// CheckFile validates the config file against the schema of conf.yaml,
+// then resolves and validates it like a reload does. It returns every
+// problem of the schema validation, the configuration is nil when it can't
+// be used.
+func CheckFile(file string) (*Config, []error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, []error{fmt.Errorf("fail to read configuration: %s", err)}
+	}
+	doc, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, []error{fmt.Errorf("%s is not valid YAML: %s", file, err)}
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(configSchema))
+	if err != nil {
+		return nil, []error{err}
+	}
+	var obj any
+	if err := json.Unmarshal(doc, &obj); err != nil {
+		return nil, []error{err}
+	}
+	if obj == nil {
+		// an empty file uses the defaults
+		obj = map[string]any{}
+	}
+	ret, err := schema.Validate(gojsonschema.NewGoLoader(withoutNulls(obj)))
+	if err != nil {
+		return nil, []error{err}
+	}
+	if !ret.Valid() {
+		var errs []error
+		for _, e := range ret.Errors() {
+			errs = append(errs, fmt.Errorf("%s: %s", e.Field(), e.Description()))
+		}
+		return nil, errs
+	}
+
+	config, _, err := readConfig(file)
+	if err != nil {
+		return nil, []error{err}
+	}
+	if err := validate(config); err != nil {
+		return nil, []error{err}
+	}
+	return config, nil
+}
+
+// withoutNulls removes the keys without value, they are unset like
+// missing keys.
+func withoutNulls(v any) any {
+	switch o := v.(type) {
+	case map[string]any:
+		for k, item := range o {
+			if item == nil {
+				delete(o, k)
+				continue
+			}
+			o[k] = withoutNulls(item)
+		}
+	case []any:
+		for i, item := range o {
+			o[i] = withoutNulls(item)
+		}
+	}
+	return v
+}
+
+// ReadSchema reads schema.json and customize_schema.json of the work dir
+// and merges them.
+func ReadSchema() (gjson.Result, error) {
+	var (
+		apisixSchemaPath    = WorkDir + "/conf/schema.json"
+		customizeSchemaPath = WorkDir + "/conf/customize_schema.json"
+	)
+
+	apisixSchemaContent, err := os.ReadFile(apisixSchemaPath)
+	if err != nil {
+		return gjson.Result{}, fmt.Errorf("fail to read configuration: %s, error: %s", apisixSchemaPath, err.Error())
+	}
+	customizeSchemaContent, err := os.ReadFile(customizeSchemaPath)
+	if err != nil {
+		return gjson.Result{}, fmt.Errorf("fail to read configuration: %s, error: %s", customizeSchemaPath, err.Error())
+	}
+
+	content, err := mergeSchema(apisixSchemaContent, customizeSchemaContent)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	return gjson.ParseBytes(content), nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantErrs []string
+	}{
+		{
+			name:    "valid",
+			content: "conf:\n  listen:\n    port: 9000\n  allow_list:\n  etcd:\n    endpoints:\n      - 127.0.0.1:2379\n    password: 123456\nplugins:\n  - key-auth\n",
+		},
+		{
+			name:    "empty file",
+			content: "",
+		},
+		{
+			name:    "schema errors",
+			content: "conf:\n  lisen:\n    port: 9000\n  log:\n    error_log:\n      level: verbose\nauthentication:\n  users:\n    - username: admin\n",
+			wantErrs: []string{
+				"authentication.users.0: password is required",
+				"conf: Additional property lisen is not allowed",
+				"conf.log.error_log.level: conf.log.error_log.level must be one of the following: \"debug\", \"info\", \"warn\", \"error\", \"panic\", \"fatal\"",
+			},
+		},
+		{
+			name:     "wrong type",
+			content:  "conf:\n  listen:\n    port: \"9000\"\n",
+			wantErrs: []string{"conf.listen.port: Invalid type. Expected: integer, given: string"},
+		},
+		{
+			name:     "unresolved reference",
+			content:  "conf:\n  etcd:\n    password: ${CHECK_UNSET_PASSWORD}\n",
+			wantErrs: []string{"conf.etcd.password: environment variable CHECK_UNSET_PASSWORD is not set"},
+		},
+		{
+			name:     "invalid value",
+			content:  "clusters:\n  - name: default\n    endpoints:\n      - 127.0.0.1:2379\n",
+			wantErrs: []string{"cluster name default is reserved for the cluster of conf.etcd"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "conf.yaml")
+			assert.NoError(t, os.WriteFile(file, []byte(tc.content), 0600))
+			config, errs := CheckFile(file)
+			if len(tc.wantErrs) == 0 {
+				assert.Empty(t, errs)
+				assert.NotNil(t, config)
+				return
+			}
+			assert.Nil(t, config)
+			assert.Len(t, errs, len(tc.wantErrs))
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			for _, want := range tc.wantErrs {
+				found := false
+				for _, g := range got {
+					found = found || strings.HasSuffix(g, want)
+				}
+				assert.True(t, found, "%q not in %q", want, got)
+			}
+		})
+	}
+}
@@ -76,6 +76,9 @@ var (
 	LdapEnabled      = false
 	LdapConfig       *Ldap
 	LdapFilter       = "(&(objectClass=inetOrgPerson)(cn=%s))"
+	BackupEnabled    = false
+	BackupConfig     *Backup
+	Clusters         = map[string]*Etcd{}
 )
 
 type MTLS struct {
@@ -92,6 +95,12 @@ type Etcd struct {
 	Prefix    string
 }
 
+// Cluster is another APISIX cluster managed by manager-api, by its etcd
+type Cluster struct {
+	Name string
+	Etcd `mapstructure:",squash"`
+}
+
 type SSL struct {
 	Host string `mapstructure:"host"`
 	Port int    `mapstructure:"port"`
@@ -161,12 +170,41 @@ type Ldap struct {
 	Filter       string `mapstructure:"filter"`
 }
 
+type BackupLocal struct {
+	Dir string `mapstructure:"dir"`
+}
+
+type BackupS3 struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	Prefix    string `mapstructure:"prefix"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+}
+
+type BackupRetention struct {
+	MaxCount int    `mapstructure:"max_count"`
+	MaxAge   string `mapstructure:"max_age"`
+}
+
+type Backup struct {
+	Enabled   bool            `mapstructure:"enabled"`
+	Interval  string          `mapstructure:"interval"`
+	Target    string          `mapstructure:"target"`
+	Local     BackupLocal     `mapstructure:"local"`
+	S3        BackupS3        `mapstructure:"s3"`
+	Retention BackupRetention `mapstructure:"retention"`
+}
+
 type Config struct {
 	Conf           Conf
 	Authentication Authentication
 	Plugins        []string
 	Oidc           Oidc
 	Ldap           Ldap
+	Backup         Backup
+	Clusters       []Cluster
 }
 
 type Security struct {
@@ -222,6 +260,10 @@ func setupConfig() {
 	if err != nil {
 		panic(fmt.Sprintf("fail to unmarshal configuration: %s, err: %s", ConfigFile, err.Error()))
 	}
+	if Substituted, err = substitute(&config); err != nil {
+		panic(fmt.Sprintf("fail to resolve configuration: %s, err: %s", ConfigFile, err.Error()))
+	}
+	loaded = &config
 
 	// listen
 	if config.Conf.Listen.Port != 0 {
@@ -303,6 +345,14 @@ func setupConfig() {
 	// set plugin
 	initPlugins(config.Plugins)
 
+	// set backup
+	if config.Backup.Enabled {
+		initBackup(config.Backup)
+	}
+
+	// set clusters
+	initClusters(config.Clusters)
+
 	// security configuration
 	initSecurity(config.Conf.Security)
 }
@@ -315,29 +365,43 @@ func setupEnv() {
 }
 
 func initAuthentication(conf Authentication) {
-	AuthConf = conf
-	if AuthConf.Secret == "secret" {
-		AuthConf.Secret = utils.GetFlakeUidStr()
+	AuthConf = newAuthentication(conf)
+	UserList = newUserList(AuthConf.Users)
+}
+
+func newAuthentication(conf Authentication) Authentication {
+	if conf.Secret == "secret" {
+		conf.Secret = utils.GetFlakeUidStr()
 	}
+	return conf
+}
 
-	userList := conf.Users
-	// create user list
-	for _, item := range userList {
-		UserList[item.Username] = item
+// create user list
+func newUserList(users []User) map[string]User {
+	userList := make(map[string]User, len(users))
+	for _, item := range users {
+		userList[item.Username] = item
 	}
+	return userList
 }
 
 func initOidc(conf Oidc) {
 	OidcEnabled = conf.Enabled
 	OidcExpireTime = conf.ExpireTime
-	OidcConfig.ClientID = conf.ClientID
-	OidcConfig.ClientSecret = conf.ClientSecret
-	OidcConfig.Endpoint = oauth2.Endpoint{AuthURL: conf.AuthURL, TokenURL: conf.TokenURL, AuthStyle: 1}
-	OidcConfig.Scopes = append(OidcConfig.Scopes, conf.Scope)
-	OidcConfig.RedirectURL = conf.RedirectURL
+	OidcConfig = newOidcConfig(conf)
 	OidcUserInfoURL = conf.UserInfoURL
 }
 
+func newOidcConfig(conf Oidc) oauth2.Config {
+	return oauth2.Config{
+		ClientID:     conf.ClientID,
+		ClientSecret: conf.ClientSecret,
+		Endpoint:     oauth2.Endpoint{AuthURL: conf.AuthURL, TokenURL: conf.TokenURL, AuthStyle: 1},
+		Scopes:       []string{conf.Scope},
+		RedirectURL:  conf.RedirectURL,
+	}
+}
+
 func initPlugins(plugins []string) {
 	for _, pluginName := range plugins {
 		Plugins[pluginName] = true
@@ -345,28 +409,11 @@ func initPlugins(plugins []string) {
 }
 
 func initSchema() {
-	var (
-		apisixSchemaPath       = WorkDir + "/conf/schema.json"
-		customizeSchemaPath    = WorkDir + "/conf/customize_schema.json"
-		apisixSchemaContent    []byte
-		customizeSchemaContent []byte
-		err                    error
-	)
-
-	if apisixSchemaContent, err = os.ReadFile(apisixSchemaPath); err != nil {
-		panic(fmt.Errorf("fail to read configuration: %s, error: %s", apisixSchemaPath, err.Error()))
-	}
-
-	if customizeSchemaContent, err = os.ReadFile(customizeSchemaPath); err != nil {
-		panic(fmt.Errorf("fail to read configuration: %s, error: %s", customizeSchemaPath, err.Error()))
-	}
-
-	content, err := mergeSchema(apisixSchemaContent, customizeSchemaContent)
+	schema, err := ReadSchema()
 	if err != nil {
 		panic(err)
 	}
-
-	Schema = gjson.ParseBytes(content)
+	Schema = schema
 }
 
 func mergeSchema(apisixSchema, customizeSchema []byte) ([]byte, error) {
@@ -397,6 +444,11 @@ func mergeSchema(apisixSchema, customizeSchema []byte) ([]byte, error) {
 
 // initialize etcd config
 func initEtcdConfig(conf Etcd) {
+	ETCDConfig = NewEtcdConfig(conf)
+}
+
+// NewEtcdConfig returns the etcd config with the defaults of unset fields
+func NewEtcdConfig(conf Etcd) *Etcd {
 	var endpoints = []string{"127.0.0.1:2379"}
 	if len(conf.Endpoints) > 0 {
 		endpoints = conf.Endpoints
@@ -407,7 +459,7 @@ func initEtcdConfig(conf Etcd) {
 		prefix = conf.Prefix
 	}
 
-	ETCDConfig = &Etcd{
+	return &Etcd{
 		Endpoints: endpoints,
 		Username:  conf.Username,
 		Password:  conf.Password,
@@ -416,27 +468,80 @@ func initEtcdConfig(conf Etcd) {
 	}
 }
 
+func initClusters(clusters []Cluster) {
+	if err := validateClusters(clusters); err != nil {
+		panic(err.Error())
+	}
+	for _, c := range clusters {
+		Clusters[c.Name] = NewEtcdConfig(c.Etcd)
+	}
+}
+
+func validateClusters(clusters []Cluster) error {
+	names := make(map[string]bool, len(clusters))
+	for _, c := range clusters {
+		if c.Name == "" {
+			return fmt.Errorf("cluster name is required")
+		}
+		if c.Name == "default" {
+			return fmt.Errorf("cluster name default is reserved for the cluster of conf.etcd")
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate cluster name: %s", c.Name)
+		}
+		names[c.Name] = true
+	}
+	return nil
+}
+
 func initLdap(conf Ldap) {
+	if conf.Enabled {
+		LdapEnabled = true
+	}
+	LdapConfig = NewLdapConfig(conf, LdapEnabled)
+	LdapFilter = LdapConfig.Filter
+}
+
+// NewLdapConfig returns the LDAP config with the defaults of unset fields
+func NewLdapConfig(conf Ldap, enabled bool) *Ldap {
 	var host = "127.0.0.1:389"
 	if conf.Host != "" {
 		host = conf.Host
 	}
-	if conf.Enabled {
-		LdapEnabled = true
-	}
+	filter := "(&(objectClass=inetOrgPerson)(cn=%s))"
 	if conf.Filter != "" {
-		LdapFilter = conf.Filter
+		filter = conf.Filter
 	}
-	LdapConfig = &Ldap{
-		Enabled:      LdapEnabled,
+	return &Ldap{
+		Enabled:      enabled,
 		Host:         host,
 		BaseDN:       conf.BaseDN,
 		BindDN:       conf.BindDN,
 		BindPassword: conf.BindPassword,
-		Filter:       LdapFilter,
+		Filter:       filter,
 		StartTLS:     conf.StartTLS,
 	}
+}
 
+func initBackup(conf Backup) {
+	BackupEnabled = true
+	backup := conf
+	if backup.Interval == "" {
+		backup.Interval = "@daily"
+	}
+	if backup.Target == "" {
+		backup.Target = "local"
+	}
+	if backup.Local.Dir == "" {
+		backup.Local.Dir = "backups"
+	}
+	if !filepath.IsAbs(backup.Local.Dir) {
+		backup.Local.Dir = filepath.Join(WorkDir, backup.Local.Dir)
+	}
+	if backup.S3.Region == "" {
+		backup.S3.Region = "us-east-1"
+	}
+	BackupConfig = &backup
 }
 
 // initialize parallelism settings
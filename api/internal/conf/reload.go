@@ -0,0 +1,255 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conf
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+)
+
+var (
+	// settingsLock guards the settings which are swapped by Reload, they are
+	// read through the accessors below while manager-api is serving.
+	settingsLock sync.RWMutex
+	// reloadLock serializes the reloads
+	reloadLock sync.Mutex
+	// loaded is the configuration which is applied, as read from the file
+	loaded *Config
+)
+
+var logLevels = map[string]bool{
+	"debug": true, "info": true, "warn": true, "error": true, "panic": true, "fatal": true,
+}
+
+// ReloadResult lists the settings which changed, by their path in conf.yaml.
+// The applied ones are in effect, the others need a restart.
+type ReloadResult struct {
+	Applied         []string
+	RestartRequired []string
+}
+
+// IsApplied reports whether the setting changed and is in effect
+func (r *ReloadResult) IsApplied(setting string) bool {
+	for _, s := range r.Applied {
+		if s == setting {
+			return true
+		}
+	}
+	return false
+}
+
+type setting struct {
+	path       string
+	reloadable bool
+	value      func(c *Config) any
+}
+
+// settings lists the settings compared by Reload. The reloadable ones are
+// read on each request, the others are used to build components at startup.
+var settings = []setting{
+	{"conf.allow_list", true, func(c *Config) any { return c.Conf.AllowList }},
+	{"conf.log.error_log.level", true, func(c *Config) any { return c.Conf.Log.ErrorLog.Level }},
+	{"plugins", true, func(c *Config) any { return c.Plugins }},
+	{"authentication", true, func(c *Config) any { return c.Authentication }},
+	{"oidc", true, func(c *Config) any { o := c.Oidc; o.Enabled = false; return o }},
+	{"ldap", true, func(c *Config) any { l := c.Ldap; l.Enabled = false; return l }},
+	{"conf.listen", false, func(c *Config) any { return c.Conf.Listen }},
+	{"conf.ssl", false, func(c *Config) any { return c.Conf.SSL }},
+	{"conf.etcd", false, func(c *Config) any { return c.Conf.Etcd }},
+	{"conf.log.error_log.file_path", false, func(c *Config) any { return c.Conf.Log.ErrorLog.FilePath }},
+	{"conf.log.access_log.file_path", false, func(c *Config) any { return c.Conf.Log.AccessLog.FilePath }},
+	{"conf.max_cpu", false, func(c *Config) any { return c.Conf.MaxCpu }},
+	{"conf.security", false, func(c *Config) any { return c.Conf.Security }},
+	{"oidc.enabled", false, func(c *Config) any { return c.Oidc.Enabled }},
+	{"ldap.enabled", false, func(c *Config) any { return c.Ldap.Enabled }},
+	{"backup", false, func(c *Config) any { return c.Backup }},
+	{"clusters", false, func(c *Config) any { return c.Clusters }},
+}
+
+// Reload reads the configuration file again and validates it. The allow
+// list, the plugins, the error log level, the authentication and the OIDC
+// and LDAP settings are swapped at once, the components built from them
+// have to be rebuilt by the caller. The other settings which changed are
+// reported as requiring a restart. An invalid file changes nothing.
+func Reload() (*ReloadResult, error) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	config, _, err := readConfig(viper.ConfigFileUsed())
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(config); err != nil {
+		return nil, err
+	}
+
+	result := &ReloadResult{}
+	for _, s := range settings {
+		if loaded != nil && reflect.DeepEqual(s.value(loaded), s.value(config)) {
+			continue
+		}
+		if s.reloadable {
+			result.Applied = append(result.Applied, s.path)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, s.path)
+		}
+	}
+	if len(result.Applied) > 0 {
+		applySettings(config)
+	}
+	loaded = config
+	return result, nil
+}
+
+// readConfig reads and resolves the configuration file, it returns the
+// substituted fields as well.
+func readConfig(file string) (*Config, []string, error) {
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("fail to read configuration: %s, err: %s", file, err)
+	}
+	config := &Config{}
+	if err := v.Unmarshal(config); err != nil {
+		return nil, nil, fmt.Errorf("fail to unmarshal configuration: %s, err: %s", file, err)
+	}
+	substituted, err := substitute(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to resolve configuration: %s, err: %s", file, err)
+	}
+	return config, substituted, nil
+}
+
+// validate checks the settings which can be reloaded and those which would
+// stop manager-api at startup.
+func validate(config *Config) error {
+	if level := config.Conf.Log.ErrorLog.Level; level != "" && !logLevels[level] {
+		return fmt.Errorf("conf.log.error_log.level: invalid level %s", level)
+	}
+	for _, item := range config.Conf.AllowList {
+		if _, _, err := net.ParseCIDR(item); err != nil && net.ParseIP(item) == nil {
+			return fmt.Errorf("conf.allow_list: %s is neither an IP nor a CIDR", item)
+		}
+	}
+	users := make(map[string]bool, len(config.Authentication.Users))
+	for i, u := range config.Authentication.Users {
+		if u.Username == "" || u.Password == "" {
+			return fmt.Errorf("authentication.users[%d]: username and password are required", i)
+		}
+		if users[u.Username] {
+			return fmt.Errorf("authentication.users[%d]: duplicate username %s", i, u.Username)
+		}
+		users[u.Username] = true
+	}
+	return validateClusters(config.Clusters)
+}
+
+func applySettings(config *Config) {
+	auth := config.Authentication
+	if auth.Secret == "secret" && loaded != nil && loaded.Authentication.Secret == "secret" {
+		// keep the generated secret, so that the sessions stay valid
+		auth.Secret = GetAuthConf().Secret
+	}
+	auth = newAuthentication(auth)
+
+	plugins := make(map[string]bool, len(config.Plugins))
+	for _, name := range config.Plugins {
+		plugins[name] = true
+	}
+
+	level := config.Conf.Log.ErrorLog.Level
+	if level == "" {
+		level = "warn"
+	}
+
+	settingsLock.Lock()
+	defer settingsLock.Unlock()
+	AllowList = config.Conf.AllowList
+	ErrorLogLevel = level
+	Plugins = plugins
+	AuthConf = auth
+	UserList = newUserList(auth.Users)
+	OidcExpireTime = config.Oidc.ExpireTime
+	OidcConfig = newOidcConfig(config.Oidc)
+	OidcUserInfoURL = config.Oidc.UserInfoURL
+	if config.Ldap.Host != "" || LdapConfig != nil {
+		LdapConfig = NewLdapConfig(config.Ldap, LdapEnabled)
+		LdapFilter = LdapConfig.Filter
+	}
+}
+
+// GetAllowList returns the IPs and CIDRs allowed to access manager-api
+func GetAllowList() []string {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return AllowList
+}
+
+// GetErrorLogLevel returns the level of the error log
+func GetErrorLogLevel() string {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return ErrorLogLevel
+}
+
+// PluginEnabled reports whether the plugin is enabled by the plugins list
+func PluginEnabled(name string) bool {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return Plugins[name]
+}
+
+// GetUser returns the user with the name
+func GetUser(name string) (User, bool) {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	user, ok := UserList[name]
+	return user, ok
+}
+
+// GetAuthConf returns the authentication settings
+func GetAuthConf() Authentication {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return AuthConf
+}
+
+// OidcSettings are the settings of the OIDC login
+type OidcSettings struct {
+	Config      oauth2.Config
+	UserInfoURL string
+	ExpireTime  int
+}
+
+// GetOidc returns the settings of the OIDC login
+func GetOidc() OidcSettings {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return OidcSettings{Config: OidcConfig, UserInfoURL: OidcUserInfoURL, ExpireTime: OidcExpireTime}
+}
+
+// GetLdapConfig returns the LDAP settings, nil when LDAP is not configured
+func GetLdapConfig() *Ldap {
+	settingsLock.RLock()
+	defer settingsLock.RUnlock()
+	return LdapConfig
+}
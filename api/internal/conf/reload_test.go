@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const reloadConfig = `
+conf:
+  listen:
+    port: 9000
+  allow_list:
+    - 127.0.0.1
+  log:
+    error_log:
+      level: warn
+authentication:
+  secret: secret
+  expire_time: 3600
+  users:
+    - username: admin
+      password: admin
+plugins:
+  - limit-count
+`
+
+func TestReload(t *testing.T) {
+	origin := struct {
+		file      string
+		loaded    *Config
+		allowList []string
+		level     string
+		plugins   map[string]bool
+		users     map[string]User
+		auth      Authentication
+	}{viper.ConfigFileUsed(), loaded, AllowList, ErrorLogLevel, Plugins, UserList, AuthConf}
+	defer func() {
+		viper.SetConfigFile(origin.file)
+		loaded, AllowList, ErrorLogLevel = origin.loaded, origin.allowList, origin.level
+		Plugins, UserList, AuthConf = origin.plugins, origin.users, origin.auth
+	}()
+
+	file := filepath.Join(t.TempDir(), "conf.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(reloadConfig), 0600))
+	viper.SetConfigFile(file)
+	loaded = nil
+	_, err := Reload()
+	assert.NoError(t, err)
+	secret := GetAuthConf().Secret
+	assert.NotEqual(t, "secret", secret)
+
+	// nothing changed
+	result, err := Reload()
+	assert.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	assert.Empty(t, result.RestartRequired)
+
+	changed := `
+conf:
+  listen:
+    port: 9001
+  allow_list:
+    - 10.0.0.0/8
+  log:
+    error_log:
+      level: debug
+authentication:
+  secret: secret
+  expire_time: 3600
+  users:
+    - username: ops
+      password: ops
+plugins:
+  - key-auth
+`
+	assert.NoError(t, os.WriteFile(file, []byte(changed), 0600))
+	result, err = Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"conf.allow_list", "conf.log.error_log.level", "plugins", "authentication"}, result.Applied)
+	assert.Equal(t, []string{"conf.listen"}, result.RestartRequired)
+	assert.True(t, result.IsApplied("plugins"))
+	assert.False(t, result.IsApplied("conf.listen"))
+
+	assert.Equal(t, []string{"10.0.0.0/8"}, GetAllowList())
+	assert.Equal(t, "debug", GetErrorLogLevel())
+	assert.True(t, PluginEnabled("key-auth"))
+	assert.False(t, PluginEnabled("limit-count"))
+	_, ok := GetUser("admin")
+	assert.False(t, ok)
+	user, ok := GetUser("ops")
+	assert.True(t, ok)
+	assert.Equal(t, "ops", user.Password)
+	// the generated secret is kept, so that the sessions stay valid
+	assert.Equal(t, secret, GetAuthConf().Secret)
+
+	// an invalid config file changes nothing
+	invalid := []string{
+		"conf:\n  log:\n    error_log:\n      level: verbose\n",
+		"conf:\n  allow_list:\n    - 10.0.0.0/33\n",
+		"authentication:\n  users:\n    - username: ops\n",
+		"clusters:\n  - name: default\n",
+		"conf: [",
+	}
+	for _, content := range invalid {
+		assert.NoError(t, os.WriteFile(file, []byte(content), 0600))
+		_, err = Reload()
+		assert.Error(t, err, content)
+		assert.Equal(t, []string{"10.0.0.0/8"}, GetAllowList())
+		assert.True(t, PluginEnabled("key-auth"))
+	}
+}
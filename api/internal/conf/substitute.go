@@ -0,0 +1,184 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const filePrefix = "file://"
+
+// Substituted lists the fields of the configuration whose value was read
+// from an environment variable or a file, like "conf.etcd.password". The
+// values are never recorded, they are usually secrets.
+var Substituted []string
+
+// substitute resolves the references of every string field of the config and
+// returns the fields which had any:
+// "${NAME}" is replaced by the environment variable NAME, which must be set,
+// and "${NAME:-default}" by default when NAME is unset or empty. "$${" is a
+// literal "${". A value which starts with "file://" after the expansion is
+// replaced by the content of the file, without the trailing newline, so
+// that secrets mounted as files can be used. Errors name the field and the
+// variable or the file but never the value.
+func substitute(config *Config) ([]string, error) {
+	var fields []string
+	err := substituteValue(reflect.ValueOf(config).Elem(), "", &fields)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func substituteValue(v reflect.Value, path string, fields *[]string) error {
+	switch v.Kind() {
+	case reflect.String:
+		resolved, changed, err := resolve(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		if changed {
+			v.SetString(resolved)
+			*fields = append(*fields, path)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return substituteValue(v.Elem(), path, fields)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := substituteValue(v.Index(i), path+"["+strconv.Itoa(i)+"]", fields); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			if err := substituteValue(v.Field(i), fieldPath(path, t.Field(i)), fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fieldPath returns the path of the field as written in conf.yaml
+func fieldPath(parent string, f reflect.StructField) string {
+	name := strings.ToLower(f.Name)
+	if tag, ok := f.Tag.Lookup("mapstructure"); ok {
+		tagName := strings.Split(tag, ",")[0]
+		if tagName == "" && strings.Contains(tag, "squash") {
+			return parent
+		}
+		if tagName != "" {
+			name = tagName
+		}
+	}
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// resolve returns the value with its references resolved, and whether it
+// had any.
+func resolve(value string) (string, bool, error) {
+	expanded, changed, err := expandEnv(value)
+	if err != nil {
+		return "", false, err
+	}
+	if !strings.HasPrefix(expanded, filePrefix) {
+		return expanded, changed, nil
+	}
+
+	path := strings.TrimPrefix(expanded, filePrefix)
+	if path == "" {
+		return "", false, fmt.Errorf("file path is required after %s", filePrefix)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(WorkDir, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("read file %s failed: %s", path, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+func expandEnv(value string) (string, bool, error) {
+	if !strings.Contains(value, "${") {
+		return value, false, nil
+	}
+
+	var b strings.Builder
+	rest := value
+	for {
+		i := strings.Index(rest, "${")
+		if i < 0 {
+			b.WriteString(rest)
+			break
+		}
+		if i > 0 && rest[i-1] == '$' {
+			b.WriteString(rest[:i-1])
+			b.WriteString("${")
+			rest = rest[i+2:]
+			continue
+		}
+		b.WriteString(rest[:i])
+
+		end := strings.Index(rest[i:], "}")
+		if end < 0 {
+			return "", false, fmt.Errorf("missing } of a ${ reference")
+		}
+		ref := rest[i+2 : i+end]
+		rest = rest[i+end+1:]
+
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		if !validEnvName(name) {
+			return "", false, fmt.Errorf("invalid environment variable name: %q", name)
+		}
+		env, ok := os.LookupEnv(name)
+		if hasDefault && env == "" {
+			env = def
+		} else if !ok {
+			return "", false, fmt.Errorf("environment variable %s is not set", name)
+		}
+		b.WriteString(env)
+	}
+	return b.String(), true, nil
+}
+
+func validEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("SUBST_PASSWORD", "s3cr3t")
+	t.Setenv("SUBST_EMPTY", "")
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "secret"), []byte("from-file\n"), 0600))
+	t.Setenv("SUBST_DIR", dir)
+
+	tests := []struct {
+		name        string
+		value       string
+		want        string
+		wantChanged bool
+		wantErr     string
+	}{
+		{name: "plain value", value: "admin", want: "admin"},
+		{name: "dollar without braces", value: "pa$$word", want: "pa$$word"},
+		{name: "env", value: "${SUBST_PASSWORD}", want: "s3cr3t", wantChanged: true},
+		{name: "env inside value", value: "root:${SUBST_PASSWORD}@etcd", want: "root:s3cr3t@etcd", wantChanged: true},
+		{name: "default of unset env", value: "${SUBST_UNSET:-fallback}", want: "fallback", wantChanged: true},
+		{name: "default of empty env", value: "${SUBST_EMPTY:-fallback}", want: "fallback", wantChanged: true},
+		{name: "empty default", value: "${SUBST_UNSET:-}", want: "", wantChanged: true},
+		{name: "set env ignores default", value: "${SUBST_PASSWORD:-fallback}", want: "s3cr3t", wantChanged: true},
+		{name: "escaped reference", value: "$${SUBST_PASSWORD}", want: "${SUBST_PASSWORD}", wantChanged: true},
+		{name: "file", value: "file://" + filepath.Join(dir, "secret"), want: "from-file", wantChanged: true},
+		{name: "file with env", value: "file://${SUBST_DIR}/secret", want: "from-file", wantChanged: true},
+		{name: "unset env", value: "${SUBST_UNSET}", wantErr: "environment variable SUBST_UNSET is not set"},
+		{name: "invalid name", value: "${1ABC}", wantErr: `invalid environment variable name: "1ABC"`},
+		{name: "unclosed reference", value: "${SUBST_PASSWORD", wantErr: "missing } of a ${ reference"},
+		{name: "empty file path", value: "file://", wantErr: "file path is required after file://"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, changed, err := resolve(tc.value)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantChanged, changed)
+		})
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	t.Setenv("SUBST_ETCD_PASSWORD", "etcd-secret")
+	t.Setenv("SUBST_USER_PASSWORD", "user-secret")
+	t.Setenv("SUBST_CLUSTER_PASSWORD", "cluster-secret")
+
+	config := &Config{
+		Conf: Conf{Etcd: Etcd{
+			Password: "${SUBST_ETCD_PASSWORD}",
+			MTLS:     &MTLS{CaFile: "${SUBST_CA_FILE:-ca.pem}"},
+		}},
+		Authentication: Authentication{Users: []User{
+			{Username: "admin", Password: "${SUBST_USER_PASSWORD}"},
+		}},
+		Clusters: []Cluster{
+			{Name: "prod", Etcd: Etcd{Password: "${SUBST_CLUSTER_PASSWORD}"}},
+		},
+		Backup: Backup{S3: BackupS3{SecretKey: "plain"}},
+	}
+	fields, err := substitute(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "etcd-secret", config.Conf.Etcd.Password)
+	assert.Equal(t, "ca.pem", config.Conf.Etcd.MTLS.CaFile)
+	assert.Equal(t, "user-secret", config.Authentication.Users[0].Password)
+	assert.Equal(t, "cluster-secret", config.Clusters[0].Password)
+	assert.Equal(t, "plain", config.Backup.S3.SecretKey)
+	assert.Equal(t, []string{
+		"conf.etcd.password",
+		"conf.etcd.mtls.ca_file",
+		"authentication.users[0].password",
+		"clusters[0].password",
+	}, fields)
+
+	config = &Config{Oidc: Oidc{ClientSecret: "${SUBST_UNSET}"}}
+	_, err = substitute(config)
+	assert.EqualError(t, err, "oidc.client_secret: environment variable SUBST_UNSET is not set")
+}
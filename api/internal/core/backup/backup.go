@@ -0,0 +1,291 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package backup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/migrate"
+	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+const (
+	namePrefix = "apisix-config-"
+	nameSuffix = ".bak"
+	timeLayout = "20060102T150405Z"
+)
+
+// Backup is a backup listed by the scheduler.
+type Backup struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// Retention decides which backups are deleted after a new one is written,
+// a zero value keeps all of them.
+type Retention struct {
+	MaxCount int
+	MaxAge   time.Duration
+}
+
+// Scheduler writes a backup of all stores to the target each interval and
+// deletes the old ones by the retention.
+type Scheduler struct {
+	target    Target
+	interval  time.Duration
+	retention Retention
+	export    func(ctx context.Context) ([]byte, error)
+	now       func() time.Time
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+var scheduler *Scheduler
+
+// Default returns the scheduler started by Init, it is nil when backups
+// are disabled.
+func Default() *Scheduler {
+	return scheduler
+}
+
+// Init starts the scheduler when backups are enabled by the configuration.
+func Init() error {
+	if !conf.BackupEnabled {
+		return nil
+	}
+	s, err := NewScheduler(conf.BackupConfig)
+	if err != nil {
+		return err
+	}
+	s.Start()
+	utils.AppendToClosers(s.Stop)
+	scheduler = s
+	return nil
+}
+
+// NewScheduler creates a scheduler by the configuration, which backs up
+// with migrate.Export.
+func NewScheduler(cfg *conf.Backup) (*Scheduler, error) {
+	interval, err := ParseInterval(cfg.Interval)
+	if err != nil {
+		return nil, err
+	}
+	var maxAge time.Duration
+	if cfg.Retention.MaxAge != "" {
+		maxAge, err = time.ParseDuration(cfg.Retention.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup max age: %s", cfg.Retention.MaxAge)
+		}
+	}
+	if cfg.Retention.MaxCount < 0 || maxAge < 0 {
+		return nil, fmt.Errorf("backup retention can't be negative")
+	}
+
+	var target Target
+	switch cfg.Target {
+	case "local":
+		target = &LocalTarget{Dir: cfg.Local.Dir}
+	case "s3":
+		if cfg.S3.Endpoint == "" || cfg.S3.Bucket == "" {
+			return nil, fmt.Errorf("backup s3 target needs an endpoint and a bucket")
+		}
+		target = &S3Target{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			Prefix:    cfg.S3.Prefix,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			Client:    &http.Client{Timeout: time.Minute},
+		}
+	default:
+		return nil, fmt.Errorf("invalid backup target: %s", cfg.Target)
+	}
+
+	return &Scheduler{
+		target:    target,
+		interval:  interval,
+		retention: Retention{MaxCount: cfg.Retention.MaxCount, MaxAge: maxAge},
+		export: func(ctx context.Context) ([]byte, error) {
+			return migrate.Export(ctx, nil)
+		},
+		now: time.Now,
+	}, nil
+}
+
+// ParseInterval parses a duration like 6h, or one of @hourly, @daily,
+// @weekly and @every <duration>.
+func ParseInterval(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	switch s {
+	case "@hourly":
+		d = time.Hour
+	case "@daily":
+		d = 24 * time.Hour
+	case "@weekly":
+		d = 7 * 24 * time.Hour
+	default:
+		d, err = time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(s, "@every")))
+	}
+	if err != nil || d < time.Minute {
+		return 0, fmt.Errorf("invalid backup interval: %s, it must be at least 1m", s)
+	}
+	return d, nil
+}
+
+// Start runs the backups in the background. The first one is due an
+// interval after the newest backup of the target, so restarts don't delay
+// the backups.
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		timer := time.NewTimer(s.firstDelay())
+		defer timer.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-timer.C:
+			}
+			if _, err := s.Run(context.Background()); err != nil {
+				log.Errorf("scheduled backup failed: %s", err)
+			}
+			timer.Reset(s.interval)
+		}
+	}()
+}
+
+// Stop stops the scheduler and waits for a running backup.
+func (s *Scheduler) Stop() error {
+	if s.stop != nil {
+		close(s.stop)
+		s.wg.Wait()
+	}
+	return nil
+}
+
+func (s *Scheduler) firstDelay() time.Duration {
+	backups, err := s.List(context.Background())
+	if err != nil {
+		log.Warnf("list backups failed: %s", err)
+		return s.interval
+	}
+	if len(backups) == 0 {
+		return 0
+	}
+	delay := time.Unix(backups[0].CreatedAt, 0).Add(s.interval).Sub(s.now())
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// Run writes a backup and deletes the old ones by the retention.
+func (s *Scheduler) Run(ctx context.Context) (*Backup, error) {
+	data, err := s.export(ctx)
+	if err != nil {
+		return nil, err
+	}
+	createdAt := s.now().UTC()
+	b := &Backup{
+		Name:      namePrefix + createdAt.Format(timeLayout) + nameSuffix,
+		Size:      int64(len(data)),
+		CreatedAt: createdAt.Unix(),
+	}
+	if err := s.target.Put(ctx, b.Name, data); err != nil {
+		return nil, err
+	}
+	log.Infof("backup %s written", b.Name)
+
+	if err := s.prune(ctx); err != nil {
+		log.Errorf("delete old backups failed: %s", err)
+	}
+	return b, nil
+}
+
+// List returns the backups of the target, the newest first. Other objects
+// of the target are left out.
+func (s *Scheduler) List(ctx context.Context) ([]Backup, error) {
+	objects, err := s.target.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]Backup, 0, len(objects))
+	for _, o := range objects {
+		createdAt, ok := parseName(o.Name)
+		if !ok {
+			continue
+		}
+		backups = append(backups, Backup{Name: o.Name, Size: o.Size, CreatedAt: createdAt.Unix()})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Name > backups[j].Name
+	})
+	return backups, nil
+}
+
+// Get returns the content of a backup.
+func (s *Scheduler) Get(ctx context.Context, name string) ([]byte, error) {
+	if _, ok := parseName(name); !ok {
+		return nil, ErrNotFound
+	}
+	return s.target.Get(ctx, name)
+}
+
+func (s *Scheduler) prune(ctx context.Context) error {
+	backups, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	for i, b := range backups {
+		expired := s.retention.MaxAge > 0 && now.Sub(time.Unix(b.CreatedAt, 0)) > s.retention.MaxAge
+		if s.retention.MaxCount > 0 && i >= s.retention.MaxCount || expired {
+			if err := s.target.Delete(ctx, b.Name); err != nil && err != ErrNotFound {
+				return err
+			}
+			log.Infof("backup %s deleted by the retention", b.Name)
+		}
+	}
+	return nil
+}
+
+// parseName returns the time a backup was created by its name, the names
+// are sorted by that time.
+func parseName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, nameSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,152 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package backup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "@hourly", want: time.Hour},
+		{in: "@daily", want: 24 * time.Hour},
+		{in: "@weekly", want: 7 * 24 * time.Hour},
+		{in: "@every 30m", want: 30 * time.Minute},
+		{in: "6h", want: 6 * time.Hour},
+		{in: "10s", wantErr: true},
+		{in: "daily", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseInterval(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func newTestScheduler(t *testing.T, retention Retention, now *time.Time) *Scheduler {
+	return &Scheduler{
+		target:    &LocalTarget{Dir: t.TempDir()},
+		interval:  time.Hour,
+		retention: retention,
+		export: func(ctx context.Context) ([]byte, error) {
+			return []byte(`{"header":{}}`), nil
+		},
+		now: func() time.Time { return *now },
+	}
+}
+
+func names(backups []Backup) []string {
+	var ret []string
+	for _, b := range backups {
+		ret = append(ret, b.Name)
+	}
+	return ret
+}
+
+func TestScheduler_Run(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestScheduler(t, Retention{MaxCount: 3}, &now)
+
+	for i := 0; i < 5; i++ {
+		b, err := s.Run(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, now.Unix(), b.CreatedAt)
+		now = now.Add(time.Hour)
+	}
+	// other files of the directory are neither listed nor deleted
+	assert.NoError(t, s.target.Put(ctx, "notes.txt", []byte("keep")))
+
+	backups, err := s.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"apisix-config-20260101T040000Z.bak",
+		"apisix-config-20260101T030000Z.bak",
+		"apisix-config-20260101T020000Z.bak",
+	}, names(backups))
+	assert.Equal(t, int64(13), backups[0].Size)
+
+	data, err := s.Get(ctx, backups[0].Name)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"header":{}}`, string(data))
+	_, err = s.Get(ctx, "notes.txt")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = s.Get(ctx, "apisix-config-20200101T000000Z.bak")
+	assert.Equal(t, ErrNotFound, err)
+	_, err = s.target.Get(ctx, "notes.txt")
+	assert.NoError(t, err)
+}
+
+func TestScheduler_Run_maxAge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestScheduler(t, Retention{MaxAge: 90 * time.Minute}, &now)
+
+	for i := 0; i < 4; i++ {
+		_, err := s.Run(ctx)
+		assert.NoError(t, err)
+		now = now.Add(time.Hour)
+	}
+	backups, err := s.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"apisix-config-20260101T030000Z.bak",
+		"apisix-config-20260101T020000Z.bak",
+	}, names(backups))
+}
+
+func TestScheduler_Run_exportFailed(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestScheduler(t, Retention{}, &now)
+	s.export = func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("etcd is down")
+	}
+	_, err := s.Run(context.Background())
+	assert.EqualError(t, err, "etcd is down")
+	backups, err := s.List(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, backups)
+}
+
+func TestScheduler_firstDelay(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newTestScheduler(t, Retention{}, &now)
+	assert.Equal(t, time.Duration(0), s.firstDelay())
+
+	_, err := s.Run(context.Background())
+	assert.NoError(t, err)
+	now = now.Add(20 * time.Minute)
+	assert.Equal(t, 40*time.Minute, s.firstDelay())
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, time.Duration(0), s.firstDelay())
+}
@@ -0,0 +1,219 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package check
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/ldap"
+	"github.com/apisix/manager-api/internal/core/storage"
+)
+
+const timeout = 5 * time.Second
+
+// Result is the result of a check. A check fails with errors, each one
+// telling what is wrong, and a hint telling how to fix it. Warnings are
+// printed with the hint but don't fail the check. Skipped checks have a
+// reason.
+type Result struct {
+	Name     string
+	Errors   []error
+	Warnings []error
+	Hint     string
+	Skipped  string
+}
+
+func (r *Result) Failed() bool {
+	return len(r.Errors) > 0
+}
+
+// Checker runs the checks of a config file
+type Checker struct {
+	// ldap and etcd are replaced by tests
+	ldap func(ldapConf *conf.Ldap) error
+	etcd func(etcdConf *conf.Etcd) error
+	http *http.Client
+}
+
+func NewChecker() *Checker {
+	return &Checker{
+		ldap: ldap.Check,
+		etcd: checkEtcd,
+		http: &http.Client{Timeout: timeout},
+	}
+}
+
+// Run checks the config file, the schemas of the work dir, and that etcd,
+// the other clusters and the enabled login providers are reachable with
+// the configured credentials. The checks which need a valid config file
+// are skipped without one.
+func (c *Checker) Run(file string) []*Result {
+	results := []*Result{}
+	config, errs := conf.CheckFile(file)
+	results = append(results, &Result{
+		Name:   "configuration file " + file,
+		Errors: errs,
+		Hint:   "fix conf.yaml as reported, every field is documented by the sample conf/conf.yaml",
+	})
+
+	schemaResult := &Result{
+		Name: "schema",
+		Hint: "conf/schema.json and conf/customize_schema.json must be valid JSON, and customize_schema.json can't redefine a key of schema.json",
+	}
+	schema, err := conf.ReadSchema()
+	if err != nil {
+		schemaResult.Errors = []error{err}
+	}
+	results = append(results, schemaResult)
+
+	if config == nil {
+		for _, name := range []string{"plugins", "etcd", "ldap", "oidc"} {
+			results = append(results, &Result{Name: name, Skipped: "the configuration file is invalid"})
+		}
+		return results
+	}
+
+	pluginsResult := &Result{
+		Name: "plugins",
+		Hint: "the plugins can't be configured by the dashboard, update conf/schema.json to the schema of your APISIX version",
+	}
+	if schemaResult.Failed() {
+		pluginsResult.Skipped = "the schema is invalid"
+	} else {
+		// APISIX may run plugins newer than the schema, so they only warn
+		for _, name := range config.Plugins {
+			if !schema.Get("plugins."+name).Exists() && !schema.Get("stream_plugins."+name).Exists() {
+				pluginsResult.Warnings = append(pluginsResult.Warnings, fmt.Errorf("plugin %s is not in the schema", name))
+			}
+		}
+	}
+	results = append(results, pluginsResult)
+
+	results = append(results, c.checkEtcd("etcd", conf.NewEtcdConfig(config.Conf.Etcd), "conf.etcd"))
+	for i, cluster := range config.Clusters {
+		results = append(results, c.checkEtcd("etcd of cluster "+cluster.Name, conf.NewEtcdConfig(cluster.Etcd),
+			fmt.Sprintf("clusters[%d]", i)))
+	}
+
+	ldapResult := &Result{
+		Name: "ldap",
+		Hint: "check ldap.host, and ldap.bind_dn and ldap.bind_password of conf.yaml",
+	}
+	if !config.Ldap.Enabled {
+		ldapResult.Skipped = "ldap is disabled"
+	} else if err := c.ldap(conf.NewLdapConfig(config.Ldap, true)); err != nil {
+		ldapResult.Errors = []error{err}
+	}
+	results = append(results, ldapResult)
+
+	results = append(results, c.checkOidc(config.Oidc))
+	return results
+}
+
+func (c *Checker) checkEtcd(name string, etcdConf *conf.Etcd, field string) *Result {
+	result := &Result{
+		Name: fmt.Sprintf("%s %s", name, strings.Join(etcdConf.Endpoints, ",")),
+		Hint: fmt.Sprintf("check that etcd is running and reachable at %s.endpoints", field),
+	}
+	err := c.etcd(etcdConf)
+	if err == nil {
+		return result
+	}
+	result.Errors = []error{err}
+	switch {
+	case strings.Contains(err.Error(), "authentication failed"):
+		result.Hint = fmt.Sprintf("check %s.username and %s.password", field, field)
+	case strings.Contains(err.Error(), "permission denied"):
+		result.Hint = fmt.Sprintf("grant the etcd user of %s.username read and write access to the keys under %s", field, etcdConf.Prefix)
+	case strings.Contains(err.Error(), "certificate") || strings.Contains(err.Error(), "tls"):
+		result.Hint = fmt.Sprintf("check the files of %s.mtls and that they are trusted by etcd", field)
+	}
+	return result
+}
+
+// checkEtcd connects to etcd and reads the keys of the prefix, which needs
+// the credentials when etcd has auth enabled.
+func checkEtcd(etcdConf *conf.Etcd) error {
+	stg, err := storage.NewEtcdStorage(etcdConf)
+	if err != nil {
+		return err
+	}
+	defer stg.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err = stg.GetClient().Get(ctx, etcdConf.Prefix+"/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	return err
+}
+
+func (c *Checker) checkOidc(oidc conf.Oidc) *Result {
+	result := &Result{
+		Name: "oidc",
+		Hint: "check the client and the URLs of the identity provider in oidc of conf.yaml",
+	}
+	if !oidc.Enabled {
+		result.Skipped = "oidc is disabled"
+		return result
+	}
+
+	if oidc.ClientID == "" {
+		result.Errors = append(result.Errors, fmt.Errorf("oidc.client_id is required"))
+	}
+	urls := map[string]string{
+		"oidc.auth_url":      oidc.AuthURL,
+		"oidc.token_url":     oidc.TokenURL,
+		"oidc.user_info_url": oidc.UserInfoURL,
+		"oidc.redirect_url":  oidc.RedirectURL,
+	}
+	fields := make([]string, 0, len(urls))
+	for field := range urls {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		u, err := url.Parse(urls[field])
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %q is not an absolute URL", field, urls[field]))
+			continue
+		}
+		if field == "oidc.redirect_url" {
+			// served by manager-api itself
+			continue
+		}
+		// any response shows the endpoint is reachable, it may not accept
+		// a request without credentials
+		resp, err := c.http.Get(u.String())
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %s", field, err))
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %s returns %s", field, u, resp.Status))
+		}
+	}
+	return result
+}
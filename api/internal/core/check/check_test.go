@@ -0,0 +1,149 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package check
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/conf"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "conf.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0600))
+	return file
+}
+
+func summary(results []*Result) map[string]string {
+	ret := map[string]string{}
+	for _, r := range results {
+		switch {
+		case r.Skipped != "":
+			ret[r.Name] = "skip: " + r.Skipped
+		case r.Failed():
+			ret[r.Name] = fmt.Sprintf("fail: %v", r.Errors)
+		case len(r.Warnings) > 0:
+			ret[r.Name] = fmt.Sprintf("warn: %v", r.Warnings)
+		default:
+			ret[r.Name] = "ok"
+		}
+	}
+	return ret
+}
+
+func TestRun(t *testing.T) {
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer idp.Close()
+
+	var etcdConfs []*conf.Etcd
+	c := NewChecker()
+	c.etcd = func(etcdConf *conf.Etcd) error {
+		etcdConfs = append(etcdConfs, etcdConf)
+		if etcdConf.Prefix == "/prod" {
+			return errors.New("etcdserver: authentication failed, invalid user ID or password")
+		}
+		return nil
+	}
+	c.ldap = func(ldapConf *conf.Ldap) error {
+		assert.Equal(t, "127.0.0.1:389", ldapConf.Host)
+		return errors.New("ldap bind failed, user or password is wrong")
+	}
+
+	file := writeConfig(t, `
+conf:
+  etcd:
+    endpoints:
+      - 127.0.0.1:2379
+plugins:
+  - key-auth
+  - no-such-plugin
+ldap:
+  enabled: true
+oidc:
+  enabled: true
+  client_id: dashboard
+  auth_url: `+idp.URL+`/auth
+  token_url: `+idp.URL+`/broken
+  user_info_url: userinfo
+  redirect_url: http://127.0.0.1:9000/apisix/admin/oidc/callback
+clusters:
+  - name: prod
+    endpoints:
+      - 10.0.0.1:2379
+    prefix: /prod
+`)
+	results := c.Run(file)
+	assert.Equal(t, map[string]string{
+		"configuration file " + file:         "ok",
+		"schema":                             "ok",
+		"plugins":                            "warn: [plugin no-such-plugin is not in the schema]",
+		"etcd 127.0.0.1:2379":                "ok",
+		"etcd of cluster prod 10.0.0.1:2379": "fail: [etcdserver: authentication failed, invalid user ID or password]",
+		"ldap":                               "fail: [ldap bind failed, user or password is wrong]",
+		"oidc": fmt.Sprintf("fail: [oidc.token_url: %s/broken returns 502 Bad Gateway oidc.user_info_url: %q is not an absolute URL]",
+			idp.URL, "userinfo"),
+	}, summary(results))
+	assert.Equal(t, "/apisix", etcdConfs[0].Prefix)
+	for _, r := range results {
+		if r.Name == "etcd of cluster prod 10.0.0.1:2379" {
+			assert.Equal(t, "check clusters[0].username and clusters[0].password", r.Hint)
+		}
+	}
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	file := writeConfig(t, "conf:\n  listen:\n    port: 0\n")
+	results := NewChecker().Run(file)
+	assert.Equal(t, map[string]string{
+		"configuration file " + file: "fail: [conf.listen.port: Must be greater than or equal to 1]",
+		"schema":                     "ok",
+		"plugins":                    "skip: the configuration file is invalid",
+		"etcd":                       "skip: the configuration file is invalid",
+		"ldap":                       "skip: the configuration file is invalid",
+		"oidc":                       "skip: the configuration file is invalid",
+	}, summary(results))
+}
+
+func TestRun_SampleConfig(t *testing.T) {
+	c := NewChecker()
+	c.etcd = func(etcdConf *conf.Etcd) error { return nil }
+	file := filepath.Join(conf.WorkDir, "conf", "conf.yaml")
+
+	// the shipped configuration passes its own check, the plugins of recent
+	// APISIX versions which are not in the schema only warn
+	results := c.Run(file)
+	for _, r := range results {
+		assert.False(t, r.Failed(), "%s: %v", r.Name, r.Errors)
+	}
+	assert.Equal(t, "warn: [plugin chaitin-waf is not in the schema plugin body-transformer is not in the schema "+
+		"plugin degraphql is not in the schema plugin loki-logger is not in the schema plugin inspect is not in the schema]",
+		summary(results)["plugins"])
+}
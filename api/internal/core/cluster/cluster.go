@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/storage"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+// DefaultName is the name of the cluster of conf.etcd, requests which
+// select no cluster are served by it.
+const DefaultName = "default"
+
+var ErrNotFound = errors.New("cluster not found")
+
+// Cluster is an APISIX cluster managed by manager-api. Err is set when its
+// stores couldn't be initialized, the cluster is listed but can't serve
+// requests.
+type Cluster struct {
+	Name string
+	Etcd *conf.Etcd
+	Hub  *store.Hub
+	Err  error
+}
+
+var registry = map[string]*Cluster{}
+
+// Init registers the default cluster, whose stores are initialized by
+// store.InitStores, and the clusters of conf.clusters. A cluster which is
+// not reachable doesn't stop manager-api from serving the others.
+func Init() error {
+	Register(&Cluster{Name: DefaultName, Etcd: conf.ETCDConfig, Hub: store.DefaultHub()})
+	for name, etcdConf := range conf.Clusters {
+		c := &Cluster{Name: name, Etcd: etcdConf}
+		c.Hub, c.Err = open(etcdConf)
+		if c.Err != nil {
+			log.Errorf("init cluster %s failed: %s", name, c.Err)
+		}
+		Register(c)
+	}
+	return nil
+}
+
+func open(etcdConf *conf.Etcd) (*store.Hub, error) {
+	stg, err := storage.NewEtcdStorage(etcdConf)
+	if err != nil {
+		return nil, err
+	}
+	hub, err := store.NewHub(stg, etcdConf.Prefix)
+	if err != nil {
+		_ = stg.Close()
+		return nil, err
+	}
+	utils.AppendToClosers(stg.Close)
+	utils.AppendToClosers(hub.Close)
+	return hub, nil
+}
+
+// Register adds the cluster to the registry, replacing the cluster of the
+// same name.
+func Register(c *Cluster) {
+	registry[c.Name] = c
+}
+
+// Get returns the cluster with the name, an error when it is unknown or
+// unavailable.
+func Get(name string) (*Cluster, error) {
+	c, ok := registry[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if c.Err != nil {
+		return nil, fmt.Errorf("cluster %s is unavailable: %s", name, c.Err)
+	}
+	return c, nil
+}
+
+// List returns all clusters, the default one first and the others by name.
+func List() []*Cluster {
+	clusters := make([]*Cluster, 0, len(registry))
+	for _, c := range registry {
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if (clusters[i].Name == DefaultName) != (clusters[j].Name == DefaultName) {
+			return clusters[i].Name == DefaultName
+		}
+		return clusters[i].Name < clusters[j].Name
+	})
+	return clusters
+}
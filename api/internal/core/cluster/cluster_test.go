@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	origin := registry
+	registry = map[string]*Cluster{}
+	defer func() { registry = origin }()
+
+	Register(&Cluster{Name: "staging"})
+	Register(&Cluster{Name: "down", Err: errors.New("connection refused")})
+	Register(&Cluster{Name: DefaultName})
+
+	var names []string
+	for _, c := range List() {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{DefaultName, "down", "staging"}, names)
+
+	c, err := Get("staging")
+	assert.Nil(t, err)
+	assert.Equal(t, "staging", c.Name)
+
+	_, err = Get("unknown")
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = Get("down")
+	assert.EqualError(t, err, "cluster down is unavailable: connection refused")
+}
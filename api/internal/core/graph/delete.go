@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+// Plan is a cascading delete of the targets together with every object
+// using them.
+type Plan struct {
+	Targets []Node `json:"targets"`
+	Impact  []Node `json:"impact"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+// NewPlan returns the plan to delete the objects with the ids. The scripts
+// of deleted routes are deleted as well, since they belong to the route.
+func (g *Graph) NewPlan(typ store.HubKey, ids []string) (*Plan, error) {
+	plan := &Plan{}
+	for _, id := range ids {
+		n, ok := g.Node(typ, id)
+		if !ok {
+			return nil, fmt.Errorf("%s %s not found", typ, id)
+		}
+		plan.Targets = append(plan.Targets, n)
+	}
+	plan.Impact = g.Impact(plan.Targets...)
+
+	for _, n := range append(plan.Targets, plan.Impact...) {
+		if n.Type != store.HubKeyRoute {
+			continue
+		}
+		for _, script := range g.Uses(n) {
+			if script.Type == store.HubKeyScript {
+				plan.Impact = append(plan.Impact, script)
+			}
+		}
+	}
+	SortNodes(plan.Impact)
+
+	return plan, nil
+}
+
+// Delete deletes the objects of the plan, users before the objects they use.
+func Delete(ctx context.Context, stores Stores, plan *Plan) error {
+	nodes := append(append([]Node{}, plan.Impact...), plan.Targets...)
+	SortNodes(nodes)
+
+	for i := 0; i < len(nodes); {
+		typ := nodes[i].Type
+		var ids []string
+		for ; i < len(nodes) && nodes[i].Type == typ; i++ {
+			ids = append(ids, nodes[i].ID)
+		}
+		s, ok := stores[typ]
+		if !ok || s == nil {
+			return fmt.Errorf("no store with key: %s", typ)
+		}
+		if err := s.BatchDelete(ctx, ids); err != nil {
+			return fmt.Errorf("delete %s %v failed: %w", typ, ids, err)
+		}
+	}
+
+	return nil
+}
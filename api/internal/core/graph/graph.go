@@ -0,0 +1,258 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+// Types lists the stores the graph is built from, in the order objects are
+// deleted: users come before the objects they use.
+var Types = []store.HubKey{
+	store.HubKeyRoute,
+	store.HubKeyStreamRoute,
+	store.HubKeyGlobalRule,
+	store.HubKeyPluginConfig,
+	store.HubKeyService,
+	store.HubKeyConsumer,
+	store.HubKeyUpstream,
+	store.HubKeySsl,
+	store.HubKeyScript,
+	store.HubKeyProto,
+}
+
+// Node is an object of one of the stores.
+type Node struct {
+	Type store.HubKey `json:"type"`
+	ID   string       `json:"id"`
+	Name string       `json:"name,omitempty"`
+}
+
+func (n Node) key() string {
+	return string(n.Type) + "/" + n.ID
+}
+
+// Stores are the stores the graph is built from, keyed by type. Missing
+// stores are treated as empty.
+type Stores map[store.HubKey]store.Interface
+
+// DefaultStores returns the stores of the cluster of each request.
+func DefaultStores() Stores {
+	stores := Stores{}
+	for _, typ := range Types {
+		stores[typ] = store.NewContextStore(typ)
+	}
+	return stores
+}
+
+// HubStores returns the stores of the hub.
+func HubStores(hub *store.Hub) Stores {
+	stores := Stores{}
+	for _, typ := range Types {
+		stores[typ] = hub.GetStore(typ)
+	}
+	return stores
+}
+
+// Graph holds the references between all stored objects. An edge from a to
+// b means a uses b, so b can't be deleted as long as a exists.
+type Graph struct {
+	nodes  map[string]Node
+	uses   map[string]map[string]struct{}
+	usedBy map[string]map[string]struct{}
+}
+
+// Build loads all objects of the stores and resolves their references.
+// References to objects which don't exist are ignored.
+func Build(ctx context.Context, stores Stores) (*Graph, error) {
+	g := &Graph{
+		nodes:  map[string]Node{},
+		uses:   map[string]map[string]struct{}{},
+		usedBy: map[string]map[string]struct{}{},
+	}
+
+	objs := map[store.HubKey][]any{}
+	for _, typ := range Types {
+		s, ok := stores[typ]
+		if !ok || s == nil {
+			continue
+		}
+		typ := typ
+		ret, err := s.List(ctx, store.ListInput{
+			// consumers and scripts have no BaseInfo for the default order
+			Less: func(i, j any) bool {
+				a, _ := newNode(typ, i)
+				b, _ := newNode(typ, j)
+				return a.ID < b.ID
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range ret.Rows {
+			node, ok := newNode(typ, obj)
+			if !ok {
+				continue
+			}
+			g.nodes[node.key()] = node
+			objs[typ] = append(objs[typ], obj)
+		}
+	}
+
+	for _, typ := range Types {
+		for _, obj := range objs[typ] {
+			from, _ := newNode(typ, obj)
+			for _, to := range references(obj) {
+				g.addEdge(from, to)
+			}
+		}
+	}
+
+	return g, nil
+}
+
+func (g *Graph) addEdge(from, to Node) {
+	to, ok := g.nodes[to.key()]
+	if !ok || from.key() == to.key() {
+		return
+	}
+	if g.uses[from.key()] == nil {
+		g.uses[from.key()] = map[string]struct{}{}
+	}
+	g.uses[from.key()][to.key()] = struct{}{}
+	if g.usedBy[to.key()] == nil {
+		g.usedBy[to.key()] = map[string]struct{}{}
+	}
+	g.usedBy[to.key()][from.key()] = struct{}{}
+}
+
+// Node returns the stored object with the type and id.
+func (g *Graph) Node(typ store.HubKey, id string) (Node, bool) {
+	n, ok := g.nodes[Node{Type: typ, ID: id}.key()]
+	return n, ok
+}
+
+// NodeOf returns the node of an object of the type.
+func NodeOf(typ store.HubKey, obj any) (Node, bool) {
+	return newNode(typ, obj)
+}
+
+// References returns the objects the obj refers to, including objects which
+// don't exist.
+func References(obj any) []Node {
+	return references(obj)
+}
+
+// Uses returns the objects the node refers to.
+func (g *Graph) Uses(n Node) []Node {
+	return g.sorted(g.uses[n.key()])
+}
+
+// UsedBy returns the objects which refer to the node.
+func (g *Graph) UsedBy(n Node) []Node {
+	return g.sorted(g.usedBy[n.key()])
+}
+
+// Impact returns all objects which use the nodes directly or indirectly,
+// excluding the nodes themselves, in the order they have to be deleted.
+func (g *Graph) Impact(nodes ...Node) []Node {
+	return g.walk(g.usedBy, nodes)
+}
+
+// Dependencies returns all objects the nodes use directly or indirectly,
+// excluding the nodes themselves, in the order they have to be deleted.
+func (g *Graph) Dependencies(nodes ...Node) []Node {
+	return g.walk(g.uses, nodes)
+}
+
+// walk returns the nodes reachable from the nodes along the edges
+func (g *Graph) walk(edges map[string]map[string]struct{}, nodes []Node) []Node {
+	seen := map[string]struct{}{}
+	for _, n := range nodes {
+		seen[n.key()] = struct{}{}
+	}
+
+	reached := map[string]struct{}{}
+	queue := nodes
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for k := range edges[n.key()] {
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			reached[k] = struct{}{}
+			queue = append(queue, g.nodes[k])
+		}
+	}
+
+	return g.sorted(reached)
+}
+
+func (g *Graph) sorted(keys map[string]struct{}) []Node {
+	nodes := make([]Node, 0, len(keys))
+	for k := range keys {
+		nodes = append(nodes, g.nodes[k])
+	}
+	SortNodes(nodes)
+	return nodes
+}
+
+// SortNodes sorts the nodes in the order they have to be deleted.
+func SortNodes(nodes []Node) {
+	rank := map[store.HubKey]int{}
+	for i, typ := range Types {
+		rank[typ] = i
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Type != nodes[j].Type {
+			return rank[nodes[i].Type] < rank[nodes[j].Type]
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+}
+
+// CheckUnused returns an error if one of the objects is used by an object
+// which is not deleted along with it.
+func (g *Graph) CheckUnused(typ store.HubKey, ids []string) error {
+	deleted := map[string]struct{}{}
+	for _, id := range ids {
+		deleted[Node{Type: typ, ID: id}.key()] = struct{}{}
+	}
+	for _, id := range ids {
+		n, ok := g.Node(typ, id)
+		if !ok {
+			continue
+		}
+		for _, user := range g.UsedBy(n) {
+			if _, ok := deleted[user.key()]; ok {
+				continue
+			}
+			name := user.Name
+			if name == "" {
+				name = user.ID
+			}
+			return fmt.Errorf("%s: %s is using this %s", user.Type, name, typ)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func newStores() Stores {
+	return Stores{
+		store.HubKeyRoute: store.NewMockStore(store.HubKeyRoute,
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, Name: "route1", ServiceID: "s1", ScriptID: "r1"},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "r2"}, UpstreamID: "u1", PluginConfigID: "pc1", Hosts: []string{"a.foo.com"}},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "r3"}, Hosts: []string{"bar.com"}, Plugins: map[string]any{
+				"consumer-restriction": map[string]any{"whitelist": []any{"jack"}, "blacklist": []any{"rose"}},
+			}},
+		),
+		store.HubKeyService: store.NewMockStore(store.HubKeyService,
+			&entity.Service{BaseInfo: entity.BaseInfo{ID: "s1"}, UpstreamID: "u1"},
+		),
+		store.HubKeyUpstream: store.NewMockStore(store.HubKeyUpstream,
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}},
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u2"}},
+		),
+		store.HubKeyPluginConfig: store.NewMockStore(store.HubKeyPluginConfig,
+			&entity.PluginConfig{BaseInfo: entity.BaseInfo{ID: "pc1"}, Plugins: map[string]any{
+				"grpc-transcode": map[string]any{"proto_id": "p1"},
+			}},
+		),
+		store.HubKeyConsumer: store.NewMockStore(store.HubKeyConsumer,
+			&entity.Consumer{Username: "jack"},
+			&entity.Consumer{Username: "rose"},
+		),
+		store.HubKeyProto: store.NewMockStore(store.HubKeyProto,
+			&entity.Proto{BaseInfo: entity.BaseInfo{ID: "p1"}},
+		),
+		store.HubKeyScript: store.NewMockStore(store.HubKeyScript,
+			&entity.Script{ID: "r1"},
+		),
+		store.HubKeySsl: store.NewMockStore(store.HubKeySsl,
+			&entity.SSL{BaseInfo: entity.BaseInfo{ID: "ssl1"}, Snis: []string{"*.foo.com"}, Status: 1},
+			&entity.SSL{BaseInfo: entity.BaseInfo{ID: "ssl2"}, Snis: []string{"bar.com"}, Status: 1},
+			&entity.SSL{BaseInfo: entity.BaseInfo{ID: "ssl3"}, Snis: []string{"bar.com"}, Status: 1},
+		),
+	}
+}
+
+func ids(nodes []Node) []string {
+	var ret []string
+	for _, n := range nodes {
+		ret = append(ret, string(n.Type)+"/"+n.ID)
+	}
+	return ret
+}
+
+func TestBuild(t *testing.T) {
+	g, err := Build(context.Background(), newStores())
+	assert.Nil(t, err)
+
+	r1, ok := g.Node(store.HubKeyRoute, "r1")
+	assert.True(t, ok)
+	assert.Equal(t, "route1", r1.Name)
+	assert.Equal(t, []string{"service/s1", "script/r1"}, ids(g.Uses(r1)))
+
+	r2, _ := g.Node(store.HubKeyRoute, "r2")
+	assert.Equal(t, []string{"plugin_config/pc1", "upstream/u1"}, ids(g.Uses(r2)))
+
+	// hosts and consumer-restriction don't refer to certificates or consumers
+	r3, _ := g.Node(store.HubKeyRoute, "r3")
+	assert.Empty(t, g.Uses(r3))
+
+	u1, _ := g.Node(store.HubKeyUpstream, "u1")
+	assert.Equal(t, []string{"route/r2", "service/s1"}, ids(g.UsedBy(u1)))
+	assert.Equal(t, []string{"route/r1", "route/r2", "service/s1"}, ids(g.Impact(u1)))
+
+	p1, _ := g.Node(store.HubKeyProto, "p1")
+	assert.Equal(t, []string{"route/r2", "plugin_config/pc1"}, ids(g.Impact(p1)))
+
+	assert.Equal(t, []string{"plugin_config/pc1", "upstream/u1", "proto/p1"}, ids(g.Dependencies(r2)))
+	assert.Equal(t, []string{"service/s1", "upstream/u1", "script/r1"}, ids(g.Dependencies(r1)))
+
+	_, ok = g.Node(store.HubKeyUpstream, "u3")
+	assert.False(t, ok)
+}
+
+func TestCheckUnused(t *testing.T) {
+	g, err := Build(context.Background(), newStores())
+	assert.Nil(t, err)
+
+	assert.EqualError(t, g.CheckUnused(store.HubKeyUpstream, []string{"u1"}), "route: r2 is using this upstream")
+	assert.Nil(t, g.CheckUnused(store.HubKeyConsumer, []string{"jack"}))
+	assert.Nil(t, g.CheckUnused(store.HubKeySsl, []string{"ssl1"}))
+	assert.Nil(t, g.CheckUnused(store.HubKeyUpstream, []string{"u2"}))
+	assert.Nil(t, g.CheckUnused(store.HubKeyRoute, []string{"r1"}))
+}
+
+func TestPlanAndDelete(t *testing.T) {
+	stores := newStores()
+	g, err := Build(context.Background(), stores)
+	assert.Nil(t, err)
+
+	_, err = g.NewPlan(store.HubKeyService, []string{"s2"})
+	assert.EqualError(t, err, "service s2 not found")
+
+	plan, err := g.NewPlan(store.HubKeyService, []string{"s1"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"service/s1"}, ids(plan.Targets))
+	assert.Equal(t, []string{"route/r1", "script/r1"}, ids(plan.Impact))
+
+	var deleted []string
+	for _, typ := range []store.HubKey{store.HubKeyRoute, store.HubKeyService, store.HubKeyScript} {
+		typ := typ
+		stores[typ].(*store.MockInterface).On("BatchDelete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			for _, id := range args.Get(1).([]string) {
+				deleted = append(deleted, string(typ)+"/"+id)
+			}
+		}).Return(nil)
+	}
+
+	err = Delete(context.Background(), stores, plan)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"route/r1", "service/s1", "script/r1"}, deleted)
+}
+
+func TestPlanWithoutUsers(t *testing.T) {
+	g, err := Build(context.Background(), newStores())
+	assert.Nil(t, err)
+
+	// deleting a certificate or a consumer never deletes routes
+	plan, err := g.NewPlan(store.HubKeySsl, []string{"ssl1"})
+	assert.Nil(t, err)
+	assert.Empty(t, plan.Impact)
+	plan, err = g.NewPlan(store.HubKeyConsumer, []string{"jack", "rose"})
+	assert.Nil(t, err)
+	assert.Empty(t, plan.Impact)
+}
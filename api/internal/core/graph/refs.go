@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package graph
+
+import (
+	"strings"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+func newNode(typ store.HubKey, obj any) (Node, bool) {
+	switch o := obj.(type) {
+	case *entity.Route:
+		return Node{typ, utils.InterfaceToString(o.ID), o.Name}, true
+	case *entity.StreamRoute:
+		return Node{typ, utils.InterfaceToString(o.ID), o.Desc}, true
+	case *entity.GlobalPlugins:
+		return Node{typ, utils.InterfaceToString(o.ID), ""}, true
+	case *entity.PluginConfig:
+		return Node{typ, utils.InterfaceToString(o.ID), o.Desc}, true
+	case *entity.Service:
+		return Node{typ, utils.InterfaceToString(o.ID), o.Name}, true
+	case *entity.Consumer:
+		return Node{typ, o.Username, ""}, true
+	case *entity.Upstream:
+		return Node{typ, utils.InterfaceToString(o.ID), o.Name}, true
+	case *entity.SSL:
+		return Node{typ, utils.InterfaceToString(o.ID), strings.Join(o.Snis, ",")}, true
+	case *entity.Script:
+		return Node{typ, o.ID, ""}, true
+	case *entity.Proto:
+		return Node{typ, utils.InterfaceToString(o.ID), o.Desc}, true
+	}
+	return Node{}, false
+}
+
+func ref(typ store.HubKey, id any) []Node {
+	if id == nil {
+		return nil
+	}
+	s := utils.InterfaceToString(id)
+	if s == "" {
+		return nil
+	}
+	return []Node{{Type: typ, ID: s}}
+}
+
+// references returns the objects the obj refers to, they may not exist.
+// Consumers and certificates are not referenced by id, so they have no users
+// and can always be deleted.
+func references(obj any) []Node {
+	var refs []Node
+	switch o := obj.(type) {
+	case *entity.Route:
+		refs = append(refs, ref(store.HubKeyService, o.ServiceID)...)
+		refs = append(refs, ref(store.HubKeyUpstream, o.UpstreamID)...)
+		refs = append(refs, ref(store.HubKeyPluginConfig, o.PluginConfigID)...)
+		refs = append(refs, ref(store.HubKeyScript, o.ScriptID)...)
+		refs = append(refs, pluginReferences(o.Plugins)...)
+	case *entity.StreamRoute:
+		refs = append(refs, ref(store.HubKeyUpstream, o.UpstreamID)...)
+		refs = append(refs, pluginReferences(o.Plugins)...)
+	case *entity.Service:
+		refs = append(refs, ref(store.HubKeyUpstream, o.UpstreamID)...)
+		refs = append(refs, pluginReferences(o.Plugins)...)
+	case entity.GetPlugins:
+		refs = append(refs, pluginReferences(o.GetPlugins())...)
+	}
+	return refs
+}
+
+// pluginReferences returns the protos used by grpc-transcode.
+func pluginReferences(plugins map[string]any) []Node {
+	if conf, ok := plugins["grpc-transcode"].(map[string]any); ok {
+		return ref(store.HubKeyProto, conf["proto_id"])
+	}
+	return nil
+}
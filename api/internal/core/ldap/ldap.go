@@ -2,6 +2,7 @@ package ldap
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/apisix/manager-api/internal/conf"
@@ -10,36 +11,82 @@ import (
 )
 
 var (
-	l *ldap_v3.Conn
+	l    *ldap_v3.Conn
+	lock sync.Mutex
 )
 
+// Init connects to the LDAP server of the configuration, the connection of a
+// previous Init is closed so that the client is rebuilt when the
+// configuration is reloaded.
 func Init() {
+	lock.Lock()
+	defer lock.Unlock()
+	if l != nil {
+		l.Close()
+		l = nil
+	}
+
+	ldapConf := conf.GetLdapConfig()
+	if ldapConf == nil {
+		return
+	}
+	conn, err := connect(ldapConf)
+	if err != nil {
+		log.Error(err.Error())
+		if conn == nil {
+			return
+		}
+	}
+	l = conn
+}
+
+// connect dials the LDAP server and binds with the bind DN, the connection
+// is returned with the error of a failed bind.
+func connect(ldapConf *conf.Ldap) (*ldap_v3.Conn, error) {
 	// TODO implement ldap connection with TLS
-	var err error
-	l, err = ldap_v3.Dial("tcp", conf.LdapConfig.Host)
+	conn, err := ldap_v3.Dial("tcp", ldapConf.Host)
 	if err != nil {
-		log.Errorf("ldap connect error: %s", err)
+		return nil, fmt.Errorf("ldap connect error: %s", err)
 	}
-	err = l.Bind(conf.LdapConfig.BindDN, conf.LdapConfig.BindPassword)
+	err = conn.Bind(ldapConf.BindDN, ldapConf.BindPassword)
 	if err != nil {
-		log.Error("ldap bind failed, user or password is wrong")
+		return conn, fmt.Errorf("ldap bind failed, user or password is wrong")
 	}
+	return conn, nil
+}
+
+// Check connects to the LDAP server and binds with the bind DN
+func Check(ldapConf *conf.Ldap) error {
+	conn, err := connect(ldapConf)
+	if conn != nil {
+		conn.Close()
+	}
+	return err
 }
 
 func UserAuthentication(username, password string) bool {
+	lock.Lock()
+	defer lock.Unlock()
+	if l == nil {
+		log.Error("ldap is not connected")
+		return false
+	}
+
+	ldapConf := conf.GetLdapConfig()
 	searchRequest := ldap_v3.NewSearchRequest(
-		conf.LdapConfig.BaseDN,
+		ldapConf.BaseDN,
 		ldap_v3.ScopeWholeSubtree,
 		ldap_v3.NeverDerefAliases,
 		0,
 		int(30*time.Second),
 		false,
-		fmt.Sprintf(conf.LdapFilter, username),
+		fmt.Sprintf(ldapConf.Filter, username),
 		[]string{"cn"},
 		nil)
 	searchResult, err := l.Search(searchRequest)
 	if err != nil {
 		log.Error(err.Error())
+		return false
 	}
 	if len(searchResult.Entries) > 0 {
 		userDN := searchResult.Entries[0].DN
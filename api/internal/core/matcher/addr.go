@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"net"
+	"strings"
+)
+
+// parseAddr converts an IP or CIDR from remote_addrs into a network.
+func parseAddr(addr string) (*net.IPNet, bool) {
+	if strings.Contains(addr, "/") {
+		_, n, err := net.ParseCIDR(addr)
+		return n, err == nil
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, false
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)}, true
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, true
+}
+
+func addrMatch(addr, ip string) bool {
+	n, ok := parseAddr(addr)
+	if !ok {
+		return false
+	}
+	parsed := net.ParseIP(ip)
+	return parsed != nil && n.Contains(parsed)
+}
+
+// addrOverlap reports whether two networks share at least one address, an
+// unparsable address is assumed to overlap with everything.
+func addrOverlap(a, b string) bool {
+	na, okA := parseAddr(a)
+	nb, okB := parseAddr(b)
+	if !okA || !okB {
+		return true
+	}
+	return na.Contains(nb.IP) || nb.Contains(na.IP)
+}
@@ -0,0 +1,244 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"fmt"
+	"sort"
+)
+
+type Severity string
+
+const (
+	// SeverityConflict means both routes match the same requests on the same
+	// radixtree node with the same priority, so the winner is undefined.
+	SeverityConflict Severity = "conflict"
+	// SeverityShadow means both routes match the same requests but one of
+	// them always wins, the other only sees the remaining requests.
+	SeverityShadow Severity = "shadow"
+)
+
+// Example is a request that is matched by both routes of a conflict.
+type Example struct {
+	Host   string `json:"host,omitempty"`
+	Path   string `json:"path"`
+	Method string `json:"method,omitempty"`
+}
+
+type Conflict struct {
+	Severity      Severity `json:"severity"`
+	RouteID       string   `json:"route_id"`
+	RouteName     string   `json:"route_name"`
+	URI           string   `json:"uri"`
+	Priority      int      `json:"priority"`
+	OtherID       string   `json:"other_id"`
+	OtherName     string   `json:"other_name"`
+	OtherURI      string   `json:"other_uri"`
+	OtherPriority int      `json:"other_priority"`
+	// WinnerID is the route that handles the example request, it is empty
+	// when the match order is undefined.
+	WinnerID string  `json:"winner_id,omitempty"`
+	Example  Example `json:"example"`
+	// Conditional is set when vars or filter_func take part in matching and
+	// may separate the routes at runtime.
+	Conditional bool   `json:"conditional,omitempty"`
+	Reason      string `json:"reason"`
+}
+
+// Analyze checks every pair of rules and returns the overlapping ones.
+func Analyze(rules []*Rule) []Conflict {
+	conflicts := make([]Conflict, 0)
+	for i := range rules {
+		for j := i + 1; j < len(rules); j++ {
+			if c := compare(rules[i], rules[j]); c != nil {
+				conflicts = append(conflicts, *c)
+			}
+		}
+	}
+	sortConflicts(conflicts)
+	return conflicts
+}
+
+// Check returns the overlaps between a single rule and all other rules,
+// rules with the same ID are skipped so that an update is not compared with
+// its stored version.
+func Check(rule *Rule, others []*Rule) []Conflict {
+	conflicts := make([]Conflict, 0)
+	for _, other := range others {
+		if other.ID == rule.ID {
+			continue
+		}
+		if c := compare(rule, other); c != nil {
+			conflicts = append(conflicts, *c)
+		}
+	}
+	sortConflicts(conflicts)
+	return conflicts
+}
+
+func sortConflicts(conflicts []Conflict) {
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		if conflicts[i].Severity != conflicts[j].Severity {
+			return conflicts[i].Severity == SeverityConflict
+		}
+		if conflicts[i].RouteID != conflicts[j].RouteID {
+			return conflicts[i].RouteID < conflicts[j].RouteID
+		}
+		return conflicts[i].OtherID < conflicts[j].OtherID
+	})
+}
+
+func methodOverlap(a, b []string) (string, bool) {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return "", true
+	case len(a) == 0:
+		return b[0], true
+	case len(b) == 0:
+		return a[0], true
+	}
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return x, true
+			}
+		}
+	}
+	return "", false
+}
+
+func remoteAddrOverlap(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, x := range a {
+		for _, y := range b {
+			if addrOverlap(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hostCandidates returns the host patterns of a rule, a nil entry stands
+// for a rule without hosts.
+func hostCandidates(r *Rule) []*hostPattern {
+	if len(r.Hosts) == 0 {
+		return []*hostPattern{nil}
+	}
+	ret := make([]*hostPattern, 0, len(r.Hosts))
+	for i := range r.Hosts {
+		ret = append(ret, &r.Hosts[i])
+	}
+	return ret
+}
+
+// order decides which of two overlapping routes APISIX tries first, it
+// follows the radixtree_host_uri router which is the default since APISIX
+// 2.x: routes with hosts first, then by uri specificity, then by priority.
+func order(a, b *Rule, ha, hb *hostPattern, ua, ub *URIPattern) (aFirst bool, decided bool, reason string) {
+	switch {
+	case ha != nil && hb == nil:
+		return true, true, "routes with hosts are matched before routes without hosts"
+	case ha == nil && hb != nil:
+		return false, true, "routes with hosts are matched before routes without hosts"
+	case ha != nil && hb != nil:
+		if first, ok := moreSpecificHost(*ha, *hb); ok {
+			return first, true, "exact hosts and longer wildcard hosts are matched first"
+		}
+	}
+
+	if first, ok := moreSpecificURI(ua, ub); ok {
+		if ua.exact != ub.exact {
+			return first, true, "exact uris are matched before prefix uris"
+		}
+		return first, true, "longer uri prefixes are matched first"
+	}
+
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority, true, "routes with higher priority are matched first"
+	}
+	return false, false, fmt.Sprintf("both routes have priority %d on the same path, the match order is undefined", a.Priority)
+}
+
+func compare(a, b *Rule) *Conflict {
+	method, ok := methodOverlap(a.Methods, b.Methods)
+	if !ok {
+		return nil
+	}
+	if !remoteAddrOverlap(a.RemoteAddrs, b.RemoteAddrs) {
+		return nil
+	}
+	if varsDisjoint(a.Vars, b.Vars) {
+		return nil
+	}
+
+	var found *Conflict
+	for _, ha := range hostCandidates(a) {
+		for _, hb := range hostCandidates(b) {
+			host := ""
+			switch {
+			case ha != nil && hb != nil:
+				if host, ok = ha.overlap(*hb); !ok {
+					continue
+				}
+			case ha != nil:
+				host, _ = ha.overlap(*ha)
+			case hb != nil:
+				host, _ = hb.overlap(*hb)
+			}
+
+			for _, ua := range a.Uris {
+				for _, ub := range b.Uris {
+					path, ok := ua.Overlap(ub)
+					if !ok {
+						continue
+					}
+
+					c := &Conflict{
+						Severity:      SeverityShadow,
+						RouteID:       a.ID,
+						RouteName:     a.Name,
+						URI:           ua.Raw,
+						Priority:      a.Priority,
+						OtherID:       b.ID,
+						OtherName:     b.Name,
+						OtherURI:      ub.Raw,
+						OtherPriority: b.Priority,
+						Example:       Example{Host: host, Path: path, Method: method},
+						Conditional:   a.conditional() || b.conditional(),
+					}
+					aFirst, decided, reason := order(a, b, ha, hb, ua, ub)
+					c.Reason = reason
+					if !decided {
+						c.Severity = SeverityConflict
+						return c
+					}
+					c.WinnerID = b.ID
+					if aFirst {
+						c.WinnerID = a.ID
+					}
+					if found == nil {
+						found = c
+					}
+				}
+			}
+		}
+	}
+	return found
+}
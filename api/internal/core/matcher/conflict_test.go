@@ -0,0 +1,188 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+func newRoute(id string, fn func(r *entity.Route)) *entity.Route {
+	r := &entity.Route{
+		BaseInfo: entity.BaseInfo{ID: id},
+		Name:     "route_" + id,
+		Status:   1,
+	}
+	fn(r)
+	return r
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		caseDesc   string
+		routes     []*entity.Route
+		service    *entity.Service
+		wantCount  int
+		wantSev    Severity
+		wantWinner string
+	}{
+		{
+			caseDesc: "same uri and same priority",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/hello" }),
+				newRoute("2", func(r *entity.Route) { r.Uris = []string{"/hello"} }),
+			},
+			wantCount: 1,
+			wantSev:   SeverityConflict,
+		},
+		{
+			caseDesc: "same uri with different priority",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/hello" }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/hello"; r.Priority = 10 }),
+			},
+			wantCount:  1,
+			wantSev:    SeverityShadow,
+			wantWinner: "2",
+		},
+		{
+			caseDesc: "exact uri wins over prefix",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/api/*"; r.Priority = 100 }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/api/users" }),
+			},
+			wantCount:  1,
+			wantSev:    SeverityShadow,
+			wantWinner: "2",
+		},
+		{
+			caseDesc: "param and wildcard share the radixtree node",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/user/:id" }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/user/*" }),
+			},
+			wantCount: 1,
+			wantSev:   SeverityConflict,
+		},
+		{
+			caseDesc: "disjoint methods",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/hello"; r.Methods = []string{"GET"} }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/hello"; r.Methods = []string{"post"} }),
+			},
+			wantCount: 0,
+		},
+		{
+			caseDesc: "disjoint hosts",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/hello"; r.Host = "a.com" }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/hello"; r.Hosts = []string{"*.b.com"} }),
+			},
+			wantCount: 0,
+		},
+		{
+			caseDesc: "wildcard host overlaps",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/hello"; r.Host = "api.foo.com" }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/hello"; r.Hosts = []string{"*.foo.com"}; r.Priority = 9 }),
+			},
+			wantCount:  1,
+			wantSev:    SeverityShadow,
+			wantWinner: "1",
+		},
+		{
+			caseDesc: "route with host wins over route without host",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/hello"; r.Priority = 100 }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/hello"; r.ServiceID = "s1" }),
+			},
+			service:    &entity.Service{Hosts: []string{"foo.com"}},
+			wantCount:  1,
+			wantSev:    SeverityShadow,
+			wantWinner: "2",
+		},
+		{
+			caseDesc: "disjoint remote addrs",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/hello"; r.RemoteAddrs = []string{"10.0.0.0/8"} }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/hello"; r.RemoteAddr = "192.168.1.1" }),
+			},
+			wantCount: 0,
+		},
+		{
+			caseDesc: "overlapping remote addrs",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/hello"; r.RemoteAddrs = []string{"10.0.0.0/8"} }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/hello"; r.RemoteAddr = "10.1.1.1" }),
+			},
+			wantCount: 1,
+			wantSev:   SeverityConflict,
+		},
+		{
+			caseDesc: "disjoint vars",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/hello"; r.Vars = []any{[]any{"arg_v", "==", "1"}} }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/hello"; r.Vars = []any{[]any{"arg_v", "==", "2"}} }),
+			},
+			wantCount: 0,
+		},
+		{
+			caseDesc: "disjoint uris",
+			routes: []*entity.Route{
+				newRoute("1", func(r *entity.Route) { r.URI = "/a/*" }),
+				newRoute("2", func(r *entity.Route) { r.URI = "/b/*" }),
+			},
+			wantCount: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.caseDesc, func(t *testing.T) {
+			var rules []*Rule
+			for _, r := range tc.routes {
+				var svc *entity.Service
+				if r.ServiceID != nil {
+					svc = tc.service
+				}
+				rules = append(rules, NewRule(r, svc))
+			}
+
+			conflicts := Analyze(rules)
+			assert.Len(t, conflicts, tc.wantCount)
+			if tc.wantCount > 0 {
+				assert.Equal(t, tc.wantSev, conflicts[0].Severity)
+				assert.Equal(t, tc.wantWinner, conflicts[0].WinnerID)
+			}
+		})
+	}
+}
+
+func TestCheck_SkipSelf(t *testing.T) {
+	stored := NewRule(newRoute("1", func(r *entity.Route) { r.URI = "/hello" }), nil)
+	updated := NewRule(newRoute("1", func(r *entity.Route) { r.URI = "/hello"; r.Priority = 1 }), nil)
+	other := NewRule(newRoute("2", func(r *entity.Route) { r.URI = "/hello*" }), nil)
+
+	conflicts := Check(updated, []*Rule{stored, other})
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, "2", conflicts[0].OtherID)
+	assert.Equal(t, "1", conflicts[0].WinnerID)
+	assert.Equal(t, Example{Path: "/hello"}, conflicts[0].Example)
+	assert.False(t, conflicts[0].Conditional)
+}
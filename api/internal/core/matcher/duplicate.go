@@ -0,0 +1,49 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package matcher
+
+import (
+	"github.com/juliangruber/go-intersect"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+// Duplicated reports whether two routes match the same requests. When a
+// duplicate host or uri has been found, the HTTP methods are checked for
+// overlap, and if there is overlap it is determined to be a duplicate route.
+func Duplicated(r, route *entity.Route) bool {
+	// Check URI and host duplication
+	isURIDuplicated := r.URI != "" && route.URI != "" && r.URI == route.URI
+	isURIsDuplicated := len(r.Uris) > 0 && len(route.Uris) > 0 &&
+		len(intersect.Hash(r.Uris, route.Uris)) > 0
+	isMethodDuplicated := len(intersect.Hash(r.Methods, route.Methods)) > 0
+
+	// First check for duplicate URIs
+	if isURIDuplicated || isURIsDuplicated {
+		// Then check if the host field exists, and if it does, check for duplicates
+		if r.Host != "" && route.Host != "" {
+			return r.Host == route.Host && isMethodDuplicated
+		} else if len(r.Hosts) > 0 && len(route.Hosts) > 0 {
+			return len(intersect.Hash(r.Hosts, route.Hosts)) > 0 && isMethodDuplicated
+		}
+		// If the host field does not exist, only the presence or absence
+		// of HTTP method duplication is returned by default.
+		return isMethodDuplicated
+	}
+	return false
+}
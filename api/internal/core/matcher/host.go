@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"strings"
+)
+
+// hostPattern is a route host, either an exact domain or a wildcard domain
+// like "*.example.com" which matches any subdomain of example.com.
+type hostPattern struct {
+	raw      string
+	wildcard bool
+	// suffix is ".example.com" for wildcard hosts and the full host otherwise
+	suffix string
+}
+
+func parseHost(host string) hostPattern {
+	host = strings.ToLower(host)
+	if strings.HasPrefix(host, "*") {
+		return hostPattern{raw: host, wildcard: true, suffix: host[1:]}
+	}
+	return hostPattern{raw: host, suffix: host}
+}
+
+func (h hostPattern) match(host string) bool {
+	host = strings.ToLower(host)
+	if h.wildcard {
+		return len(host) > len(h.suffix) && strings.HasSuffix(host, h.suffix)
+	}
+	return host == h.suffix
+}
+
+// overlap returns a host matched by both patterns.
+func (h hostPattern) overlap(o hostPattern) (string, bool) {
+	switch {
+	case !h.wildcard:
+		return h.raw, o.match(h.raw)
+	case !o.wildcard:
+		return o.raw, h.match(o.raw)
+	case strings.HasSuffix(h.suffix, o.suffix):
+		return "a" + h.suffix, true
+	case strings.HasSuffix(o.suffix, h.suffix):
+		return "a" + o.suffix, true
+	}
+	return "", false
+}
+
+// moreSpecificHost reports whether the radixtree_host_uri router tries host
+// a before host b: exact hosts come before wildcard hosts, and a longer
+// wildcard suffix before a shorter one. ok is false when both hosts are
+// on the same level.
+func moreSpecificHost(a, b hostPattern) (aFirst bool, ok bool) {
+	if a.wildcard != b.wildcard {
+		return !a.wildcard, true
+	}
+	if len(a.suffix) == len(b.suffix) {
+		return false, false
+	}
+	return len(a.suffix) > len(b.suffix), true
+}
@@ -0,0 +1,197 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Candidate is a rule matching a request, together with the host and uri
+// pattern that matched.
+type Candidate struct {
+	Rule *Rule
+	host *hostPattern
+	uri  *URIPattern
+}
+
+func (c *Candidate) URI() string {
+	return c.uri.Raw
+}
+
+func (c *Candidate) Host() string {
+	if c.host == nil {
+		return ""
+	}
+	return c.host.raw
+}
+
+// rank is the (host, uri, priority) specificity of the candidate, greater
+// values are matched first. The candidates of a request have nested uri
+// prefixes and hosts matching the same name, so comparing lengths agrees
+// with order.
+func (c *Candidate) rank() [6]int {
+	var r [6]int
+	if c.host != nil {
+		r[0] = 1
+		if !c.host.wildcard {
+			r[1] = 1
+		}
+		r[2] = len(c.host.suffix)
+	}
+	if c.uri.exact {
+		r[3] = 1
+	}
+	r[4] = len(c.uri.prefix)
+	r[5] = c.Rule.Priority
+	return r
+}
+
+// less compares the (rank, id) tuples lexicographically, the id keeps the
+// output stable for routes without a defined order.
+func (c *Candidate) less(o *Candidate) bool {
+	a, b := c.rank(), o.rank()
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return c.Rule.ID < o.Rule.ID
+}
+
+type MatchResult struct {
+	// Matched is the candidate APISIX selects, nil when no route matches
+	Matched *Candidate
+	// Ambiguous holds the candidates that APISIX may select instead of
+	// Matched because they are on the same radixtree node with the same
+	// priority
+	Ambiguous []*Candidate
+	// Candidates holds every matching rule in match order
+	Candidates []*Candidate
+	// Warnings lists the conditions that could not be evaluated offline
+	Warnings []string
+}
+
+// Match selects the rule APISIX would use for the request, following the
+// same order as the conflict analysis.
+func Match(rules []*Rule, req Request) *MatchResult {
+	req.Normalize()
+	ret := &MatchResult{}
+
+	for _, rule := range rules {
+		c, warning := matchRule(rule, &req)
+		if warning != "" {
+			ret.Warnings = append(ret.Warnings, warning)
+		}
+		if c != nil {
+			ret.Candidates = append(ret.Candidates, c)
+		}
+	}
+	if len(ret.Candidates) == 0 {
+		return ret
+	}
+
+	sort.Slice(ret.Candidates, func(i, j int) bool {
+		return ret.Candidates[i].less(ret.Candidates[j])
+	})
+
+	ret.Matched = ret.Candidates[0]
+	for _, c := range ret.Candidates[1:] {
+		if _, decided, _ := order(ret.Matched.Rule, c.Rule, ret.Matched.host, c.host, ret.Matched.uri, c.uri); !decided {
+			ret.Ambiguous = append(ret.Ambiguous, c)
+		}
+	}
+	return ret
+}
+
+// matchRule checks every condition of the rule, when it matches the most
+// specific host and uri of the rule are returned.
+func matchRule(rule *Rule, req *Request) (*Candidate, string) {
+	if len(rule.Methods) > 0 && !containsString(rule.Methods, req.Method) {
+		return nil, ""
+	}
+
+	if len(rule.RemoteAddrs) > 0 {
+		matched := false
+		for _, addr := range rule.RemoteAddrs {
+			if addrMatch(addr, req.ClientIP) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, ""
+		}
+	}
+
+	c := &Candidate{Rule: rule}
+	if len(rule.Hosts) > 0 {
+		for i := range rule.Hosts {
+			h := &rule.Hosts[i]
+			if !h.match(req.Host) {
+				continue
+			}
+			if c.host == nil {
+				c.host = h
+			} else if first, ok := moreSpecificHost(*h, *c.host); ok && first {
+				c.host = h
+			}
+		}
+		if c.host == nil {
+			return nil, ""
+		}
+	}
+
+	for _, uri := range rule.Uris {
+		if !uri.Match(req.Path) {
+			continue
+		}
+		if c.uri == nil {
+			c.uri = uri
+		} else if first, ok := moreSpecificURI(uri, c.uri); ok && first {
+			c.uri = uri
+		}
+	}
+	if c.uri == nil {
+		return nil, ""
+	}
+
+	if len(rule.Vars) > 0 {
+		ok, err := evalVars(rule.Vars, req)
+		if err != nil {
+			return nil, fmt.Sprintf("route %s: %s", rule.ID, err)
+		}
+		if !ok {
+			return nil, ""
+		}
+	}
+
+	warning := ""
+	if rule.FilterFunc != "" {
+		warning = fmt.Sprintf("route %s: filter_func is not evaluated and assumed to pass", rule.ID)
+	}
+	return c, warning
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
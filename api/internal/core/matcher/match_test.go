@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+func TestMatch(t *testing.T) {
+	rules := []*Rule{
+		NewRule(newRoute("prefix", func(r *entity.Route) { r.URI = "/api/*"; r.Priority = 100 }), nil),
+		NewRule(newRoute("exact", func(r *entity.Route) { r.URI = "/api/users"; r.Methods = []string{"GET"} }), nil),
+		NewRule(newRoute("host", func(r *entity.Route) { r.URI = "/api/*"; r.Hosts = []string{"*.foo.com"} }), nil),
+		NewRule(newRoute("vars", func(r *entity.Route) {
+			r.URI = "/v/*"
+			r.Vars = []any{[]any{"arg_version", "==", "2"}, []any{"http_x_env", "~~", "^prod"}}
+		}), nil),
+		NewRule(newRoute("ip", func(r *entity.Route) { r.URI = "/v/*"; r.RemoteAddrs = []string{"10.0.0.0/8"} }), nil),
+	}
+
+	tests := []struct {
+		caseDesc   string
+		req        Request
+		want       string
+		candidates int
+	}{
+		{
+			caseDesc:   "exact uri wins over higher priority prefix",
+			req:        Request{Method: "get", Path: "/api/users"},
+			want:       "exact",
+			candidates: 2,
+		},
+		{
+			caseDesc:   "method mismatch falls back to prefix",
+			req:        Request{Method: "POST", Path: "/api/users"},
+			want:       "prefix",
+			candidates: 1,
+		},
+		{
+			caseDesc:   "route with host wins",
+			req:        Request{Host: "a.foo.com", Path: "/api/users"},
+			want:       "host",
+			candidates: 3,
+		},
+		{
+			caseDesc:   "vars from query string in path and headers",
+			req:        Request{Path: "/v/1?version=2", Headers: map[string]string{"X-Env": "production"}},
+			want:       "vars",
+			candidates: 1,
+		},
+		{
+			caseDesc:   "remote addr",
+			req:        Request{Path: "/v/1", ClientIP: "10.2.3.4"},
+			want:       "ip",
+			candidates: 1,
+		},
+		{
+			caseDesc: "no match",
+			req:      Request{Path: "/none"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.caseDesc, func(t *testing.T) {
+			ret := Match(rules, tc.req)
+			assert.Len(t, ret.Candidates, tc.candidates)
+			if tc.want == "" {
+				assert.Nil(t, ret.Matched)
+				return
+			}
+			assert.Equal(t, tc.want, ret.Matched.Rule.ID)
+			assert.Empty(t, ret.Ambiguous)
+		})
+	}
+}
+
+func TestMatch_Ambiguous(t *testing.T) {
+	rules := []*Rule{
+		NewRule(newRoute("2", func(r *entity.Route) { r.URI = "/user/:id" }), nil),
+		NewRule(newRoute("1", func(r *entity.Route) { r.URI = "/user/*"; r.FilterFunc = "function() return true end" }), nil),
+	}
+
+	ret := Match(rules, Request{Path: "/user/1"})
+	assert.Equal(t, "1", ret.Matched.Rule.ID)
+	assert.Len(t, ret.Ambiguous, 1)
+	assert.Equal(t, "2", ret.Ambiguous[0].Rule.ID)
+	assert.Len(t, ret.Warnings, 1)
+}
+
+func TestMatch_Order(t *testing.T) {
+	rules := []*Rule{
+		NewRule(newRoute("c", func(r *entity.Route) { r.URI = "/api/*" }), nil),
+		NewRule(newRoute("b", func(r *entity.Route) { r.URI = "/api/*"; r.Priority = 10 }), nil),
+		NewRule(newRoute("a", func(r *entity.Route) { r.URI = "/api/*" }), nil),
+		NewRule(newRoute("long", func(r *entity.Route) { r.URI = "/api/users/*" }), nil),
+		NewRule(newRoute("exact", func(r *entity.Route) { r.URI = "/api/users/1" }), nil),
+		NewRule(newRoute("wildcard", func(r *entity.Route) { r.URI = "/*"; r.Hosts = []string{"*.foo.com"} }), nil),
+		NewRule(newRoute("host", func(r *entity.Route) { r.URI = "/*"; r.Hosts = []string{"a.foo.com"} }), nil),
+	}
+	want := []string{"host", "wildcard", "exact", "long", "b", "a", "c"}
+
+	// every rotation of the rules gives the same order
+	for i := range rules {
+		rotated := append(append([]*Rule{}, rules[i:]...), rules[:i]...)
+		var got []string
+		for _, c := range Match(rotated, Request{Host: "a.foo.com", Path: "/api/users/1"}).Candidates {
+			got = append(got, c.Rule.ID)
+		}
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestEvalVars(t *testing.T) {
+	req := &Request{
+		Method:   "GET",
+		Path:     "/hello",
+		ClientIP: "192.168.1.10",
+		Args:     map[string]string{"age": "20", "name": "json"},
+		Headers:  map[string]string{"Cookie": "session=abc", "Accept": "a, b"},
+	}
+
+	tests := []struct {
+		vars []any
+		want bool
+	}{
+		{[]any{[]any{"arg_name", "==", "json"}}, true},
+		{[]any{[]any{"arg_name", "json"}}, true},
+		{[]any{[]any{"arg_name", "~=", "json"}}, false},
+		{[]any{[]any{"arg_missing", "~=", "json"}}, true},
+		{[]any{[]any{"arg_age", ">", float64(18)}}, true},
+		{[]any{[]any{"arg_age", "<=", float64(18)}}, false},
+		{[]any{[]any{"arg_name", "~*", "JS"}}, true},
+		{[]any{[]any{"arg_name", "in", []any{"xml", "json"}}}, true},
+		{[]any{[]any{"http_accept", "has", "b"}}, true},
+		{[]any{[]any{"cookie_session", "==", "abc"}}, true},
+		{[]any{[]any{"remote_addr", "ipmatch", []any{"192.168.0.0/16"}}}, true},
+		{[]any{[]any{"arg_name", "!", "==", "json"}}, false},
+		{[]any{[]any{"OR", []any{"arg_name", "==", "xml"}, []any{"uri", "==", "/hello"}}}, true},
+		{[]any{[]any{"!AND", []any{"arg_name", "==", "json"}, []any{"request_method", "==", "GET"}}}, false},
+	}
+
+	for _, tc := range tests {
+		ret, err := evalVars(tc.vars, req)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.want, ret, "%v", tc.vars)
+	}
+
+	_, err := evalVars([]any{[]any{"arg_name", "=~", "json"}}, req)
+	assert.EqualError(t, err, "unsupported operator in vars: =~")
+}
@@ -0,0 +1,110 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Request is the part of an HTTP request that APISIX uses to select a route.
+type Request struct {
+	Method   string            `json:"method"`
+	Host     string            `json:"host"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	ClientIP string            `json:"client_ip,omitempty"`
+	Args     map[string]string `json:"args,omitempty"`
+}
+
+// Normalize fills the defaults and moves a query string carried in the path
+// into the args.
+func (r *Request) Normalize() {
+	r.Method = strings.ToUpper(r.Method)
+	if r.Method == "" {
+		r.Method = http.MethodGet
+	}
+	if r.Path == "" {
+		r.Path = "/"
+	}
+	idx := strings.Index(r.Path, "?")
+	if idx < 0 {
+		return
+	}
+	query, err := url.ParseQuery(r.Path[idx+1:])
+	r.Path = r.Path[:idx]
+	if err != nil {
+		return
+	}
+	if r.Args == nil {
+		r.Args = make(map[string]string)
+	}
+	for k, v := range query {
+		if _, ok := r.Args[k]; !ok && len(v) > 0 {
+			r.Args[k] = v[0]
+		}
+	}
+}
+
+// Header returns a request header, the lookup is case insensitive.
+func (r *Request) Header(name string) string {
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func (r *Request) cookie(name string) (string, bool) {
+	header := http.Header{}
+	header.Set("Cookie", r.Header("Cookie"))
+	c, err := (&http.Request{Header: header}).Cookie(name)
+	if err != nil {
+		return "", false
+	}
+	return c.Value, true
+}
+
+// Var resolves an nginx variable like "arg_name", "http_x_token" or
+// "cookie_session" which route vars refer to.
+func (r *Request) Var(name string) (string, bool) {
+	switch {
+	case strings.HasPrefix(name, "arg_"):
+		v, ok := r.Args[strings.TrimPrefix(name, "arg_")]
+		return v, ok
+	case strings.HasPrefix(name, "http_"):
+		header := strings.ReplaceAll(strings.TrimPrefix(name, "http_"), "_", "-")
+		v := r.Header(header)
+		return v, v != ""
+	case strings.HasPrefix(name, "cookie_"):
+		return r.cookie(strings.TrimPrefix(name, "cookie_"))
+	}
+
+	switch name {
+	case "uri":
+		return r.Path, true
+	case "host":
+		return r.Host, r.Host != ""
+	case "remote_addr":
+		return r.ClientIP, r.ClientIP != ""
+	case "request_method":
+		return r.Method, true
+	}
+	return "", false
+}
@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"strings"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+// routeDisabled is the route status that removes a route from the router
+const routeDisabled entity.Status = 0
+
+// Rule is the set of matching conditions of a route, normalized so that the
+// singular and plural fields are merged and service hosts are inherited.
+type Rule struct {
+	ID          string
+	Name        string
+	Uris        []*URIPattern
+	Hosts       []hostPattern
+	Methods     []string
+	RemoteAddrs []string
+	Vars        []any
+	FilterFunc  string
+	Priority    int
+}
+
+// NewRule builds the rule of a route, service is the service the route
+// refers to and may be nil.
+func NewRule(route *entity.Route, service *entity.Service) *Rule {
+	rule := &Rule{
+		ID:         utils.InterfaceToString(route.ID),
+		Name:       route.Name,
+		Vars:       route.Vars,
+		FilterFunc: route.FilterFunc,
+		Priority:   route.Priority,
+	}
+
+	uris := route.Uris
+	if route.URI != "" {
+		uris = append([]string{route.URI}, uris...)
+	}
+	for _, uri := range uris {
+		rule.Uris = append(rule.Uris, ParseURI(uri))
+	}
+
+	hosts := route.Hosts
+	if route.Host != "" {
+		hosts = append([]string{route.Host}, hosts...)
+	}
+	// hosts of the route take precedence over the hosts of its service
+	if len(hosts) == 0 && service != nil {
+		hosts = service.Hosts
+	}
+	for _, host := range hosts {
+		rule.Hosts = append(rule.Hosts, parseHost(host))
+	}
+
+	for _, method := range route.Methods {
+		rule.Methods = append(rule.Methods, strings.ToUpper(method))
+	}
+
+	rule.RemoteAddrs = route.RemoteAddrs
+	if route.RemoteAddr != "" {
+		rule.RemoteAddrs = append([]string{route.RemoteAddr}, rule.RemoteAddrs...)
+	}
+
+	return rule
+}
+
+// RouteEnabled reports whether the route takes part in request matching.
+func RouteEnabled(route *entity.Route) bool {
+	return route.Status != routeDisabled
+}
+
+// conditional reports whether the rule has conditions that cannot be
+// analyzed statically.
+func (r *Rule) conditional() bool {
+	return len(r.Vars) > 0 || r.FilterFunc != ""
+}
@@ -0,0 +1,255 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"strings"
+)
+
+// charClass is the set of characters a single pattern item accepts.
+type charClass struct {
+	// any accepts every character
+	any bool
+	// noSlash accepts every character except '/'
+	noSlash bool
+	// char is the only accepted character when neither flag is set
+	char byte
+}
+
+func (c charClass) accepts(b byte) bool {
+	switch {
+	case c.any:
+		return true
+	case c.noSlash:
+		return b != '/'
+	default:
+		return c.char == b
+	}
+}
+
+// intersect returns a character accepted by both classes.
+func (c charClass) intersect(o charClass) (byte, bool) {
+	switch {
+	case !c.any && !c.noSlash:
+		return c.char, o.accepts(c.char)
+	case !o.any && !o.noSlash:
+		return o.char, c.accepts(o.char)
+	default:
+		// both are wide classes, a plain letter satisfies either of them
+		return 'x', true
+	}
+}
+
+type patternItem struct {
+	class charClass
+	// repeat marks the item as "zero or more"
+	repeat bool
+}
+
+// URIPattern is a parsed APISIX uri, following lua-resty-radixtree syntax:
+// a trailing "*" (optionally named, e.g. "/foo/*path") is a prefix match and
+// ":name" matches exactly one non-empty path segment.
+type URIPattern struct {
+	Raw   string
+	items []patternItem
+	// prefix is the literal part that is used as the radixtree key
+	prefix string
+	exact  bool
+}
+
+func ParseURI(uri string) *URIPattern {
+	p := &URIPattern{Raw: uri, exact: true}
+	for i := 0; i < len(uri); i++ {
+		switch uri[i] {
+		case '*':
+			// anything after the star is only the name of the capture
+			p.items = append(p.items, patternItem{class: charClass{any: true}, repeat: true})
+			p.markPrefix(uri[:i])
+			return p
+		case ':':
+			j := i + 1
+			for j < len(uri) && uri[j] != '/' {
+				j++
+			}
+			p.items = append(p.items,
+				patternItem{class: charClass{noSlash: true}},
+				patternItem{class: charClass{noSlash: true}, repeat: true})
+			p.markPrefix(uri[:i])
+			i = j - 1
+		default:
+			p.items = append(p.items, patternItem{class: charClass{char: uri[i]}})
+		}
+	}
+	if p.exact {
+		p.prefix = uri
+	}
+	return p
+}
+
+func (p *URIPattern) markPrefix(prefix string) {
+	if p.exact {
+		p.exact = false
+		p.prefix = prefix
+	}
+}
+
+// IsExact reports whether the pattern only matches its literal value.
+func (p *URIPattern) IsExact() bool {
+	return p.exact
+}
+
+// Prefix returns the radixtree key of the pattern.
+func (p *URIPattern) Prefix() string {
+	return p.prefix
+}
+
+// Match reports whether the request path is matched by the pattern.
+func (p *URIPattern) Match(path string) bool {
+	// the set of item positions reachable after consuming a prefix of the path
+	states := p.closure(map[int]bool{0: true})
+	for i := 0; i < len(path); i++ {
+		next := make(map[int]bool)
+		for s := range states {
+			if s == len(p.items) || !p.items[s].class.accepts(path[i]) {
+				continue
+			}
+			if p.items[s].repeat {
+				next[s] = true
+			} else {
+				next[s+1] = true
+			}
+		}
+		if len(next) == 0 {
+			return false
+		}
+		states = p.closure(next)
+	}
+	return states[len(p.items)]
+}
+
+func (p *URIPattern) closure(states map[int]bool) map[int]bool {
+	for changed := true; changed; {
+		changed = false
+		for s := range states {
+			if s < len(p.items) && p.items[s].repeat && !states[s+1] {
+				states[s+1] = true
+				changed = true
+			}
+		}
+	}
+	return states
+}
+
+// Overlap reports whether at least one request path is matched by both
+// patterns, and returns the shortest such path as an example.
+func (p *URIPattern) Overlap(o *URIPattern) (string, bool) {
+	type state struct{ i, j int }
+	type visit struct {
+		from state
+		char byte
+		// eps marks a transition that does not consume a character
+		eps bool
+	}
+
+	start := state{}
+	end := state{len(p.items), len(o.items)}
+	parents := map[state]visit{start: {}}
+	dist := map[state]int{start: 0}
+	queue := []state{start}
+
+	// 0-1 breadth first search: every transition consumes one character or
+	// none, so the first time the end state is popped its path is shortest
+	push := func(from, to state, char byte, eps bool) {
+		d := dist[from]
+		if !eps {
+			d++
+		}
+		if old, ok := dist[to]; ok && old <= d {
+			return
+		}
+		dist[to] = d
+		parents[to] = visit{from: from, char: char, eps: eps}
+		if eps {
+			queue = append([]state{to}, queue...)
+		} else {
+			queue = append(queue, to)
+		}
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur == end {
+			break
+		}
+		if cur.i < len(p.items) && p.items[cur.i].repeat {
+			push(cur, state{cur.i + 1, cur.j}, 0, true)
+		}
+		if cur.j < len(o.items) && o.items[cur.j].repeat {
+			push(cur, state{cur.i, cur.j + 1}, 0, true)
+		}
+		if cur.i == len(p.items) || cur.j == len(o.items) {
+			continue
+		}
+		a, b := p.items[cur.i], o.items[cur.j]
+		char, ok := a.class.intersect(b.class)
+		if !ok {
+			continue
+		}
+		next := cur
+		if !a.repeat {
+			next.i++
+		}
+		if !b.repeat {
+			next.j++
+		}
+		push(cur, next, char, false)
+	}
+
+	if _, ok := parents[end]; !ok {
+		return "", false
+	}
+
+	var chars []byte
+	for cur := end; cur != start; cur = parents[cur].from {
+		if v := parents[cur]; !v.eps {
+			chars = append(chars, v.char)
+		}
+	}
+	for i, j := 0, len(chars)-1; i < j; i, j = i+1, j-1 {
+		chars[i], chars[j] = chars[j], chars[i]
+	}
+	return string(chars), true
+}
+
+// moreSpecificURI reports whether radixtree tries pattern a before pattern b
+// for a path both of them match: exact paths win over prefix paths and a
+// longer prefix wins over a shorter one. Patterns with the same key are
+// ordered by route priority instead, which is reported through ok == false.
+func moreSpecificURI(a, b *URIPattern) (aFirst bool, ok bool) {
+	if a.exact != b.exact {
+		return a.exact, true
+	}
+	if a.prefix == b.prefix {
+		return false, false
+	}
+	if a.exact {
+		// two different exact paths never match the same request
+		return false, false
+	}
+	return strings.HasPrefix(a.prefix, b.prefix), true
+}
@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURIPattern_Match(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/hello", "/hello", true},
+		{"/hello", "/hello/", false},
+		{"/hello*", "/hello", true},
+		{"/hello*", "/helloworld", true},
+		{"/hello/*", "/hello/a/b", true},
+		{"/hello/*", "/hello", false},
+		{"/hello/*path", "/hello/a", true},
+		{"/user/:id", "/user/1", true},
+		{"/user/:id", "/user/", false},
+		{"/user/:id", "/user/1/profile", false},
+		{"/user/:id/profile", "/user/1/profile", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern+" "+tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.want, ParseURI(tc.pattern).Match(tc.path))
+		})
+	}
+}
+
+func TestURIPattern_Overlap(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    bool
+		example string
+	}{
+		{"/hello", "/hello", true, "/hello"},
+		{"/hello", "/world", false, ""},
+		{"/hello*", "/hello/world", true, "/hello/world"},
+		{"/api/*", "/api/v1/*", true, "/api/v1/"},
+		{"/api/*", "/web/*", false, ""},
+		{"/user/:id", "/user/*", true, "/user/x"},
+		{"/user/:id", "/user/1/profile", false, ""},
+		{"/user/:id/profile", "/user/:name/:action", true, "/user/x/profile"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			example, ok := ParseURI(tc.a).Overlap(ParseURI(tc.b))
+			assert.Equal(t, tc.want, ok)
+			assert.Equal(t, tc.example, example)
+			if ok {
+				assert.True(t, ParseURI(tc.a).Match(example))
+				assert.True(t, ParseURI(tc.b).Match(example))
+			}
+		})
+	}
+}
+
+func TestURIPattern_Prefix(t *testing.T) {
+	p := ParseURI("/user/:id/profile")
+	assert.False(t, p.IsExact())
+	assert.Equal(t, "/user/", p.Prefix())
+
+	p = ParseURI("/static/*")
+	assert.False(t, p.IsExact())
+	assert.Equal(t, "/static/", p.Prefix())
+
+	p = ParseURI("/hello")
+	assert.True(t, p.IsExact())
+	assert.Equal(t, "/hello", p.Prefix())
+}
@@ -0,0 +1,251 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+// varExpr is a single lua-resty-expr expression like ["arg_name", "==", "json"].
+type varExpr struct {
+	name  string
+	not   bool
+	op    string
+	value any
+}
+
+// parseVars flattens route vars into expressions. ok is false when the vars
+// use a form that is not understood, such as nested logical operators.
+func parseVars(vars []any) ([]varExpr, bool) {
+	exprs := make([]varExpr, 0, len(vars))
+	for _, v := range vars {
+		items, isList := v.([]any)
+		if !isList || len(items) < 2 {
+			return nil, false
+		}
+		name, isStr := items[0].(string)
+		if !isStr || name == "AND" || name == "OR" || name == "!AND" || name == "!OR" {
+			return nil, false
+		}
+		expr := varExpr{name: name}
+		rest := items[1:]
+		if op, _ := rest[0].(string); op == "!" {
+			expr.not = true
+			rest = rest[1:]
+		}
+		switch len(rest) {
+		case 1:
+			// ["arg_name", "json"] is a shorthand for equality
+			expr.op = "=="
+			expr.value = rest[0]
+		case 2:
+			op, isStr := rest[0].(string)
+			if !isStr {
+				return nil, false
+			}
+			expr.op = op
+			expr.value = rest[1]
+		default:
+			return nil, false
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, true
+}
+
+// constraint returns the set of values a variable must be one of, or the
+// single value it must not be, according to the expression.
+func (e varExpr) constraint() (in []string, notEqual string, ok bool) {
+	switch {
+	case e.op == "==" && !e.not, e.op == "~=" && e.not:
+		return []string{fmt.Sprint(e.value)}, "", true
+	case e.op == "~=" && !e.not, e.op == "==" && e.not:
+		return nil, fmt.Sprint(e.value), true
+	case e.op == "in" && !e.not:
+		list, isList := e.value.([]any)
+		if !isList {
+			return nil, "", false
+		}
+		for _, item := range list {
+			in = append(in, fmt.Sprint(item))
+		}
+		return in, "", true
+	}
+	return nil, "", false
+}
+
+// varsDisjoint reports whether no request can satisfy both sets of vars.
+// Only equality, inequality and "in" expressions are compared, everything
+// else is assumed to be satisfiable together.
+func varsDisjoint(a, b []any) bool {
+	ea, okA := parseVars(a)
+	eb, okB := parseVars(b)
+	if !okA || !okB {
+		return false
+	}
+	for _, x := range ea {
+		xIn, xNot, okX := x.constraint()
+		if !okX {
+			continue
+		}
+		for _, y := range eb {
+			if x.name != y.name {
+				continue
+			}
+			yIn, yNot, okY := y.constraint()
+			if !okY {
+				continue
+			}
+			switch {
+			case xIn != nil && yIn != nil:
+				if !utils.StringSliceContains(xIn, yIn) {
+					return true
+				}
+			case xIn != nil && len(xIn) == 1 && xIn[0] == yNot:
+				return true
+			case yIn != nil && len(yIn) == 1 && yIn[0] == xNot:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evalVars evaluates route vars against a request following lua-resty-expr,
+// the top level list is an implicit AND.
+func evalVars(vars []any, req *Request) (bool, error) {
+	return evalLogical("AND", vars, req)
+}
+
+func evalLogical(op string, exprs []any, req *Request) (bool, error) {
+	negate := strings.HasPrefix(op, "!")
+	op = strings.TrimPrefix(op, "!")
+	result := op == "AND"
+	for _, e := range exprs {
+		items, ok := e.([]any)
+		if !ok || len(items) == 0 {
+			return false, fmt.Errorf("invalid vars expression: %v", e)
+		}
+		matched, err := evalExpr(items, req)
+		if err != nil {
+			return false, err
+		}
+		if op == "AND" && !matched {
+			result = false
+			break
+		}
+		if op == "OR" && matched {
+			result = true
+			break
+		}
+	}
+	return result != negate, nil
+}
+
+func evalExpr(items []any, req *Request) (bool, error) {
+	if first, ok := items[0].(string); ok {
+		switch first {
+		case "AND", "OR", "!AND", "!OR":
+			return evalLogical(first, items[1:], req)
+		}
+	}
+
+	exprs, ok := parseVars([]any{items})
+	if !ok {
+		return false, fmt.Errorf("invalid vars expression: %v", items)
+	}
+	expr := exprs[0]
+	value, exists := req.Var(expr.name)
+	ret, err := compareVar(expr.op, value, exists, expr.value)
+	if err != nil {
+		return false, err
+	}
+	return ret != expr.not, nil
+}
+
+func compareVar(op, value string, exists bool, want any) (bool, error) {
+	switch op {
+	case "==":
+		return exists && value == fmt.Sprint(want), nil
+	case "~=":
+		return !exists || value != fmt.Sprint(want), nil
+	case ">", "<", ">=", "<=":
+		left, err := strconv.ParseFloat(value, 64)
+		if !exists || err != nil {
+			return false, nil
+		}
+		right, err := strconv.ParseFloat(fmt.Sprint(want), 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid number in vars: %v", want)
+		}
+		switch op {
+		case ">":
+			return left > right, nil
+		case "<":
+			return left < right, nil
+		case ">=":
+			return left >= right, nil
+		}
+		return left <= right, nil
+	case "~~", "~*":
+		pattern := fmt.Sprint(want)
+		if op == "~*" {
+			pattern = "(?i)" + pattern
+		}
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid regex in vars: %s", err)
+		}
+		return exists && reg.MatchString(value), nil
+	case "in":
+		list, ok := want.([]any)
+		if !ok {
+			return false, fmt.Errorf("the value of \"in\" must be an array: %v", want)
+		}
+		for _, item := range list {
+			if exists && value == fmt.Sprint(item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "has":
+		// the variable is treated as a comma separated list
+		for _, item := range strings.Split(value, ",") {
+			if exists && strings.TrimSpace(item) == fmt.Sprint(want) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "ipmatch":
+		list, ok := want.([]any)
+		if !ok {
+			list = []any{want}
+		}
+		for _, item := range list {
+			if exists && addrMatch(fmt.Sprint(item), value) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, fmt.Errorf("unsupported operator in vars: %s", op)
+}
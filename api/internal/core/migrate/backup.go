@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+
+	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+// BackupFormatVersion is the version of the backups written by Export.
+// Backups without a header were written before the format was versioned
+// and are read as version 0.
+const BackupFormatVersion = 1
+
+const checksumLength = 4 // CRC32 appended to the backups of version 0
+
+// BackupHeader describes a backup. The checksum is the SHA-256 of the data
+// exactly as it is stored in the backup.
+type BackupHeader struct {
+	FormatVersion  int                  `json:"format_version"`
+	ManagerVersion string               `json:"manager_api_version"`
+	EtcdPrefix     string               `json:"etcd_prefix"`
+	CreatedAt      int64                `json:"created_at"`
+	Counts         map[store.HubKey]int `json:"counts"`
+	SHA256         string               `json:"sha256"`
+}
+
+type backup struct {
+	Header BackupHeader    `json:"header"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// EncodeBackup wraps the data set in a backup with a header.
+func EncodeBackup(data *DataSet) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	_, version := utils.GetHashAndVersion()
+	header := BackupHeader{
+		FormatVersion:  BackupFormatVersion,
+		ManagerVersion: version,
+		CreatedAt:      time.Now().Unix(),
+		Counts:         data.counts(),
+		SHA256:         hex.EncodeToString(sum[:]),
+	}
+	if conf.ETCDConfig != nil {
+		header.EtcdPrefix = conf.ETCDConfig.Prefix
+	}
+	return json.Marshal(backup{Header: header, Data: raw})
+}
+
+// DecodeBackup reads a backup of any format version and verifies it with
+// its checksum.
+func DecodeBackup(content []byte) (*DataSet, *BackupHeader, error) {
+	var b backup
+	if err := json.Unmarshal(content, &b); err == nil && b.Header.FormatVersion > 0 {
+		return decodeBackup(&b)
+	}
+	return decodeLegacyBackup(content)
+}
+
+func decodeBackup(b *backup) (*DataSet, *BackupHeader, error) {
+	if b.Header.FormatVersion > BackupFormatVersion {
+		return nil, nil, fmt.Errorf("unsupported backup format version %d, the newest supported is %d",
+			b.Header.FormatVersion, BackupFormatVersion)
+	}
+	sum := sha256.Sum256(b.Data)
+	if hex.EncodeToString(sum[:]) != b.Header.SHA256 {
+		return nil, nil, errors.New("checksum mismatch, the backup may be broken")
+	}
+
+	data := newDataSet()
+	if err := json.Unmarshal(b.Data, data); err != nil {
+		return nil, nil, err
+	}
+	counts := data.counts()
+	for _, typ := range dataSetTypes {
+		if counts[typ] != b.Header.Counts[typ] {
+			return nil, nil, fmt.Errorf("%s: found %d objects but the header lists %d, the backup may be broken",
+				typ, counts[typ], b.Header.Counts[typ])
+		}
+	}
+	return data, &b.Header, nil
+}
+
+// decodeLegacyBackup reads a backup of version 0: the data set followed by
+// its CRC32 in 4 bytes.
+func decodeLegacyBackup(content []byte) (*DataSet, *BackupHeader, error) {
+	if len(content) < checksumLength {
+		return nil, nil, errors.New("invalid backup: file is too short")
+	}
+	raw := content[:len(content)-checksumLength]
+	checksum := binary.BigEndian.Uint32(content[len(content)-checksumLength:])
+	if checksum != crc32.ChecksumIEEE(raw) {
+		return nil, nil, errors.New("checksum check failure, maybe file broken")
+	}
+
+	data := newDataSet()
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, nil, err
+	}
+	return data, &BackupHeader{Counts: data.counts()}, nil
+}
@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func testDataSet(t *testing.T) *DataSet {
+	data := newDataSet()
+	for _, obj := range []any{
+		&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, URI: "/hello"},
+		&entity.Proto{BaseInfo: entity.BaseInfo{ID: "p1"}, Content: `syntax = "proto3";`},
+		&entity.StreamRoute{BaseInfo: entity.BaseInfo{ID: "s1"}},
+		&entity.SystemConfig{ConfigName: "grafana"},
+		&entity.User{BaseInfo: entity.BaseInfo{ID: "u1"}, Name: "jack"},
+		&entity.Team{BaseInfo: entity.BaseInfo{ID: "t1"}, Name: "payments"},
+		&entity.Role{BaseInfo: entity.BaseInfo{ID: "ro1"}, Name: "viewer"},
+	} {
+		assert.NoError(t, data.Add(obj))
+	}
+	return data
+}
+
+func TestBackup(t *testing.T) {
+	content, err := EncodeBackup(testDataSet(t))
+	assert.NoError(t, err)
+
+	data, header, err := DecodeBackup(content)
+	assert.NoError(t, err)
+	assert.Equal(t, BackupFormatVersion, header.FormatVersion)
+	assert.Equal(t, 1, header.Counts[store.HubKeyRoute])
+	assert.Equal(t, 1, header.Counts[store.HubKeyRole])
+	assert.Equal(t, 0, header.Counts[store.HubKeyUpstream])
+	assert.Equal(t, "/hello", data.Routes[0].URI)
+	assert.Equal(t, "grafana", data.SystemConfigs[0].ConfigName)
+	assert.Len(t, data.Protos, 1)
+	assert.Len(t, data.StreamRoutes, 1)
+	assert.Len(t, data.Users, 1)
+	assert.Len(t, data.Teams, 1)
+}
+
+func TestBackup_legacy(t *testing.T) {
+	raw := []byte(`{"Routes":[{"id":"r1","uri":"/hello"}],"Upstreams":[{"id":"u1"}]}`)
+	checksum := make([]byte, checksumLength)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(raw))
+
+	data, header, err := DecodeBackup(append(raw, checksum...))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, header.FormatVersion)
+	assert.Equal(t, 1, header.Counts[store.HubKeyRoute])
+	assert.Equal(t, "/hello", data.Routes[0].URI)
+	assert.Equal(t, "u1", data.Upstreams[0].ID)
+	assert.Empty(t, data.Protos)
+
+	_, _, err = DecodeBackup(append(raw, 0, 0, 0, 0))
+	assert.EqualError(t, err, "checksum check failure, maybe file broken")
+}
+
+func TestBackup_broken(t *testing.T) {
+	content, err := EncodeBackup(testDataSet(t))
+	assert.NoError(t, err)
+
+	_, _, err = DecodeBackup(bytes.Replace(content, []byte("/hello"), []byte("/world"), 1))
+	assert.EqualError(t, err, "checksum mismatch, the backup may be broken")
+
+	b := backup{}
+	assert.NoError(t, json.Unmarshal(content, &b))
+	b.Header.Counts[store.HubKeyRoute] = 2
+	changed, _ := json.Marshal(b)
+	_, _, err = DecodeBackup(changed)
+	assert.EqualError(t, err, "route: found 1 objects but the header lists 2, the backup may be broken")
+
+	b.Header.FormatVersion = BackupFormatVersion + 1
+	changed, _ = json.Marshal(b)
+	_, _, err = DecodeBackup(changed)
+	assert.EqualError(t, err, "unsupported backup format version 2, the newest supported is 1")
+}
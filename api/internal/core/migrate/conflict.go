@@ -19,30 +19,115 @@ package migrate
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/matcher"
 	"github.com/apisix/manager-api/internal/core/store"
-	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils"
 )
 
-func isConflicted(ctx context.Context, new *DataSet) (bool, *DataSet) {
-	isConflict := false
-	conflictedData := newDataSet()
-	store.RangeStore(func(key store.HubKey, s *store.GenericStore) bool {
-		new.rangeData(key, func(i int, obj any) bool {
-			// Only check key of store conflict for now.
-			// TODO: Maybe check name of some entiries.
-			_, err := s.CreateCheck(obj)
-			if err != nil {
-				isConflict = true
-				err = conflictedData.Add(obj)
-				if err != nil {
-					log.Errorf("Add obj to conflict list failed:%s", err)
-					return true
-				}
+type ConflictReason string
+
+const (
+	// ConflictKey means an object with the same key is stored already.
+	ConflictKey ConflictReason = "key"
+	// ConflictName means another stored object of the type has the same name.
+	ConflictName ConflictReason = "name"
+	// ConflictDuplicate means a stored route matches the same uri, host and
+	// methods.
+	ConflictDuplicate ConflictReason = "duplicate"
+)
+
+// Conflict is an imported object which clashes with the stored object With.
+type Conflict struct {
+	Type   store.HubKey   `json:"type"`
+	Key    string         `json:"key"`
+	Reason ConflictReason `json:"reason"`
+	With   string         `json:"with"`
+}
+
+func (c Conflict) message() string {
+	switch c.Reason {
+	case ConflictName:
+		return fmt.Sprintf("%s %s has the name of %s %s", c.Type, c.Key, c.Type, c.With)
+	case ConflictDuplicate:
+		return fmt.Sprintf("%s %s matches the same requests as %s %s", c.Type, c.Key, c.Type, c.With)
+	}
+	return fmt.Sprintf("%s %s exists", c.Type, c.Key)
+}
+
+// conflictIndex finds the stored objects an imported object conflicts with
+type conflictIndex struct {
+	stores Stores
+	names  map[store.HubKey]map[string]string
+	routes []*entity.Route
+}
+
+func newConflictIndex(ctx context.Context, stores Stores) *conflictIndex {
+	idx := &conflictIndex{stores: stores, names: make(map[store.HubKey]map[string]string)}
+	for typ, s := range stores {
+		if s == nil {
+			continue
+		}
+		names := make(map[string]string)
+		s.Range(ctx, func(key string, obj any) bool {
+			if name := nameOf(obj); name != "" {
+				names[name] = key
+			}
+			if r, ok := obj.(*entity.Route); ok {
+				idx.routes = append(idx.routes, r)
 			}
 			return true
 		})
-		return true
-	})
-	return isConflict, conflictedData
+		idx.names[typ] = names
+	}
+	return idx
+}
+
+// find returns the conflicts of the imported object of the type
+func (idx *conflictIndex) find(ctx context.Context, typ store.HubKey, obj any) []Conflict {
+	s := idx.stores[typ]
+	key := s.Key(obj)
+	var conflicts []Conflict
+	if key != "" {
+		if _, err := s.Get(ctx, key); err == nil {
+			conflicts = append(conflicts, Conflict{Type: typ, Key: key, Reason: ConflictKey, With: key})
+		}
+	}
+	if name := nameOf(obj); name != "" {
+		if with, ok := idx.names[typ][name]; ok && with != key {
+			conflicts = append(conflicts, Conflict{Type: typ, Key: key, Reason: ConflictName, With: with})
+		}
+	}
+	if r, ok := obj.(*entity.Route); ok {
+		for _, stored := range idx.routes {
+			with := utils.InterfaceToString(stored.ID)
+			if with != key && matcher.Duplicated(r, stored) {
+				conflicts = append(conflicts, Conflict{Type: typ, Key: key, Reason: ConflictDuplicate, With: with})
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// nameOf returns the name of the object for the types whose names are
+// unique, as checked by the handlers.
+func nameOf(obj any) string {
+	switch o := obj.(type) {
+	case *entity.Route:
+		return o.Name
+	case *entity.Service:
+		return o.Name
+	case *entity.Upstream:
+		return o.Name
+	case *entity.User:
+		return o.Name
+	case *entity.Team:
+		return o.Name
+	case *entity.Role:
+		return o.Name
+	}
+	return ""
 }
@@ -33,6 +33,31 @@ type DataSet struct {
 	Scripts       []*entity.Script
 	GlobalPlugins []*entity.GlobalPlugins
 	PluginConfigs []*entity.PluginConfig
+	Protos        []*entity.Proto
+	StreamRoutes  []*entity.StreamRoute
+	SystemConfigs []*entity.SystemConfig
+	Users         []*entity.User
+	Teams         []*entity.Team
+	Roles         []*entity.Role
+}
+
+// dataSetTypes lists the types of a data set. Server info is reported by
+// the APISIX instances themselves and is never backed up.
+var dataSetTypes = []store.HubKey{
+	store.HubKeyConsumer,
+	store.HubKeyRoute,
+	store.HubKeyService,
+	store.HubKeySsl,
+	store.HubKeyUpstream,
+	store.HubKeyScript,
+	store.HubKeyGlobalRule,
+	store.HubKeyPluginConfig,
+	store.HubKeyProto,
+	store.HubKeyStreamRoute,
+	store.HubKeySystemConfig,
+	store.HubKeyUser,
+	store.HubKeyTeam,
+	store.HubKeyRole,
 }
 
 func newDataSet() *DataSet {
@@ -45,7 +70,26 @@ func newDataSet() *DataSet {
 		Scripts:       make([]*entity.Script, 0),
 		GlobalPlugins: make([]*entity.GlobalPlugins, 0),
 		PluginConfigs: make([]*entity.PluginConfig, 0),
+		Protos:        make([]*entity.Proto, 0),
+		StreamRoutes:  make([]*entity.StreamRoute, 0),
+		SystemConfigs: make([]*entity.SystemConfig, 0),
+		Users:         make([]*entity.User, 0),
+		Teams:         make([]*entity.Team, 0),
+		Roles:         make([]*entity.Role, 0),
+	}
+}
+
+// counts returns the number of objects of each type
+func (a *DataSet) counts() map[store.HubKey]int {
+	counts := make(map[store.HubKey]int, len(dataSetTypes))
+	for _, typ := range dataSetTypes {
+		counts[typ] = 0
+		a.rangeData(typ, func(int, any) bool {
+			counts[typ]++
+			return true
+		})
 	}
+	return counts
 }
 
 func (a *DataSet) rangeData(key store.HubKey, f func(int, any) bool) {
@@ -98,7 +142,57 @@ func (a *DataSet) rangeData(key store.HubKey, f func(int, any) bool) {
 				break
 			}
 		}
+	case store.HubKeyProto:
+		for i, v := range a.Protos {
+			if !f(i, v) {
+				break
+			}
+		}
+	case store.HubKeyStreamRoute:
+		for i, v := range a.StreamRoutes {
+			if !f(i, v) {
+				break
+			}
+		}
+	case store.HubKeySystemConfig:
+		for i, v := range a.SystemConfigs {
+			if !f(i, v) {
+				break
+			}
+		}
+	case store.HubKeyUser:
+		for i, v := range a.Users {
+			if !f(i, v) {
+				break
+			}
+		}
+	case store.HubKeyTeam:
+		for i, v := range a.Teams {
+			if !f(i, v) {
+				break
+			}
+		}
+	case store.HubKeyRole:
+		for i, v := range a.Roles {
+			if !f(i, v) {
+				break
+			}
+		}
+	}
+}
+
+// filter returns the objects of the data set selected by the filter
+func (a *DataSet) filter(f *Filter, keyOf func(typ store.HubKey, obj any) string) *DataSet {
+	ret := newDataSet()
+	for _, typ := range dataSetTypes {
+		a.rangeData(typ, func(_ int, obj any) bool {
+			if f.Match(typ, keyOf(typ, obj), obj) {
+				_ = ret.Add(obj)
+			}
+			return true
+		})
 	}
+	return ret
 }
 
 func (a *DataSet) Add(obj any) error {
@@ -120,6 +214,18 @@ func (a *DataSet) Add(obj any) error {
 		a.GlobalPlugins = append(a.GlobalPlugins, obj)
 	case *entity.PluginConfig:
 		a.PluginConfigs = append(a.PluginConfigs, obj)
+	case *entity.Proto:
+		a.Protos = append(a.Protos, obj)
+	case *entity.StreamRoute:
+		a.StreamRoutes = append(a.StreamRoutes, obj)
+	case *entity.SystemConfig:
+		a.SystemConfigs = append(a.SystemConfigs, obj)
+	case *entity.User:
+		a.Users = append(a.Users, obj)
+	case *entity.Team:
+		a.Teams = append(a.Teams, obj)
+	case *entity.Role:
+		a.Roles = append(a.Roles, obj)
 	default:
 		err = errors.New("Unknown type of obj")
 	}
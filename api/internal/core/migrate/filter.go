@@ -0,0 +1,186 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+// Filter selects a part of the objects of an export or an import. An object
+// is selected when it matches every field which is set, so an empty filter
+// selects every object.
+type Filter struct {
+	Types []store.HubKey
+	IDs   []string
+	// Labels is a label selector as parsed by utils.GenLabelMap, objects
+	// without labels never match it.
+	Labels map[string]struct{}
+}
+
+// ParseFilter parses the comma separated types and ids and the label
+// selector of a request, the label selector has the format of the list APIs.
+func ParseFilter(types, ids, label string) (*Filter, error) {
+	f := &Filter{}
+	for _, t := range splitList(types) {
+		typ := store.HubKey(t)
+		if !hasType(dataSetTypes, typ) {
+			return nil, fmt.Errorf("invalid type: %s", t)
+		}
+		f.Types = append(f.Types, typ)
+	}
+	f.IDs = splitList(ids)
+	labels, err := utils.GenLabelMap(label)
+	if err != nil {
+		return nil, err
+	}
+	f.Labels = labels
+	return f, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func hasType(types []store.HubKey, typ store.HubKey) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func hasID(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) empty() bool {
+	return f == nil || (len(f.Types) == 0 && len(f.IDs) == 0 && len(f.Labels) == 0)
+}
+
+// Match reports whether the object with the key is selected by the filter.
+func (f *Filter) Match(typ store.HubKey, key string, obj any) bool {
+	if f.empty() {
+		return true
+	}
+	if len(f.Types) > 0 && !hasType(f.Types, typ) {
+		return false
+	}
+	if len(f.IDs) > 0 && !hasID(f.IDs, key) {
+		return false
+	}
+	if len(f.Labels) > 0 {
+		labels, ok := labelsOf(obj)
+		if !ok || !utils.LabelContains(labels, f.Labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// labelsOf returns the labels of the object, false when the type has none
+func labelsOf(obj any) (map[string]string, bool) {
+	switch o := obj.(type) {
+	case *entity.Route:
+		return o.Labels, true
+	case *entity.Service:
+		return o.Labels, true
+	case *entity.Upstream:
+		return o.Labels, true
+	case *entity.Consumer:
+		return o.Labels, true
+	case *entity.SSL:
+		return o.Labels, true
+	case *entity.PluginConfig:
+		return o.Labels, true
+	}
+	return nil, false
+}
+
+// selection holds the type/key of the selected objects, a nil selection
+// selects every object.
+type selection map[string]struct{}
+
+func (s selection) has(typ store.HubKey, key string) bool {
+	if s == nil {
+		return true
+	}
+	_, ok := s[string(typ)+"/"+key]
+	return ok
+}
+
+// selectObjects returns the stored objects matching the filter together
+// with the objects they use directly or indirectly, so that an export of
+// them is self-contained.
+func selectObjects(ctx context.Context, stores Stores, f *Filter) (selection, error) {
+	if f.empty() {
+		return nil, nil
+	}
+
+	sel := selection{}
+	var nodes []graph.Node
+	for typ, s := range stores {
+		if s == nil {
+			continue
+		}
+		typ := typ
+		s.Range(ctx, func(key string, obj any) bool {
+			if f.Match(typ, key, obj) {
+				sel[string(typ)+"/"+key] = struct{}{}
+				nodes = append(nodes, graph.Node{Type: typ, ID: key})
+			}
+			return true
+		})
+	}
+	if len(nodes) == 0 {
+		return sel, nil
+	}
+
+	gs := graph.Stores{}
+	for _, typ := range graph.Types {
+		if s, ok := stores[typ]; ok && s != nil {
+			gs[typ] = s
+		}
+	}
+	g, err := graph.Build(ctx, gs)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range g.Dependencies(nodes...) {
+		sel[string(n.Type)+"/"+n.ID] = struct{}{}
+	}
+	return sel, nil
+}
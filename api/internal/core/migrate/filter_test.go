@@ -0,0 +1,110 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter("route, upstream", "r1,r2", "team:payments")
+	assert.NoError(t, err)
+	assert.Equal(t, []store.HubKey{store.HubKeyRoute, store.HubKeyUpstream}, f.Types)
+	assert.Equal(t, []string{"r1", "r2"}, f.IDs)
+	assert.Equal(t, map[string]struct{}{"team:payments": {}}, f.Labels)
+
+	f, err = ParseFilter("", "", "")
+	assert.NoError(t, err)
+	assert.True(t, f.empty())
+
+	_, err = ParseFilter("server_info", "", "")
+	assert.EqualError(t, err, "invalid type: server_info")
+	_, err = ParseFilter("", "", "team:")
+	assert.EqualError(t, err, "malformed label")
+}
+
+func TestFilter_match(t *testing.T) {
+	route := &entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, Labels: map[string]string{"team": "payments"}}
+	proto := &entity.Proto{BaseInfo: entity.BaseInfo{ID: "p1"}}
+
+	f, _ := ParseFilter("", "", "team:payments")
+	assert.True(t, f.Match(store.HubKeyRoute, "r1", route))
+	// objects without labels never match a label selector
+	assert.False(t, f.Match(store.HubKeyProto, "p1", proto))
+
+	f, _ = ParseFilter("proto", "p1", "")
+	assert.False(t, f.Match(store.HubKeyRoute, "r1", route))
+	assert.True(t, f.Match(store.HubKeyProto, "p1", proto))
+	assert.False(t, f.Match(store.HubKeyProto, "p2", proto))
+}
+
+func TestFilter_stream(t *testing.T) {
+	var written []string
+	src := newStores(t, &written, map[store.HubKey][]string{
+		store.HubKeyRoute: {
+			`{"id":"r1","uri":"/pay","upstream_id":"u1","labels":{"team":"payments"}}`,
+			`{"id":"r2","uri":"/other","upstream_id":"u2","labels":{"team":"other"}}`,
+		},
+		store.HubKeyUpstream: {
+			`{"id":"u1","type":"roundrobin","nodes":{"127.0.0.1:1980":1}}`,
+			`{"id":"u2","type":"roundrobin","nodes":{"127.0.0.1:1981":1}}`,
+		},
+	})
+
+	// the upstream of the selected route is exported along with it
+	f, _ := ParseFilter("route", "", "team:payments")
+	buf := &bytes.Buffer{}
+	assert.NoError(t, ExportStream(context.TODO(), buf, FormatNDJSON, src, f))
+
+	ret, err := ImportStream(context.TODO(), bytes.NewReader(buf.Bytes()), ModeReturn, newStores(t, &written, nil), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, ret.Created)
+	assert.Equal(t, []string{"/apisix/upstream/u1", "/apisix/route/r1"}, written)
+
+	// an import restricted to upstreams
+	written = nil
+	f, _ = ParseFilter("upstream", "", "")
+	ret, err = ImportStream(context.TODO(), bytes.NewReader(buf.Bytes()), ModeReturn, newStores(t, &written, nil), f)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ret.Created)
+	assert.Equal(t, 1, ret.Filtered)
+	assert.Equal(t, []string{"/apisix/upstream/u1"}, written)
+}
+
+func TestDataSet_filter(t *testing.T) {
+	data := testDataSet(t)
+	f, err := ParseFilter("proto,roles", "", "")
+	assert.NoError(t, err)
+	ret := data.filter(f, func(typ store.HubKey, obj any) string {
+		if c, ok := obj.(*entity.SystemConfig); ok {
+			return c.ConfigName
+		}
+		return obj.(entity.GetBaseInfo).GetBaseInfo().ID.(string)
+	})
+	assert.Equal(t, 1, len(ret.Protos))
+	assert.Equal(t, 1, len(ret.Roles))
+	assert.Empty(t, ret.Routes)
+	assert.Empty(t, ret.Users)
+}
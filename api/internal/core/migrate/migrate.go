@@ -19,21 +19,34 @@ package migrate
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils"
 )
 
 var (
 	ErrConflict = errors.New("conflict")
 )
 
-func Export(ctx context.Context) ([]byte, error) {
+// Export returns a backup of the objects selected by the filter and the
+// objects they use, of all stores except the server info.
+func Export(ctx context.Context, filter *Filter) ([]byte, error) {
+	stores := hubStores(ctx)
+	selected, err := selectObjects(ctx, stores, filter)
+	if err != nil {
+		return nil, err
+	}
+
 	exportData := newDataSet()
-	store.RangeStore(func(key store.HubKey, s *store.GenericStore) bool {
-		s.Range(ctx, func(_ string, obj any) bool {
+	for key, s := range stores {
+		key := key
+		s.Range(ctx, func(k string, obj any) bool {
+			if !selected.has(key, k) {
+				return true
+			}
 			err := exportData.Add(obj)
 			if err != nil {
 				log.Errorf("Add obj to export list failed:%s", err)
@@ -41,15 +54,22 @@ func Export(ctx context.Context) ([]byte, error) {
 			}
 			return true
 		})
-		return true
-	})
-
-	data, err := json.Marshal(exportData)
-	if err != nil {
-		return nil, err
 	}
 
-	return data, nil
+	return EncodeBackup(exportData)
+}
+
+// hubStores returns all stores of the cluster of the request of ctx except
+// the server info.
+func hubStores(ctx context.Context) Stores {
+	stores := Stores{}
+	store.HubOf(ctx).Range(func(key store.HubKey, s *store.GenericStore) bool {
+		if key != store.HubKeyServerInfo {
+			stores[key] = s
+		}
+		return true
+	})
+	return stores
 }
 
 type ConflictMode int
@@ -58,42 +78,194 @@ const (
 	ModeReturn ConflictMode = iota
 	ModeOverwrite
 	ModeSkip
+	// ModeRemap creates the objects whose key is stored already with a new
+	// key and rewrites the references to them in the imported objects.
+	ModeRemap
+)
+
+// importOrder lists the types of a data set in the order they are
+// imported: objects come before the objects which use them.
+var importOrder = []store.HubKey{
+	store.HubKeyUpstream,
+	store.HubKeyService,
+	store.HubKeyPluginConfig,
+	store.HubKeyProto,
+	store.HubKeySsl,
+	store.HubKeyConsumer,
+	store.HubKeyGlobalRule,
+	store.HubKeyScript,
+	store.HubKeyRoute,
+	store.HubKeyStreamRoute,
+	store.HubKeySystemConfig,
+	store.HubKeyRole,
+	store.HubKeyUser,
+	store.HubKeyTeam,
+}
+
+type ItemStatus string
+
+const (
+	StatusCreated  ItemStatus = "created"
+	StatusUpdated  ItemStatus = "updated"
+	StatusRemapped ItemStatus = "remapped"
+	StatusSkipped  ItemStatus = "skipped"
+	StatusFailed   ItemStatus = "failed"
 )
 
-func Import(ctx context.Context, data []byte, mode ConflictMode) (*DataSet, error) {
-	importData := newDataSet()
-	err := json.Unmarshal(data, &importData)
+// ImportItem is the outcome of the import of one object. NewKey is set when
+// the object is written with another key, all references to it in the
+// imported objects are rewritten.
+type ImportItem struct {
+	Type   store.HubKey `json:"type"`
+	Key    string       `json:"key"`
+	NewKey string       `json:"new_key,omitempty"`
+	Status ItemStatus   `json:"status"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// ImportReport lists the outcome of each imported object and the conflicts
+// found before anything was written.
+type ImportReport struct {
+	Total     int          `json:"total"`
+	Failed    int          `json:"failed"`
+	Items     []ImportItem `json:"items"`
+	Conflicts []Conflict   `json:"conflicts"`
+}
+
+// Import restores the objects of a backup of any format version which are
+// selected by the filter.
+func Import(ctx context.Context, data []byte, mode ConflictMode, filter *Filter) (*ImportReport, error) {
+	importData, _, err := DecodeBackup(data)
 	if err != nil {
 		return nil, err
 	}
-	conflict, conflictData := isConflicted(ctx, importData)
-	if conflict && mode == ModeReturn {
-		return conflictData, ErrConflict
+	stores := hubStores(ctx)
+	if !filter.empty() {
+		importData = importData.filter(filter, func(typ store.HubKey, obj any) string {
+			return stores[typ].Key(obj)
+		})
 	}
-	store.RangeStore(func(key store.HubKey, s *store.GenericStore) bool {
-		importData.rangeData(key, func(i int, obj any) bool {
-			_, e := s.CreateCheck(obj)
-			if e != nil {
-				switch mode {
-				case ModeSkip:
-					return true
-				case ModeOverwrite:
-					_, e := s.Update(ctx, obj, true)
-					if e != nil {
-						err = e
-						return false
-					}
-				}
-			} else {
-				_, e := s.Create(ctx, obj)
-				if err != nil {
-					err = e
-					return false
-				}
+	return importDataSet(ctx, stores, importData, mode)
+}
+
+// importDataSet writes the objects in dependency order. The conflicts of
+// all objects are collected first, in return mode nothing is written when
+// there is one. Otherwise the conflicting objects are resolved by the mode:
+//   - skip leaves them out,
+//   - overwrite replaces the stored object they conflict with, taking over
+//     its key when they conflict by name or as a duplicate route,
+//   - remap writes them with a new key, objects which conflict by name or as
+//     a duplicate route fail.
+//
+// An object fails as well when one of the objects it uses failed. The
+// import goes on after a failure, the report lists the outcome of each
+// object.
+func importDataSet(ctx context.Context, stores Stores, data *DataSet, mode ConflictMode) (*ImportReport, error) {
+	report := &ImportReport{Items: []ImportItem{}, Conflicts: []Conflict{}}
+	idx := newConflictIndex(ctx, stores)
+	conflicts := make(map[any][]Conflict)
+	for _, typ := range importOrder {
+		if stores[typ] == nil {
+			continue
+		}
+		data.rangeData(typ, func(_ int, obj any) bool {
+			if c := idx.find(ctx, typ, obj); len(c) > 0 {
+				conflicts[obj] = c
+				report.Conflicts = append(report.Conflicts, c...)
 			}
 			return true
 		})
-		return true
+	}
+	if len(report.Conflicts) > 0 && mode == ModeReturn {
+		return report, ErrConflict
+	}
+
+	remapped := make(map[string]string)
+	failed := make(map[string]struct{})
+	for _, typ := range importOrder {
+		s := stores[typ]
+		if s == nil {
+			continue
+		}
+		typ := typ
+		data.rangeData(typ, func(_ int, obj any) bool {
+			item := ImportItem{Type: typ, Key: s.Key(obj)}
+			err := importObject(ctx, s, typ, obj, conflicts[obj], mode, remapped, failed, &item)
+			if err != nil {
+				item.Status = StatusFailed
+				item.Error = err.Error()
+				failed[string(typ)+"/"+item.Key] = struct{}{}
+				report.Failed++
+			}
+			report.Total++
+			report.Items = append(report.Items, item)
+			return true
+		})
+	}
+	return report, nil
+}
+
+func importObject(ctx context.Context, s *store.GenericStore, typ store.HubKey, obj any, conflicts []Conflict,
+	mode ConflictMode, remapped map[string]string, failed map[string]struct{}, item *ImportItem) error {
+	var refErr error
+	rewriteReferences(obj, func(refType store.HubKey, id string) string {
+		if _, ok := failed[string(refType)+"/"+id]; ok && refErr == nil {
+			refErr = fmt.Errorf("%s %s failed to import", refType, id)
+		}
+		if n, ok := remapped[string(refType)+"/"+id]; ok {
+			return n
+		}
+		return id
 	})
-	return nil, err
+	if refErr != nil {
+		return refErr
+	}
+
+	if len(conflicts) == 0 {
+		if _, err := s.Create(ctx, obj); err != nil {
+			return err
+		}
+		item.Key = s.Key(obj)
+		item.Status = StatusCreated
+		return nil
+	}
+
+	newKey := ""
+	switch mode {
+	case ModeSkip:
+		item.Status = StatusSkipped
+		return nil
+	case ModeOverwrite:
+		for _, c := range conflicts[1:] {
+			if c.With != conflicts[0].With {
+				return fmt.Errorf("%s; %s", conflicts[0].message(), c.message())
+			}
+		}
+		if conflicts[0].With != item.Key {
+			newKey = conflicts[0].With
+		}
+		item.Status = StatusUpdated
+	case ModeRemap:
+		for _, c := range conflicts {
+			if c.Reason != ConflictKey {
+				return errors.New(c.message())
+			}
+		}
+		newKey = utils.GetFlakeUidStr()
+		// the script of a route has the id of the route
+		if key, ok := remapped[string(store.HubKeyScript)+"/"+item.Key]; ok && typ == store.HubKeyRoute {
+			newKey = key
+		}
+		item.Status = StatusRemapped
+	}
+
+	if newKey != "" {
+		if !setKey(obj, newKey) {
+			return fmt.Errorf("%s %s can't be written with another key", typ, item.Key)
+		}
+		remapped[string(typ)+"/"+item.Key] = newKey
+		item.NewKey = newKey
+	}
+	_, err := s.Update(ctx, obj, true)
+	return err
 }
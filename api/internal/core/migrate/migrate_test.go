@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func TestImportDataSet_order(t *testing.T) {
+	var written []string
+	data := newDataSet()
+	_ = data.Add(&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, URI: "/hello", UpstreamID: "u1"})
+	_ = data.Add(&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}})
+
+	report, err := importDataSet(context.TODO(), newStores(t, &written, nil), data, ModeReturn)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, 0, report.Failed)
+	assert.Equal(t, []ImportItem{
+		{Type: store.HubKeyUpstream, Key: "u1", Status: StatusCreated},
+		{Type: store.HubKeyRoute, Key: "r1", Status: StatusCreated},
+	}, report.Items)
+	assert.Equal(t, []string{"/apisix/upstream/u1", "/apisix/route/r1"}, written)
+}
+
+func conflictingDataSet() *DataSet {
+	data := newDataSet()
+	_ = data.Add(&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}})
+	_ = data.Add(&entity.Route{BaseInfo: entity.BaseInfo{ID: "r2"}, Name: "pay", URI: "/checkout"})
+	_ = data.Add(&entity.Route{BaseInfo: entity.BaseInfo{ID: "r3"}, URI: "/pay", Methods: []string{"GET"}})
+	_ = data.Add(&entity.Route{BaseInfo: entity.BaseInfo{ID: "r4"}, URI: "/new", UpstreamID: "u1"})
+	return data
+}
+
+func TestImportDataSet_conflicts(t *testing.T) {
+	stored := map[store.HubKey][]string{
+		store.HubKeyRoute:    {`{"id":"r1","name":"pay","uri":"/pay","methods":["GET","POST"]}`},
+		store.HubKeyUpstream: {`{"id":"u1","type":"roundrobin","nodes":{"127.0.0.1:1980":1}}`},
+	}
+	conflicts := []Conflict{
+		{Type: store.HubKeyUpstream, Key: "u1", Reason: ConflictKey, With: "u1"},
+		{Type: store.HubKeyRoute, Key: "r2", Reason: ConflictName, With: "r1"},
+		{Type: store.HubKeyRoute, Key: "r3", Reason: ConflictDuplicate, With: "r1"},
+	}
+
+	var written []string
+	report, err := importDataSet(context.TODO(), newStores(t, &written, stored), conflictingDataSet(), ModeReturn)
+	assert.Equal(t, ErrConflict, err)
+	assert.Equal(t, conflicts, report.Conflicts)
+	assert.Empty(t, written)
+
+	report, err = importDataSet(context.TODO(), newStores(t, &written, stored), conflictingDataSet(), ModeSkip)
+	assert.NoError(t, err)
+	assert.Equal(t, []ImportItem{
+		{Type: store.HubKeyUpstream, Key: "u1", Status: StatusSkipped},
+		{Type: store.HubKeyRoute, Key: "r2", Status: StatusSkipped},
+		{Type: store.HubKeyRoute, Key: "r3", Status: StatusSkipped},
+		{Type: store.HubKeyRoute, Key: "r4", Status: StatusCreated},
+	}, report.Items)
+	assert.Equal(t, []string{"/apisix/route/r4"}, written)
+
+	// the routes conflicting by name and as a duplicate replace r1
+	written = nil
+	report, err = importDataSet(context.TODO(), newStores(t, &written, stored), conflictingDataSet(), ModeOverwrite)
+	assert.NoError(t, err)
+	assert.Equal(t, []ImportItem{
+		{Type: store.HubKeyUpstream, Key: "u1", Status: StatusUpdated},
+		{Type: store.HubKeyRoute, Key: "r2", NewKey: "r1", Status: StatusUpdated},
+		{Type: store.HubKeyRoute, Key: "r3", NewKey: "r1", Status: StatusUpdated},
+		{Type: store.HubKeyRoute, Key: "r4", Status: StatusCreated},
+	}, report.Items)
+	assert.Equal(t, []string{"/apisix/upstream/u1", "/apisix/route/r1", "/apisix/route/r1", "/apisix/route/r4"}, written)
+
+	// the upstream gets a new id which r4 refers to
+	written = nil
+	data := conflictingDataSet()
+	report, err = importDataSet(context.TODO(), newStores(t, &written, stored), data, ModeRemap)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 2, report.Failed)
+	u1 := report.Items[0]
+	assert.Equal(t, StatusRemapped, u1.Status)
+	assert.NotEmpty(t, u1.NewKey)
+	assert.Equal(t, ImportItem{Type: store.HubKeyRoute, Key: "r2", Status: StatusFailed,
+		Error: "route r2 has the name of route r1"}, report.Items[1])
+	assert.Equal(t, ImportItem{Type: store.HubKeyRoute, Key: "r3", Status: StatusFailed,
+		Error: "route r3 matches the same requests as route r1"}, report.Items[2])
+	assert.Equal(t, StatusCreated, report.Items[3].Status)
+	assert.Equal(t, u1.NewKey, data.Routes[2].UpstreamID)
+	assert.Equal(t, []string{"/apisix/upstream/" + u1.NewKey, "/apisix/route/r4"}, written)
+}
+
+func TestRewriteReferences(t *testing.T) {
+	remap := func(typ store.HubKey, id string) string {
+		return string(typ) + "-" + id
+	}
+
+	route := &entity.Route{
+		ServiceID: "s1",
+		ScriptID:  "r1",
+		Plugins: map[string]any{
+			"grpc-transcode":       map[string]any{"proto_id": "p1"},
+			"consumer-restriction": map[string]any{"type": "service_id", "whitelist": []any{"s2"}},
+		},
+	}
+	rewriteReferences(route, remap)
+	assert.Equal(t, "service-s1", route.ServiceID)
+	assert.Equal(t, "script-r1", route.ScriptID)
+	assert.Nil(t, route.UpstreamID)
+	assert.Equal(t, "proto-p1", route.Plugins["grpc-transcode"].(map[string]any)["proto_id"])
+	assert.Equal(t, []any{"service-s2"}, route.Plugins["consumer-restriction"].(map[string]any)["whitelist"])
+
+	user := &entity.User{TeamsID: []any{"t1"}, RoleID: []any{"ro1"}}
+	rewriteReferences(user, remap)
+	assert.Equal(t, []any{"teams-t1"}, user.TeamsID)
+	assert.Equal(t, []any{"roles-ro1"}, user.RoleID)
+}
@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+// rewriteReferences calls f with each object the obj refers to by id and
+// replaces the id with the one f returns.
+func rewriteReferences(obj any, f func(typ store.HubKey, id string) string) {
+	switch o := obj.(type) {
+	case *entity.Route:
+		o.ServiceID = rewriteID(store.HubKeyService, o.ServiceID, f)
+		o.UpstreamID = rewriteID(store.HubKeyUpstream, o.UpstreamID, f)
+		o.PluginConfigID = rewriteID(store.HubKeyPluginConfig, o.PluginConfigID, f)
+		o.ScriptID = rewriteID(store.HubKeyScript, o.ScriptID, f)
+		rewritePlugins(o.Plugins, f)
+	case *entity.StreamRoute:
+		o.UpstreamID = rewriteID(store.HubKeyUpstream, o.UpstreamID, f)
+		rewritePlugins(o.Plugins, f)
+	case *entity.Service:
+		o.UpstreamID = rewriteID(store.HubKeyUpstream, o.UpstreamID, f)
+		rewritePlugins(o.Plugins, f)
+	case *entity.User:
+		rewriteIDs(store.HubKeyTeam, o.TeamsID, f)
+		rewriteIDs(store.HubKeyRole, o.RoleID, f)
+	case *entity.Team:
+		rewriteIDs(store.HubKeyUser, o.UsersID, f)
+	case entity.GetPlugins:
+		rewritePlugins(o.GetPlugins(), f)
+	}
+}
+
+// rewritePlugins rewrites the proto used by grpc-transcode and the services
+// listed by consumer-restriction.
+func rewritePlugins(plugins map[string]any, f func(typ store.HubKey, id string) string) {
+	if conf, ok := plugins["grpc-transcode"].(map[string]any); ok {
+		if id := rewriteID(store.HubKeyProto, conf["proto_id"], f); id != nil {
+			conf["proto_id"] = id
+		}
+	}
+	if conf, ok := plugins["consumer-restriction"].(map[string]any); ok && conf["type"] == "service_id" {
+		for _, field := range []string{"whitelist", "blacklist"} {
+			list, _ := conf[field].([]any)
+			rewriteIDs(store.HubKeyService, list, f)
+		}
+	}
+}
+
+func rewriteID(typ store.HubKey, id any, f func(typ store.HubKey, id string) string) any {
+	s := utils.InterfaceToString(id)
+	if s == "" {
+		return id
+	}
+	if n := f(typ, s); n != s {
+		return n
+	}
+	return id
+}
+
+func rewriteIDs(typ store.HubKey, ids []any, f func(typ store.HubKey, id string) string) {
+	for i := range ids {
+		ids[i] = rewriteID(typ, ids[i], f)
+	}
+}
+
+// setKey replaces the key of the object, false is returned for the types
+// whose key is their name.
+func setKey(obj any, key string) bool {
+	switch o := obj.(type) {
+	case *entity.Script:
+		o.ID = key
+		return true
+	case entity.GetBaseInfo:
+		o.GetBaseInfo().ID = key
+		return true
+	}
+	return false
+}
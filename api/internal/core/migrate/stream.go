@@ -0,0 +1,547 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+// Format is the layout of a streamed export.
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatTar    Format = "tar"
+)
+
+const (
+	manifestVersion = 1
+	manifestName    = "manifest.json"
+	// batchSize is the number of objects written to etcd in one transaction,
+	// it must stay below the max-txn-ops of etcd (128 by default).
+	batchSize = 100
+)
+
+// StreamTypes lists the types of a streamed export in the order they are
+// written and imported: objects come before the objects which use them. A
+// streamed export is a full backup like the other formats.
+var StreamTypes = importOrder
+
+// Stores are the stores a stream is exported from and imported into, keyed
+// by type. Missing stores are exported as empty.
+type Stores map[store.HubKey]*store.GenericStore
+
+// DefaultStores returns the stores of the cluster of the request of ctx.
+func DefaultStores(ctx context.Context) Stores {
+	hub := store.HubOf(ctx)
+	stores := Stores{}
+	for _, typ := range StreamTypes {
+		stores[typ] = hub.GetStore(typ)
+	}
+	return stores
+}
+
+// ParseFormat returns the format with the name.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case FormatNDJSON, FormatTar:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid format: %s, format should be ndjson or tar", name)
+}
+
+// Manifest describes the content of a streamed export. The checksum of a
+// type is the SHA-256 of its objects, each one as a line of compact JSON.
+type Manifest struct {
+	Version   int           `json:"version"`
+	CreatedAt int64         `json:"created_at"`
+	Types     []TypeSummary `json:"types"`
+}
+
+type TypeSummary struct {
+	Type   store.HubKey `json:"type"`
+	Count  int          `json:"count"`
+	SHA256 string       `json:"sha256"`
+}
+
+// record is a line of an NDJSON export, the last line holds the manifest.
+type record struct {
+	Type     store.HubKey    `json:"type,omitempty"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Manifest *Manifest       `json:"manifest,omitempty"`
+}
+
+type digest struct {
+	count int
+	hash  hash.Hash
+}
+
+func newDigest() *digest {
+	return &digest{hash: sha256.New()}
+}
+
+func (d *digest) add(value []byte) {
+	d.count++
+	d.hash.Write(value)
+	d.hash.Write([]byte{'\n'})
+}
+
+func (d *digest) summary(typ store.HubKey) TypeSummary {
+	return TypeSummary{Type: typ, Count: d.count, SHA256: hex.EncodeToString(d.hash.Sum(nil))}
+}
+
+// verify checks the objects read from an export against the manifest
+func (m *Manifest) verify(digests map[store.HubKey]*digest) error {
+	if m.Version != manifestVersion {
+		return fmt.Errorf("unsupported manifest version: %d", m.Version)
+	}
+	listed := make(map[store.HubKey]struct{}, len(m.Types))
+	for _, t := range m.Types {
+		listed[t.Type] = struct{}{}
+		d, ok := digests[t.Type]
+		if !ok {
+			d = newDigest()
+		}
+		sum := d.summary(t.Type)
+		if sum.Count != t.Count {
+			return fmt.Errorf("%s: found %d objects but the manifest lists %d, the file may be broken", t.Type, sum.Count, t.Count)
+		}
+		if sum.SHA256 != t.SHA256 {
+			return fmt.Errorf("%s: checksum mismatch, the file may be broken", t.Type)
+		}
+	}
+	for typ := range digests {
+		if _, ok := listed[typ]; !ok {
+			return fmt.Errorf("%s is not listed in the manifest", typ)
+		}
+	}
+	return nil
+}
+
+// ExportStream writes the objects of the stores which are selected by the
+// filter, and the objects they use, to w one at a time, so the export is
+// never held in memory as a whole. An NDJSON export has one object per line
+// and ends with the manifest, a tar export starts with the manifest followed
+// by one NDJSON file per type.
+func ExportStream(ctx context.Context, w io.Writer, format Format, stores Stores, filter *Filter) error {
+	if format != FormatNDJSON && format != FormatTar {
+		return fmt.Errorf("invalid format: %s, format should be ndjson or tar", format)
+	}
+	selected, err := selectObjects(ctx, stores, filter)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatNDJSON:
+		return exportNDJSON(ctx, w, stores, selected)
+	case FormatTar:
+		return exportTar(ctx, w, stores, selected)
+	}
+	return fmt.Errorf("invalid format: %s, format should be ndjson or tar", format)
+}
+
+// rangeValues calls f with the JSON of each selected object of the type
+func rangeValues(ctx context.Context, stores Stores, selected selection, typ store.HubKey, f func(value []byte) error) error {
+	s, ok := stores[typ]
+	if !ok || s == nil {
+		return nil
+	}
+	var err error
+	s.Range(ctx, func(key string, obj any) bool {
+		if !selected.has(typ, key) {
+			return true
+		}
+		var value []byte
+		if value, err = json.Marshal(obj); err != nil {
+			return false
+		}
+		err = f(value)
+		return err == nil
+	})
+	return err
+}
+
+func exportNDJSON(ctx context.Context, w io.Writer, stores Stores, selected selection) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	manifest := &Manifest{Version: manifestVersion, CreatedAt: time.Now().Unix()}
+	for _, typ := range StreamTypes {
+		d := newDigest()
+		err := rangeValues(ctx, stores, selected, typ, func(value []byte) error {
+			d.add(value)
+			return enc.Encode(record{Type: typ, Value: value})
+		})
+		if err != nil {
+			return err
+		}
+		manifest.Types = append(manifest.Types, d.summary(typ))
+	}
+	if err := enc.Encode(record{Manifest: manifest}); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// exportTar spools the objects of each type to a temporary file first, as
+// the size of a tar entry has to be known before its content is written.
+func exportTar(ctx context.Context, w io.Writer, stores Stores, selected selection) error {
+	manifest := &Manifest{Version: manifestVersion, CreatedAt: time.Now().Unix()}
+	files := make([]*os.File, 0, len(StreamTypes))
+	defer func() {
+		for _, f := range files {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	sizes := make([]int64, 0, len(StreamTypes))
+	for _, typ := range StreamTypes {
+		f, err := os.CreateTemp("", "apisix-export-*.ndjson")
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+
+		bw := bufio.NewWriter(f)
+		d := newDigest()
+		var size int64
+		err = rangeValues(ctx, stores, selected, typ, func(value []byte) error {
+			d.add(value)
+			size += int64(len(value)) + 1
+			if _, err := bw.Write(value); err != nil {
+				return err
+			}
+			return bw.WriteByte('\n')
+		})
+		if err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		sizes = append(sizes, size)
+		manifest.Types = append(manifest.Types, d.summary(typ))
+	}
+
+	tw := tar.NewWriter(w)
+	modTime := time.Unix(manifest.CreatedAt, 0)
+	m, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, manifestName, int64(len(m)), modTime, bytes.NewReader(m)); err != nil {
+		return err
+	}
+	for i, typ := range StreamTypes {
+		if _, err := files[i].Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := writeTarFile(tw, string(typ)+".ndjson", sizes[i], modTime, files[i]); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, size int64, modTime time.Time, r io.Reader) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(tw, r, size)
+	return err
+}
+
+// StreamResult is the outcome of a streamed import.
+type StreamResult struct {
+	Manifest  *Manifest  `json:"manifest"`
+	Created   int        `json:"created"`
+	Updated   int        `json:"updated"`
+	Skipped   int        `json:"skipped"`
+	Filtered  int        `json:"filtered"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// ImportStream imports the objects of an NDJSON or tar export which are
+// selected by the filter. Only the keys are checked for conflicts. The file
+// is read twice: the first pass verifies it against its manifest, validates
+// the objects and looks for conflicts, so that nothing is written from a
+// broken file. The second pass writes the objects in the order of the file,
+// in batches of one etcd transaction, so only one batch is held in memory.
+func ImportStream(ctx context.Context, r io.ReadSeeker, mode ConflictMode, stores Stores, filter *Filter) (*StreamResult, error) {
+	if mode == ModeRemap {
+		return nil, errors.New("remap mode is not supported by streamed imports")
+	}
+	format, err := detectFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StreamResult{Conflicts: []Conflict{}}
+	conflicted := make(map[string]struct{})
+	seen := make(map[string]struct{})
+	dryRun := store.WithDryRun(ctx)
+	result.Manifest, err = readStream(r, format, func(typ store.HubKey, value []byte) error {
+		s, obj, err := decode(stores, typ, value)
+		if err != nil {
+			return err
+		}
+		key := s.Key(obj)
+		if !filter.Match(typ, key, obj) {
+			result.Filtered++
+			return nil
+		}
+		if key != "" {
+			if _, ok := seen[string(typ)+"/"+key]; ok {
+				return fmt.Errorf("%s %s is duplicated", typ, key)
+			}
+			seen[string(typ)+"/"+key] = struct{}{}
+			if _, err := s.Get(ctx, key); err == nil {
+				conflicted[string(typ)+"/"+key] = struct{}{}
+				result.Conflicts = append(result.Conflicts, Conflict{Type: typ, Key: key, Reason: ConflictKey, With: key})
+			}
+		}
+		if err := s.BatchPut(dryRun, []any{obj}); err != nil {
+			return fmt.Errorf("%s %s: %s", typ, key, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Conflicts) > 0 && mode == ModeReturn {
+		return result, ErrConflict
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var (
+		batch            []any
+		batchType        store.HubKey
+		created, updated int
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := stores[batchType].BatchPut(ctx, batch); err != nil {
+			return fmt.Errorf("write %s failed: %s", batchType, err)
+		}
+		result.Created += created
+		result.Updated += updated
+		batch, created, updated = batch[:0], 0, 0
+		return nil
+	}
+	_, err = readStream(r, format, func(typ store.HubKey, value []byte) error {
+		if typ != batchType {
+			if err := flush(); err != nil {
+				return err
+			}
+			batchType = typ
+		}
+		s, obj, err := decode(stores, typ, value)
+		if err != nil {
+			return err
+		}
+		key := s.Key(obj)
+		if !filter.Match(typ, key, obj) {
+			return nil
+		}
+		if _, ok := conflicted[string(typ)+"/"+key]; !ok {
+			created++
+		} else if mode == ModeSkip {
+			result.Skipped++
+			return nil
+		} else {
+			updated++
+		}
+		batch = append(batch, obj)
+		if len(batch) >= batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	return result, err
+}
+
+func decode(stores Stores, typ store.HubKey, value []byte) (*store.GenericStore, any, error) {
+	s, ok := stores[typ]
+	if !ok || s == nil {
+		return nil, nil, fmt.Errorf("%s can't be imported", typ)
+	}
+	obj, err := s.StringToObjPtr(string(value), "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", typ, err)
+	}
+	return s, obj, nil
+}
+
+// detectFormat tells a tar archive from NDJSON by the magic of the tar header
+func detectFormat(r io.ReadSeeker) (Format, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if n >= 262 && string(head[257:262]) == "ustar" {
+		return FormatTar, nil
+	}
+	return FormatNDJSON, nil
+}
+
+// readStream calls f for each object of the export in r and verifies the
+// objects against the manifest at the end.
+func readStream(r io.Reader, format Format, f func(typ store.HubKey, value []byte) error) (*Manifest, error) {
+	digests := make(map[store.HubKey]*digest)
+	add := func(typ store.HubKey, value []byte) error {
+		d, ok := digests[typ]
+		if !ok {
+			d = newDigest()
+			digests[typ] = d
+		}
+		d.add(value)
+		return f(typ, value)
+	}
+
+	var (
+		manifest *Manifest
+		err      error
+	)
+	if format == FormatTar {
+		manifest, err = readTar(r, add)
+	} else {
+		manifest, err = readNDJSON(r, add)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := manifest.verify(digests); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func readNDJSON(r io.Reader, f func(typ store.HubKey, value []byte) error) (*Manifest, error) {
+	var manifest *Manifest
+	err := readLines(r, func(line []byte) error {
+		if manifest != nil {
+			return errors.New("invalid file: found data after the manifest")
+		}
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("invalid record: %s", err)
+		}
+		if rec.Manifest != nil {
+			manifest = rec.Manifest
+			return nil
+		}
+		if rec.Type == "" || len(rec.Value) == 0 {
+			return errors.New("invalid record: type and value are required")
+		}
+		return f(rec.Type, rec.Value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, errors.New("manifest is missing, the file may be truncated")
+	}
+	return manifest, nil
+}
+
+func readTar(r io.Reader, f func(typ store.HubKey, value []byte) error) (*Manifest, error) {
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return nil, errors.New("manifest is missing, the file may be broken")
+	}
+	manifest := &Manifest{}
+	if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %s", err)
+	}
+	if manifest.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version: %d", manifest.Version)
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasSuffix(hdr.Name, ".ndjson") {
+			return nil, fmt.Errorf("invalid file: unexpected entry %s", hdr.Name)
+		}
+		typ := store.HubKey(strings.TrimSuffix(hdr.Name, ".ndjson"))
+		err = readLines(tr, func(line []byte) error {
+			return f(typ, line)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// readLines calls f for each non-empty line, without the line break
+func readLines(r io.Reader, f func(line []byte) error) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if e := f(line); e != nil {
+				return e
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
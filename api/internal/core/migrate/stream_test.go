@@ -0,0 +1,169 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/storage"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+// newStores returns a route and an upstream store loaded with the values,
+// the keys written to the storage are appended to written.
+func newStores(t *testing.T, written *[]string, values map[store.HubKey][]string) Stores {
+	stores := Stores{}
+	for typ, objType := range map[store.HubKey]reflect.Type{
+		store.HubKeyRoute:    reflect.TypeOf(entity.Route{}),
+		store.HubKeyUpstream: reflect.TypeOf(entity.Upstream{}),
+	} {
+		s, err := store.NewGenericStore(store.GenericStoreOption{
+			BasePath: "/apisix/" + string(typ),
+			ObjType:  objType,
+			KeyFunc: func(obj any) string {
+				return obj.(entity.GetBaseInfo).GetBaseInfo().ID.(string)
+			},
+			HubKey: typ,
+		})
+		assert.NoError(t, err)
+
+		var kvs []storage.Keypair
+		for _, v := range values[typ] {
+			m := map[string]any{}
+			assert.NoError(t, json.Unmarshal([]byte(v), &m))
+			kvs = append(kvs, storage.Keypair{Key: "/apisix/" + string(typ) + "/" + m["id"].(string), Value: v})
+		}
+		mStorage := &storage.MockInterface{}
+		mStorage.On("List", mock.Anything, mock.Anything).Return(kvs, nil)
+		mStorage.On("Watch", mock.Anything, mock.Anything).Return(make(<-chan storage.WatchResponse))
+		mStorage.On("BatchPut", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			for _, kv := range args[1].([]storage.Keypair) {
+				*written = append(*written, kv.Key)
+			}
+		}).Return(nil)
+		for _, method := range []string{"Create", "Update"} {
+			mStorage.On(method, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				*written = append(*written, args[1].(string))
+			}).Return(nil)
+		}
+		s.Stg = mStorage
+		assert.NoError(t, s.Init())
+		stores[typ] = s
+	}
+	return stores
+}
+
+var streamValues = map[store.HubKey][]string{
+	store.HubKeyRoute:    {`{"id":"r1","uri":"/hello","upstream_id":"u1"}`},
+	store.HubKeyUpstream: {`{"id":"u1","type":"roundrobin","nodes":{"127.0.0.1:1980":1}}`},
+}
+
+func TestStream(t *testing.T) {
+	for _, format := range []Format{FormatNDJSON, FormatTar} {
+		var written []string
+		src := newStores(t, &written, streamValues)
+		buf := &bytes.Buffer{}
+		assert.NoError(t, ExportStream(context.TODO(), buf, format, src, nil), format)
+
+		// into empty stores, upstreams are written before the routes
+		dst := newStores(t, &written, nil)
+		ret, err := ImportStream(context.TODO(), bytes.NewReader(buf.Bytes()), ModeReturn, dst, nil)
+		assert.NoError(t, err, format)
+		assert.Equal(t, 2, ret.Created, format)
+		assert.Len(t, ret.Manifest.Types, len(StreamTypes), format)
+		assert.Equal(t, []string{"/apisix/upstream/u1", "/apisix/route/r1"}, written, format)
+
+		// into the stores holding the objects
+		written = nil
+		ret, err = ImportStream(context.TODO(), bytes.NewReader(buf.Bytes()), ModeReturn, src, nil)
+		assert.Equal(t, ErrConflict, err, format)
+		assert.Equal(t, []Conflict{
+			{Type: store.HubKeyUpstream, Key: "u1", Reason: ConflictKey, With: "u1"},
+			{Type: store.HubKeyRoute, Key: "r1", Reason: ConflictKey, With: "r1"},
+		}, ret.Conflicts, format)
+		assert.Empty(t, written, format)
+
+		ret, err = ImportStream(context.TODO(), bytes.NewReader(buf.Bytes()), ModeSkip, src, nil)
+		assert.NoError(t, err, format)
+		assert.Equal(t, 2, ret.Skipped, format)
+		assert.Empty(t, written, format)
+
+		ret, err = ImportStream(context.TODO(), bytes.NewReader(buf.Bytes()), ModeOverwrite, src, nil)
+		assert.NoError(t, err, format)
+		assert.Equal(t, 2, ret.Updated, format)
+		assert.Len(t, written, 2, format)
+	}
+}
+
+// a streamed export covers the types of the other backup formats
+func TestStreamTypes(t *testing.T) {
+	assert.ElementsMatch(t, dataSetTypes, StreamTypes)
+	assert.ElementsMatch(t, dataSetTypes, importOrder)
+}
+
+func TestImportStream_broken(t *testing.T) {
+	var written []string
+	buf := &bytes.Buffer{}
+	assert.NoError(t, ExportStream(context.TODO(), buf, FormatNDJSON, newStores(t, &written, streamValues), nil))
+	lines := strings.SplitAfter(strings.TrimSpace(buf.String()), "\n")
+
+	tests := []struct {
+		caseDesc string
+		give     string
+		wantErr  string
+	}{
+		{
+			caseDesc: "truncated",
+			give:     strings.Join(lines[:len(lines)-1], ""),
+			wantErr:  "manifest is missing, the file may be truncated",
+		},
+		{
+			caseDesc: "changed",
+			give:     strings.Replace(buf.String(), "/hello", "/world", 1),
+			wantErr:  "route: checksum mismatch, the file may be broken",
+		},
+		{
+			caseDesc: "object removed",
+			give:     strings.Join(lines[1:], ""),
+			wantErr:  "upstream: found 0 objects but the manifest lists 1, the file may be broken",
+		},
+		{
+			caseDesc: "duplicated",
+			give:     lines[0] + buf.String(),
+			wantErr:  "upstream u1 is duplicated",
+		},
+	}
+	for _, tc := range tests {
+		dst := newStores(t, &written, nil)
+		_, err := ImportStream(context.TODO(), strings.NewReader(tc.give), ModeOverwrite, dst, nil)
+		assert.EqualError(t, err, tc.wantErr, tc.caseDesc)
+	}
+	assert.Empty(t, written)
+
+	err := ExportStream(context.TODO(), buf, "xml", nil, nil)
+	assert.EqualError(t, err, "invalid format: xml, format should be ndjson or tar")
+}
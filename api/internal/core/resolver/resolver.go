@@ -0,0 +1,328 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/shiningrush/droplet/data"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+// Source names the object a piece of effective configuration comes from.
+type Source string
+
+const (
+	SourceGlobalRule   Source = "global_rule"
+	SourceService      Source = "service"
+	SourcePluginConfig Source = "plugin_config"
+	SourceRoute        Source = "route"
+	SourceConsumer     Source = "consumer"
+	SourceUpstream     Source = "upstream"
+)
+
+// Origin identifies a single object of a source.
+type Origin struct {
+	Source Source `json:"source"`
+	ID     string `json:"id"`
+}
+
+// Plugin is a plugin that runs for a request, annotated with where its
+// configuration comes from.
+type Plugin struct {
+	Name   string `json:"name"`
+	Config any    `json:"config"`
+	Origin
+	// Overrides lists the lower precedence objects that configure the same
+	// plugin, their configuration is discarded.
+	Overrides []Origin `json:"overrides,omitempty"`
+}
+
+// Upstream is the upstream requests are proxied to.
+type Upstream struct {
+	Origin
+	// UpstreamID is set when the upstream is referenced instead of inlined
+	UpstreamID string              `json:"upstream_id,omitempty"`
+	Upstream   *entity.UpstreamDef `json:"upstream"`
+	Nodes      []*entity.Node      `json:"nodes"`
+}
+
+// Field is the effective value of a route field and where it comes from.
+type Field struct {
+	Value any `json:"value"`
+	Origin
+}
+
+// Resolved is a route with all its references resolved.
+type Resolved struct {
+	Route        *entity.Route        `json:"route"`
+	Service      *entity.Service      `json:"service,omitempty"`
+	PluginConfig *entity.PluginConfig `json:"plugin_config,omitempty"`
+	Consumer     *entity.Consumer     `json:"consumer,omitempty"`
+	// GlobalPlugins run for every request in addition to Plugins
+	GlobalPlugins []Plugin  `json:"global_plugins"`
+	Plugins       []Plugin  `json:"plugins"`
+	Upstream      *Upstream `json:"upstream,omitempty"`
+	// Fields holds the effective value of every non-empty route field
+	Fields map[string]Field `json:"fields"`
+	// Errors lists broken references, the rest of the route is still resolved
+	Errors []string `json:"errors,omitempty"`
+}
+
+type Resolver struct {
+	ServiceStore      store.Interface
+	UpstreamStore     store.Interface
+	PluginConfigStore store.Interface
+	GlobalRuleStore   store.Interface
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		ServiceStore:      store.NewContextStore(store.HubKeyService),
+		UpstreamStore:     store.NewContextStore(store.HubKeyUpstream),
+		PluginConfigStore: store.NewContextStore(store.HubKeyPluginConfig),
+		GlobalRuleStore:   store.NewContextStore(store.HubKeyGlobalRule),
+	}
+}
+
+// Resolve merges the configuration of a route as APISIX does at runtime.
+// Plugin precedence is consumer > route > plugin_config > service, global
+// rules run independently. The upstream is taken from the first of
+// route.upstream, route.upstream_id, service.upstream and service.upstream_id.
+// consumer may be nil.
+func (r *Resolver) Resolve(ctx context.Context, route *entity.Route, consumer *entity.Consumer) (*Resolved, error) {
+	ret := &Resolved{Route: route, Consumer: consumer}
+
+	if route.ServiceID != nil {
+		obj, err := r.get(ctx, r.ServiceStore, route.ServiceID)
+		if err != nil {
+			if err != data.ErrNotFound {
+				return nil, err
+			}
+			ret.Errors = append(ret.Errors, fmt.Sprintf("service %s not found", utils.InterfaceToString(route.ServiceID)))
+		} else {
+			ret.Service = obj.(*entity.Service)
+		}
+	}
+
+	if route.PluginConfigID != nil {
+		obj, err := r.get(ctx, r.PluginConfigStore, route.PluginConfigID)
+		if err != nil {
+			if err != data.ErrNotFound {
+				return nil, err
+			}
+			ret.Errors = append(ret.Errors, fmt.Sprintf("plugin_config %s not found", utils.InterfaceToString(route.PluginConfigID)))
+		} else {
+			ret.PluginConfig = obj.(*entity.PluginConfig)
+		}
+	}
+
+	globals, err := r.globalPlugins(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret.GlobalPlugins = globals
+	ret.Plugins = r.mergePlugins(ret)
+
+	upstream, err := r.upstream(ctx, ret)
+	if err != nil {
+		return nil, err
+	}
+	ret.Upstream = upstream
+	ret.Fields = r.mergeFields(ret)
+
+	return ret, nil
+}
+
+func (r *Resolver) get(ctx context.Context, s store.Interface, id any) (any, error) {
+	obj, err := s.Get(ctx, utils.InterfaceToString(id))
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, data.ErrNotFound
+	}
+	return obj, nil
+}
+
+func (r *Resolver) globalPlugins(ctx context.Context) ([]Plugin, error) {
+	ret, err := r.GlobalRuleStore.List(ctx, store.ListInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := make([]Plugin, 0)
+	for _, row := range ret.Rows {
+		rule := row.(*entity.GlobalPlugins)
+		origin := Origin{Source: SourceGlobalRule, ID: utils.InterfaceToString(rule.ID)}
+		for _, name := range sortedKeys(rule.Plugins) {
+			plugins = append(plugins, Plugin{Name: name, Config: rule.Plugins[name], Origin: origin})
+		}
+	}
+	return plugins, nil
+}
+
+// mergePlugins applies the plugin sources from the lowest to the highest
+// precedence, a higher source replaces the whole configuration of a plugin.
+func (r *Resolver) mergePlugins(ret *Resolved) []Plugin {
+	type layer struct {
+		origin  Origin
+		plugins map[string]any
+	}
+	var layers []layer
+	if ret.Service != nil {
+		layers = append(layers, layer{Origin{SourceService, utils.InterfaceToString(ret.Service.ID)}, ret.Service.Plugins})
+	}
+	if ret.PluginConfig != nil {
+		layers = append(layers, layer{Origin{SourcePluginConfig, utils.InterfaceToString(ret.PluginConfig.ID)}, ret.PluginConfig.Plugins})
+	}
+	layers = append(layers, layer{Origin{SourceRoute, utils.InterfaceToString(ret.Route.ID)}, ret.Route.Plugins})
+	if ret.Consumer != nil {
+		layers = append(layers, layer{Origin{SourceConsumer, ret.Consumer.Username}, ret.Consumer.Plugins})
+	}
+
+	merged := make(map[string]any)
+	for _, l := range layers {
+		for name, conf := range l.plugins {
+			p := &Plugin{Name: name, Config: conf, Origin: l.origin}
+			if prev, ok := merged[name]; ok {
+				prev := prev.(*Plugin)
+				p.Overrides = append(append(p.Overrides, prev.Overrides...), prev.Origin)
+			}
+			merged[name] = p
+		}
+	}
+
+	plugins := make([]Plugin, 0, len(merged))
+	for _, name := range sortedKeys(merged) {
+		plugins = append(plugins, *merged[name].(*Plugin))
+	}
+	return plugins
+}
+
+// mergeFields annotates the route fields, the service only provides hosts,
+// enable_websocket and script when the route leaves them empty.
+func (r *Resolver) mergeFields(ret *Resolved) map[string]Field {
+	route := ret.Route
+	routeOrigin := Origin{SourceRoute, utils.InterfaceToString(route.ID)}
+	fields := make(map[string]Field)
+	set := func(name string, value any, empty bool, origin Origin) {
+		if !empty {
+			fields[name] = Field{Value: value, Origin: origin}
+		}
+	}
+
+	set("name", route.Name, route.Name == "", routeOrigin)
+	set("desc", route.Desc, route.Desc == "", routeOrigin)
+	set("uri", route.URI, route.URI == "", routeOrigin)
+	set("uris", route.Uris, len(route.Uris) == 0, routeOrigin)
+	set("methods", route.Methods, len(route.Methods) == 0, routeOrigin)
+	set("priority", route.Priority, route.Priority == 0, routeOrigin)
+	set("remote_addr", route.RemoteAddr, route.RemoteAddr == "", routeOrigin)
+	set("remote_addrs", route.RemoteAddrs, len(route.RemoteAddrs) == 0, routeOrigin)
+	set("vars", route.Vars, len(route.Vars) == 0, routeOrigin)
+	set("filter_func", route.FilterFunc, route.FilterFunc == "", routeOrigin)
+	set("labels", route.Labels, len(route.Labels) == 0, routeOrigin)
+	set("status", route.Status, false, routeOrigin)
+	set("plugin_config_id", route.PluginConfigID, route.PluginConfigID == nil, routeOrigin)
+	set("service_id", route.ServiceID, route.ServiceID == nil, routeOrigin)
+
+	set("host", route.Host, route.Host == "", routeOrigin)
+	set("hosts", route.Hosts, len(route.Hosts) == 0, routeOrigin)
+	set("enable_websocket", route.EnableWebsocket, !route.EnableWebsocket, routeOrigin)
+	set("script", route.Script, route.Script == nil, routeOrigin)
+
+	if svc := ret.Service; svc != nil {
+		serviceOrigin := Origin{SourceService, utils.InterfaceToString(svc.ID)}
+		if route.Host == "" && len(route.Hosts) == 0 {
+			set("hosts", svc.Hosts, len(svc.Hosts) == 0, serviceOrigin)
+		}
+		if !route.EnableWebsocket {
+			set("enable_websocket", svc.EnableWebsocket, !svc.EnableWebsocket, serviceOrigin)
+		}
+		if route.Script == nil {
+			set("script", svc.Script, svc.Script == "", serviceOrigin)
+		}
+	}
+
+	if ret.Upstream != nil {
+		if ret.Upstream.UpstreamID != "" {
+			set("upstream_id", ret.Upstream.UpstreamID, false, ret.Upstream.Origin)
+		} else {
+			set("upstream", ret.Upstream.Upstream, false, ret.Upstream.Origin)
+		}
+	}
+
+	return fields
+}
+
+func (r *Resolver) upstream(ctx context.Context, ret *Resolved) (*Upstream, error) {
+	routeOrigin := Origin{SourceRoute, utils.InterfaceToString(ret.Route.ID)}
+	if ret.Route.Upstream != nil {
+		return newUpstream(routeOrigin, "", ret.Route.Upstream), nil
+	}
+	if ret.Route.UpstreamID != nil {
+		return r.upstreamByID(ctx, ret, routeOrigin, ret.Route.UpstreamID)
+	}
+	if ret.Service == nil {
+		return nil, nil
+	}
+
+	serviceOrigin := Origin{SourceService, utils.InterfaceToString(ret.Service.ID)}
+	if ret.Service.Upstream != nil {
+		return newUpstream(serviceOrigin, "", ret.Service.Upstream), nil
+	}
+	if ret.Service.UpstreamID != nil {
+		return r.upstreamByID(ctx, ret, serviceOrigin, ret.Service.UpstreamID)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) upstreamByID(ctx context.Context, ret *Resolved, origin Origin, id any) (*Upstream, error) {
+	obj, err := r.get(ctx, r.UpstreamStore, id)
+	if err != nil {
+		if err != data.ErrNotFound {
+			return nil, err
+		}
+		ret.Errors = append(ret.Errors, fmt.Sprintf("upstream %s not found", utils.InterfaceToString(id)))
+		return nil, nil
+	}
+	upstream := obj.(*entity.Upstream)
+	return newUpstream(origin, utils.InterfaceToString(upstream.ID), &upstream.UpstreamDef), nil
+}
+
+func newUpstream(origin Origin, id string, def *entity.UpstreamDef) *Upstream {
+	ret := &Upstream{Origin: origin, UpstreamID: id, Upstream: def, Nodes: make([]*entity.Node, 0)}
+	if nodes, ok := entity.NodesFormat(def.Nodes).([]*entity.Node); ok {
+		ret.Nodes = nodes
+	}
+	return ret
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
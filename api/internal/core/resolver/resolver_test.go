@@ -0,0 +1,207 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package resolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shiningrush/droplet/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func newResolver(service *entity.Service, pluginConfig *entity.PluginConfig, upstream *entity.Upstream, globals ...any) *Resolver {
+	serviceStore := &store.MockInterface{}
+	if service != nil {
+		serviceStore.On("Get", "s1").Return(service, nil)
+	} else {
+		serviceStore.On("Get", mock.Anything).Return(nil, data.ErrNotFound)
+	}
+
+	pluginConfigStore := &store.MockInterface{}
+	pluginConfigStore.On("Get", "pc1").Return(pluginConfig, nil)
+
+	upstreamStore := &store.MockInterface{}
+	if upstream != nil {
+		upstreamStore.On("Get", "u1").Return(upstream, nil)
+	} else {
+		upstreamStore.On("Get", mock.Anything).Return(nil, data.ErrNotFound)
+	}
+
+	globalRuleStore := &store.MockInterface{}
+	globalRuleStore.On("List", mock.Anything).Return(&store.ListOutput{Rows: globals, TotalSize: len(globals)}, nil)
+
+	return &Resolver{
+		ServiceStore:      serviceStore,
+		UpstreamStore:     upstreamStore,
+		PluginConfigStore: pluginConfigStore,
+		GlobalRuleStore:   globalRuleStore,
+	}
+}
+
+func TestResolve_Plugins(t *testing.T) {
+	service := &entity.Service{
+		BaseInfo: entity.BaseInfo{ID: "s1"},
+		Plugins: map[string]any{
+			"limit-count": map[string]any{"count": float64(1)},
+			"cors":        map[string]any{},
+		},
+	}
+	pluginConfig := &entity.PluginConfig{
+		BaseInfo: entity.BaseInfo{ID: "pc1"},
+		Plugins: map[string]any{
+			"limit-count": map[string]any{"count": float64(2)},
+			"key-auth":    map[string]any{},
+		},
+	}
+	global := &entity.GlobalPlugins{
+		BaseInfo: entity.BaseInfo{ID: "g1"},
+		Plugins:  map[string]any{"prometheus": map[string]any{}},
+	}
+	route := &entity.Route{
+		BaseInfo:       entity.BaseInfo{ID: "r1"},
+		ServiceID:      "s1",
+		PluginConfigID: "pc1",
+		Plugins:        map[string]any{"limit-count": map[string]any{"count": float64(3)}},
+	}
+	consumer := &entity.Consumer{
+		Username: "jack",
+		Plugins: map[string]any{
+			"key-auth":    map[string]any{"key": "auth-one"},
+			"limit-count": map[string]any{"count": float64(4)},
+		},
+	}
+
+	r := newResolver(service, pluginConfig, nil, global)
+	ret, err := r.Resolve(context.Background(), route, consumer)
+	assert.Nil(t, err)
+	assert.Empty(t, ret.Errors)
+	assert.Equal(t, []Plugin{{Name: "prometheus", Config: map[string]any{}, Origin: Origin{SourceGlobalRule, "g1"}}}, ret.GlobalPlugins)
+	assert.Equal(t, []Plugin{
+		{Name: "cors", Config: map[string]any{}, Origin: Origin{SourceService, "s1"}},
+		{
+			Name:      "key-auth",
+			Config:    map[string]any{"key": "auth-one"},
+			Origin:    Origin{SourceConsumer, "jack"},
+			Overrides: []Origin{{SourcePluginConfig, "pc1"}},
+		},
+		{
+			Name:   "limit-count",
+			Config: map[string]any{"count": float64(4)},
+			Origin: Origin{SourceConsumer, "jack"},
+			Overrides: []Origin{
+				{SourceService, "s1"},
+				{SourcePluginConfig, "pc1"},
+				{SourceRoute, "r1"},
+			},
+		},
+	}, ret.Plugins)
+	assert.Nil(t, ret.Upstream)
+}
+
+func TestResolve_Upstream(t *testing.T) {
+	upstream := &entity.Upstream{
+		BaseInfo:    entity.BaseInfo{ID: "u1"},
+		UpstreamDef: entity.UpstreamDef{Type: "roundrobin", Nodes: map[string]float64{"127.0.0.1:80": 1}},
+	}
+	service := &entity.Service{BaseInfo: entity.BaseInfo{ID: "s1"}, UpstreamID: "u1"}
+
+	tests := []struct {
+		caseDesc   string
+		route      *entity.Route
+		service    *entity.Service
+		wantOrigin Origin
+		wantID     string
+		wantErrors []string
+	}{
+		{
+			caseDesc: "inline upstream of route",
+			route: &entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, ServiceID: "s1", UpstreamID: "u1",
+				Upstream: &entity.UpstreamDef{Nodes: map[string]float64{"127.0.0.1:80": 1}}},
+			service:    service,
+			wantOrigin: Origin{SourceRoute, "r1"},
+		},
+		{
+			caseDesc:   "upstream id of route",
+			route:      &entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, ServiceID: "s1", UpstreamID: "u1"},
+			service:    service,
+			wantOrigin: Origin{SourceRoute, "r1"},
+			wantID:     "u1",
+		},
+		{
+			caseDesc:   "upstream id of service",
+			route:      &entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, ServiceID: "s1"},
+			service:    service,
+			wantOrigin: Origin{SourceService, "s1"},
+			wantID:     "u1",
+		},
+		{
+			caseDesc:   "missing service",
+			route:      &entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, ServiceID: "s1"},
+			wantErrors: []string{"service s1 not found"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.caseDesc, func(t *testing.T) {
+			r := newResolver(tc.service, nil, upstream)
+			ret, err := r.Resolve(context.Background(), tc.route, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantErrors, ret.Errors)
+			if tc.wantOrigin.Source == "" {
+				assert.Nil(t, ret.Upstream)
+				return
+			}
+			assert.Equal(t, tc.wantOrigin, ret.Upstream.Origin)
+			assert.Equal(t, tc.wantID, ret.Upstream.UpstreamID)
+			assert.Equal(t, []*entity.Node{{Host: "127.0.0.1", Port: 80, Weight: 1}}, ret.Upstream.Nodes)
+		})
+	}
+}
+
+func TestResolve_Fields(t *testing.T) {
+	service := &entity.Service{
+		BaseInfo:        entity.BaseInfo{ID: "s1"},
+		Hosts:           []string{"foo.com"},
+		EnableWebsocket: true,
+		Upstream:        &entity.UpstreamDef{Type: "roundrobin"},
+	}
+	route := &entity.Route{
+		BaseInfo:  entity.BaseInfo{ID: "r1"},
+		URI:       "/foo",
+		Status:    1,
+		ServiceID: "s1",
+	}
+
+	ret, err := newResolver(service, nil, nil).Resolve(context.Background(), route, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, Field{Value: "/foo", Origin: Origin{SourceRoute, "r1"}}, ret.Fields["uri"])
+	assert.Equal(t, Field{Value: []string{"foo.com"}, Origin: Origin{SourceService, "s1"}}, ret.Fields["hosts"])
+	assert.Equal(t, Field{Value: true, Origin: Origin{SourceService, "s1"}}, ret.Fields["enable_websocket"])
+	assert.Equal(t, Origin{SourceService, "s1"}, ret.Fields["upstream"].Origin)
+	assert.NotContains(t, ret.Fields, "script")
+
+	// the route's own hosts take precedence over the service
+	route.Hosts = []string{"bar.com"}
+	ret, err = newResolver(service, nil, nil).Resolve(context.Background(), route, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, Field{Value: []string{"bar.com"}, Origin: Origin{SourceRoute, "r1"}}, ret.Fields["hosts"])
+}
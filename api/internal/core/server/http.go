@@ -27,7 +27,9 @@ import (
 
 	"github.com/apisix/manager-api/internal"
 	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/filter"
 	"github.com/apisix/manager-api/internal/handler"
+	"github.com/apisix/manager-api/internal/utils"
 )
 
 func (s *server) setupAPI() {
@@ -36,36 +38,45 @@ func (s *server) setupAPI() {
 		var newMws []droplet.Middleware
 		// default middleware order: resp_reshape, auto_input, traffic_log
 		// We should put err_transform at second to catch all error
-		newMws = append(newMws, mws[0], &handler.ErrorTransformMiddleware{})
+		newMws = append(newMws, mws[0], &handler.ErrorTransformMiddleware{}, &handler.DryRunMiddleware{})
 		newMws = append(newMws, mws[1:]...)
 		return newMws
 	}
 
 	// routes
 	r := internal.SetUpRouter()
+	h := filter.ClusterPath(r)
 
 	// HTTP
 	addr := net.JoinHostPort(conf.ServerHost, strconv.Itoa(conf.ServerPort))
-	s.server = &http.Server{
-		Addr:         addr,
-		Handler:      r,
-		ReadTimeout:  time.Duration(1000) * time.Millisecond,
-		WriteTimeout: time.Duration(5000) * time.Millisecond,
-	}
+	s.server = newHTTPServer(addr, h)
 
 	// HTTPS
 	if conf.SSLCert != "" && conf.SSLKey != "" {
 		addrSSL := net.JoinHostPort(conf.SSLHost, strconv.Itoa(conf.SSLPort))
-		s.serverSSL = &http.Server{
-			Addr:         addrSSL,
-			Handler:      r,
-			ReadTimeout:  time.Duration(1000) * time.Millisecond,
-			WriteTimeout: time.Duration(5000) * time.Millisecond,
-			TLSConfig: &tls.Config{
-				// Causes servers to use Go's default ciphersuite preferences,
-				// which are tuned to avoid attacks. Does nothing on clients.
-				PreferServerCipherSuites: true,
-			},
+		s.serverSSL = newHTTPServer(addrSSL, h)
+		s.serverSSL.TLSConfig = &tls.Config{
+			// Causes servers to use Go's default ciphersuite preferences,
+			// which are tuned to avoid attacks. Does nothing on clients.
+			PreferServerCipherSuites: true,
 		}
 	}
 }
+
+// the timeouts of the requests, imports and exports lift them with
+// utils.ClearDeadlines
+var (
+	readTimeout  = time.Duration(1000) * time.Millisecond
+	writeTimeout = time.Duration(5000) * time.Millisecond
+)
+
+func newHTTPServer(addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      h,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		// the handlers reach the connection to lift the timeouts
+		ConnContext: utils.WithConn,
+	}
+}
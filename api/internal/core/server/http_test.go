@@ -0,0 +1,99 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+const chunk = "0123456789\n"
+
+// slowReader returns the chunks with a pause before each of them
+type slowReader struct {
+	n int
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	r.n--
+	time.Sleep(50 * time.Millisecond)
+	return copy(p, chunk), nil
+}
+
+// streamHandler reads the upload and writes it back slowly, like a large
+// import or export
+func streamHandler(clear bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if clear {
+			_ = utils.ClearDeadlines(r.Context())
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusRequestTimeout)
+			return
+		}
+		for _, line := range strings.SplitAfter(string(body), "\n") {
+			if _, err := io.WriteString(w, line); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+}
+
+func TestClearDeadlines(t *testing.T) {
+	defer func(read, write time.Duration) {
+		readTimeout, writeTimeout = read, write
+	}(readTimeout, writeTimeout)
+	readTimeout, writeTimeout = 100*time.Millisecond, 200*time.Millisecond
+
+	stream := func(clear bool) (string, error) {
+		srv := httptest.NewUnstartedServer(nil)
+		srv.Config = newHTTPServer("", streamHandler(clear))
+		srv.Start()
+		defer srv.Close()
+
+		// the upload takes 500ms and the download as long
+		resp, err := http.Post(srv.URL, "application/x-ndjson", &slowReader{n: 10})
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	body, err := stream(true)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat(chunk, 10), body)
+
+	// without clearing them, the timeouts cut the stream
+	body, err = stream(false)
+	assert.True(t, err != nil || body != strings.Repeat(chunk, 10))
+}
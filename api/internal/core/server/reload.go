@@ -0,0 +1,113 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+
+	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/ldap"
+	"github.com/apisix/manager-api/internal/filter"
+	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+// editors and ConfigMap updates write the config file in several steps,
+// the reload waits for them to be done.
+const reloadDelay = 500 * time.Millisecond
+
+// Reload reloads the config file. The settings which can be reloaded are
+// applied and the components built from them rebuilt, the changed settings
+// which need a restart are reported. An invalid config file is rejected and
+// the current configuration kept.
+func (s *server) Reload() {
+	result, err := conf.Reload()
+	if err != nil {
+		log.Errorf("reload configuration failed, the current configuration is kept: %s", err)
+		return
+	}
+
+	if result.IsApplied("conf.allow_list") {
+		filter.ReloadAllowList()
+	}
+	if result.IsApplied("conf.log.error_log.level") {
+		log.ReloadLevel()
+	}
+	if result.IsApplied("ldap") && conf.LdapEnabled {
+		ldap.Init()
+	}
+
+	if len(result.Applied) == 0 && len(result.RestartRequired) == 0 {
+		log.Info("configuration reloaded, nothing changed")
+	}
+	if len(result.Applied) > 0 {
+		log.Infof("configuration reloaded, applied: %s", strings.Join(result.Applied, ", "))
+	}
+	if len(result.RestartRequired) > 0 {
+		log.Warnf("configuration reloaded, restart manager-api to apply: %s", strings.Join(result.RestartRequired, ", "))
+	}
+}
+
+// watchConfig reloads the configuration when the config file changes. Its
+// directory is watched, so that files replaced by editors and mounted
+// ConfigMaps, which are swapped through a symlink, are followed.
+func (s *server) watchConfig() error {
+	file := filepath.Clean(viper.ConfigFileUsed())
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	utils.AppendToClosers(watcher.Close)
+
+	go func() {
+		realFile, _ := filepath.EvalSymlinks(file)
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				currentFile, _ := filepath.EvalSymlinks(file)
+				written := filepath.Clean(event.Name) == file && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+				if !written && currentFile == realFile {
+					continue
+				}
+				realFile = currentFile
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, s.Reload)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Errorf("watch config file failed: %s", err)
+			}
+		}
+	}()
+	return nil
+}
@@ -21,11 +21,14 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 
 	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/backup"
+	"github.com/apisix/manager-api/internal/core/cluster"
 	"github.com/apisix/manager-api/internal/log"
 	"github.com/apisix/manager-api/internal/utils"
 )
@@ -91,6 +94,20 @@ func (s *server) init() error {
 		return err
 	}
 
+	if err := cluster.Init(); err != nil {
+		log.Errorf("init clusters fail: %v", err)
+		return err
+	}
+
+	if err := backup.Init(); err != nil {
+		log.Errorf("init backup scheduler fail: %v", err)
+		return err
+	}
+
+	if err := s.watchConfig(); err != nil {
+		log.Errorf("watch config file fail, reload it with SIGHUP: %v", err)
+	}
+
 	log.Info("Initialize Manager API server")
 	s.setupAPI()
 
@@ -112,6 +129,10 @@ func (s *server) printInfo() {
 	fmt.Fprint(os.Stdout, "The manager-api is running successfully!\n\n")
 	utils.PrintVersion()
 	fmt.Fprintf(os.Stdout, "%-8s: %s\n", "Config File", viper.ConfigFileUsed())
+	if len(conf.Substituted) > 0 {
+		// only the names of the fields, their values are usually secrets
+		fmt.Fprintf(os.Stdout, "%-8s: %s\n", "Substituted", strings.Join(conf.Substituted, ", "))
+	}
 	fmt.Fprintf(os.Stdout, "%-8s: %s:%d\n", "Listen", conf.ServerHost, conf.ServerPort)
 	if conf.SSLCert != "" && conf.SSLKey != "" {
 		fmt.Fprintf(os.Stdout, "%-8s: %s:%d\n", "HTTPS Listen", conf.SSLHost, conf.SSLPort)
@@ -55,6 +55,27 @@ type EtcdV3Storage struct {
 }
 
 func InitETCDClient(etcdConf *conf.Etcd) error {
+	cli, err := newClient(etcdConf)
+	if err != nil {
+		return err
+	}
+
+	etcdClient = cli
+	utils.AppendToClosers(Close)
+	return nil
+}
+
+// NewEtcdStorage returns a storage with its own client of the etcd cluster,
+// the caller closes it.
+func NewEtcdStorage(etcdConf *conf.Etcd) (*EtcdV3Storage, error) {
+	cli, err := newClient(etcdConf)
+	if err != nil {
+		return nil, err
+	}
+	return &EtcdV3Storage{client: cli}, nil
+}
+
+func newClient(etcdConf *conf.Etcd) (*clientv3.Client, error) {
 	config := clientv3.Config{
 		Endpoints:   etcdConf.Endpoints,
 		DialTimeout: 5 * time.Second,
@@ -71,7 +92,7 @@ func InitETCDClient(etcdConf *conf.Etcd) error {
 		}
 		tlsConfig, err := tlsInfo.ClientConfig()
 		if err != nil {
-			return err
+			return nil, err
 		}
 		config.TLS = tlsConfig
 	}
@@ -79,12 +100,9 @@ func InitETCDClient(etcdConf *conf.Etcd) error {
 	cli, err := clientv3.New(config)
 	if err != nil {
 		log.Errorf("init etcd failed: %s", err)
-		return fmt.Errorf("init etcd failed: %s", err)
+		return nil, fmt.Errorf("init etcd failed: %s", err)
 	}
-
-	etcdClient = cli
-	utils.AppendToClosers(Close)
-	return nil
+	return cli, nil
 }
 
 func GenEtcdStorage() *EtcdV3Storage {
@@ -101,6 +119,11 @@ func Close() error {
 	return nil
 }
 
+// Close closes the client of a storage created by NewEtcdStorage.
+func (s *EtcdV3Storage) Close() error {
+	return s.client.Close()
+}
+
 func (s *EtcdV3Storage) Get(ctx context.Context, key string) (string, error) {
 	resp, err := s.client.Get(ctx, key)
 	if err != nil {
@@ -178,6 +201,20 @@ func (s *EtcdV3Storage) BatchDelete(ctx context.Context, keys []string) error {
 	return nil
 }
 
+// BatchPut writes all keys in one transaction, so the number of keys must
+// stay below the max-txn-ops of etcd.
+func (s *EtcdV3Storage) BatchPut(ctx context.Context, kvs []Keypair) error {
+	ops := make([]clientv3.Op, 0, len(kvs))
+	for i := range kvs {
+		ops = append(ops, clientv3.OpPut(kvs[i].Key, kvs[i].Value))
+	}
+	if _, err := s.client.Txn(ctx).Then(ops...).Commit(); err != nil {
+		log.Errorf("etcd batch put failed: %s", err)
+		return fmt.Errorf("etcd batch put failed: %s", err)
+	}
+	return nil
+}
+
 func (s *EtcdV3Storage) Watch(ctx context.Context, key string) <-chan WatchResponse {
 	eventChan := s.client.Watch(ctx, key, clientv3.WithPrefix())
 	ch := make(chan WatchResponse, 1)
@@ -24,6 +24,7 @@ type Interface interface {
 	Create(ctx context.Context, key, val string) error
 	Update(ctx context.Context, key, val string) error
 	BatchDelete(ctx context.Context, keys []string) error
+	BatchPut(ctx context.Context, kvs []Keypair) error
 	Watch(ctx context.Context, key string) <-chan WatchResponse
 }
 
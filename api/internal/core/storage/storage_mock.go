@@ -41,6 +41,20 @@ func (_m *MockInterface) BatchDelete(ctx context.Context, keys []string) error {
 	return r0
 }
 
+// BatchPut provides a mock function with given fields: ctx, kvs
+func (_m *MockInterface) BatchPut(ctx context.Context, kvs []Keypair) error {
+	ret := _m.Called(ctx, kvs)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []Keypair) error); ok {
+		r0 = rf(ctx, kvs)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Create provides a mock function with given fields: ctx, key, val
 func (_m *MockInterface) Create(ctx context.Context, key string, val string) error {
 	ret := _m.Called(ctx, key, val)
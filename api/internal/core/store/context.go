@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package store
+
+import "context"
+
+type hubKey struct{}
+
+// WithHub returns a context of a request to the cluster of the hub.
+func WithHub(ctx context.Context, hub *Hub) context.Context {
+	return context.WithValue(ctx, hubKey{}, hub)
+}
+
+// HubOf returns the hub of the cluster of the request of ctx, the default
+// hub when no cluster is selected.
+func HubOf(ctx context.Context) *Hub {
+	if hub, ok := ctx.Value(hubKey{}).(*Hub); ok && hub != nil {
+		return hub
+	}
+	return storeHub
+}
+
+// ContextStore is the store of a type in the cluster of a request. It is
+// resolved by the context of each call, so handlers can keep it from their
+// construction and serve every cluster.
+type ContextStore struct {
+	key HubKey
+}
+
+func NewContextStore(key HubKey) *ContextStore {
+	return &ContextStore{key: key}
+}
+
+func (s *ContextStore) Type() HubKey {
+	return s.key
+}
+
+func (s *ContextStore) Get(ctx context.Context, key string) (any, error) {
+	return HubOf(ctx).GetStore(s.key).Get(ctx, key)
+}
+
+func (s *ContextStore) List(ctx context.Context, input ListInput) (*ListOutput, error) {
+	return HubOf(ctx).GetStore(s.key).List(ctx, input)
+}
+
+func (s *ContextStore) Create(ctx context.Context, obj any) (any, error) {
+	return HubOf(ctx).GetStore(s.key).Create(ctx, obj)
+}
+
+func (s *ContextStore) Update(ctx context.Context, obj any, createIfNotExist bool) (any, error) {
+	return HubOf(ctx).GetStore(s.key).Update(ctx, obj, createIfNotExist)
+}
+
+func (s *ContextStore) BatchDelete(ctx context.Context, keys []string) error {
+	return HubOf(ctx).GetStore(s.key).BatchDelete(ctx, keys)
+}
@@ -0,0 +1,58 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shiningrush/droplet/data"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextStore(t *testing.T) {
+	defaultRoutes := &GenericStore{}
+	defaultRoutes.cache.Store("r1", "default")
+	otherRoutes := &GenericStore{}
+	otherRoutes.cache.Store("r1", "other")
+	otherRoutes.cache.Store("r2", "other")
+
+	origin := storeHub
+	storeHub = &Hub{stores: map[HubKey]*GenericStore{HubKeyRoute: defaultRoutes}}
+	defer func() { storeHub = origin }()
+	other := &Hub{stores: map[HubKey]*GenericStore{HubKeyRoute: otherRoutes}}
+
+	s := NewContextStore(HubKeyRoute)
+	assert.Equal(t, HubKeyRoute, s.Type())
+
+	ret, err := s.Get(context.Background(), "r1")
+	assert.Nil(t, err)
+	assert.Equal(t, "default", ret)
+	_, err = s.Get(context.Background(), "r2")
+	assert.Equal(t, data.ErrNotFound, err)
+
+	ctx := WithHub(context.Background(), other)
+	ret, err = s.Get(ctx, "r1")
+	assert.Nil(t, err)
+	assert.Equal(t, "other", ret)
+	ret, err = s.Get(ctx, "r2")
+	assert.Nil(t, err)
+	assert.Equal(t, "other", ret)
+
+	assert.Equal(t, storeHub, HubOf(context.Background()))
+	assert.Equal(t, other, HubOf(ctx))
+}
@@ -278,6 +278,10 @@ func (s *GenericStore) Create(ctx context.Context, obj any) (any, error) {
 		return nil, err
 	}
 
+	if IsDryRun(ctx) {
+		return obj, nil
+	}
+
 	if err := s.Stg.Create(ctx, s.GetObjStorageKey(obj), string(bytes)); err != nil {
 		return nil, err
 	}
@@ -315,6 +319,10 @@ func (s *GenericStore) Update(ctx context.Context, obj any, createIfNotExist boo
 		log.Errorf("json marshal failed: %s", err)
 		return nil, fmt.Errorf("json marshal failed: %s", err)
 	}
+	if IsDryRun(ctx) {
+		return obj, nil
+	}
+
 	if err := s.Stg.Update(ctx, s.GetObjStorageKey(obj), string(bs)); err != nil {
 		return nil, err
 	}
@@ -328,9 +336,56 @@ func (s *GenericStore) BatchDelete(ctx context.Context, keys []string) error {
 		storageKeys = append(storageKeys, s.GetStorageKey(keys[i]))
 	}
 
+	if IsDryRun(ctx) {
+		for _, key := range keys {
+			if _, ok := s.cache.Load(key); !ok {
+				return fmt.Errorf("key: %s is not found", s.GetStorageKey(key))
+			}
+		}
+		return nil
+	}
+
 	return s.Stg.BatchDelete(ctx, storageKeys)
 }
 
+// BatchPut validates the objects and writes them in one storage request.
+// Objects which are stored already are replaced, the others are created.
+func (s *GenericStore) BatchPut(ctx context.Context, objs []any) error {
+	kvs := make([]storage.Keypair, 0, len(objs))
+	for _, obj := range objs {
+		if setter, ok := obj.(entity.GetBaseInfo); ok {
+			info := setter.GetBaseInfo()
+			stored, ok := s.cache.Load(s.opt.KeyFunc(obj))
+			if ok {
+				info.Updating(stored.(entity.GetBaseInfo).GetBaseInfo())
+			} else {
+				info.Creating()
+			}
+		}
+
+		if err := s.ingestValidate(obj); err != nil {
+			return err
+		}
+
+		key := s.opt.KeyFunc(obj)
+		if key == "" {
+			return fmt.Errorf("key is required")
+		}
+		bs, err := json.Marshal(obj)
+		if err != nil {
+			log.Errorf("json marshal failed: %s", err)
+			return fmt.Errorf("json marshal failed: %s", err)
+		}
+		kvs = append(kvs, storage.Keypair{Key: s.GetStorageKey(key), Value: string(bs)})
+	}
+
+	if IsDryRun(ctx) || len(kvs) == 0 {
+		return nil
+	}
+
+	return s.Stg.BatchPut(ctx, kvs)
+}
+
 func (s *GenericStore) listAndWatch() error {
 	lc, lcancel := context.WithTimeout(context.TODO(), 5*time.Second)
 	defer lcancel()
@@ -414,6 +469,11 @@ func (s *GenericStore) StringToObjPtr(str, key string) (any, error) {
 	return ret, nil
 }
 
+// Key returns the key of the object in the store.
+func (s *GenericStore) Key(obj any) string {
+	return s.opt.KeyFunc(obj)
+}
+
 func (s *GenericStore) GetObjStorageKey(obj any) string {
 	return s.GetStorageKey(s.opt.KeyFunc(obj))
 }
@@ -28,6 +28,23 @@ type MockInterface struct {
 	HubKey HubKey
 }
 
+// NewMockStore returns a mock store of the type which lists the objects
+// matching the predicate of the input. Tests add the expectations of the
+// other methods.
+func NewMockStore(typ HubKey, objs ...any) *MockInterface {
+	m := &MockInterface{HubKey: typ}
+	m.On("List", mock.Anything).Return(func(input ListInput) *ListOutput {
+		rows := []any{}
+		for _, obj := range objs {
+			if input.Predicate == nil || input.Predicate(obj) {
+				rows = append(rows, obj)
+			}
+		}
+		return &ListOutput{Rows: rows, TotalSize: len(rows)}
+	}, nil)
+	return m
+}
+
 func (m *MockInterface) Type() HubKey {
 	return m.HubKey
 }
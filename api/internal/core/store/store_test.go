@@ -794,6 +794,101 @@ func TestGenericStore_Delete(t *testing.T) {
 	}
 }
 
+func TestGenericStore_BatchPut(t *testing.T) {
+	s := &GenericStore{
+		opt: GenericStoreOption{
+			BasePath: "test/path",
+			KeyFunc: func(obj any) string {
+				return obj.(*TestStruct).Field1
+			},
+		},
+	}
+	s.cache.Store("test1", &TestStruct{BaseInfo: entity.BaseInfo{ID: "1", CreateTime: 100}, Field1: "test1"})
+
+	var kvs []storage.Keypair
+	mStorage := &storage.MockInterface{}
+	mStorage.On("BatchPut", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		kvs = args[1].([]storage.Keypair)
+	}).Return(nil)
+	s.Stg = mStorage
+	mValidator := &MockValidator{}
+	mValidator.On("Validate", mock.Anything).Return(nil)
+	s.opt.Validator = mValidator
+
+	err := s.BatchPut(context.TODO(), []any{
+		&TestStruct{Field1: "test1", Field2: "new"},
+		&TestStruct{Field1: "test2"},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, kvs, 2)
+	assert.Equal(t, "test/path/test1", kvs[0].Key)
+	assert.Equal(t, "test/path/test2", kvs[1].Key)
+
+	// the stored object keeps its id and create time
+	updated := TestStruct{}
+	assert.Nil(t, json.Unmarshal([]byte(kvs[0].Value), &updated))
+	assert.Equal(t, "1", updated.ID)
+	assert.Equal(t, int64(100), updated.CreateTime)
+	assert.Equal(t, "new", updated.Field2)
+	created := TestStruct{}
+	assert.Nil(t, json.Unmarshal([]byte(kvs[1].Value), &created))
+	assert.NotNil(t, created.ID)
+	assert.NotEqual(t, int64(0), created.CreateTime)
+
+	err = s.BatchPut(context.TODO(), []any{&TestStruct{Field1: ""}})
+	assert.Equal(t, fmt.Errorf("key is required"), err)
+	mStorage.AssertNumberOfCalls(t, "BatchPut", 1)
+
+	assert.Nil(t, s.BatchPut(WithDryRun(context.TODO()), []any{&TestStruct{Field1: "test3"}}))
+	mStorage.AssertNumberOfCalls(t, "BatchPut", 1)
+}
+
+func TestGenericStore_DryRun(t *testing.T) {
+	s := &GenericStore{
+		opt: GenericStoreOption{
+			BasePath: "test/path",
+			KeyFunc: func(obj any) string {
+				return obj.(*TestStruct).Field1
+			},
+		},
+	}
+	s.cache.Store("test1", &TestStruct{Field1: "test1"})
+
+	mStorage := &storage.MockInterface{}
+	s.Stg = mStorage
+	mValidator := &MockValidator{}
+	mValidator.On("Validate", mock.Anything).Return(nil)
+	s.opt.Validator = mValidator
+	ctx := WithDryRun(context.TODO())
+
+	ret, err := s.Create(ctx, &TestStruct{Field1: "test2"})
+	assert.Nil(t, err)
+	assert.NotNil(t, ret.(*TestStruct).ID)
+	assert.NotEqual(t, int64(0), ret.(*TestStruct).CreateTime)
+
+	_, err = s.Create(ctx, &TestStruct{Field1: "test1"})
+	assert.Equal(t, fmt.Errorf("key: test1 is conflicted"), err)
+
+	ret, err = s.Update(ctx, &TestStruct{Field1: "test1", Field2: "new"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "new", ret.(*TestStruct).Field2)
+
+	_, err = s.Update(ctx, &TestStruct{Field1: "test3"}, false)
+	assert.Equal(t, fmt.Errorf("key: test3 is not found"), err)
+
+	assert.Nil(t, s.BatchDelete(ctx, []string{"test1"}))
+	assert.Equal(t, fmt.Errorf("key: test/path/test3 is not found"), s.BatchDelete(ctx, []string{"test1", "test3"}))
+
+	// the cache is untouched and the storage is never called
+	_, ok := s.cache.Load("test2")
+	assert.False(t, ok)
+	obj, _ := s.cache.Load("test1")
+	assert.Equal(t, "", obj.(*TestStruct).Field2)
+	mStorage.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	mStorage.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+	mStorage.AssertNotCalled(t, "BatchDelete", mock.Anything, mock.Anything)
+}
+
 func TestGenericStore_StringToObjPtr(t *testing.T) {
 	s, err := NewGenericStore(GenericStoreOption{
 		BasePath: "test",
@@ -22,6 +22,7 @@ import (
 
 	"github.com/apisix/manager-api/internal/conf"
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/storage"
 	"github.com/apisix/manager-api/internal/log"
 	"github.com/apisix/manager-api/internal/utils"
 )
@@ -46,11 +47,38 @@ const (
 	HubKeyRole         HubKey = "roles"
 )
 
+// Hub holds the stores of one cluster.
+type Hub struct {
+	stores map[HubKey]*GenericStore
+}
+
 var (
-	storeHub = map[HubKey]*GenericStore{}
+	// storeHub holds the stores of the cluster of conf.ETCDConfig
+	storeHub = &Hub{stores: map[HubKey]*GenericStore{}}
 )
 
+// DefaultHub returns the hub of the cluster of conf.ETCDConfig.
+func DefaultHub() *Hub {
+	return storeHub
+}
+
 func InitStore(key HubKey, opt GenericStoreOption) error {
+	s, err := newStore(key, opt)
+	if err != nil {
+		return err
+	}
+	if err := s.Init(); err != nil {
+		log.Errorf("GenericStore init error: %s", err)
+		return err
+	}
+
+	utils.AppendToClosers(s.Close)
+	storeHub.stores[key] = s
+	return nil
+}
+
+// newStore creates a store with the validator of its type
+func newStore(key HubKey, opt GenericStoreOption) (*GenericStore, error) {
 	hubsNeedCheck := map[HubKey]bool{
 		HubKeyConsumer:     true,
 		HubKeyRoute:        true,
@@ -68,7 +96,7 @@ func InitStore(key HubKey, opt GenericStoreOption) error {
 	if _, ok := hubsNeedCheck[key]; ok {
 		validator, err := NewAPISIXJsonSchemaValidator("main." + string(key))
 		if err != nil {
-			return err
+			return nil, err
 		}
 		opt.Validator = validator
 	}
@@ -76,27 +104,30 @@ func InitStore(key HubKey, opt GenericStoreOption) error {
 	s, err := NewGenericStore(opt)
 	if err != nil {
 		log.Errorf("NewGenericStore error: %s", err)
-		return err
+		return nil, err
 	}
-	if err := s.Init(); err != nil {
-		log.Errorf("GenericStore init error: %s", err)
-		return err
-	}
-
-	utils.AppendToClosers(s.Close)
-	storeHub[key] = s
-	return nil
+	return s, nil
 }
 
 func GetStore(key HubKey) *GenericStore {
-	if s, ok := storeHub[key]; ok {
+	return storeHub.GetStore(key)
+}
+
+func RangeStore(f func(key HubKey, store *GenericStore) bool) {
+	storeHub.Range(f)
+}
+
+// GetStore returns the store of the hub with the key.
+func (h *Hub) GetStore(key HubKey) *GenericStore {
+	if s, ok := h.stores[key]; ok {
 		return s
 	}
 	panic(fmt.Sprintf("no store with key: %s", key))
 }
 
-func RangeStore(f func(key HubKey, store *GenericStore) bool) {
-	for k, s := range storeHub {
+// Range calls f for each store of the hub until it returns false.
+func (h *Hub) Range(f func(key HubKey, store *GenericStore) bool) {
+	for k, s := range h.stores {
 		if k != "" && s != nil {
 			if !f(k, s) {
 				break
@@ -105,186 +136,181 @@ func RangeStore(f func(key HubKey, store *GenericStore) bool) {
 	}
 }
 
-func InitStores() error {
-	err := InitStore(HubKeyConsumer, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/consumers",
-		ObjType:  reflect.TypeOf(entity.Consumer{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.Consumer)
-			return r.Username
-		},
-	})
-	if err != nil {
-		return err
+// Close stops the watches of the stores of the hub.
+func (h *Hub) Close() error {
+	for _, s := range h.stores {
+		_ = s.Close()
 	}
+	return nil
+}
 
-	err = InitStore(HubKeyRoute, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/routes",
-		ObjType:  reflect.TypeOf(entity.Route{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.Route)
-			return utils.InterfaceToString(r.ID)
+// hubOptions returns the options of all stores of a cluster with the prefix
+func hubOptions(prefix string) []GenericStoreOption {
+	return []GenericStoreOption{
+		{
+			HubKey:   HubKeyConsumer,
+			BasePath: prefix + "/consumers",
+			ObjType:  reflect.TypeOf(entity.Consumer{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.Consumer)
+				return r.Username
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyService, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/services",
-		ObjType:  reflect.TypeOf(entity.Service{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.Service)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeyRoute,
+			BasePath: prefix + "/routes",
+			ObjType:  reflect.TypeOf(entity.Route{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.Route)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeySsl, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/ssls",
-		ObjType:  reflect.TypeOf(entity.SSL{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.SSL)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeyService,
+			BasePath: prefix + "/services",
+			ObjType:  reflect.TypeOf(entity.Service{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.Service)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyUpstream, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/upstreams",
-		ObjType:  reflect.TypeOf(entity.Upstream{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.Upstream)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeySsl,
+			BasePath: prefix + "/ssls",
+			ObjType:  reflect.TypeOf(entity.SSL{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.SSL)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyScript, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/scripts",
-		ObjType:  reflect.TypeOf(entity.Script{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.Script)
-			return r.ID
+		{
+			HubKey:   HubKeyUpstream,
+			BasePath: prefix + "/upstreams",
+			ObjType:  reflect.TypeOf(entity.Upstream{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.Upstream)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyGlobalRule, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/global_rules",
-		ObjType:  reflect.TypeOf(entity.GlobalPlugins{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.GlobalPlugins)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeyScript,
+			BasePath: prefix + "/scripts",
+			ObjType:  reflect.TypeOf(entity.Script{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.Script)
+				return r.ID
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyServerInfo, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/data_plane/server_info",
-		ObjType:  reflect.TypeOf(entity.ServerInfo{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.ServerInfo)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeyGlobalRule,
+			BasePath: prefix + "/global_rules",
+			ObjType:  reflect.TypeOf(entity.GlobalPlugins{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.GlobalPlugins)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyPluginConfig, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/plugin_configs",
-		ObjType:  reflect.TypeOf(entity.PluginConfig{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.PluginConfig)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeyServerInfo,
+			BasePath: prefix + "/data_plane/server_info",
+			ObjType:  reflect.TypeOf(entity.ServerInfo{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.ServerInfo)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyProto, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/protos",
-		ObjType:  reflect.TypeOf(entity.Proto{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.Proto)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeyPluginConfig,
+			BasePath: prefix + "/plugin_configs",
+			ObjType:  reflect.TypeOf(entity.PluginConfig{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.PluginConfig)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyStreamRoute, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/stream_routes",
-		ObjType:  reflect.TypeOf(entity.StreamRoute{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.StreamRoute)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeyProto,
+			BasePath: prefix + "/protos",
+			ObjType:  reflect.TypeOf(entity.Proto{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.Proto)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeySystemConfig, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/system_config",
-		ObjType:  reflect.TypeOf(entity.SystemConfig{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.SystemConfig)
-			return r.ConfigName
+		{
+			HubKey:   HubKeyStreamRoute,
+			BasePath: prefix + "/stream_routes",
+			ObjType:  reflect.TypeOf(entity.StreamRoute{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.StreamRoute)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyUser, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/users",
-		ObjType:  reflect.TypeOf(entity.User{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.User)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeySystemConfig,
+			BasePath: prefix + "/system_config",
+			ObjType:  reflect.TypeOf(entity.SystemConfig{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.SystemConfig)
+				return r.ConfigName
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyTeam, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/teams",
-		ObjType:  reflect.TypeOf(entity.Team{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.Team)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeyUser,
+			BasePath: prefix + "/users",
+			ObjType:  reflect.TypeOf(entity.User{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.User)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
-	}
-
-	err = InitStore(HubKeyRole, GenericStoreOption{
-		BasePath: conf.ETCDConfig.Prefix + "/roles",
-		ObjType:  reflect.TypeOf(entity.Role{}),
-		KeyFunc: func(obj any) string {
-			r := obj.(*entity.Role)
-			return utils.InterfaceToString(r.ID)
+		{
+			HubKey:   HubKeyTeam,
+			BasePath: prefix + "/teams",
+			ObjType:  reflect.TypeOf(entity.Team{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.Team)
+				return utils.InterfaceToString(r.ID)
+			},
+		},
+		{
+			HubKey:   HubKeyRole,
+			BasePath: prefix + "/roles",
+			ObjType:  reflect.TypeOf(entity.Role{}),
+			KeyFunc: func(obj any) string {
+				r := obj.(*entity.Role)
+				return utils.InterfaceToString(r.ID)
+			},
 		},
-	})
-	if err != nil {
-		return err
 	}
+}
 
+func InitStores() error {
+	for _, opt := range hubOptions(conf.ETCDConfig.Prefix) {
+		if err := InitStore(opt.HubKey, opt); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// NewHub creates the stores of another cluster with the storage and the
+// prefix, they are filled from the storage and watch it until the hub is
+// closed.
+func NewHub(stg storage.Interface, prefix string) (*Hub, error) {
+	hub := &Hub{stores: make(map[HubKey]*GenericStore)}
+	for _, opt := range hubOptions(prefix) {
+		s, err := newStore(opt.HubKey, opt)
+		if err != nil {
+			_ = hub.Close()
+			return nil, err
+		}
+		s.Stg = stg
+		if err := s.Init(); err != nil {
+			_ = hub.Close()
+			return nil, err
+		}
+		hub.stores[opt.HubKey] = s
+	}
+	return hub, nil
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/apisix/manager-api/internal/conf"
 	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils"
 )
 
 func Authentication() gin.HandlerFunc {
@@ -46,7 +47,7 @@ func Authentication() gin.HandlerFunc {
 			tokenStr := c.GetHeader("Authorization")
 			// verify token
 			token, err := jwt.ParseWithClaims(tokenStr, &jwt.StandardClaims{}, func(token *jwt.Token) (any, error) {
-				return []byte(conf.AuthConf.Secret), nil
+				return []byte(conf.GetAuthConf().Secret), nil
 			})
 
 			if err != nil || token == nil || !token.Valid {
@@ -74,16 +75,18 @@ func Authentication() gin.HandlerFunc {
 				return
 			}
 
-			if _, ok := conf.UserList[claims.Subject]; !ok {
+			if _, ok := conf.GetUser(claims.Subject); !ok {
 				log.Warnf("user not exists by token claims subject %s", claims.Subject)
 				c.AbortWithStatusJSON(http.StatusUnauthorized, errResp)
 				return
 			}
+			c.Request = c.Request.WithContext(utils.WithUsername(c.Request.Context(), claims.Subject))
 		} else {
 			if cookie.Values["oidc_id"] != conf.OidcId {
 				c.AbortWithStatusJSON(http.StatusUnauthorized, errResp)
 				return
 			}
+			c.Request = c.Request.WithContext(utils.WithUsername(c.Request.Context(), conf.OidcId))
 		}
 
 		c.Next()
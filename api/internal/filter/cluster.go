@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package filter
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/apisix/manager-api/internal/core/cluster"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils/consts"
+)
+
+// ClusterHeader selects the cluster a request of the admin API is served by,
+// requests without it are served by the default cluster.
+const ClusterHeader = "X-APISIX-Cluster"
+
+const clusterPathPrefix = "/apisix/admin/clusters/"
+
+// ClusterPath serves /apisix/admin/clusters/{name}/<path> as /apisix/admin/<path>
+// on the cluster {name}, so that every admin API is available for every
+// cluster without repeating the routes.
+func ClusterPath(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name, rest, ok := splitClusterPath(r.URL.Path); ok {
+			r.URL.Path = "/apisix/admin/" + rest
+			if r.URL.RawPath != "" {
+				if _, rawRest, ok := splitClusterPath(r.URL.RawPath); ok {
+					r.URL.RawPath = "/apisix/admin/" + rawRest
+				} else {
+					r.URL.RawPath = ""
+				}
+			}
+			r.Header.Set(ClusterHeader, name)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// splitClusterPath returns the cluster name and the rest of a cluster path,
+// false when the path has no rest.
+func splitClusterPath(path string) (string, string, bool) {
+	if !strings.HasPrefix(path, clusterPathPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(path, clusterPathPrefix), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Cluster makes the stores of the cluster selected by the request available
+// to the handlers through the request context.
+func Cluster() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.GetHeader(ClusterHeader)
+		if name == "" || name == cluster.DefaultName {
+			c.Next()
+			return
+		}
+
+		cl, err := cluster.Get(name)
+		if err == cluster.ErrNotFound {
+			c.AbortWithStatusJSON(http.StatusNotFound, consts.NotFound("cluster "+name+" is not configured"))
+			return
+		}
+		if err != nil {
+			log.Warnf("request to cluster %s rejected: %s", name, err)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, consts.SystemError(err.Error()))
+			return
+		}
+
+		c.Request = c.Request.WithContext(store.WithHub(c.Request.Context(), cl.Hub))
+		c.Next()
+	}
+}
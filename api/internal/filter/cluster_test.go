@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package filter
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/cluster"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func TestClusterPath(t *testing.T) {
+	tests := []struct {
+		path        string
+		wantPath    string
+		wantCluster string
+	}{
+		{"/apisix/admin/clusters/prod/routes/r1", "/apisix/admin/routes/r1", "prod"},
+		{"/apisix/admin/clusters/prod/routes", "/apisix/admin/routes", "prod"},
+		{"/apisix/admin/clusters/prod", "/apisix/admin/clusters/prod", ""},
+		{"/apisix/admin/clusters/prod/", "/apisix/admin/clusters/prod/", ""},
+		{"/apisix/admin/clusters", "/apisix/admin/clusters", ""},
+		{"/apisix/admin/routes", "/apisix/admin/routes", ""},
+	}
+	for _, tc := range tests {
+		var gotPath, gotCluster string
+		h := ClusterPath(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			gotCluster = r.Header.Get(ClusterHeader)
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+		assert.Equal(t, tc.wantPath, gotPath, tc.path)
+		assert.Equal(t, tc.wantCluster, gotCluster, tc.path)
+	}
+}
+
+func TestCluster(t *testing.T) {
+	hub := &store.Hub{}
+	cluster.Register(&cluster.Cluster{Name: "prod", Etcd: &conf.Etcd{}, Hub: hub})
+	cluster.Register(&cluster.Cluster{Name: "down", Etcd: &conf.Etcd{}, Err: errors.New("connection refused")})
+
+	r := gin.New()
+	r.Use(Cluster())
+	r.GET("/apisix/admin/routes", func(c *gin.Context) {
+		if store.HubOf(c.Request.Context()) == hub {
+			c.String(http.StatusOK, "prod")
+			return
+		}
+		c.String(http.StatusOK, "default")
+	})
+
+	tests := []struct {
+		cluster  string
+		wantCode int
+		wantBody string
+	}{
+		{"", http.StatusOK, "default"},
+		{cluster.DefaultName, http.StatusOK, "default"},
+		{"prod", http.StatusOK, "prod"},
+		{"unknown", http.StatusNotFound, `{"code":10002,"message":"cluster unknown is not configured"}`},
+		{"down", http.StatusServiceUnavailable, `{"code":10001,"message":"cluster down is unavailable: connection refused"}`},
+	}
+	for _, tc := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/apisix/admin/routes", nil)
+		req.Header.Set(ClusterHeader, tc.cluster)
+		r.ServeHTTP(w, req)
+		assert.Equal(t, tc.wantCode, w.Code, tc.cluster)
+		assert.Equal(t, tc.wantBody, w.Body.String(), tc.cluster)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 
@@ -79,15 +80,36 @@ func checkIP(ipStr string, ips map[string]bool, subnets []*subnet) bool {
 	return false
 }
 
+// allowSet is the allow list of the IP filter with its IP sets
+type allowSet struct {
+	list    []string
+	ips     map[string]bool
+	subnets []*subnet
+}
+
+var allowed atomic.Value
+
+func newAllowSet(list []string) *allowSet {
+	ips, subnets := generateIPSet(list)
+	return &allowSet{list: list, ips: ips, subnets: subnets}
+}
+
+// ReloadAllowList rebuilds the IP sets of the IP filter from the allow list
+// of the reloaded configuration.
+func ReloadAllowList() {
+	allowed.Store(newAllowSet(conf.GetAllowList()))
+}
+
 func IPFilter() gin.HandlerFunc {
-	ips, subnets := generateIPSet(conf.AllowList)
+	ReloadAllowList()
 	return func(c *gin.Context) {
+		set := allowed.Load().(*allowSet)
 		var ipStr string
 		if ip, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr)); err == nil {
 			ipStr = ip
 		}
 
-		if len(conf.AllowList) < 1 {
+		if len(set.list) < 1 {
 			c.Next()
 			return
 		}
@@ -98,9 +120,9 @@ func IPFilter() gin.HandlerFunc {
 			return
 		}
 
-		res := checkIP(ipStr, ips, subnets)
+		res := checkIP(ipStr, set.ips, set.subnets)
 		if !res {
-			log.Warnf("forbidden by IP: %s, allowed list: %v", ipStr, conf.AllowList)
+			log.Warnf("forbidden by IP: %s, allowed list: %v", ipStr, set.list)
 			c.AbortWithStatusJSON(http.StatusForbidden, consts.ErrIPNotAllow)
 		}
 
@@ -39,7 +39,8 @@ func (token *Token) Token() (*oauth2.Token, error) {
 func Oidc() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if c.Request.URL.Path == "/apisix/admin/oidc/login" {
-			url := conf.OidcConfig.AuthCodeURL(conf.State)
+			oidcConf := conf.GetOidc()
+			url := oidcConf.Config.AuthCodeURL(conf.State)
 			c.Redirect(302, url)
 			c.Abort()
 			return
@@ -54,7 +55,8 @@ func Oidc() gin.HandlerFunc {
 			}
 
 			// in exchange for token
-			oauth2Token, err := conf.OidcConfig.Exchange(c, c.Query("code"))
+			oidcConf := conf.GetOidc()
+			oauth2Token, err := oidcConf.Config.Exchange(c, c.Query("code"))
 			if err != nil {
 				log.Warnf("exchange code for token failed: %s", err)
 				c.AbortWithStatus(http.StatusForbidden)
@@ -63,7 +65,7 @@ func Oidc() gin.HandlerFunc {
 
 			// in exchange for user's information
 			token := &Token{oauth2Token.AccessToken}
-			providerConfig := oidc.ProviderConfig{UserInfoURL: conf.OidcUserInfoURL}
+			providerConfig := oidc.ProviderConfig{UserInfoURL: oidcConf.UserInfoURL}
 			provider := providerConfig.NewProvider(c)
 			userInfo, err := provider.UserInfo(c, token)
 			if err != nil {
@@ -73,7 +75,7 @@ func Oidc() gin.HandlerFunc {
 			}
 
 			// set the cookie
-			conf.CookieStore.MaxAge(conf.OidcExpireTime)
+			conf.CookieStore.MaxAge(oidcConf.ExpireTime)
 			cookie, _ := conf.CookieStore.Get(c.Request, "oidc")
 			cookie.Values["oidc_id"] = userInfo.Subject
 			conf.OidcId = userInfo.Subject
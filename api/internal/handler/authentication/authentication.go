@@ -92,20 +92,21 @@ func (h *Handler) userLogin(c droplet.Context) (any, error) {
 	password := input.Password
 
 	if !c.Get("isLdap").(bool) {
-		user := conf.UserList[username]
+		user, _ := conf.GetUser(username)
 		if username != user.Username || password != user.Password {
 			return nil, consts.ErrUsernamePassword
 		}
 	}
 
 	// create JWT for session
+	authConf := conf.GetAuthConf()
 	claims := jwt.StandardClaims{
 		Subject:   username,
 		IssuedAt:  time.Now().Unix(),
-		ExpiresAt: time.Now().Add(time.Second * time.Duration(conf.AuthConf.ExpireTime)).Unix(),
+		ExpiresAt: time.Now().Add(time.Second * time.Duration(authConf.ExpireTime)).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, _ := token.SignedString([]byte(conf.AuthConf.Secret))
+	signedToken, _ := token.SignedString([]byte(authConf.Secret))
 
 	// output token
 	return &UserSession{
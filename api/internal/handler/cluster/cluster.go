@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shiningrush/droplet"
+	wgin "github.com/shiningrush/droplet/wrapper/gin"
+
+	"github.com/apisix/manager-api/internal/core/cluster"
+	"github.com/apisix/manager-api/internal/handler"
+)
+
+type Handler struct {
+	list func() []*cluster.Cluster
+}
+
+func NewHandler() (handler.RouteRegister, error) {
+	return &Handler{list: cluster.List}, nil
+}
+
+func (h *Handler) ApplyRoute(r *gin.Engine) {
+	r.GET("/apisix/admin/clusters", wgin.Wraps(h.List))
+}
+
+const (
+	StatusAvailable   = "available"
+	StatusUnavailable = "unavailable"
+)
+
+type Cluster struct {
+	Name      string   `json:"name"`
+	Endpoints []string `json:"endpoints"`
+	Prefix    string   `json:"prefix"`
+	Default   bool     `json:"default"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// List returns the clusters managed by manager-api without their
+// credentials, the default cluster first.
+func (h *Handler) List(_ droplet.Context) (any, error) {
+	clusters := h.list()
+	ret := make([]Cluster, 0, len(clusters))
+	for _, c := range clusters {
+		item := Cluster{
+			Name:      c.Name,
+			Endpoints: c.Etcd.Endpoints,
+			Prefix:    c.Etcd.Prefix,
+			Default:   c.Name == cluster.DefaultName,
+			Status:    StatusAvailable,
+		}
+		if c.Err != nil {
+			item.Status = StatusUnavailable
+			item.Error = c.Err.Error()
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
+}
@@ -37,7 +37,7 @@ type Handler struct {
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		consumerStore: store.GetStore(store.HubKeyConsumer),
+		consumerStore: store.NewContextStore(store.HubKeyConsumer),
 	}, nil
 }
 
@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package data_loader
+
+import (
+	"context"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/declarative"
+)
+
+var declarativeContentTypes = map[string]string{
+	declarative.FormatYAML: "application/x-yaml",
+	declarative.FormatJSON: "application/json",
+}
+
+type ExportDeclarativeInput struct {
+	Format string `auto_read:"format,query"`
+}
+
+// ExportDeclarative exports all objects as the apisix.yaml file of APISIX
+// standalone mode.
+func (h *Handler) ExportDeclarative(c droplet.Context) (any, error) {
+	input := c.Input().(*ExportDeclarativeInput)
+	if input.Format == "" {
+		input.Format = declarative.FormatYAML
+	}
+	contentType, ok := declarativeContentTypes[input.Format]
+	if !ok {
+		return nil, errors.Errorf("invalid format: %s, format should be yaml or json", input.Format)
+	}
+
+	dataSets, err := h.listAll(c.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	l := &declarative.Loader{Format: input.Format}
+	content, err := l.Export(*dataSets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.FileResponse{
+		Name:        "apisix." + input.Format,
+		ContentType: contentType,
+		Content:     content.([]byte),
+	}, nil
+}
+
+// listAll loads the objects of every store
+func (h *Handler) listAll(ctx context.Context) (*loader.DataSets, error) {
+	dataSets := &loader.DataSets{}
+	lists := []struct {
+		store  store.Interface
+		append func(obj any)
+	}{
+		{h.routeStore, func(obj any) { dataSets.Routes = append(dataSets.Routes, *obj.(*entity.Route)) }},
+		{h.serviceStore, func(obj any) { dataSets.Services = append(dataSets.Services, *obj.(*entity.Service)) }},
+		{h.upstreamStore, func(obj any) { dataSets.Upstreams = append(dataSets.Upstreams, *obj.(*entity.Upstream)) }},
+		{h.consumerStore, func(obj any) { dataSets.Consumers = append(dataSets.Consumers, *obj.(*entity.Consumer)) }},
+		{h.sslStore, func(obj any) { dataSets.SSLs = append(dataSets.SSLs, *obj.(*entity.SSL)) }},
+		{h.globalPluginStore, func(obj any) {
+			dataSets.GlobalPlugins = append(dataSets.GlobalPlugins, *obj.(*entity.GlobalPlugins))
+		}},
+		{h.pluginConfigStore, func(obj any) {
+			dataSets.PluginConfigs = append(dataSets.PluginConfigs, *obj.(*entity.PluginConfig))
+		}},
+		{h.protoStore, func(obj any) { dataSets.Protos = append(dataSets.Protos, *obj.(*entity.Proto)) }},
+		{h.streamRouteStore, func(obj any) {
+			dataSets.StreamRoutes = append(dataSets.StreamRoutes, *obj.(*entity.StreamRoute))
+		}},
+	}
+
+	for _, l := range lists {
+		ret, err := l.store.List(ctx, store.ListInput{})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range ret.Rows {
+			l.append(row)
+		}
+	}
+	return dataSets, nil
+}
+
+type ImportDeclarativeInput struct {
+	FileName    string `auto_read:"_file"`
+	FileContent []byte `auto_read:"file"`
+}
+
+// ImportDeclarative imports the apisix.yaml file of APISIX standalone mode,
+// objects with an ID replace the stored objects with the same ID.
+func (h *ImportHandler) ImportDeclarative(c droplet.Context) (any, error) {
+	input := c.Input().(*ImportDeclarativeInput)
+
+	suffix := path.Ext(input.FileName)
+	if suffix != ".json" && suffix != ".yaml" && suffix != ".yml" {
+		return nil, errors.Errorf("required file type is .yaml, .yml or .json but got: %s", suffix)
+	}
+	if err := checkFileContent(input.FileContent); err != nil {
+		return nil, err
+	}
+
+	l := &declarative.Loader{}
+	dataSets, err := l.Import(input.FileContent)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := c.Context()
+	errs := h.writeEntities(dataSets, func(s store.Interface, obj any) error {
+		if info, ok := obj.(entity.GetBaseInfo); ok && info.GetBaseInfo().ID == nil {
+			_, err := s.Create(ctx, obj)
+			return err
+		}
+		_, err := s.Update(ctx, obj, true)
+		return err
+	})
+	return h.convertToImportResult(dataSets, errs), nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package data_loader
+
+import (
+	"testing"
+
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func TestImportDeclarative(t *testing.T) {
+	input := &ImportDeclarativeInput{
+		FileName: "apisix.yaml",
+		FileContent: []byte(`
+routes:
+  - id: 1
+    uri: /hello
+    upstream_id: 1
+  - uri: /world
+    upstream_id: 1
+upstreams:
+  - id: 1
+    type: roundrobin
+    nodes:
+      "127.0.0.1:1980": 1
+consumers:
+  - username: jack
+#END
+`),
+	}
+
+	var written []string
+	newStore := func(name string) *store.MockInterface {
+		s := &store.MockInterface{}
+		s.On("Update", mock.Anything, mock.Anything, true).Run(func(args mock.Arguments) {
+			written = append(written, "update "+name)
+		}).Return(nil, nil)
+		s.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			written = append(written, "create "+name)
+		}).Return(nil, nil)
+		return s
+	}
+	h := ImportHandler{
+		routeStore:    newStore("route"),
+		upstreamStore: newStore("upstream"),
+		consumerStore: newStore("consumer"),
+	}
+	ctx := droplet.NewContext()
+	ctx.SetInput(input)
+
+	ret, err := h.ImportDeclarative(ctx)
+	assert.NoError(t, err)
+	result := ret.(map[store.HubKey]ImportResult)
+	assert.Equal(t, 2, result[store.HubKeyRoute].Total)
+	assert.Equal(t, 0, result[store.HubKeyRoute].Failed)
+	assert.Equal(t, 1, result[store.HubKeyConsumer].Total)
+
+	// upstreams are written before the routes using them
+	assert.Equal(t, []string{"update upstream", "update route", "create route", "update consumer"}, written)
+}
+
+func TestImportDeclarative_invalid_file_type(t *testing.T) {
+	input := &ImportDeclarativeInput{FileName: "apisix.txt", FileContent: []byte("routes: []")}
+
+	h := ImportHandler{}
+	ctx := droplet.NewContext()
+	ctx.SetInput(input)
+
+	_, err := h.ImportDeclarative(ctx)
+	assert.EqualError(t, err, "required file type is .yaml, .yml or .json but got: .txt")
+}
+
+func TestExportDeclarative(t *testing.T) {
+	listStore := func(rows ...any) *store.MockInterface {
+		s := &store.MockInterface{}
+		s.On("List", mock.Anything).Return(&store.ListOutput{Rows: rows, TotalSize: len(rows)}, nil)
+		return s
+	}
+	h := Handler{
+		routeStore: listStore(
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "2", UpdateTime: 100}, URI: "/b", UpstreamID: "1"},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "1", UpdateTime: 100}, URI: "/a", UpstreamID: "1"},
+		),
+		serviceStore:      listStore(),
+		upstreamStore:     listStore(&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "1"}}),
+		consumerStore:     listStore(),
+		sslStore:          listStore(),
+		globalPluginStore: listStore(),
+		pluginConfigStore: listStore(),
+		protoStore:        listStore(),
+		streamRouteStore:  listStore(),
+	}
+
+	ctx := droplet.NewContext()
+	ctx.SetInput(&ExportDeclarativeInput{})
+	ret, err := h.ExportDeclarative(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &data.FileResponse{
+		Name:        "apisix.yaml",
+		ContentType: "application/x-yaml",
+		Content: []byte(`routes:
+- id: "1"
+  name: ""
+  status: 0
+  upstream_id: "1"
+  uri: /a
+- id: "2"
+  name: ""
+  status: 0
+  upstream_id: "1"
+  uri: /b
+upstreams:
+- id: "1"
+#END
+`),
+	}, ret)
+
+	ctx.SetInput(&ExportDeclarativeInput{Format: "xml"})
+	_, err = h.ExportDeclarative(ctx)
+	assert.EqualError(t, err, "invalid format: xml, format should be yaml or json")
+}
@@ -0,0 +1,51 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package data_loader
+
+import (
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/k8s"
+)
+
+type ExportK8sInput struct {
+	Namespace string `auto_read:"namespace,query"`
+}
+
+// ExportK8s exports the objects as the custom resources of the APISIX
+// ingress controller.
+func (h *Handler) ExportK8s(c droplet.Context) (any, error) {
+	input := c.Input().(*ExportK8sInput)
+
+	dataSets, err := h.listAll(c.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	l := &k8s.Loader{Namespace: input.Namespace}
+	content, err := l.Export(*dataSets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.FileResponse{
+		Name:        "apisix-crds.yaml",
+		ContentType: "application/x-yaml",
+		Content:     content.([]byte),
+	}, nil
+}
@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package data_loader
+
+import (
+	"testing"
+
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func TestExportK8s(t *testing.T) {
+	listStore := func(rows ...any) *store.MockInterface {
+		s := &store.MockInterface{}
+		s.On("List", mock.Anything).Return(&store.ListOutput{Rows: rows, TotalSize: len(rows)}, nil)
+		return s
+	}
+	h := Handler{
+		routeStore: listStore(
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "1"}, Name: "hello", URI: "/hello", UpstreamID: "1", Status: 1},
+		),
+		serviceStore: listStore(),
+		upstreamStore: listStore(&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "1"}, UpstreamDef: entity.UpstreamDef{
+			DiscoveryType: "kubernetes",
+			ServiceName:   "prod/hello:80",
+		}}),
+		consumerStore:     listStore(),
+		sslStore:          listStore(),
+		globalPluginStore: listStore(),
+		pluginConfigStore: listStore(),
+		protoStore:        listStore(),
+		streamRouteStore:  listStore(),
+	}
+
+	ctx := droplet.NewContext()
+	ctx.SetInput(&ExportK8sInput{Namespace: "prod"})
+	ret, err := h.ExportK8s(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &data.FileResponse{
+		Name:        "apisix-crds.yaml",
+		ContentType: "application/x-yaml",
+		Content: []byte(`apiVersion: apisix.apache.org/v2
+kind: ApisixRoute
+metadata:
+  name: hello
+  namespace: prod
+spec:
+  http:
+  - backends:
+    - serviceName: hello
+      servicePort: 80
+    match:
+      paths:
+      - /hello
+    name: hello
+`),
+	}, ret)
+}
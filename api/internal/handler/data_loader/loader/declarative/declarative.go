@@ -0,0 +1,258 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package declarative
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/utils"
+	"github.com/apisix/manager-api/internal/utils/consts"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+
+	// endMarker tells APISIX in standalone mode that the file is complete
+	endMarker = "#END\n"
+)
+
+// Loader reads and writes the apisix.yaml file of APISIX standalone mode,
+// which keeps the whole configuration in one declarative file.
+type Loader struct {
+	// Format is the format of exported files, yaml or json, YAML is used by default
+	Format string
+}
+
+// Document is the content of apisix.yaml
+type Document struct {
+	Routes        []entity.Route         `json:"routes,omitempty"`
+	Services      []entity.Service       `json:"services,omitempty"`
+	Upstreams     []entity.Upstream      `json:"upstreams,omitempty"`
+	Consumers     []entity.Consumer      `json:"consumers,omitempty"`
+	SSLs          []entity.SSL           `json:"ssls,omitempty"`
+	GlobalRules   []entity.GlobalPlugins `json:"global_rules,omitempty"`
+	PluginConfigs []entity.PluginConfig  `json:"plugin_configs,omitempty"`
+	Protos        []entity.Proto         `json:"protos,omitempty"`
+	StreamRoutes  []entity.StreamRoute   `json:"stream_routes,omitempty"`
+}
+
+func (o Loader) Import(input any) (*loader.DataSets, error) {
+	if input == nil {
+		panic("input is nil")
+	}
+
+	d, ok := input.([]byte)
+	if !ok {
+		panic(fmt.Sprintf("input format error: expected []byte but it is %s", reflect.TypeOf(input).Kind().String()))
+	}
+
+	// YAML is a superset of JSON, the end marker is a comment
+	d, err := yaml.YAMLToJSON(d)
+	if err != nil {
+		return nil, errors.Wrap(err, consts.ErrImportFile.Error())
+	}
+	doc := &Document{}
+	if err := json.Unmarshal(d, doc); err != nil {
+		return nil, errors.Wrap(err, consts.ErrImportFile.Error())
+	}
+
+	data := &loader.DataSets{
+		Routes:        doc.Routes,
+		Services:      doc.Services,
+		Upstreams:     doc.Upstreams,
+		Consumers:     doc.Consumers,
+		SSLs:          doc.SSLs,
+		GlobalPlugins: doc.GlobalRules,
+		PluginConfigs: doc.PluginConfigs,
+		Protos:        doc.Protos,
+		StreamRoutes:  doc.StreamRoutes,
+	}
+	normalizeIDs(data)
+	return data, nil
+}
+
+// Export returns the file content, objects are sorted by ID and the
+// timestamps are left out so that exporting unchanged objects gives the same
+// file.
+func (o Loader) Export(data loader.DataSets) (any, error) {
+	doc := NewDocument(data)
+
+	switch o.Format {
+	case FormatJSON:
+		d, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(d, '\n'), nil
+	case FormatYAML, "":
+		d, err := yaml.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		return append(d, endMarker...), nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", o.Format)
+	}
+}
+
+// NewDocument copies the data sets into a document in export order
+func NewDocument(data loader.DataSets) *Document {
+	doc := &Document{
+		Routes:        append([]entity.Route(nil), data.Routes...),
+		Services:      append([]entity.Service(nil), data.Services...),
+		Upstreams:     append([]entity.Upstream(nil), data.Upstreams...),
+		Consumers:     append([]entity.Consumer(nil), data.Consumers...),
+		SSLs:          append([]entity.SSL(nil), data.SSLs...),
+		GlobalRules:   append([]entity.GlobalPlugins(nil), data.GlobalPlugins...),
+		PluginConfigs: append([]entity.PluginConfig(nil), data.PluginConfigs...),
+		Protos:        append([]entity.Proto(nil), data.Protos...),
+		StreamRoutes:  append([]entity.StreamRoute(nil), data.StreamRoutes...),
+	}
+
+	for i := range doc.Routes {
+		clearTime(&doc.Routes[i].BaseInfo)
+	}
+	for i := range doc.Services {
+		clearTime(&doc.Services[i].BaseInfo)
+	}
+	for i := range doc.Upstreams {
+		clearTime(&doc.Upstreams[i].BaseInfo)
+	}
+	for i := range doc.Consumers {
+		doc.Consumers[i].CreateTime, doc.Consumers[i].UpdateTime = 0, 0
+	}
+	for i := range doc.SSLs {
+		clearTime(&doc.SSLs[i].BaseInfo)
+	}
+	for i := range doc.GlobalRules {
+		clearTime(&doc.GlobalRules[i].BaseInfo)
+	}
+	for i := range doc.PluginConfigs {
+		clearTime(&doc.PluginConfigs[i].BaseInfo)
+	}
+	for i := range doc.Protos {
+		clearTime(&doc.Protos[i].BaseInfo)
+	}
+	for i := range doc.StreamRoutes {
+		clearTime(&doc.StreamRoutes[i].BaseInfo)
+	}
+
+	sortByID(doc.Routes, func(i int) any { return doc.Routes[i].ID })
+	sortByID(doc.Services, func(i int) any { return doc.Services[i].ID })
+	sortByID(doc.Upstreams, func(i int) any { return doc.Upstreams[i].ID })
+	sortByID(doc.Consumers, func(i int) any { return doc.Consumers[i].Username })
+	sortByID(doc.SSLs, func(i int) any { return doc.SSLs[i].ID })
+	sortByID(doc.GlobalRules, func(i int) any { return doc.GlobalRules[i].ID })
+	sortByID(doc.PluginConfigs, func(i int) any { return doc.PluginConfigs[i].ID })
+	sortByID(doc.Protos, func(i int) any { return doc.Protos[i].ID })
+	sortByID(doc.StreamRoutes, func(i int) any { return doc.StreamRoutes[i].ID })
+	return doc
+}
+
+func clearTime(info *entity.BaseInfo) {
+	info.CreateTime = 0
+	info.UpdateTime = 0
+}
+
+// sortByID sorts the slice by the IDs returned by id, numeric IDs are sorted
+// by value and before the other IDs.
+func sortByID(slice any, id func(i int) any) {
+	sort.SliceStable(slice, func(i, j int) bool {
+		return lessID(utils.InterfaceToString(id(i)), utils.InterfaceToString(id(j)))
+	})
+}
+
+func lessID(a, b string) bool {
+	na, nb := isNumeric(a), isNumeric(b)
+	switch {
+	case na && nb:
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	case na != nb:
+		return na
+	default:
+		return a < b
+	}
+}
+
+func isNumeric(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeIDs turns the numeric IDs and references of the file into strings
+// which are used by the stored objects.
+func normalizeIDs(data *loader.DataSets) {
+	for i := range data.Routes {
+		r := &data.Routes[i]
+		r.ID = normalizeID(r.ID)
+		r.ServiceID = normalizeID(r.ServiceID)
+		r.UpstreamID = normalizeID(r.UpstreamID)
+		r.PluginConfigID = normalizeID(r.PluginConfigID)
+	}
+	for i := range data.Services {
+		s := &data.Services[i]
+		s.ID = normalizeID(s.ID)
+		s.UpstreamID = normalizeID(s.UpstreamID)
+	}
+	for i := range data.Upstreams {
+		data.Upstreams[i].ID = normalizeID(data.Upstreams[i].ID)
+	}
+	for i := range data.SSLs {
+		data.SSLs[i].ID = normalizeID(data.SSLs[i].ID)
+	}
+	for i := range data.GlobalPlugins {
+		data.GlobalPlugins[i].ID = normalizeID(data.GlobalPlugins[i].ID)
+	}
+	for i := range data.PluginConfigs {
+		data.PluginConfigs[i].ID = normalizeID(data.PluginConfigs[i].ID)
+	}
+	for i := range data.Protos {
+		data.Protos[i].ID = normalizeID(data.Protos[i].ID)
+	}
+	for i := range data.StreamRoutes {
+		r := &data.StreamRoutes[i]
+		r.ID = normalizeID(r.ID)
+		r.UpstreamID = normalizeID(r.UpstreamID)
+	}
+}
+
+func normalizeID(id any) any {
+	if n, ok := id.(float64); ok {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return id
+}
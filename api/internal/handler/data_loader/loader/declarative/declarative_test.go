@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package declarative
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+)
+
+var (
+	TestDeclarative = "../../../../../test/testdata/import/apisix.yaml"
+)
+
+func TestImportDeclarative(t *testing.T) {
+	fileContent, err := ioutil.ReadFile(TestDeclarative)
+	assert.NoError(t, err)
+
+	l := &Loader{}
+	data, err := l.Import(fileContent)
+	assert.NoError(t, err)
+
+	assert.Len(t, data.Routes, 2)
+	assert.Len(t, data.Services, 1)
+	assert.Len(t, data.Upstreams, 1)
+	assert.Len(t, data.Consumers, 1)
+	assert.Len(t, data.SSLs, 1)
+	assert.Len(t, data.GlobalPlugins, 1)
+	assert.Len(t, data.PluginConfigs, 1)
+	assert.Len(t, data.Protos, 1)
+	assert.Len(t, data.StreamRoutes, 1)
+
+	// numeric IDs and references are turned into strings
+	assert.Equal(t, "2", data.Routes[0].ID)
+	assert.Equal(t, "1", data.Routes[0].UpstreamID)
+	assert.Equal(t, "1", data.Routes[0].PluginConfigID)
+	assert.Equal(t, "/hello", data.Routes[0].URI)
+	assert.Equal(t, "svc", data.Routes[1].ServiceID)
+	assert.Equal(t, "1", data.Services[0].UpstreamID)
+	assert.Equal(t, "1", data.Upstreams[0].ID)
+	assert.Equal(t, map[string]any{"127.0.0.1:1980": float64(1)}, data.Upstreams[0].Nodes)
+	assert.Equal(t, "jack", data.Consumers[0].Username)
+	assert.Equal(t, []string{"test.com"}, data.SSLs[0].Snis)
+	assert.Equal(t, `syntax = "proto3";`, data.Protos[0].Content)
+	assert.Equal(t, "1", data.StreamRoutes[0].UpstreamID)
+	assert.Equal(t, 9100, data.StreamRoutes[0].ServerPort)
+}
+
+func TestImportDeclarativeInvalid(t *testing.T) {
+	l := &Loader{}
+	_, err := l.Import([]byte("routes: {"))
+	assert.Error(t, err)
+
+	_, err = l.Import([]byte("routes: test"))
+	assert.Error(t, err)
+}
+
+func TestExportDeclarative(t *testing.T) {
+	data := loader.DataSets{
+		Routes: []entity.Route{
+			{BaseInfo: entity.BaseInfo{ID: "r10", CreateTime: 1, UpdateTime: 2}, URI: "/b", UpstreamID: "u1"},
+			{BaseInfo: entity.BaseInfo{ID: "r2", CreateTime: 1, UpdateTime: 2}, URI: "/a", UpstreamID: "u1"},
+		},
+		Upstreams: []entity.Upstream{
+			{BaseInfo: entity.BaseInfo{ID: "u1"}, UpstreamDef: entity.UpstreamDef{
+				Type:  "roundrobin",
+				Nodes: []*entity.Node{{Host: "127.0.0.1", Port: 1980, Weight: 1}},
+			}},
+		},
+		Consumers: []entity.Consumer{
+			{Username: "tom", CreateTime: 1},
+			{Username: "jack", CreateTime: 1},
+		},
+	}
+
+	l := &Loader{}
+	ret, err := l.Export(data)
+	assert.NoError(t, err)
+	assert.Equal(t, `consumers:
+- username: jack
+- username: tom
+routes:
+- id: r10
+  name: ""
+  status: 0
+  upstream_id: u1
+  uri: /b
+- id: r2
+  name: ""
+  status: 0
+  upstream_id: u1
+  uri: /a
+upstreams:
+- id: u1
+  nodes:
+  - host: 127.0.0.1
+    port: 1980
+    weight: 1
+  type: roundrobin
+#END
+`, string(ret.([]byte)))
+
+	// the input is not changed
+	assert.Equal(t, "r10", data.Routes[0].ID)
+	assert.Equal(t, int64(1), data.Routes[0].CreateTime)
+
+	// exported file is imported again as the same objects
+	imported, err := l.Import(ret)
+	assert.NoError(t, err)
+	again, err := l.Export(*imported)
+	assert.NoError(t, err)
+	assert.Equal(t, ret, again)
+
+	l = &Loader{Format: FormatJSON}
+	ret, err = l.Export(data)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(ret.([]byte)), "{\n  \"routes\": [\n    {\n      \"id\": \"r10\""))
+
+	l = &Loader{Format: "xml"}
+	_, err = l.Export(data)
+	assert.EqualError(t, err, "unsupported format: xml")
+}
+
+func TestLessID(t *testing.T) {
+	ids := []any{"b", "10", "a", "2", "01", nil}
+	sortByID(ids, func(i int) any { return ids[i] })
+	assert.Equal(t, []any{"2", "10", nil, "01", "a", "b"}, ids)
+}
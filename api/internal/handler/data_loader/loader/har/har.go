@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/httpreq"
+	"github.com/apisix/manager-api/internal/utils/consts"
+)
+
+// Loader imports HTTP Archive (HAR) files, the requests to a host are
+// grouped into one service and repeated requests are imported once.
+type Loader struct {
+	// MergeMethod indicates whether to merge routes when multiple HTTP methods are on the same path
+	MergeMethod bool
+	// TaskName indicates the name of current import/export task
+	TaskName string
+}
+
+var httpSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+}
+
+type archive struct {
+	Log *struct {
+		Version string  `json:"version"`
+		Entries []entry `json:"entries"`
+	} `json:"log"`
+}
+
+type entry struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"request"`
+}
+
+func (o Loader) Import(input any) (*loader.DataSets, error) {
+	if input == nil {
+		panic("input is nil")
+	}
+
+	d, ok := input.([]byte)
+	if !ok {
+		panic(fmt.Sprintf("input format error: expected []byte but it is %s", reflect.TypeOf(input).Kind().String()))
+	}
+
+	a := &archive{}
+	if err := json.Unmarshal(d, a); err != nil {
+		return nil, err
+	}
+	if a.Log == nil {
+		return nil, errors.Wrap(errors.New("HAR file does not contain a log"), consts.ErrImportFile.Error())
+	}
+	if len(a.Log.Entries) == 0 {
+		return nil, errors.Wrap(errors.New("HAR file does not contain any entries"), consts.ErrImportFile.Error())
+	}
+
+	reqs := make([]httpreq.Request, 0, len(a.Log.Entries))
+	for _, e := range a.Log.Entries {
+		// browsers also record data URLs and other schemes
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || !httpSchemes[strings.ToLower(u.Scheme)] {
+			continue
+		}
+		reqs = append(reqs, httpreq.Request{
+			Method: e.Request.Method,
+			URL:    e.Request.URL,
+		})
+	}
+
+	if o.TaskName == "" {
+		o.TaskName = "har_" + time.Now().Format("20060102150405")
+	}
+
+	cv := httpreq.Converter{MergeMethod: o.MergeMethod, TaskName: o.TaskName}
+	return cv.Convert(reqs)
+}
+
+func (Loader) Export(data loader.DataSets) (any, error) {
+	return nil, errors.New("export to HAR is not supported")
+}
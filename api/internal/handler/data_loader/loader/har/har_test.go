@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package har
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+var (
+	TestHAR = "../../../../../test/testdata/import/har.har"
+)
+
+func TestParseHAR(t *testing.T) {
+	fileContent, err := ioutil.ReadFile(TestHAR)
+	assert.NoError(t, err)
+
+	l := &Loader{MergeMethod: true, TaskName: "test"}
+	data, err := l.Import(fileContent)
+	assert.NoError(t, err)
+
+	// repeated requests are merged and the data URL is left out
+	assert.Len(t, data.Routes, 2)
+	assert.Len(t, data.Services, 2)
+	assert.Len(t, data.Upstreams, 2)
+
+	assert.Equal(t, "test_api.example.com", data.Services[0].ID)
+	assert.Equal(t, "test_api.example.com_443", data.Services[0].UpstreamID)
+	assert.Equal(t, []*entity.Node{{Host: "api.example.com", Port: 443, Weight: 1}}, data.Upstreams[0].Nodes)
+	assert.Equal(t, []*entity.Node{{Host: "127.0.0.1", Port: 9080, Weight: 1}}, data.Upstreams[1].Nodes)
+
+	assert.Equal(t, "test_users", data.Routes[0].Name)
+	assert.Equal(t, []string{"/users"}, data.Routes[0].Uris)
+	assert.Equal(t, []string{"GET", "POST"}, data.Routes[0].Methods)
+	assert.Equal(t, "api.example.com", data.Routes[0].Host)
+	assert.Equal(t, "test_api.example.com", data.Routes[0].ServiceID)
+
+	assert.Equal(t, "test_health", data.Routes[1].Name)
+	assert.Equal(t, "127.0.0.1", data.Routes[1].Host)
+	assert.Equal(t, "test_127.0.0.1", data.Routes[1].ServiceID)
+}
+
+func TestParseHARInvalid(t *testing.T) {
+	l := &Loader{TaskName: "test"}
+
+	_, err := l.Import([]byte(`{"test": "a"}`))
+	assert.EqualError(t, err, "empty or invalid imported file: HAR file does not contain a log")
+
+	_, err = l.Import([]byte(`{"log": {"entries": [{"request": {"method": "GET", "url": "data:text/plain,a"}}]}}`))
+	assert.EqualError(t, err, "empty or invalid imported file: no request found")
+}
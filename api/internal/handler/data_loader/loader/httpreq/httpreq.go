@@ -0,0 +1,301 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package httpreq
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/utils/consts"
+)
+
+// Request is a request kept by an API client or captured by a browser.
+type Request struct {
+	// Group is the folder of the request, requests without folder are grouped by host
+	Group string
+	// Name describes the request
+	Name   string
+	Method string
+	// URL is the request URL, path variables are written as :var or {{var}}
+	URL string
+}
+
+// Converter creates routes from requests. Each group becomes a service and
+// the hosts of the requests become upstreams.
+type Converter struct {
+	// MergeMethod indicates whether to merge routes when multiple HTTP methods are on the same path
+	MergeMethod bool
+	// TaskName indicates the name of current import task
+	TaskName string
+}
+
+const maxIDLength = 64
+
+var (
+	regVar       = regexp.MustCompile(`{{[^{}]*}}`)
+	regInvalidID = regexp.MustCompile(`[^a-zA-Z0-9-_.]+`)
+
+	defaultPorts = map[string]int{
+		"http":  80,
+		"https": 443,
+	}
+)
+
+// target is the parsed URL of a request, the host is empty when unknown
+type target struct {
+	scheme string
+	host   string
+	port   int
+	path   string
+}
+
+// converter keeps the state of one conversion
+type converter struct {
+	Converter
+	data      *loader.DataSets
+	ids       map[string]string
+	upstreams map[string]string
+	services  map[string]int
+	routes    map[string]int
+}
+
+func (c Converter) Convert(reqs []Request) (*loader.DataSets, error) {
+	cv := &converter{
+		Converter: c,
+		data:      &loader.DataSets{},
+		ids:       make(map[string]string),
+		upstreams: make(map[string]string),
+		services:  make(map[string]int),
+		routes:    make(map[string]int),
+	}
+	for _, req := range reqs {
+		cv.add(req)
+	}
+
+	if len(cv.data.Routes) == 0 {
+		return nil, errors.Wrap(errors.New("no request found"), consts.ErrImportFile.Error())
+	}
+	return cv.data, nil
+}
+
+func (c *converter) add(req Request) {
+	t := parseURL(req.URL)
+	if t == nil {
+		return
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(req.Method))
+	if method == "" {
+		method = "GET"
+	}
+	uri := normalizePath(t.path)
+
+	var upstreamID any
+	if t.host != "" {
+		upstreamID = c.upstream(t)
+	}
+
+	group := req.Group
+	if group == "" {
+		group = t.host
+	}
+	if group == "" {
+		group = "default"
+	}
+	service := c.service(group, upstreamID)
+
+	// the same request is only imported once
+	key := strings.Join([]string{group, t.scheme, t.host, strconv.Itoa(t.port), uri}, " ")
+	if !c.MergeMethod {
+		key += " " + method
+	}
+	if i, ok := c.routes[key]; ok {
+		route := &c.data.Routes[i]
+		for _, m := range route.Methods {
+			if m == method {
+				return
+			}
+		}
+		route.Methods = append(route.Methods, method)
+		return
+	}
+
+	name := c.TaskName + "_" + strings.TrimPrefix(uri, "/")
+	if !c.MergeMethod {
+		name += "_" + method
+	}
+	route := entity.Route{
+		Name:      name,
+		Desc:      req.Name,
+		Uris:      []string{uri},
+		Methods:   []string{method},
+		Host:      t.host,
+		ServiceID: service.ID,
+	}
+	// requests to another host than the other requests of the group
+	if upstreamID != nil && service.UpstreamID != upstreamID {
+		route.UpstreamID = upstreamID
+	}
+
+	c.routes[key] = len(c.data.Routes)
+	c.data.Routes = append(c.data.Routes, route)
+}
+
+// upstream returns the ID of the upstream of the host, it is created for
+// the first request to the host.
+func (c *converter) upstream(t *target) string {
+	origin := t.scheme + "://" + net.JoinHostPort(t.host, strconv.Itoa(t.port))
+	if id, ok := c.upstreams[origin]; ok {
+		return id
+	}
+
+	id := c.id(c.TaskName+"_"+t.host+"_"+strconv.Itoa(t.port), "upstream "+origin)
+	upstream := entity.UpstreamDef{
+		Name:   id,
+		Type:   "roundrobin",
+		Scheme: t.scheme,
+		Nodes:  []*entity.Node{{Host: t.host, Port: t.port, Weight: 1}},
+	}
+	if net.ParseIP(t.host) == nil {
+		// backends behind a domain usually rely on the Host header
+		upstream.PassHost = "node"
+	}
+	c.data.Upstreams = append(c.data.Upstreams, entity.Upstream{
+		BaseInfo:    entity.BaseInfo{ID: id},
+		UpstreamDef: upstream,
+	})
+	c.upstreams[origin] = id
+	return id
+}
+
+// service returns the service of the group, it uses the upstream of the
+// first request with a host.
+func (c *converter) service(group string, upstreamID any) *entity.Service {
+	i, ok := c.services[group]
+	if !ok {
+		id := c.id(c.TaskName+"_"+group, "service "+group)
+		i = len(c.data.Services)
+		c.services[group] = i
+		c.data.Services = append(c.data.Services, entity.Service{
+			BaseInfo: entity.BaseInfo{ID: id},
+			Name:     c.TaskName + "_" + group,
+		})
+	}
+
+	service := &c.data.Services[i]
+	if service.UpstreamID == nil {
+		service.UpstreamID = upstreamID
+	}
+	return service
+}
+
+// id turns the name into a valid ID which is not used by another object
+func (c *converter) id(name, owner string) string {
+	base := regInvalidID.ReplaceAllString(name, "_")
+	if len(base) > maxIDLength {
+		base = base[:maxIDLength]
+	}
+	id := base
+	for n := 2; c.ids[id] != "" && c.ids[id] != owner; n++ {
+		suffix := "_" + strconv.Itoa(n)
+		if len(base)+len(suffix) > maxIDLength {
+			id = base[:maxIDLength-len(suffix)] + suffix
+		} else {
+			id = base + suffix
+		}
+	}
+	c.ids[id] = owner
+	return id
+}
+
+// parseURL splits the URL without validating the parts which may contain
+// variables. The host is left empty when it is a variable. URLs with another
+// scheme than HTTP or HTTPS are not usable.
+func parseURL(raw string) *target {
+	raw = strings.TrimSpace(raw)
+	if i := strings.IndexAny(raw, "?#"); i >= 0 {
+		raw = raw[:i]
+	}
+
+	t := &target{}
+	if i := strings.Index(raw, "://"); i >= 0 {
+		t.scheme = strings.ToLower(raw[:i])
+		raw = raw[i+3:]
+		if _, ok := defaultPorts[t.scheme]; !ok {
+			return nil
+		}
+	}
+
+	authority := ""
+	if t.scheme != "" || !strings.HasPrefix(raw, "/") {
+		authority = raw
+		raw = ""
+		if i := strings.Index(authority, "/"); i >= 0 {
+			authority, raw = authority[:i], authority[i:]
+		}
+	}
+	t.path = raw
+
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		authority = authority[i+1:]
+	}
+	if authority == "" || regVar.MatchString(authority) {
+		return t
+	}
+	u, err := url.Parse("//" + authority)
+	if err != nil || u.Hostname() == "" {
+		return t
+	}
+
+	if t.scheme == "" {
+		t.scheme = "http"
+	}
+	t.host = strings.ToLower(u.Hostname())
+	t.port = defaultPorts[t.scheme]
+	if p := u.Port(); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil || port <= 0 {
+			t.host, t.port = "", 0
+			return t
+		}
+		t.port = port
+	}
+	return t
+}
+
+// normalizePath replaces the path variables with wildcards
+func normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || regVar.MatchString(segment) {
+			segments[i] = "*"
+		}
+	}
+
+	uri := strings.Join(segments, "/")
+	if !strings.HasPrefix(uri, "/") {
+		uri = "/" + uri
+	}
+	return uri
+}
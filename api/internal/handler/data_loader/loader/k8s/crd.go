@@ -0,0 +1,335 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+const (
+	APIVersion       = "apisix.apache.org/v2"
+	DefaultNamespace = "default"
+
+	KindRoute        = "ApisixRoute"
+	KindUpstream     = "ApisixUpstream"
+	KindTLS          = "ApisixTls"
+	KindConsumer     = "ApisixConsumer"
+	KindPluginConfig = "ApisixPluginConfig"
+	KindSecret       = "Secret"
+
+	// backendType is the entity.K8sInfo backend type of Kubernetes services
+	backendType = "service"
+	// discoveryType is the APISIX service discovery of Kubernetes services,
+	// its service name is "namespace/name:port".
+	discoveryType = "kubernetes"
+)
+
+// Loader converts the objects to the custom resources of the APISIX ingress
+// controller and back, the resources are written as one multi-document YAML.
+type Loader struct {
+	// Namespace is the namespace of exported resources and the default
+	// namespace of imported ones, "default" is used without it.
+	Namespace string
+}
+
+type Metadata struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// Object is a Kubernetes resource, Secrets have data instead of a spec.
+type Object struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   Metadata          `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Spec       any               `json:"spec,omitempty"`
+	Data       map[string]string `json:"data,omitempty"`
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+type RouteSpec struct {
+	HTTP   []HTTPRoute       `json:"http"`
+	Stream []json.RawMessage `json:"stream,omitempty"`
+}
+
+type HTTPRoute struct {
+	Name             string          `json:"name"`
+	Priority         int             `json:"priority,omitempty"`
+	Match            Match           `json:"match"`
+	Websocket        bool            `json:"websocket,omitempty"`
+	PluginConfigName string          `json:"plugin_config_name,omitempty"`
+	Backends         []Backend       `json:"backends,omitempty"`
+	Upstreams        []UpstreamRef   `json:"upstreams,omitempty"`
+	Plugins          []Plugin        `json:"plugins,omitempty"`
+	Authentication   *Authentication `json:"authentication,omitempty"`
+	Timeout          *Timeout        `json:"timeout,omitempty"`
+}
+
+type Match struct {
+	Paths       []string `json:"paths"`
+	Methods     []string `json:"methods,omitempty"`
+	Hosts       []string `json:"hosts,omitempty"`
+	RemoteAddrs []string `json:"remoteAddrs,omitempty"`
+	Exprs       []Expr   `json:"exprs,omitempty"`
+}
+
+type Expr struct {
+	Subject Subject  `json:"subject"`
+	Op      string   `json:"op"`
+	Value   *string  `json:"value,omitempty"`
+	Set     []string `json:"set,omitempty"`
+}
+
+type Subject struct {
+	Scope string `json:"scope"`
+	Name  string `json:"name,omitempty"`
+}
+
+// Backend is a Kubernetes service, the port is a number or a port name.
+type Backend struct {
+	ServiceName string `json:"serviceName"`
+	ServicePort any    `json:"servicePort"`
+	Weight      *int   `json:"weight,omitempty"`
+}
+
+type UpstreamRef struct {
+	Name   string `json:"name"`
+	Weight *int   `json:"weight,omitempty"`
+}
+
+type Plugin struct {
+	Name   string         `json:"name"`
+	Enable bool           `json:"enable"`
+	Config map[string]any `json:"config,omitempty"`
+}
+
+type Authentication struct {
+	Enable  bool   `json:"enable"`
+	Type    string `json:"type"`
+	KeyAuth *struct {
+		Header string `json:"header,omitempty"`
+	} `json:"keyAuth,omitempty"`
+}
+
+type Timeout struct {
+	Connect string `json:"connect,omitempty"`
+	Send    string `json:"send,omitempty"`
+	Read    string `json:"read,omitempty"`
+}
+
+type UpstreamSpec struct {
+	ExternalNodes []ExternalNode `json:"externalNodes,omitempty"`
+	Discovery     *Discovery     `json:"discovery,omitempty"`
+	LoadBalancer  *LoadBalancer  `json:"loadbalancer,omitempty"`
+	Scheme        string         `json:"scheme,omitempty"`
+	Retries       *int           `json:"retries,omitempty"`
+	Timeout       *Timeout       `json:"timeout,omitempty"`
+	PassHost      string         `json:"passHost,omitempty"`
+	UpstreamHost  string         `json:"upstreamHost,omitempty"`
+}
+
+type ExternalNode struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Port   int    `json:"port,omitempty"`
+	Weight *int   `json:"weight,omitempty"`
+}
+
+type Discovery struct {
+	ServiceName string         `json:"serviceName"`
+	Type        string         `json:"type"`
+	Args        map[string]any `json:"args,omitempty"`
+}
+
+type LoadBalancer struct {
+	Type   string `json:"type"`
+	HashOn string `json:"hashOn,omitempty"`
+	Key    string `json:"key,omitempty"`
+}
+
+type TLSSpec struct {
+	Hosts  []string   `json:"hosts"`
+	Secret SecretRef  `json:"secret"`
+	Client *ClientTLS `json:"client,omitempty"`
+}
+
+type SecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type ClientTLS struct {
+	CASecret SecretRef `json:"caSecret"`
+	Depth    int       `json:"depth,omitempty"`
+}
+
+type ConsumerSpec struct {
+	AuthParameter map[string]AuthParameter `json:"authParameter"`
+}
+
+// AuthParameter is the configuration of an authentication plugin, the
+// ingress controller reads it from the value or from a secret.
+type AuthParameter struct {
+	Value     map[string]any `json:"value,omitempty"`
+	SecretRef *SecretRef     `json:"secretRef,omitempty"`
+}
+
+type PluginConfigSpec struct {
+	Plugins []Plugin `json:"plugins"`
+}
+
+// authParameters maps the authentication plugins to the authParameter
+// fields of ApisixConsumer.
+var authParameters = map[string]string{
+	"key-auth":   "keyAuth",
+	"basic-auth": "basicAuth",
+	"jwt-auth":   "jwtAuth",
+	"hmac-auth":  "hmacAuth",
+	"wolf-rbac":  "wolfRBAC",
+	"ldap-auth":  "ldapAuth",
+}
+
+// exprOps maps the operators of route vars to the operators of exprs, the
+// negated operators start with "!".
+var exprOps = map[string]string{
+	"==":   "Equal",
+	"~=":   "NotEqual",
+	">":    "GreaterThan",
+	"<":    "LessThan",
+	"in":   "In",
+	"! in": "NotIn",
+	"~~":   "RegexMatch",
+	"! ~~": "RegexNotMatch",
+	"~*":   "RegexMatchCaseInsensitive",
+	"! ~*": "RegexNotMatchCaseInsensitive",
+}
+
+// exprScopes maps the prefix of route vars to the scope of exprs
+var exprScopes = []struct {
+	prefix string
+	scope  string
+}{
+	{"http_", "Header"},
+	{"arg_", "Query"},
+	{"cookie_", "Cookie"},
+}
+
+var (
+	regInvalidName = regexp.MustCompile(`[^a-z0-9.-]+`)
+	regInvalidID   = regexp.MustCompile(`[^a-zA-Z0-9-_.]+`)
+)
+
+// k8sInfo returns the Kubernetes service of an upstream using the
+// Kubernetes service discovery, ports are referred to by number.
+func k8sInfo(def *entity.UpstreamDef) *entity.K8sInfo {
+	if def == nil || def.DiscoveryType != discoveryType {
+		return nil
+	}
+	ns, name, ok := strings.Cut(def.ServiceName, "/")
+	if !ok {
+		return nil
+	}
+	name, port, ok := strings.Cut(name, ":")
+	if !ok {
+		return nil
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n <= 0 {
+		return nil
+	}
+	return &entity.K8sInfo{
+		Namespace:   ns,
+		ServiceName: name,
+		Port:        n,
+		BackendType: backendType,
+	}
+}
+
+// serviceUpstream returns the upstream of a Kubernetes service
+func serviceUpstream(info *entity.K8sInfo) *entity.UpstreamDef {
+	return &entity.UpstreamDef{
+		Name:          info.ServiceName,
+		Type:          "roundrobin",
+		DiscoveryType: discoveryType,
+		ServiceName:   fmt.Sprintf("%s/%s:%d", info.Namespace, info.ServiceName, info.Port),
+	}
+}
+
+// k8sName turns a name into a valid resource name
+func k8sName(name string) string {
+	name = regInvalidName.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return strings.Trim(name, "-.")
+}
+
+// toID turns the resource names into a valid ID
+func toID(names ...string) string {
+	id := regInvalidID.ReplaceAllString(strings.Join(names, "_"), "_")
+	if len(id) > 64 {
+		id = id[:64]
+	}
+	return id
+}
+
+func formatTimeout(t *entity.Timeout) *Timeout {
+	if t == nil {
+		return nil
+	}
+	format := func(v entity.TimeoutValue) string {
+		if v == 0 {
+			return ""
+		}
+		return time.Duration(float64(v) * float64(time.Second)).String()
+	}
+	return &Timeout{
+		Connect: format(t.Connect),
+		Send:    format(t.Send),
+		Read:    format(t.Read),
+	}
+}
+
+func parseTimeout(t *Timeout) (*entity.Timeout, error) {
+	if t == nil {
+		return nil, nil
+	}
+	ret := &entity.Timeout{}
+	for _, v := range []struct {
+		s string
+		v *entity.TimeoutValue
+	}{{t.Connect, &ret.Connect}, {t.Send, &ret.Send}, {t.Read, &ret.Read}} {
+		if v.s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v.s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout %s", v.s)
+		}
+		*v.v = entity.TimeoutValue(d.Seconds())
+	}
+	return ret, nil
+}
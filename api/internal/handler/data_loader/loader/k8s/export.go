@@ -0,0 +1,474 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+// exporter keeps the state of one export, each object becomes at most one
+// resource of each kind.
+type exporter struct {
+	namespace string
+
+	upstreams     map[string]*entity.Upstream
+	services      map[string]*entity.Service
+	pluginConfigs map[string]string
+	// upstream resources by the upstream they are created for
+	upstreamRefs map[string]string
+	names        map[string]map[string]bool
+
+	configs  []Object
+	ups      []Object
+	routes   []Object
+	tls      []Object
+	users    []Object
+	warnings []string
+}
+
+// Export writes the routes, upstreams, consumers, plugin configs and SSLs as
+// custom resources. The services of routes are merged into the routes as
+// ApisixRoute has no services, settings which can't be expressed are
+// listed as comments at the top of the file.
+func (o Loader) Export(data loader.DataSets) (any, error) {
+	e := &exporter{
+		namespace:     o.Namespace,
+		upstreams:     make(map[string]*entity.Upstream),
+		services:      make(map[string]*entity.Service),
+		pluginConfigs: make(map[string]string),
+		upstreamRefs:  make(map[string]string),
+		names:         make(map[string]map[string]bool),
+	}
+	if e.namespace == "" {
+		e.namespace = DefaultNamespace
+	}
+	for i := range data.Upstreams {
+		e.upstreams[utils.InterfaceToString(data.Upstreams[i].ID)] = &data.Upstreams[i]
+	}
+	for i := range data.Services {
+		e.services[utils.InterfaceToString(data.Services[i].ID)] = &data.Services[i]
+	}
+
+	for _, pc := range data.PluginConfigs {
+		e.pluginConfig(pc)
+	}
+	for _, r := range data.Routes {
+		e.route(r)
+	}
+	for _, c := range data.Consumers {
+		e.consumer(c)
+	}
+	for _, ssl := range data.SSLs {
+		e.ssl(ssl)
+	}
+	if n := len(data.GlobalPlugins); n > 0 {
+		e.warnf("%d global rules are not exported", n)
+	}
+	if n := len(data.StreamRoutes); n > 0 {
+		e.warnf("%d stream routes are not exported", n)
+	}
+	if n := len(data.Protos); n > 0 {
+		e.warnf("%d protos are not exported", n)
+	}
+
+	buf := &bytes.Buffer{}
+	for _, w := range e.warnings {
+		fmt.Fprintf(buf, "# %s\n", w)
+	}
+	objs := append(append(append(append(e.configs, e.ups...), e.routes...), e.users...), e.tls...)
+	for i, obj := range objs {
+		if i > 0 || len(e.warnings) > 0 {
+			buf.WriteString("---\n")
+		}
+		b, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *exporter) warnf(format string, args ...any) {
+	e.warnings = append(e.warnings, fmt.Sprintf(format, args...))
+}
+
+// name returns an unused resource name of the kind
+func (e *exporter) name(kind string, names ...any) string {
+	base := ""
+	for _, n := range names {
+		if base = k8sName(utils.InterfaceToString(n)); base != "" {
+			break
+		}
+	}
+	if base == "" {
+		base = "unnamed"
+	}
+	if e.names[kind] == nil {
+		e.names[kind] = make(map[string]bool)
+	}
+	name := base
+	for i := 2; e.names[kind][name]; i++ {
+		name = base + "-" + strconv.Itoa(i)
+	}
+	e.names[kind][name] = true
+	return name
+}
+
+func (e *exporter) object(kind, name string, labels map[string]string, spec any) Object {
+	return Object{
+		APIVersion: APIVersion,
+		Kind:       kind,
+		Metadata:   Metadata{Name: name, Namespace: e.namespace, Labels: labels},
+		Spec:       spec,
+	}
+}
+
+func (e *exporter) pluginConfig(pc entity.PluginConfig) {
+	name := e.name(KindPluginConfig, pc.Desc, pc.ID)
+	e.pluginConfigs[utils.InterfaceToString(pc.ID)] = name
+	e.configs = append(e.configs, e.object(KindPluginConfig, name, pc.Labels, PluginConfigSpec{
+		Plugins: plugins(pc.Plugins),
+	}))
+}
+
+func (e *exporter) route(r entity.Route) {
+	where := "route " + utils.InterfaceToString(r.ID)
+	if r.FilterFunc != "" || r.Script != nil {
+		e.warnf("%s: filter_func and script are not supported, the route is not exported", where)
+		return
+	}
+	exprs, err := exprs(r.Vars)
+	if err != nil {
+		e.warnf("%s: %s, the route is not exported", where, err)
+		return
+	}
+
+	rule := HTTPRoute{
+		Priority: r.Priority,
+		Match: Match{
+			Paths:       r.Uris,
+			Methods:     r.Methods,
+			Hosts:       r.Hosts,
+			RemoteAddrs: r.RemoteAddrs,
+			Exprs:       exprs,
+		},
+		Websocket: r.EnableWebsocket,
+	}
+	if r.URI != "" {
+		rule.Match.Paths = append(rule.Match.Paths, r.URI)
+	}
+	if r.Host != "" {
+		rule.Match.Hosts = append(rule.Match.Hosts, r.Host)
+	}
+	if r.RemoteAddr != "" {
+		rule.Match.RemoteAddrs = append(rule.Match.RemoteAddrs, r.RemoteAddr)
+	}
+
+	// the service settings are used unless the route overrides them
+	pluginMap := map[string]any{}
+	upstreamID, upstream, key, upstreamName := r.UpstreamID, r.Upstream, "route/"+utils.InterfaceToString(r.ID), r.Name
+	if r.ServiceID != nil {
+		svc, ok := e.services[utils.InterfaceToString(r.ServiceID)]
+		if !ok {
+			e.warnf("%s: service %s not found, the route is not exported", where, utils.InterfaceToString(r.ServiceID))
+			return
+		}
+		for k, v := range svc.Plugins {
+			pluginMap[k] = v
+		}
+		if len(rule.Match.Hosts) == 0 {
+			rule.Match.Hosts = svc.Hosts
+		}
+		rule.Websocket = rule.Websocket || svc.EnableWebsocket
+		if upstreamID == nil && upstream == nil {
+			upstreamID, upstream = svc.UpstreamID, svc.Upstream
+			key, upstreamName = "service/"+utils.InterfaceToString(svc.ID), svc.Name
+		}
+	}
+	for k, v := range r.Plugins {
+		pluginMap[k] = v
+	}
+	rule.Plugins = plugins(pluginMap)
+
+	if r.PluginConfigID != nil {
+		name, ok := e.pluginConfigs[utils.InterfaceToString(r.PluginConfigID)]
+		if !ok {
+			e.warnf("%s: plugin config %s not found, the route is not exported", where, utils.InterfaceToString(r.PluginConfigID))
+			return
+		}
+		rule.PluginConfigName = name
+	}
+
+	if upstreamID != nil {
+		u, ok := e.upstreams[utils.InterfaceToString(upstreamID)]
+		if !ok {
+			e.warnf("%s: upstream %s not found, the route is not exported", where, utils.InterfaceToString(upstreamID))
+			return
+		}
+		upstream, key, upstreamName = &u.UpstreamDef, "upstream/"+utils.InterfaceToString(u.ID), u.Name
+		if upstreamName == "" {
+			upstreamName = utils.InterfaceToString(u.ID)
+		}
+	}
+	if upstream == nil {
+		e.warnf("%s: no upstream, the route is not exported", where)
+		return
+	}
+	e.backend(&rule, key, upstreamName, upstream)
+
+	if r.Status != 1 {
+		e.warnf("%s: the route is offline but ApisixRoute has no status, it is exported as online", where)
+	}
+
+	name := e.name(KindRoute, r.Name, r.ID)
+	rule.Name = name
+	e.routes = append(e.routes, e.object(KindRoute, name, r.Labels, RouteSpec{HTTP: []HTTPRoute{rule}}))
+}
+
+// backend sets the backend of the rule. Upstreams using a Kubernetes service
+// of the namespace are exported as service backends, the other upstreams
+// are exported as external upstreams.
+func (e *exporter) backend(rule *HTTPRoute, key, name string, def *entity.UpstreamDef) {
+	if info := k8sInfo(def); info != nil && info.Namespace == e.namespace {
+		rule.Backends = []Backend{{ServiceName: info.ServiceName, ServicePort: info.Port}}
+		// ApisixUpstream has the name of the service it configures
+		if _, ok := e.upstreamRefs[key]; !ok && !e.names[KindUpstream][info.ServiceName] {
+			e.upstreamRefs[key] = info.ServiceName
+			spec := upstreamSpec(def)
+			if spec.LoadBalancer != nil || spec.Scheme != "" || spec.Retries != nil || spec.Timeout != nil ||
+				spec.PassHost != "" {
+				e.name(KindUpstream, info.ServiceName)
+				e.ups = append(e.ups, e.object(KindUpstream, info.ServiceName, def.Labels, spec))
+			}
+		}
+		return
+	}
+
+	ref, ok := e.upstreamRefs[key]
+	if !ok {
+		ref = e.name(KindUpstream, name, key)
+		e.upstreamRefs[key] = ref
+		spec := upstreamSpec(def)
+		if def.DiscoveryType != "" {
+			spec.Discovery = &Discovery{
+				ServiceName: def.ServiceName,
+				Type:        def.DiscoveryType,
+				Args:        def.DiscoveryArgs,
+			}
+		} else {
+			nodes, _ := entity.NodesFormat(def.Nodes).([]*entity.Node)
+			for _, n := range nodes {
+				weight := n.Weight
+				spec.ExternalNodes = append(spec.ExternalNodes, ExternalNode{
+					Name:   n.Host,
+					Type:   "Domain",
+					Port:   n.Port,
+					Weight: &weight,
+				})
+			}
+		}
+		if def.Checks != nil {
+			e.warnf("%s: health checks are not exported", key)
+		}
+		if def.TLS != nil {
+			e.warnf("%s: client certificate is not exported", key)
+		}
+		e.ups = append(e.ups, e.object(KindUpstream, ref, def.Labels, spec))
+	}
+	rule.Upstreams = []UpstreamRef{{Name: ref}}
+}
+
+func upstreamSpec(def *entity.UpstreamDef) UpstreamSpec {
+	spec := UpstreamSpec{
+		Scheme:       def.Scheme,
+		Retries:      def.Retries,
+		Timeout:      formatTimeout(def.Timeout),
+		PassHost:     def.PassHost,
+		UpstreamHost: def.UpstreamHost,
+	}
+	if def.Type != "" && def.Type != "roundrobin" {
+		spec.LoadBalancer = &LoadBalancer{Type: def.Type, HashOn: def.HashOn, Key: def.Key}
+	}
+	return spec
+}
+
+func (e *exporter) consumer(c entity.Consumer) {
+	spec := ConsumerSpec{AuthParameter: map[string]AuthParameter{}}
+	var names []string
+	for name := range c.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		param, ok := authParameters[name]
+		if !ok {
+			e.warnf("consumer %s: plugin %s is not supported by ApisixConsumer", c.Username, name)
+			continue
+		}
+		conf, _ := c.Plugins[name].(map[string]any)
+		spec.AuthParameter[param] = AuthParameter{Value: conf}
+	}
+	if len(spec.AuthParameter) == 0 {
+		e.warnf("consumer %s: no authentication plugin, the consumer is not exported", c.Username)
+		return
+	}
+	name := e.name(KindConsumer, c.Username)
+	e.users = append(e.users, e.object(KindConsumer, name, c.Labels, spec))
+}
+
+// ssl exports the certificate as an ApisixTls and the Secret it refers to
+func (e *exporter) ssl(ssl entity.SSL) {
+	where := "ssl " + utils.InterfaceToString(ssl.ID)
+	if len(ssl.Certs) > 0 {
+		e.warnf("%s: additional certificates are not exported", where)
+	}
+	hosts := ssl.Snis
+	if ssl.Sni != "" {
+		hosts = append(hosts, ssl.Sni)
+	}
+
+	name := e.name(KindTLS, ssl.ID)
+	secret := e.name(KindSecret, name)
+	spec := TLSSpec{
+		Hosts:  hosts,
+		Secret: SecretRef{Name: secret, Namespace: e.namespace},
+	}
+	e.tls = append(e.tls, Object{
+		APIVersion: "v1",
+		Kind:       KindSecret,
+		Metadata:   Metadata{Name: secret, Namespace: e.namespace},
+		Type:       "kubernetes.io/tls",
+		StringData: map[string]string{"tls.crt": ssl.Cert, "tls.key": ssl.Key},
+	})
+	if ssl.Client != nil {
+		ca := e.name(KindSecret, name+"-ca")
+		spec.Client = &ClientTLS{CASecret: SecretRef{Name: ca, Namespace: e.namespace}, Depth: ssl.Client.Depth}
+		e.tls = append(e.tls, Object{
+			APIVersion: "v1",
+			Kind:       KindSecret,
+			Metadata:   Metadata{Name: ca, Namespace: e.namespace},
+			StringData: map[string]string{"ca.crt": ssl.Client.CA},
+		})
+	}
+	e.tls = append(e.tls, e.object(KindTLS, name, ssl.Labels, spec))
+}
+
+// plugins returns the plugins sorted by name, disabled plugins are kept
+// with enable set to false.
+func plugins(m map[string]any) []Plugin {
+	var ret []Plugin
+	for name, v := range m {
+		conf, _ := v.(map[string]any)
+		p := Plugin{Name: name, Enable: true}
+		if len(conf) > 0 {
+			p.Config = make(map[string]any, len(conf))
+			for k, v := range conf {
+				if k == "_meta" {
+					if meta, ok := v.(map[string]any); ok && meta["disable"] == true {
+						p.Enable = false
+					}
+					continue
+				}
+				p.Config[k] = v
+			}
+		}
+		ret = append(ret, p)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// exprs converts route vars, only vars matching headers, query arguments,
+// cookies and the uri can be converted.
+func exprs(vars []any) ([]Expr, error) {
+	var ret []Expr
+	for _, v := range vars {
+		item, _ := v.([]any)
+		if len(item) < 3 {
+			return nil, fmt.Errorf("vars %v can't be converted", v)
+		}
+		name, _ := item[0].(string)
+		op, _ := item[1].(string)
+		values := item[2:]
+		if op == "!" && len(item) == 4 {
+			op, _ = item[2].(string)
+			op, values = "! "+op, item[3:]
+		}
+		exprOp, ok := exprOps[op]
+		if !ok {
+			return nil, fmt.Errorf("vars %v can't be converted", v)
+		}
+
+		expr := Expr{Op: exprOp}
+		if name == "uri" {
+			expr.Subject.Scope = "Path"
+		}
+		for _, s := range exprScopes {
+			if strings.HasPrefix(name, s.prefix) && name != s.prefix {
+				expr.Subject = Subject{Scope: s.scope, Name: strings.TrimPrefix(name, s.prefix)}
+				break
+			}
+		}
+		if expr.Subject.Scope == "" {
+			return nil, fmt.Errorf("vars %v can't be converted", v)
+		}
+		if expr.Subject.Scope == "Header" {
+			expr.Subject.Name = headerName(expr.Subject.Name)
+		}
+
+		if list, ok := values[0].([]any); ok {
+			for _, s := range list {
+				expr.Set = append(expr.Set, utils.InterfaceToString(s))
+			}
+		} else {
+			s := utils.InterfaceToString(values[0])
+			expr.Value = &s
+		}
+		ret = append(ret, expr)
+	}
+	return ret, nil
+}
+
+// headerName returns the header of an nginx variable, http_x_user is X-User
+func headerName(v string) string {
+	b := []byte(v)
+	upper := true
+	for i, c := range b {
+		switch {
+		case c == '_':
+			b[i], upper = '-', true
+		case upper && c >= 'a' && c <= 'z':
+			b[i], upper = c-'a'+'A', false
+		default:
+			upper = false
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,561 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/utils/consts"
+)
+
+var regDocumentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// rawObject is a resource whose spec is decoded according to its kind
+type rawObject struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   Metadata          `json:"metadata"`
+	Spec       json.RawMessage   `json:"spec"`
+	Data       map[string]string `json:"data"`
+	StringData map[string]string `json:"stringData"`
+}
+
+// importer keeps the state of one import, resources are found by their
+// namespace and name.
+type importer struct {
+	namespace string
+	data      *loader.DataSets
+
+	secrets       map[string]*rawObject
+	upstreamSpecs map[string]*UpstreamSpec
+	upstreamIDs   map[string]string
+	pluginConfigs map[string]string
+	services      map[string]bool
+}
+
+// Import reads the custom resources of a multi-document YAML file, other
+// resources like Deployments are skipped. Routes use the ApisixUpstream of
+// the same name as their Kubernetes service backend, which is found with
+// the Kubernetes service discovery of APISIX.
+func (o Loader) Import(input any) (*loader.DataSets, error) {
+	if input == nil {
+		panic("input is nil")
+	}
+
+	d, ok := input.([]byte)
+	if !ok {
+		panic(fmt.Sprintf("input format error: expected []byte but it is %s", reflect.TypeOf(input).Kind().String()))
+	}
+
+	im := &importer{
+		namespace:     o.Namespace,
+		data:          &loader.DataSets{Errors: make(map[store.HubKey][]string)},
+		secrets:       make(map[string]*rawObject),
+		upstreamSpecs: make(map[string]*UpstreamSpec),
+		upstreamIDs:   make(map[string]string),
+		pluginConfigs: make(map[string]string),
+		services:      make(map[string]bool),
+	}
+	if im.namespace == "" {
+		im.namespace = DefaultNamespace
+	}
+
+	var objs []*rawObject
+	for _, doc := range regDocumentSeparator.Split(string(d), -1) {
+		b, err := yaml.YAMLToJSON([]byte(doc))
+		if err != nil {
+			return nil, errors.Wrap(err, consts.ErrImportFile.Error())
+		}
+		if string(b) == "null" {
+			continue
+		}
+		obj := &rawObject{}
+		if err := json.Unmarshal(b, obj); err != nil {
+			return nil, errors.Wrap(err, consts.ErrImportFile.Error())
+		}
+		if obj.Metadata.Namespace == "" {
+			obj.Metadata.Namespace = im.namespace
+		}
+		if obj.Kind == KindSecret {
+			im.secrets[key(obj.Metadata.Namespace, obj.Metadata.Name)] = obj
+			continue
+		}
+		if !strings.HasPrefix(obj.Kind, "Apisix") {
+			continue
+		}
+		if obj.APIVersion != APIVersion {
+			return nil, errors.Wrap(fmt.Errorf("%s %s: apiVersion %s is not supported, it should be %s",
+				obj.Kind, obj.Metadata.Name, obj.APIVersion, APIVersion), consts.ErrImportFile.Error())
+		}
+		switch obj.Kind {
+		case KindUpstream, KindPluginConfig, KindRoute, KindConsumer, KindTLS:
+			objs = append(objs, obj)
+		case "ApisixGlobalRule":
+			im.errorf(store.HubKeyGlobalRule, "%s %s: kind %s is not supported", obj.Kind, obj.Metadata.Name, obj.Kind)
+		default:
+			im.errorf(store.HubKeyRoute, "%s %s: kind %s is not supported", obj.Kind, obj.Metadata.Name, obj.Kind)
+		}
+	}
+
+	// routes refer to the upstreams and plugin configs
+	for _, kind := range []string{KindUpstream, KindPluginConfig, KindRoute, KindConsumer, KindTLS} {
+		for _, obj := range objs {
+			if obj.Kind != kind {
+				continue
+			}
+			if err := im.add(obj); err != nil {
+				return nil, errors.Wrap(err, consts.ErrImportFile.Error())
+			}
+		}
+	}
+
+	if len(im.data.Routes)+len(im.data.Upstreams)+len(im.data.Consumers)+len(im.data.SSLs)+len(im.data.PluginConfigs) == 0 {
+		return nil, errors.Wrap(errors.New("no APISIX custom resource found"), consts.ErrImportFile.Error())
+	}
+	return im.data, nil
+}
+
+func key(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func (im *importer) errorf(key store.HubKey, format string, args ...any) {
+	im.data.Errors[key] = append(im.data.Errors[key], fmt.Sprintf(format, args...))
+}
+
+func (im *importer) add(obj *rawObject) error {
+	where := obj.Kind + " " + key(obj.Metadata.Namespace, obj.Metadata.Name)
+	var spec any
+	switch obj.Kind {
+	case KindUpstream:
+		spec = &UpstreamSpec{}
+	case KindPluginConfig:
+		spec = &PluginConfigSpec{}
+	case KindRoute:
+		spec = &RouteSpec{}
+	case KindConsumer:
+		spec = &ConsumerSpec{}
+	case KindTLS:
+		spec = &TLSSpec{}
+	}
+	if len(obj.Spec) > 0 {
+		if err := json.Unmarshal(obj.Spec, spec); err != nil {
+			return fmt.Errorf("%s: %s", where, err)
+		}
+	}
+
+	switch s := spec.(type) {
+	case *UpstreamSpec:
+		im.upstream(obj, s)
+	case *PluginConfigSpec:
+		im.pluginConfig(obj, s)
+	case *RouteSpec:
+		im.route(obj, s)
+	case *ConsumerSpec:
+		im.consumer(obj, s)
+	case *TLSSpec:
+		im.tls(obj, s)
+	}
+	return nil
+}
+
+// upstream adds the ApisixUpstream with external nodes or discovery as an
+// upstream, the others configure the Kubernetes service of the same name.
+func (im *importer) upstream(obj *rawObject, spec *UpstreamSpec) {
+	k := key(obj.Metadata.Namespace, obj.Metadata.Name)
+	if len(spec.ExternalNodes) == 0 && spec.Discovery == nil {
+		im.upstreamSpecs[k] = spec
+		return
+	}
+
+	def := entity.UpstreamDef{
+		Name:   obj.Metadata.Name,
+		Labels: obj.Metadata.Labels,
+		Type:   "roundrobin",
+	}
+	if spec.Discovery != nil {
+		def.DiscoveryType = spec.Discovery.Type
+		def.ServiceName = spec.Discovery.ServiceName
+		def.DiscoveryArgs = spec.Discovery.Args
+	} else {
+		var nodes []*entity.Node
+		for _, n := range spec.ExternalNodes {
+			if n.Type == "Service" {
+				im.errorf(store.HubKeyUpstream, "ApisixUpstream %s: external node of type Service is not supported", k)
+				continue
+			}
+			node := &entity.Node{Host: n.Name, Port: n.Port, Weight: 1}
+			if node.Port == 0 {
+				node.Port = 80
+			}
+			if n.Weight != nil {
+				node.Weight = *n.Weight
+			}
+			nodes = append(nodes, node)
+		}
+		if len(nodes) == 0 {
+			return
+		}
+		def.Nodes = nodes
+	}
+	if err := applyUpstream(&def, spec); err != nil {
+		im.errorf(store.HubKeyUpstream, "ApisixUpstream %s: %s", k, err)
+	}
+
+	id := toID(obj.Metadata.Name)
+	im.upstreamIDs[k] = id
+	im.data.Upstreams = append(im.data.Upstreams, entity.Upstream{
+		BaseInfo:    entity.BaseInfo{ID: id},
+		UpstreamDef: def,
+	})
+}
+
+func applyUpstream(def *entity.UpstreamDef, spec *UpstreamSpec) error {
+	if spec.LoadBalancer != nil && spec.LoadBalancer.Type != "" {
+		def.Type = spec.LoadBalancer.Type
+		def.HashOn = spec.LoadBalancer.HashOn
+		def.Key = spec.LoadBalancer.Key
+	}
+	def.Scheme = spec.Scheme
+	def.Retries = spec.Retries
+	def.PassHost = spec.PassHost
+	def.UpstreamHost = spec.UpstreamHost
+	timeout, err := parseTimeout(spec.Timeout)
+	if err != nil {
+		return err
+	}
+	def.Timeout = timeout
+	return nil
+}
+
+func (im *importer) pluginConfig(obj *rawObject, spec *PluginConfigSpec) {
+	id := toID(obj.Metadata.Name)
+	im.pluginConfigs[key(obj.Metadata.Namespace, obj.Metadata.Name)] = id
+	im.data.PluginConfigs = append(im.data.PluginConfigs, entity.PluginConfig{
+		BaseInfo: entity.BaseInfo{ID: id},
+		Desc:     obj.Metadata.Name,
+		Plugins:  pluginMap(spec.Plugins),
+		Labels:   obj.Metadata.Labels,
+	})
+}
+
+// route adds a route for each HTTP rule, the route of a rule with the name
+// of the resource has the name of the resource.
+func (im *importer) route(obj *rawObject, spec *RouteSpec) {
+	ns, name := obj.Metadata.Namespace, obj.Metadata.Name
+	if len(spec.Stream) > 0 {
+		im.errorf(store.HubKeyStreamRoute, "ApisixRoute %s: stream rules are not supported", key(ns, name))
+	}
+	for _, rule := range spec.HTTP {
+		id, routeName := toID(name), name
+		if rule.Name != name {
+			id, routeName = toID(name, rule.Name), name+"_"+rule.Name
+		}
+		where := "ApisixRoute " + key(ns, name) + " rule " + rule.Name
+
+		vars, err := vars(rule.Match.Exprs)
+		if err != nil {
+			im.errorf(store.HubKeyRoute, "%s: %s, the rule is skipped", where, err)
+			continue
+		}
+		r := entity.Route{
+			BaseInfo:        entity.BaseInfo{ID: id},
+			Name:            routeName,
+			Priority:        rule.Priority,
+			Uris:            rule.Match.Paths,
+			Methods:         rule.Match.Methods,
+			Hosts:           rule.Match.Hosts,
+			RemoteAddrs:     rule.Match.RemoteAddrs,
+			Vars:            vars,
+			Plugins:         pluginMap(rule.Plugins),
+			Labels:          obj.Metadata.Labels,
+			EnableWebsocket: rule.Websocket,
+			Status:          1,
+		}
+		if a := rule.Authentication; a != nil && a.Enable {
+			plugin, ok := authPlugin(a.Type)
+			if !ok {
+				im.errorf(store.HubKeyRoute, "%s: authentication %s is not supported", where, a.Type)
+			} else if _, ok := r.Plugins[plugin]; !ok {
+				conf := map[string]any{}
+				if a.KeyAuth != nil && a.KeyAuth.Header != "" {
+					conf["header"] = a.KeyAuth.Header
+				}
+				r.Plugins[plugin] = conf
+			}
+		}
+		if len(r.Plugins) == 0 {
+			r.Plugins = nil
+		}
+		if rule.Timeout != nil {
+			im.errorf(store.HubKeyRoute, "%s: timeout is not supported, set it in the ApisixUpstream", where)
+		}
+		if rule.PluginConfigName != "" {
+			pc, ok := im.pluginConfigs[key(ns, rule.PluginConfigName)]
+			if !ok {
+				im.errorf(store.HubKeyRoute, "%s: ApisixPluginConfig %s not found, the rule is skipped", where, rule.PluginConfigName)
+				continue
+			}
+			r.PluginConfigID = pc
+		}
+
+		if len(rule.Backends)+len(rule.Upstreams) > 1 {
+			im.errorf(store.HubKeyRoute, "%s: only the first backend is used, traffic split is not supported", where)
+		}
+		switch {
+		case len(rule.Backends) > 0:
+			upstreamID, err := im.serviceUpstream(ns, rule.Backends[0])
+			if err != nil {
+				im.errorf(store.HubKeyRoute, "%s: %s, the rule is skipped", where, err)
+				continue
+			}
+			r.UpstreamID = upstreamID
+		case len(rule.Upstreams) > 0:
+			upstreamID, ok := im.upstreamIDs[key(ns, rule.Upstreams[0].Name)]
+			if !ok {
+				im.errorf(store.HubKeyRoute, "%s: ApisixUpstream %s not found, the rule is skipped", where, rule.Upstreams[0].Name)
+				continue
+			}
+			r.UpstreamID = upstreamID
+		default:
+			im.errorf(store.HubKeyRoute, "%s: no backend, the rule is skipped", where)
+			continue
+		}
+		im.data.Routes = append(im.data.Routes, r)
+	}
+}
+
+// serviceUpstream returns the upstream of a Kubernetes service backend, it
+// is added once for each service port.
+func (im *importer) serviceUpstream(ns string, b Backend) (string, error) {
+	var port int
+	switch p := b.ServicePort.(type) {
+	case float64:
+		port = int(p)
+	case string:
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return "", fmt.Errorf("named service port %s is not supported", p)
+		}
+		port = n
+	}
+	if port <= 0 {
+		return "", fmt.Errorf("invalid service port of %s", b.ServiceName)
+	}
+
+	info := &entity.K8sInfo{
+		Namespace:   ns,
+		ServiceName: b.ServiceName,
+		Port:        port,
+		BackendType: backendType,
+	}
+	id := toID(info.ServiceName, strconv.Itoa(info.Port))
+	if im.services[id] {
+		return id, nil
+	}
+	im.services[id] = true
+
+	def := serviceUpstream(info)
+	if spec, ok := im.upstreamSpecs[key(ns, b.ServiceName)]; ok {
+		if err := applyUpstream(def, spec); err != nil {
+			im.errorf(store.HubKeyUpstream, "ApisixUpstream %s: %s", key(ns, b.ServiceName), err)
+		}
+	}
+	im.data.Upstreams = append(im.data.Upstreams, entity.Upstream{
+		BaseInfo:    entity.BaseInfo{ID: id},
+		UpstreamDef: *def,
+	})
+	return id, nil
+}
+
+func (im *importer) consumer(obj *rawObject, spec *ConsumerSpec) {
+	k := key(obj.Metadata.Namespace, obj.Metadata.Name)
+	plugins := map[string]any{}
+	for plugin, param := range authParameters {
+		auth, ok := spec.AuthParameter[param]
+		if !ok {
+			continue
+		}
+		conf := auth.Value
+		if auth.SecretRef != nil {
+			secret, ok := im.secrets[key(obj.Metadata.Namespace, auth.SecretRef.Name)]
+			if !ok {
+				im.errorf(store.HubKeyConsumer, "ApisixConsumer %s: Secret %s not found", k, auth.SecretRef.Name)
+				continue
+			}
+			conf = map[string]any{}
+			for name, value := range secretData(secret) {
+				conf[name] = value
+			}
+		}
+		plugins[plugin] = conf
+	}
+	if len(plugins) == 0 {
+		im.errorf(store.HubKeyConsumer, "ApisixConsumer %s: no supported authentication, the consumer is skipped", k)
+		return
+	}
+	im.data.Consumers = append(im.data.Consumers, entity.Consumer{
+		Username: regInvalidUsername.ReplaceAllString(obj.Metadata.Name, "_"),
+		Plugins:  plugins,
+		Labels:   obj.Metadata.Labels,
+	})
+}
+
+var regInvalidUsername = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+func (im *importer) tls(obj *rawObject, spec *TLSSpec) {
+	k := key(obj.Metadata.Namespace, obj.Metadata.Name)
+	secret, ok := im.secrets[secretKey(obj, spec.Secret)]
+	if !ok {
+		im.errorf(store.HubKeySsl, "ApisixTls %s: Secret %s not found, the certificate is skipped", k, spec.Secret.Name)
+		return
+	}
+	data := secretData(secret)
+	ssl := entity.SSL{
+		BaseInfo: entity.BaseInfo{ID: toID(obj.Metadata.Name)},
+		Cert:     first(data, "tls.crt", "cert"),
+		Key:      first(data, "tls.key", "key"),
+		Snis:     spec.Hosts,
+		Labels:   obj.Metadata.Labels,
+		Status:   1,
+	}
+	if spec.Client != nil {
+		ca, ok := im.secrets[secretKey(obj, spec.Client.CASecret)]
+		if !ok {
+			im.errorf(store.HubKeySsl, "ApisixTls %s: Secret %s not found, the certificate is skipped", k, spec.Client.CASecret.Name)
+			return
+		}
+		ssl.Client = &entity.SSLClient{CA: first(secretData(ca), "ca.crt", "cert"), Depth: spec.Client.Depth}
+	}
+	im.data.SSLs = append(im.data.SSLs, ssl)
+}
+
+func secretKey(obj *rawObject, ref SecretRef) string {
+	if ref.Namespace == "" {
+		return key(obj.Metadata.Namespace, ref.Name)
+	}
+	return key(ref.Namespace, ref.Name)
+}
+
+// secretData returns the decoded data of a Secret
+func secretData(secret *rawObject) map[string]string {
+	ret := map[string]string{}
+	for k, v := range secret.Data {
+		if b, err := base64.StdEncoding.DecodeString(v); err == nil {
+			ret[k] = string(b)
+		}
+	}
+	for k, v := range secret.StringData {
+		ret[k] = v
+	}
+	return ret
+}
+
+func first(m map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func authPlugin(typ string) (string, bool) {
+	for plugin, param := range authParameters {
+		if param == typ {
+			return plugin, true
+		}
+	}
+	return "", false
+}
+
+func pluginMap(plugins []Plugin) map[string]any {
+	ret := map[string]any{}
+	for _, p := range plugins {
+		conf := map[string]any{}
+		for k, v := range p.Config {
+			conf[k] = v
+		}
+		if !p.Enable {
+			conf["_meta"] = map[string]any{"disable": true}
+		}
+		ret[p.Name] = conf
+	}
+	return ret
+}
+
+// vars converts exprs to route vars
+func vars(exprs []Expr) ([]any, error) {
+	var ret []any
+	for _, e := range exprs {
+		var name string
+		switch e.Subject.Scope {
+		case "Header":
+			name = "http_" + strings.ReplaceAll(strings.ToLower(e.Subject.Name), "-", "_")
+		case "Query":
+			name = "arg_" + e.Subject.Name
+		case "Cookie":
+			name = "cookie_" + e.Subject.Name
+		case "Path":
+			name = "uri"
+		default:
+			return nil, fmt.Errorf("expr scope %s is not supported", e.Subject.Scope)
+		}
+
+		op := ""
+		for varOp, exprOp := range exprOps {
+			if exprOp == e.Op {
+				op = varOp
+			}
+		}
+		if op == "" {
+			return nil, fmt.Errorf("expr operator %s is not supported", e.Op)
+		}
+
+		item := []any{name}
+		if strings.HasPrefix(op, "! ") {
+			item = append(item, "!")
+			op = strings.TrimPrefix(op, "! ")
+		}
+		item = append(item, op)
+		if e.Op == "In" || e.Op == "NotIn" {
+			set := []any{}
+			for _, s := range e.Set {
+				set = append(set, s)
+			}
+			item = append(item, set)
+		} else if e.Value != nil {
+			item = append(item, *e.Value)
+		} else {
+			return nil, fmt.Errorf("expr of %s has no value", name)
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
+}
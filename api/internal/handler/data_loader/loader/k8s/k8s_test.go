@@ -0,0 +1,294 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package k8s
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+)
+
+var (
+	TestCRDs = "../../../../../test/testdata/import/apisix-crds.yaml"
+)
+
+func exportData() loader.DataSets {
+	return loader.DataSets{
+		Routes: []entity.Route{
+			{
+				BaseInfo: entity.BaseInfo{ID: "r1"},
+				Name:     "Users API",
+				Uris:     []string{"/users/*"},
+				Methods:  []string{"GET"},
+				Vars:     []any{[]any{"http_x_user", "==", "jack"}, []any{"arg_v", "!", "in", []any{"1", "2"}}},
+				Plugins: map[string]any{
+					"limit-count": map[string]any{"count": float64(10), "time_window": float64(60)},
+					"cors":        map[string]any{"_meta": map[string]any{"disable": true}},
+				},
+				ServiceID: "s1",
+				Labels:    map[string]string{"team": "users"},
+				Status:    1,
+			},
+			{
+				BaseInfo:       entity.BaseInfo{ID: "r2"},
+				Name:           "orders",
+				URI:            "/orders",
+				Host:           "api.example.com",
+				PluginConfigID: "pc1",
+				UpstreamID:     "u2",
+				Status:         1,
+			},
+			{
+				BaseInfo:   entity.BaseInfo{ID: "r3"},
+				URI:        "/debug",
+				FilterFunc: "function(vars) return true end",
+				UpstreamID: "u2",
+				Status:     1,
+			},
+		},
+		Services: []entity.Service{
+			{
+				BaseInfo:   entity.BaseInfo{ID: "s1"},
+				Hosts:      []string{"users.example.com"},
+				UpstreamID: "u1",
+				Plugins:    map[string]any{"key-auth": map[string]any{}},
+			},
+		},
+		Upstreams: []entity.Upstream{
+			{
+				BaseInfo: entity.BaseInfo{ID: "u1"},
+				UpstreamDef: entity.UpstreamDef{
+					Type:          "chash",
+					HashOn:        "header",
+					Key:           "X-User",
+					DiscoveryType: "kubernetes",
+					ServiceName:   "apps/users:8080",
+					Timeout:       &entity.Timeout{Connect: 5, Read: 60},
+				},
+			},
+			{
+				BaseInfo: entity.BaseInfo{ID: "u2"},
+				UpstreamDef: entity.UpstreamDef{
+					Name:   "orders",
+					Type:   "roundrobin",
+					Scheme: "https",
+					Nodes:  []*entity.Node{{Host: "orders.example.com", Port: 443, Weight: 1}},
+				},
+			},
+		},
+		PluginConfigs: []entity.PluginConfig{
+			{
+				BaseInfo: entity.BaseInfo{ID: "pc1"},
+				Desc:     "auth",
+				Plugins:  map[string]any{"jwt-auth": map[string]any{}},
+			},
+		},
+		Consumers: []entity.Consumer{
+			{
+				Username: "jack_1",
+				Plugins: map[string]any{
+					"key-auth":    map[string]any{"key": "jack-key"},
+					"limit-count": map[string]any{"count": float64(1)},
+				},
+			},
+		},
+		SSLs: []entity.SSL{
+			{
+				BaseInfo: entity.BaseInfo{ID: "ssl1"},
+				Cert:     "CERT",
+				Key:      "KEY",
+				Snis:     []string{"api.example.com"},
+				Status:   1,
+			},
+		},
+	}
+}
+
+func TestExport(t *testing.T) {
+	ret, err := Loader{Namespace: "apps"}.Export(exportData())
+	assert.NoError(t, err)
+	content := string(ret.([]byte))
+
+	// unsupported objects are listed at the top
+	assert.Contains(t, content, `# route r3: filter_func and script are not supported, the route is not exported
+# consumer jack_1: plugin limit-count is not supported by ApisixConsumer
+---
+apiVersion: apisix.apache.org/v2
+kind: ApisixPluginConfig
+`)
+	// the upstream of a Kubernetes service configures the service
+	assert.Contains(t, content, `kind: ApisixUpstream
+metadata:
+  name: users
+  namespace: apps
+spec:
+  loadbalancer:
+    hashOn: header
+    key: X-User
+    type: chash
+  timeout:
+    connect: 5s
+    read: 1m0s
+`)
+	assert.Contains(t, content, `  - backends:
+    - serviceName: users
+      servicePort: 8080
+`)
+	assert.Contains(t, content, `    name: orders
+    plugin_config_name: auth
+    upstreams:
+    - name: orders
+`)
+	assert.Contains(t, content, `stringData:
+  tls.crt: CERT
+  tls.key: KEY
+type: kubernetes.io/tls
+`)
+}
+
+func TestExportAndImport(t *testing.T) {
+	ret, err := Loader{Namespace: "apps"}.Export(exportData())
+	assert.NoError(t, err)
+
+	data, err := Loader{}.Import(ret)
+	assert.NoError(t, err)
+
+	// the service is merged into the route
+	assert.Len(t, data.Routes, 2)
+	r := data.Routes[0]
+	assert.Equal(t, "users-api", r.ID)
+	assert.Equal(t, []string{"users.example.com"}, r.Hosts)
+	assert.Equal(t, []any{
+		[]any{"http_x_user", "==", "jack"},
+		[]any{"arg_v", "!", "in", []any{"1", "2"}},
+	}, r.Vars)
+	assert.Equal(t, map[string]any{
+		"cors":        map[string]any{"_meta": map[string]any{"disable": true}},
+		"key-auth":    map[string]any{},
+		"limit-count": map[string]any{"count": float64(10), "time_window": float64(60)},
+	}, r.Plugins)
+	assert.Equal(t, "users_8080", r.UpstreamID)
+	assert.Equal(t, "auth", data.Routes[1].PluginConfigID)
+	assert.Equal(t, "orders", data.Routes[1].UpstreamID)
+
+	assert.Len(t, data.Upstreams, 2)
+	assert.Equal(t, "orders", data.Upstreams[0].ID)
+	assert.Equal(t, []*entity.Node{{Host: "orders.example.com", Port: 443, Weight: 1}}, data.Upstreams[0].Nodes)
+	assert.Equal(t, entity.UpstreamDef{
+		Name:          "users",
+		Type:          "chash",
+		HashOn:        "header",
+		Key:           "X-User",
+		DiscoveryType: "kubernetes",
+		ServiceName:   "apps/users:8080",
+		Timeout:       &entity.Timeout{Connect: 5, Read: 60},
+	}, data.Upstreams[1].UpstreamDef)
+
+	assert.Equal(t, "jack_1", data.Consumers[0].Username)
+	assert.Equal(t, "CERT", data.SSLs[0].Cert)
+	assert.Equal(t, "KEY", data.SSLs[0].Key)
+}
+
+func TestImport(t *testing.T) {
+	fileContent, err := ioutil.ReadFile(TestCRDs)
+	assert.NoError(t, err)
+
+	data, err := Loader{}.Import(fileContent)
+	assert.NoError(t, err)
+
+	assert.Len(t, data.Routes, 2)
+	r := data.Routes[0]
+	assert.Equal(t, "httpbin_get", r.ID)
+	assert.Equal(t, "httpbin_get", r.Name)
+	assert.Equal(t, []string{"/ip"}, r.Uris)
+	assert.Equal(t, []any{[]any{"http_x_foo", "~~", "^bar"}}, r.Vars)
+	assert.Equal(t, map[string]any{"key-auth": map[string]any{}}, r.Plugins)
+	assert.Equal(t, "echo", r.PluginConfigID)
+	assert.Equal(t, "httpbin_80", r.UpstreamID)
+	assert.Equal(t, map[string]string{"app": "httpbin"}, r.Labels)
+
+	r = data.Routes[1]
+	assert.Equal(t, "external", r.UpstreamID)
+	assert.Equal(t, map[string]any{"proxy-rewrite": map[string]any{
+		"regex_uri": []any{"^/ext/(.*)", "/$1"},
+		"_meta":     map[string]any{"disable": true},
+	}}, r.Plugins)
+
+	retries := 2
+	assert.Len(t, data.Upstreams, 2)
+	assert.Equal(t, entity.Upstream{
+		BaseInfo: entity.BaseInfo{ID: "external"},
+		UpstreamDef: entity.UpstreamDef{
+			Name:  "external",
+			Type:  "roundrobin",
+			Nodes: []*entity.Node{{Host: "httpbin.org", Port: 80, Weight: 5}},
+		},
+	}, data.Upstreams[0])
+	assert.Equal(t, entity.Upstream{
+		BaseInfo: entity.BaseInfo{ID: "httpbin_80"},
+		UpstreamDef: entity.UpstreamDef{
+			Name:          "httpbin",
+			Type:          "ewma",
+			Retries:       &retries,
+			Timeout:       &entity.Timeout{Connect: 0.5},
+			DiscoveryType: "kubernetes",
+			ServiceName:   "default/httpbin:80",
+		},
+	}, data.Upstreams[1])
+
+	assert.Equal(t, []entity.PluginConfig{{
+		BaseInfo: entity.BaseInfo{ID: "echo"},
+		Desc:     "echo",
+		Plugins:  map[string]any{"echo": map[string]any{"body": "hello"}},
+	}}, data.PluginConfigs)
+	assert.Equal(t, []entity.Consumer{{
+		Username: "jack",
+		Plugins:  map[string]any{"key-auth": map[string]any{"key": "jack-key"}},
+	}}, data.Consumers)
+	assert.Equal(t, []entity.SSL{{
+		BaseInfo: entity.BaseInfo{ID: "httpbin"},
+		Cert:     "CERT",
+		Key:      "KEY",
+		Snis:     []string{"httpbin.example.com"},
+		Status:   1,
+	}}, data.SSLs)
+
+	assert.Equal(t, map[store.HubKey][]string{
+		store.HubKeyUpstream: {
+			"ApisixUpstream default/external: external node of type Service is not supported",
+		},
+		store.HubKeyRoute: {
+			"ApisixRoute default/httpbin rule named-port: named service port http is not supported, the rule is skipped",
+		},
+		store.HubKeyStreamRoute: {
+			"ApisixRoute default/httpbin: stream rules are not supported",
+		},
+	}, data.Errors)
+}
+
+func TestImportInvalid(t *testing.T) {
+	_, err := Loader{}.Import([]byte("apiVersion: apisix.apache.org/v2beta3\nkind: ApisixRoute\nmetadata:\n  name: a\n"))
+	assert.EqualError(t, err, "empty or invalid imported file: ApisixRoute a: apiVersion apisix.apache.org/v2beta3 is not supported, it should be apisix.apache.org/v2")
+
+	_, err = Loader{}.Import([]byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: a\n"))
+	assert.EqualError(t, err, "empty or invalid imported file: no APISIX custom resource found")
+}
@@ -0,0 +1,672 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package kong
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/utils/consts"
+)
+
+// Loader imports Kong declarative config files as written by decK. Settings
+// without APISIX equivalent are left out and reported with the import result.
+type Loader struct {
+	// TaskName indicates the name of current import/export task
+	TaskName string
+}
+
+const maxIDLength = 64
+
+var (
+	regInvalidID       = regexp.MustCompile(`[^a-zA-Z0-9-_.]+`)
+	regInvalidUsername = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+	defaultPorts = map[string]int{
+		"http":  80,
+		"https": 443,
+		"grpc":  80,
+		"grpcs": 443,
+	}
+
+	balancers = map[string]string{
+		"round-robin":        "roundrobin",
+		"consistent-hashing": "chash",
+		"least-connections":  "least_conn",
+		"latency":            "ewma",
+	}
+)
+
+type document struct {
+	FormatVersion string        `json:"_format_version"`
+	Services      []service     `json:"services"`
+	Routes        []route       `json:"routes"`
+	Upstreams     []upstream    `json:"upstreams"`
+	Consumers     []consumer    `json:"consumers"`
+	Certificates  []certificate `json:"certificates"`
+	Plugins       []plugin      `json:"plugins"`
+}
+
+type service struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	URL            string   `json:"url"`
+	Protocol       string   `json:"protocol"`
+	Host           string   `json:"host"`
+	Port           int      `json:"port"`
+	Path           string   `json:"path"`
+	Retries        *int     `json:"retries"`
+	ConnectTimeout int      `json:"connect_timeout"`
+	WriteTimeout   int      `json:"write_timeout"`
+	ReadTimeout    int      `json:"read_timeout"`
+	Routes         []route  `json:"routes"`
+	Plugins        []plugin `json:"plugins"`
+}
+
+type route struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Paths     []string        `json:"paths"`
+	Methods   []string        `json:"methods"`
+	Hosts     []string        `json:"hosts"`
+	StripPath *bool           `json:"strip_path"`
+	Service   json.RawMessage `json:"service"`
+	Plugins   []plugin        `json:"plugins"`
+}
+
+type upstream struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Algorithm    string          `json:"algorithm"`
+	HashOn       string          `json:"hash_on"`
+	HashOnHeader string          `json:"hash_on_header"`
+	HashOnCookie string          `json:"hash_on_cookie"`
+	HashOnQuery  string          `json:"hash_on_query_arg"`
+	Healthchecks json.RawMessage `json:"healthchecks"`
+	Targets      []struct {
+		Target string `json:"target"`
+		Weight *int   `json:"weight"`
+	} `json:"targets"`
+}
+
+type consumer struct {
+	ID                 string `json:"id"`
+	Username           string `json:"username"`
+	CustomID           string `json:"custom_id"`
+	KeyauthCredentials []struct {
+		Key string `json:"key"`
+	} `json:"keyauth_credentials"`
+	BasicauthCredentials []struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"basicauth_credentials"`
+	JWTSecrets []struct {
+		Key          string `json:"key"`
+		Secret       string `json:"secret"`
+		Algorithm    string `json:"algorithm"`
+		RSAPublicKey string `json:"rsa_public_key"`
+	} `json:"jwt_secrets"`
+	HmacauthCredentials []json.RawMessage `json:"hmacauth_credentials"`
+	Oauth2Credentials   []json.RawMessage `json:"oauth2_credentials"`
+	Acls                []json.RawMessage `json:"acls"`
+	Plugins             []plugin          `json:"plugins"`
+}
+
+type certificate struct {
+	ID   string            `json:"id"`
+	Cert string            `json:"cert"`
+	Key  string            `json:"key"`
+	SNIs []json.RawMessage `json:"snis"`
+}
+
+type plugin struct {
+	Name     string          `json:"name"`
+	Config   map[string]any  `json:"config"`
+	Enabled  *bool           `json:"enabled"`
+	Service  json.RawMessage `json:"service"`
+	Route    json.RawMessage `json:"route"`
+	Consumer json.RawMessage `json:"consumer"`
+}
+
+// converter keeps the state of one import, Kong objects are referred to by
+// name or ID.
+type converter struct {
+	taskName  string
+	data      *loader.DataSets
+	upstreams map[string]int
+	services  map[string]*serviceInfo
+	routes    map[string]int
+	consumers map[string]int
+}
+
+type serviceInfo struct {
+	index int
+	path  string
+}
+
+func (o Loader) Import(input any) (*loader.DataSets, error) {
+	if input == nil {
+		panic("input is nil")
+	}
+
+	d, ok := input.([]byte)
+	if !ok {
+		panic(fmt.Sprintf("input format error: expected []byte but it is %s", reflect.TypeOf(input).Kind().String()))
+	}
+
+	d, err := yaml.YAMLToJSON(d)
+	if err != nil {
+		return nil, errors.Wrap(err, consts.ErrImportFile.Error())
+	}
+	doc := &document{}
+	if err := json.Unmarshal(d, doc); err != nil {
+		return nil, errors.Wrap(err, consts.ErrImportFile.Error())
+	}
+	if doc.FormatVersion == "" {
+		return nil, errors.Wrap(errors.New("Kong declarative config requires _format_version"), consts.ErrImportFile.Error())
+	}
+
+	if o.TaskName == "" {
+		o.TaskName = "kong_" + time.Now().Format("20060102150405")
+	}
+
+	c := &converter{
+		taskName:  o.TaskName,
+		data:      &loader.DataSets{Errors: make(map[store.HubKey][]string)},
+		upstreams: make(map[string]int),
+		services:  make(map[string]*serviceInfo),
+		routes:    make(map[string]int),
+		consumers: make(map[string]int),
+	}
+	for _, u := range doc.Upstreams {
+		c.addUpstream(u)
+	}
+	for i, cert := range doc.Certificates {
+		c.addCertificate(i, cert)
+	}
+	for _, cons := range doc.Consumers {
+		c.addConsumer(cons)
+	}
+	for _, svc := range doc.Services {
+		c.addService(svc)
+	}
+	for _, r := range doc.Routes {
+		name := refName(r.Service)
+		info, ok := c.services[name]
+		if name != "" && !ok {
+			c.errorf(store.HubKeyRoute, "route %s: service %s not found", routeName(r, ""), name)
+			continue
+		}
+		c.addRoute(r, info, "")
+	}
+	c.addPlugins(doc.Plugins)
+
+	if len(c.data.Routes)+len(c.data.Services)+len(c.data.Upstreams)+len(c.data.Consumers)+len(c.data.SSLs)+len(c.data.GlobalPlugins) == 0 {
+		return nil, errors.Wrap(errors.New("Kong declarative config does not contain any supported objects"), consts.ErrImportFile.Error())
+	}
+	return c.data, nil
+}
+
+func (Loader) Export(data loader.DataSets) (any, error) {
+	return nil, errors.New("export to Kong declarative config is not supported")
+}
+
+func (c *converter) errorf(key store.HubKey, format string, args ...any) {
+	c.data.Errors[key] = append(c.data.Errors[key], fmt.Sprintf(format, args...))
+}
+
+func (c *converter) addUpstream(u upstream) {
+	where := "upstream " + u.Name
+	def := entity.UpstreamDef{
+		Name: u.Name,
+		Type: "roundrobin",
+	}
+	if u.Algorithm != "" {
+		typ, ok := balancers[u.Algorithm]
+		if !ok {
+			c.errorf(store.HubKeyUpstream, "%s: algorithm %s is not supported, round robin is used", where, u.Algorithm)
+		} else {
+			def.Type = typ
+		}
+	}
+	if def.Type == "chash" {
+		switch u.HashOn {
+		case "ip":
+			def.HashOn, def.Key = "vars", "remote_addr"
+		case "path":
+			def.HashOn, def.Key = "vars", "uri"
+		case "header":
+			def.HashOn, def.Key = "header", u.HashOnHeader
+		case "cookie":
+			def.HashOn, def.Key = "cookie", u.HashOnCookie
+		case "query_arg":
+			def.HashOn, def.Key = "vars", "arg_"+u.HashOnQuery
+		case "consumer":
+			def.HashOn = "consumer"
+		default:
+			def.HashOn, def.Key = "vars", "remote_addr"
+			c.errorf(store.HubKeyUpstream, "%s: hash on %s is not supported, the client address is used", where, u.HashOn)
+		}
+	}
+	if len(u.Healthchecks) > 0 && string(u.Healthchecks) != "null" {
+		c.errorf(store.HubKeyUpstream, "%s: health checks are not converted", where)
+	}
+
+	nodes := []*entity.Node{}
+	for _, t := range u.Targets {
+		host, port, err := splitHostPort(t.Target, 8000)
+		if err != nil {
+			c.errorf(store.HubKeyUpstream, "%s: invalid target %s", where, t.Target)
+			continue
+		}
+		weight := 100
+		if t.Weight != nil {
+			weight = *t.Weight
+		}
+		nodes = append(nodes, &entity.Node{Host: host, Port: port, Weight: weight})
+	}
+	def.Nodes = nodes
+
+	c.upstreams[u.Name] = len(c.data.Upstreams)
+	if u.ID != "" {
+		c.upstreams[u.ID] = len(c.data.Upstreams)
+	}
+	c.data.Upstreams = append(c.data.Upstreams, entity.Upstream{
+		BaseInfo:    entity.BaseInfo{ID: toID(u.Name, u.ID)},
+		UpstreamDef: def,
+	})
+}
+
+func (c *converter) addCertificate(i int, cert certificate) {
+	ssl := entity.SSL{
+		Cert:   cert.Cert,
+		Key:    cert.Key,
+		Status: 1,
+	}
+	if id := toID(cert.ID, ""); id != nil {
+		ssl.ID = id
+	}
+	for _, raw := range cert.SNIs {
+		if name := refName(raw); name != "" {
+			ssl.Snis = append(ssl.Snis, name)
+		}
+	}
+	if len(ssl.Snis) == 0 {
+		c.errorf(store.HubKeySsl, "certificate %d: no SNIs, the certificate is not served", i+1)
+	}
+	c.data.SSLs = append(c.data.SSLs, ssl)
+}
+
+func (c *converter) addConsumer(cons consumer) {
+	name := cons.Username
+	if name == "" {
+		name = cons.CustomID
+	}
+	where := "consumer " + name
+	username := regInvalidUsername.ReplaceAllString(name, "_")
+	if len(username) > 100 {
+		username = username[:100]
+	}
+
+	plugins := c.convertPlugins(store.HubKeyConsumer, where, cons.Plugins)
+	if n := len(cons.KeyauthCredentials); n > 0 {
+		plugins["key-auth"] = map[string]any{"key": cons.KeyauthCredentials[0].Key}
+		if n > 1 {
+			c.errorf(store.HubKeyConsumer, "%s: only the first of %d key-auth credentials is converted", where, n)
+		}
+	}
+	if n := len(cons.BasicauthCredentials); n > 0 {
+		cred := cons.BasicauthCredentials[0]
+		plugins["basic-auth"] = map[string]any{"username": cred.Username, "password": cred.Password}
+		if n > 1 {
+			c.errorf(store.HubKeyConsumer, "%s: only the first of %d basic-auth credentials is converted", where, n)
+		}
+	}
+	if n := len(cons.JWTSecrets); n > 0 {
+		secret := cons.JWTSecrets[0]
+		switch secret.Algorithm {
+		case "", "HS256", "HS512":
+			conf := map[string]any{"key": secret.Key, "secret": secret.Secret}
+			if secret.Algorithm != "" {
+				conf["algorithm"] = secret.Algorithm
+			}
+			plugins["jwt-auth"] = conf
+		default:
+			c.errorf(store.HubKeyConsumer, "%s: jwt secret with algorithm %s is not supported", where, secret.Algorithm)
+		}
+		if n > 1 {
+			c.errorf(store.HubKeyConsumer, "%s: only the first of %d jwt secrets is converted", where, n)
+		}
+	}
+	if len(cons.HmacauthCredentials) > 0 {
+		c.errorf(store.HubKeyConsumer, "%s: hmac-auth credentials are not supported", where)
+	}
+	if len(cons.Oauth2Credentials) > 0 {
+		c.errorf(store.HubKeyConsumer, "%s: oauth2 credentials are not supported", where)
+	}
+	if len(cons.Acls) > 0 {
+		c.errorf(store.HubKeyConsumer, "%s: acl groups are not supported", where)
+	}
+
+	c.consumers[name] = len(c.data.Consumers)
+	if cons.ID != "" {
+		c.consumers[cons.ID] = len(c.data.Consumers)
+	}
+	c.data.Consumers = append(c.data.Consumers, entity.Consumer{
+		Username: username,
+		Plugins:  plugins,
+	})
+}
+
+func (c *converter) addService(svc service) {
+	name := svc.Name
+	if name == "" {
+		name = svc.ID
+	}
+	where := "service " + name
+
+	protocol, host, port, path := svc.Protocol, svc.Host, svc.Port, svc.Path
+	if svc.URL != "" {
+		u, err := url.Parse(svc.URL)
+		if err != nil || u.Hostname() == "" {
+			c.errorf(store.HubKeyService, "%s: invalid url %s", where, svc.URL)
+			return
+		}
+		protocol, host, path = u.Scheme, u.Hostname(), u.Path
+		port, _ = strconv.Atoi(u.Port())
+	}
+	if protocol == "" {
+		protocol = "http"
+	}
+	if _, ok := defaultPorts[protocol]; !ok {
+		c.errorf(store.HubKeyService, "%s: protocol %s is not supported", where, protocol)
+		return
+	}
+
+	s := entity.Service{
+		BaseInfo: entity.BaseInfo{ID: toID(name, svc.ID)},
+		Name:     name,
+	}
+	if i, ok := c.upstreams[host]; ok {
+		// the service uses a Kong upstream, which takes the service settings
+		u := &c.data.Upstreams[i]
+		s.UpstreamID = u.ID
+		if u.Scheme == "" {
+			u.Scheme = protocol
+		}
+		if u.Timeout == nil {
+			u.Timeout = timeout(svc)
+		}
+		if u.Retries == nil {
+			u.Retries = svc.Retries
+		}
+	} else {
+		if port == 0 {
+			port = defaultPorts[protocol]
+		}
+		def := &entity.UpstreamDef{
+			Type:    "roundrobin",
+			Scheme:  protocol,
+			Nodes:   []*entity.Node{{Host: host, Port: port, Weight: 1}},
+			Timeout: timeout(svc),
+			Retries: svc.Retries,
+		}
+		if net.ParseIP(host) == nil {
+			// Kong sends the host of the service unless the route preserves the host
+			def.PassHost = "node"
+		}
+		s.Upstream = def
+	}
+	s.Plugins = c.convertPlugins(store.HubKeyService, where, svc.Plugins)
+	if len(s.Plugins) == 0 {
+		s.Plugins = nil
+	}
+
+	info := &serviceInfo{index: len(c.data.Services), path: strings.TrimRight(path, "/")}
+	c.services[name] = info
+	if svc.ID != "" {
+		c.services[svc.ID] = info
+	}
+	c.data.Services = append(c.data.Services, s)
+
+	for i, r := range svc.Routes {
+		c.addRoute(r, info, name+"_"+strconv.Itoa(i+1))
+	}
+}
+
+// addRoute converts a route of the service, Kong paths are prefixes which
+// are stripped by default before the service path is added.
+func (c *converter) addRoute(r route, info *serviceInfo, defaultName string) {
+	name := routeName(r, defaultName)
+	where := "route " + name
+
+	var prefixes []string
+	for _, p := range r.Paths {
+		if strings.HasPrefix(p, "~") {
+			c.errorf(store.HubKeyRoute, "%s: regex path %s is not supported", where, p)
+			continue
+		}
+		prefixes = append(prefixes, p)
+	}
+	if len(r.Paths) > 0 && len(prefixes) == 0 {
+		return
+	}
+
+	route := entity.Route{
+		BaseInfo: entity.BaseInfo{ID: toID(name, r.ID)},
+		Name:     name,
+		Methods:  r.Methods,
+		Hosts:    r.Hosts,
+		Status:   1,
+	}
+	for _, p := range prefixes {
+		route.Uris = append(route.Uris, strings.TrimSuffix(p, "*")+"*")
+	}
+	if len(route.Uris) == 0 {
+		route.Uris = []string{"/*"}
+	}
+
+	route.Plugins = c.convertPlugins(store.HubKeyRoute, where, r.Plugins)
+	servicePath := ""
+	if info != nil {
+		route.ServiceID = c.data.Services[info.index].ID
+		servicePath = info.path
+	}
+	if rewrite := rewriteURI(prefixes, r.StripPath == nil || *r.StripPath, servicePath); rewrite != nil {
+		conf, _ := route.Plugins["proxy-rewrite"].(map[string]any)
+		switch {
+		case conf == nil:
+			route.Plugins["proxy-rewrite"] = map[string]any{"regex_uri": rewrite}
+		case conf["uri"] != nil:
+			// like Kong, the replaced uri is the whole upstream path
+			c.errorf(store.HubKeyRoute, "%s: path stripping and service path are dropped, the uri of plugin request-transformer is used", where)
+		default:
+			conf["regex_uri"] = rewrite
+		}
+	}
+	if len(route.Plugins) == 0 {
+		route.Plugins = nil
+	}
+
+	c.routes[name] = len(c.data.Routes)
+	if r.ID != "" {
+		c.routes[r.ID] = len(c.data.Routes)
+	}
+	c.data.Routes = append(c.data.Routes, route)
+}
+
+// rewriteURI returns the regex_uri of proxy-rewrite which strips the matched
+// prefix and adds the service path.
+func rewriteURI(prefixes []string, strip bool, servicePath string) []string {
+	var stripped []string
+	if strip {
+		for _, p := range prefixes {
+			if p = strings.TrimRight(p, "/"); p != "" {
+				stripped = append(stripped, regexp.QuoteMeta(p))
+			}
+		}
+	}
+	if len(stripped) > 0 {
+		return []string{"^(?:" + strings.Join(stripped, "|") + ")/?(.*)", servicePath + "/$1"}
+	}
+	if servicePath != "" {
+		return []string{"^/(.*)", servicePath + "/$1"}
+	}
+	return nil
+}
+
+// addPlugins adds the plugins of the document to the objects they are
+// scoped to, plugins without scope become one global rule.
+func (c *converter) addPlugins(plugins []plugin) {
+	global := make(map[string]any)
+	for _, p := range plugins {
+		switch {
+		case refName(p.Route) != "":
+			name := refName(p.Route)
+			i, ok := c.routes[name]
+			if !ok {
+				c.errorf(store.HubKeyRoute, "plugin %s: route %s not found", p.Name, name)
+				continue
+			}
+			route := &c.data.Routes[i]
+			route.Plugins = c.mergePlugins(store.HubKeyRoute, "route "+route.Name, route.Plugins, p)
+		case refName(p.Service) != "":
+			name := refName(p.Service)
+			info, ok := c.services[name]
+			if !ok {
+				c.errorf(store.HubKeyService, "plugin %s: service %s not found", p.Name, name)
+				continue
+			}
+			service := &c.data.Services[info.index]
+			service.Plugins = c.mergePlugins(store.HubKeyService, "service "+service.Name, service.Plugins, p)
+		case refName(p.Consumer) != "":
+			name := refName(p.Consumer)
+			i, ok := c.consumers[name]
+			if !ok {
+				c.errorf(store.HubKeyConsumer, "plugin %s: consumer %s not found", p.Name, name)
+				continue
+			}
+			consumer := &c.data.Consumers[i]
+			consumer.Plugins = c.mergePlugins(store.HubKeyConsumer, "consumer "+consumer.Username, consumer.Plugins, p)
+		default:
+			global = c.mergePlugins(store.HubKeyGlobalRule, "global rule", global, p)
+		}
+	}
+
+	if len(global) > 0 {
+		c.data.GlobalPlugins = append(c.data.GlobalPlugins, entity.GlobalPlugins{
+			BaseInfo: entity.BaseInfo{ID: toID(c.taskName, "")},
+			Plugins:  global,
+		})
+	}
+}
+
+func (c *converter) mergePlugins(key store.HubKey, where string, plugins map[string]any, p plugin) map[string]any {
+	if plugins == nil {
+		plugins = make(map[string]any)
+	}
+	for name, conf := range c.convertPlugins(key, where, []plugin{p}) {
+		plugins[name] = conf
+	}
+	return plugins
+}
+
+func timeout(svc service) *entity.Timeout {
+	if svc.ConnectTimeout == 0 && svc.WriteTimeout == 0 && svc.ReadTimeout == 0 {
+		return nil
+	}
+	// Kong timeouts are in milliseconds
+	return &entity.Timeout{
+		Connect: entity.TimeoutValue(float32(svc.ConnectTimeout) / 1000),
+		Send:    entity.TimeoutValue(float32(svc.WriteTimeout) / 1000),
+		Read:    entity.TimeoutValue(float32(svc.ReadTimeout) / 1000),
+	}
+}
+
+func routeName(r route, defaultName string) string {
+	switch {
+	case r.Name != "":
+		return r.Name
+	case r.ID != "":
+		return r.ID
+	}
+	return defaultName
+}
+
+// refName returns the name or ID of a reference, which is either a string
+// or an object with a name or an id.
+func refName(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var ref struct {
+		Name string `json:"name"`
+		ID   string `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &ref); err != nil {
+		return ""
+	}
+	if ref.Name != "" {
+		return ref.Name
+	}
+	return ref.ID
+}
+
+// toID turns the name into a valid ID, the Kong ID is used without name
+func toID(name, id string) any {
+	if name == "" {
+		name = id
+	}
+	name = regInvalidID.ReplaceAllString(name, "_")
+	if len(name) > maxIDLength {
+		name = name[:maxIDLength]
+	}
+	if name == "" {
+		return nil
+	}
+	return name
+}
+
+func splitHostPort(target string, defaultPort int) (string, int, error) {
+	host, p, err := net.SplitHostPort(target)
+	if err != nil {
+		// target without port
+		return strings.Trim(target, "[]"), defaultPort, nil
+	}
+	port, err := strconv.Atoi(p)
+	if err != nil || port <= 0 {
+		return "", 0, fmt.Errorf("invalid port: %s", p)
+	}
+	return host, port, nil
+}
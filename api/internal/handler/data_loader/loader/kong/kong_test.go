@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package kong
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+var (
+	TestKong = "../../../../../test/testdata/import/kong.yaml"
+)
+
+func TestParseKong(t *testing.T) {
+	fileContent, err := ioutil.ReadFile(TestKong)
+	assert.NoError(t, err)
+
+	l := &Loader{TaskName: "test"}
+	data, err := l.Import(fileContent)
+	assert.NoError(t, err)
+
+	// upstreams
+	assert.Len(t, data.Upstreams, 1)
+	u := data.Upstreams[0]
+	assert.Equal(t, "users-upstream", u.ID)
+	assert.Equal(t, "chash", u.Type)
+	assert.Equal(t, "header", u.HashOn)
+	assert.Equal(t, "X-User", u.Key)
+	assert.Equal(t, "http", u.Scheme)
+	assert.Equal(t, &entity.Timeout{Connect: 5, Send: 60, Read: 60}, u.Timeout)
+	assert.Equal(t, []*entity.Node{
+		{Host: "10.0.0.1", Port: 8080, Weight: 50},
+		{Host: "10.0.0.2", Port: 8000, Weight: 100},
+	}, u.Nodes)
+
+	// services, the tcp service is left out
+	assert.Len(t, data.Services, 2)
+	assert.Equal(t, "users", data.Services[0].ID)
+	assert.Equal(t, "users-upstream", data.Services[0].UpstreamID)
+	assert.Equal(t, map[string]any{
+		"limit-count": map[string]any{
+			"count":         float64(100),
+			"time_window":   60,
+			"key_type":      "var",
+			"key":           "remote_addr",
+			"rejected_code": 429,
+		},
+	}, data.Services[0].Plugins)
+	assert.Equal(t, "orders", data.Services[1].ID)
+	assert.Equal(t, &entity.UpstreamDef{
+		Type:     "roundrobin",
+		Scheme:   "https",
+		Nodes:    []*entity.Node{{Host: "orders.example.com", Port: 8443, Weight: 1}},
+		PassHost: "node",
+	}, data.Services[1].Upstream)
+
+	// routes, the regex route is left out
+	assert.Len(t, data.Routes, 2)
+	r := data.Routes[0]
+	assert.Equal(t, "users-list", r.ID)
+	assert.Equal(t, []string{"/users*"}, r.Uris)
+	assert.Equal(t, []string{"GET"}, r.Methods)
+	assert.Equal(t, []string{"api.example.com"}, r.Hosts)
+	assert.Equal(t, "users", r.ServiceID)
+	assert.Equal(t, map[string]any{"header": "apikey", "query": "apikey"}, r.Plugins["key-auth"])
+	assert.Equal(t, map[string]any{}, r.Plugins["jwt-auth"])
+	assert.Equal(t, map[string]any{
+		"headers": map[string]any{
+			"add":    map[string]any{"X-Source": "kong"},
+			"remove": []string{"X-Debug"},
+		},
+		"regex_uri": []string{"^(?:/users)/?(.*)", "/api/$1"},
+	}, r.Plugins["proxy-rewrite"])
+
+	r = data.Routes[1]
+	assert.Equal(t, "orders_1", r.ID)
+	assert.Equal(t, []string{"/orders*"}, r.Uris)
+	assert.Equal(t, "orders", r.ServiceID)
+	assert.Equal(t, map[string]any{
+		"allow_origins":    "https://a.example.com,https://b.example.com",
+		"allow_methods":    "GET,POST",
+		"allow_credential": true,
+		"max_age":          float64(3600),
+		"_meta":            map[string]any{"disable": true},
+	}, r.Plugins["cors"])
+	assert.Nil(t, r.Plugins["proxy-rewrite"])
+
+	// consumers
+	assert.Len(t, data.Consumers, 2)
+	assert.Equal(t, "jack", data.Consumers[0].Username)
+	assert.Equal(t, map[string]any{"key-auth": map[string]any{"key": "jack-key"}}, data.Consumers[0].Plugins)
+	assert.Equal(t, "rose_smith", data.Consumers[1].Username)
+	assert.Equal(t, map[string]any{"jwt-auth": map[string]any{"key": "rose", "secret": "rose-secret"}}, data.Consumers[1].Plugins)
+
+	// certificates and global plugins
+	assert.Len(t, data.SSLs, 1)
+	assert.Equal(t, "example-cert", data.SSLs[0].ID)
+	assert.Equal(t, []string{"api.example.com"}, data.SSLs[0].Snis)
+	assert.Len(t, data.GlobalPlugins, 1)
+	assert.Equal(t, "test", data.GlobalPlugins[0].ID)
+	assert.Equal(t, map[string]any{"blacklist": []string{"10.1.0.0/16"}}, data.GlobalPlugins[0].Plugins["ip-restriction"])
+
+	// settings which can't be converted are reported
+	assert.Equal(t, map[store.HubKey][]string{
+		store.HubKeyService: {
+			"service users: plugin rate-limiting: limit per hour is dropped, only one window is supported",
+			"service users: plugin prometheus is not supported",
+			"service stream: protocol tcp is not supported",
+		},
+		store.HubKeyRoute: {
+			"route users-list: plugin request-transformer: add of querystring is dropped",
+			"route users-regex: regex path ~/users/\\d+$ is not supported",
+		},
+		store.HubKeyConsumer: {
+			"consumer jack: acl groups are not supported",
+		},
+	}, data.Errors)
+}
+
+func TestParseKongInvalid(t *testing.T) {
+	l := &Loader{TaskName: "test"}
+
+	_, err := l.Import([]byte(`services: []`))
+	assert.EqualError(t, err, "empty or invalid imported file: Kong declarative config requires _format_version")
+
+	_, err = l.Import([]byte("_format_version: \"3.0\"\nservices:\n  - name: a\n    protocol: tcp\n    host: 127.0.0.1\n"))
+	assert.EqualError(t, err, "empty or invalid imported file: Kong declarative config does not contain any supported objects")
+}
+
+func TestParseKongRequestTransformer(t *testing.T) {
+	l := &Loader{TaskName: "test"}
+	data, err := l.Import([]byte(`_format_version: "3.0"
+services:
+  - name: users
+    url: http://10.0.0.1:8080/api
+    routes:
+      - name: users-list
+        paths:
+          - /users
+        plugins:
+          - name: request-transformer
+            config:
+              add:
+                headers:
+                  - "X-Source:kong"
+                  - "X-Env:dev"
+              append:
+                headers:
+                  - "X-Env:prod"
+                  - "X-Trace:1"
+              replace:
+                uri: /v2/users
+`))
+	assert.NoError(t, err)
+	assert.Len(t, data.Routes, 1)
+	assert.Equal(t, map[string]any{
+		"headers": map[string]any{
+			"add": map[string]any{"X-Source": "kong", "X-Env": "prod", "X-Trace": "1"},
+		},
+		"uri": "/v2/users",
+	}, data.Routes[0].Plugins["proxy-rewrite"])
+	assert.Equal(t, map[store.HubKey][]string{
+		store.HubKeyRoute: {
+			"route users-list: plugin request-transformer: add of header X-Env is dropped, the appended value is used",
+			"route users-list: path stripping and service path are dropped, the uri of plugin request-transformer is used",
+		},
+	}, data.Errors)
+}
@@ -0,0 +1,302 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package kong
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+// pluginConverter returns the APISIX plugin name and configuration, the
+// notes describe Kong settings which are dropped.
+type pluginConverter func(conf map[string]any) (name string, apisix map[string]any, notes []string)
+
+var pluginConverters = map[string]pluginConverter{
+	"rate-limiting":       rateLimiting,
+	"key-auth":            keyAuth,
+	"jwt":                 jwt,
+	"basic-auth":          basicAuth,
+	"cors":                cors,
+	"ip-restriction":      ipRestriction,
+	"request-transformer": requestTransformer,
+}
+
+// convertPlugins converts the plugins of an object, unsupported plugins and
+// settings are reported with the import result.
+func (c *converter) convertPlugins(key store.HubKey, where string, plugins []plugin) map[string]any {
+	ret := make(map[string]any)
+	for _, p := range plugins {
+		convert, ok := pluginConverters[p.Name]
+		if !ok {
+			c.errorf(key, "%s: plugin %s is not supported", where, p.Name)
+			continue
+		}
+		conf := p.Config
+		if conf == nil {
+			conf = map[string]any{}
+		}
+		name, apisix, notes := convert(conf)
+		for _, note := range notes {
+			c.errorf(key, "%s: plugin %s: %s", where, p.Name, note)
+		}
+		if p.Enabled != nil && !*p.Enabled {
+			apisix["_meta"] = map[string]any{"disable": true}
+		}
+		ret[name] = apisix
+	}
+	return ret
+}
+
+var rateLimitWindows = []struct {
+	name    string
+	seconds int
+}{
+	{"second", 1},
+	{"minute", 60},
+	{"hour", 3600},
+	{"day", 86400},
+	{"month", 2592000},
+	{"year", 31536000},
+}
+
+func rateLimiting(conf map[string]any) (string, map[string]any, []string) {
+	ret := map[string]any{
+		"rejected_code": 429,
+		"key_type":      "var",
+		"key":           "remote_addr",
+	}
+	var notes []string
+	for _, w := range rateLimitWindows {
+		count, ok := number(conf[w.name])
+		if !ok {
+			continue
+		}
+		if _, ok := ret["count"]; ok {
+			notes = append(notes, fmt.Sprintf("limit per %s is dropped, only one window is supported", w.name))
+			continue
+		}
+		ret["count"] = count
+		ret["time_window"] = w.seconds
+	}
+
+	switch conf["limit_by"] {
+	case nil, "ip":
+	case "consumer", "credential":
+		ret["key"] = "consumer_name"
+	case "header":
+		ret["key_type"], ret["key"] = "var", "http_"+headerVar(str(conf["header_name"]))
+	case "path":
+		ret["key"] = "uri"
+	default:
+		notes = append(notes, fmt.Sprintf("limit by %v is not supported, the client address is used", conf["limit_by"]))
+	}
+
+	if conf["policy"] == "redis" {
+		ret["policy"] = "redis"
+		for kong, apisix := range map[string]string{
+			"redis_host":     "redis_host",
+			"redis_port":     "redis_port",
+			"redis_password": "redis_password",
+			"redis_database": "redis_database",
+			"redis_timeout":  "redis_timeout",
+		} {
+			if v, ok := conf[kong]; ok && v != nil {
+				ret[apisix] = v
+			}
+		}
+	}
+	return "limit-count", ret, notes
+}
+
+func keyAuth(conf map[string]any) (string, map[string]any, []string) {
+	ret := map[string]any{}
+	var notes []string
+	names := stringList(conf["key_names"])
+	if len(names) > 0 {
+		ret["header"] = names[0]
+		ret["query"] = names[0]
+	}
+	if len(names) > 1 {
+		notes = append(notes, "only the first key name is used")
+	}
+	if b, ok := conf["hide_credentials"].(bool); ok && b {
+		ret["hide_credentials"] = true
+	}
+	return "key-auth", ret, notes
+}
+
+func jwt(conf map[string]any) (string, map[string]any, []string) {
+	ret := map[string]any{}
+	var notes []string
+	if names := stringList(conf["header_names"]); len(names) > 0 && names[0] != "authorization" {
+		ret["header"] = names[0]
+	}
+	if names := stringList(conf["uri_param_names"]); len(names) > 0 {
+		ret["query"] = names[0]
+	}
+	if names := stringList(conf["cookie_names"]); len(names) > 0 {
+		ret["cookie"] = names[0]
+	}
+	if claims := stringList(conf["claims_to_verify"]); len(claims) > 0 {
+		notes = append(notes, "claims_to_verify is dropped")
+	}
+	return "jwt-auth", ret, notes
+}
+
+func basicAuth(conf map[string]any) (string, map[string]any, []string) {
+	ret := map[string]any{}
+	if b, ok := conf["hide_credentials"].(bool); ok && b {
+		ret["hide_credentials"] = true
+	}
+	return "basic-auth", ret, nil
+}
+
+func cors(conf map[string]any) (string, map[string]any, []string) {
+	ret := map[string]any{}
+	if origins := stringList(conf["origins"]); len(origins) > 0 {
+		ret["allow_origins"] = strings.Join(origins, ",")
+	}
+	if methods := stringList(conf["methods"]); len(methods) > 0 {
+		ret["allow_methods"] = strings.Join(methods, ",")
+	}
+	if headers := stringList(conf["headers"]); len(headers) > 0 {
+		ret["allow_headers"] = strings.Join(headers, ",")
+	}
+	if headers := stringList(conf["exposed_headers"]); len(headers) > 0 {
+		ret["expose_headers"] = strings.Join(headers, ",")
+	}
+	if b, ok := conf["credentials"].(bool); ok && b {
+		ret["allow_credential"] = true
+	}
+	if maxAge, ok := number(conf["max_age"]); ok {
+		ret["max_age"] = maxAge
+	}
+	return "cors", ret, nil
+}
+
+func ipRestriction(conf map[string]any) (string, map[string]any, []string) {
+	ret := map[string]any{}
+	var notes []string
+	allow, deny := stringList(conf["allow"]), stringList(conf["deny"])
+	switch {
+	case len(allow) > 0:
+		ret["whitelist"] = allow
+		if len(deny) > 0 {
+			notes = append(notes, "deny list is dropped, the allow list is used")
+		}
+	case len(deny) > 0:
+		ret["blacklist"] = deny
+	}
+	return "ip-restriction", ret, notes
+}
+
+// requestTransformer converts the header and uri settings, the header
+// operations of proxy-rewrite are applied in the order add, set, remove.
+// Appended headers are added too, proxy-rewrite adds one value per header so
+// a header both added and appended keeps the appended value.
+func requestTransformer(conf map[string]any) (string, map[string]any, []string) {
+	ret := map[string]any{}
+	var notes []string
+	headers := map[string]any{}
+	add := headerMap(conf, "add")
+	appended := headerMap(conf, "append")
+	names := make([]string, 0, len(appended))
+	for k := range appended {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if _, ok := add[k]; ok {
+			notes = append(notes, fmt.Sprintf("add of header %s is dropped, the appended value is used", k))
+		}
+		add[k] = appended[k]
+	}
+	if len(add) > 0 {
+		headers["add"] = add
+	}
+	if set := headerMap(conf, "replace"); len(set) > 0 {
+		headers["set"] = set
+	}
+	if section, ok := conf["remove"].(map[string]any); ok {
+		if remove := stringList(section["headers"]); len(remove) > 0 {
+			headers["remove"] = remove
+		}
+	}
+	if len(headers) > 0 {
+		ret["headers"] = headers
+	}
+	if method := str(conf["http_method"]); method != "" {
+		ret["method"] = method
+	}
+	if section, ok := conf["replace"].(map[string]any); ok {
+		if uri := str(section["uri"]); uri != "" {
+			ret["uri"] = uri
+		}
+	}
+	for _, op := range []string{"add", "append", "replace", "remove", "rename"} {
+		section, _ := conf[op].(map[string]any)
+		for _, field := range []string{"querystring", "body"} {
+			if len(stringList(section[field])) > 0 {
+				notes = append(notes, fmt.Sprintf("%s of %s is dropped", op, field))
+			}
+		}
+		if op == "rename" && len(stringList(section["headers"])) > 0 {
+			notes = append(notes, "rename of headers is dropped")
+		}
+	}
+	return "proxy-rewrite", ret, notes
+}
+
+// headerMap turns the "name:value" list of a request-transformer section
+// into a map.
+func headerMap(conf map[string]any, op string) map[string]any {
+	ret := map[string]any{}
+	section, _ := conf[op].(map[string]any)
+	for _, h := range stringList(section["headers"]) {
+		name, value, _ := strings.Cut(h, ":")
+		ret[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return ret
+}
+
+func headerVar(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func number(v any) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func stringList(v any) []string {
+	list, _ := v.([]any)
+	var ret []string
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
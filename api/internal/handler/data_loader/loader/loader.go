@@ -16,7 +16,10 @@
  */
 package loader
 
-import "github.com/apisix/manager-api/internal/core/entity"
+import (
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
+)
 
 // DataSets are intermediate structures used to handle
 // import and export data with APISIX entities.
@@ -32,6 +35,11 @@ type DataSets struct {
 	GlobalPlugins []entity.GlobalPlugins
 	PluginConfigs []entity.PluginConfig
 	Protos        []entity.Proto
+
+	// Errors are the problems found while converting the objects of each
+	// type, such as unsupported settings which are left out. They are
+	// reported with the import result without failing the import.
+	Errors map[store.HubKey][]string
 }
 
 // Loader provide data loader abstraction
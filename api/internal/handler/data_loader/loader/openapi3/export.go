@@ -16,9 +16,463 @@
  */
 package openapi3
 
-import "github.com/apisix/manager-api/internal/handler/data_loader/loader"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 
-func (Loader) Export(data loader.DataSets) (any, error) {
-	//TODO implement me
-	panic("implement me")
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils"
+	"github.com/apisix/manager-api/internal/utils/consts"
+)
+
+const (
+	openAPIVersion = "3.0.0"
+	exportTitle    = "RoutesExport"
+
+	// repeatURISuffix is added to the paths of routes using an already exported uri
+	repeatURISuffix = "-APISIX-REPEAT-URI-"
+)
+
+const (
+	basicAuth = "basic-auth"
+	keyAuth   = "key-auth"
+	jwtAuth   = "jwt-auth"
+)
+
+var allHTTPMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodHead, http.MethodConnect, http.MethodTrace, http.MethodOptions}
+
+// Export converts the routes of the data sets into an OpenAPI 3 document.
+// The services and upstreams of the data sets are the ones the routes refer
+// to, see Loader.Inline for how they are exported.
+func (o Loader) Export(data loader.DataSets) (any, error) {
+	e := &exporter{
+		loader:    o,
+		services:  make(map[string]*entity.Service, len(data.Services)),
+		upstreams: make(map[string]*entity.Upstream, len(data.Upstreams)),
+	}
+	for i := range data.Services {
+		e.services[utils.InterfaceToString(data.Services[i].ID)] = &data.Services[i]
+	}
+	for i := range data.Upstreams {
+		e.upstreams[utils.InterfaceToString(data.Upstreams[i].ID)] = &data.Upstreams[i]
+	}
+
+	swagger, err := e.export(data.Routes)
+	if err != nil {
+		return nil, err
+	}
+
+	if !o.Inline {
+		if len(data.Services) > 0 {
+			swagger.Extensions[extensionPrefix+"services"] = data.Services
+		}
+		if len(data.Upstreams) > 0 {
+			swagger.Extensions[extensionPrefix+"upstreams"] = data.Upstreams
+		}
+	}
+	return swagger, nil
+}
+
+// exporter holds the state of one export.
+type exporter struct {
+	loader    Loader
+	services  map[string]*entity.Service
+	upstreams map[string]*entity.Upstream
+}
+
+func (e *exporter) export(routes []entity.Route) (*openapi3.Swagger, error) {
+	paths := openapi3.Paths{}
+	secSchemas := openapi3.SecuritySchemes{}
+
+	for i, route := range routes {
+		paramsRefs := []*openapi3.ParameterRef{}
+		requestBody := &openapi3.RequestBody{}
+		pathItem := &openapi3.PathItem{}
+		path := openapi3.Operation{}
+		path.Summary = route.Desc
+		path.OperationID = route.Name
+
+		var (
+			extensions map[string]any
+			plugins    map[string]any
+			err        error
+		)
+		if e.loader.Inline {
+			extensions, plugins, err = e.inlineExtensions(&route)
+		} else {
+			extensions, plugins, err = routeExtensions(&route)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Parse Route URIs
+		paramsRefs = parseRouteUris(&route, paths, paramsRefs, pathItem, i+1)
+
+		//Parse Route Plugins
+		paramsRefs = parseRoutePlugins(&route, paramsRefs, &path, secSchemas, requestBody)
+
+		if len(plugins) > 0 {
+			extensions[extensionPrefix+"plugins"] = plugins
+		}
+
+		path.Extensions = extensions
+		path.Parameters = paramsRefs
+		path.RequestBody = &openapi3.RequestBodyRef{Value: requestBody}
+		path.Responses = openapi3.NewResponses()
+
+		routeMethods := allHTTPMethods
+		if len(route.Methods) > 0 {
+			routeMethods = route.Methods
+		}
+
+		for _, method := range routeMethods {
+			method = strings.ToUpper(method)
+			for _, m := range allHTTPMethods {
+				if m == method {
+					pathItem.SetOperation(method, parsePathItem(path, method))
+				}
+			}
+		}
+	}
+
+	return &openapi3.Swagger{
+		ExtensionProps: openapi3.ExtensionProps{Extensions: map[string]any{}},
+		OpenAPI:        openAPIVersion,
+		Info:           &openapi3.Info{Title: exportTitle, Version: openAPIVersion},
+		Paths:          paths,
+		Components:     openapi3.Components{SecuritySchemes: secSchemas},
+	}, nil
+}
+
+// inlineExtensions returns the extensions of a route with the upstream,
+// plugins and labels of its service and upstream copied into it.
+func (e *exporter) inlineExtensions(route *entity.Route) (map[string]any, map[string]any, error) {
+	extensions := make(map[string]any)
+	var service *entity.Service
+	if route.ServiceID != nil {
+		service = e.services[utils.InterfaceToString(route.ServiceID)]
+		if service == nil {
+			return nil, nil, fmt.Errorf(consts.IDNotFound, "service", route.ServiceID)
+		}
+	}
+
+	//Parse upstream
+	upstream, err := e.routeUpstream(route, service)
+	if err != nil {
+		return nil, nil, err
+	} else if upstream != nil {
+		extensions[extensionPrefix+"upstream"] = upstream
+	}
+
+	if route.Host != "" {
+		extensions[extensionPrefix+"host"] = route.Host
+	}
+
+	if route.Hosts != nil {
+		extensions[extensionPrefix+"hosts"] = route.Hosts
+	}
+
+	//Parse Labels
+	if labels := parseLabels(route, service); labels != nil {
+		extensions[extensionPrefix+"labels"] = labels
+	}
+
+	if route.RemoteAddr != "" {
+		extensions[extensionPrefix+"remote_addr"] = route.RemoteAddr
+	}
+
+	if route.RemoteAddrs != nil {
+		extensions[extensionPrefix+"remote_addrs"] = route.RemoteAddrs
+	}
+
+	if route.FilterFunc != "" {
+		extensions[extensionPrefix+"filter_func"] = route.FilterFunc
+	}
+
+	if route.Script != nil {
+		extensions[extensionPrefix+"script"] = route.Script
+	}
+
+	if route.ServiceProtocol != "" {
+		extensions[extensionPrefix+"service_protocol"] = route.ServiceProtocol
+	}
+
+	if route.Vars != nil {
+		extensions[extensionPrefix+"vars"] = route.Vars
+	}
+
+	if route.ID != nil {
+		extensions[extensionPrefix+"id"] = route.ID
+	}
+
+	extensions[extensionPrefix+"priority"] = route.Priority
+	extensions[extensionPrefix+"status"] = route.Status
+	extensions[extensionPrefix+"enable_websocket"] = route.EnableWebsocket
+
+	plugins, err := mergeServicePlugins(route, service)
+	if err != nil {
+		return nil, nil, err
+	}
+	return extensions, plugins, nil
+}
+
+// routeExtensions returns every field of the route as an extension, so the
+// route is imported again as it is, references to other objects included.
+func routeExtensions(route *entity.Route) (map[string]any, map[string]any, error) {
+	bs, err := json.Marshal(route)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(bs, &fields); err != nil {
+		return nil, nil, err
+	}
+	delete(fields, "create_time")
+	delete(fields, "update_time")
+	delete(fields, "plugins")
+
+	extensions := make(map[string]any, len(fields)+1)
+	for k, v := range fields {
+		extensions[extensionPrefix+k] = v
+	}
+	// routes without methods match every method, the operations can not tell
+	methods := route.Methods
+	if methods == nil {
+		methods = []string{}
+	}
+	extensions[extensionPrefix+"methods"] = methods
+
+	return extensions, route.Plugins, nil
+}
+
+// parseLabels When service and route have labels at the same time, use route's label.
+// When route has no label, service sometimes uses service's label. This function is used to process this logic
+func parseLabels(route *entity.Route, service *entity.Service) map[string]string {
+	if route.Labels != nil {
+		return route.Labels
+	} else if service != nil {
+		return service.Labels
+	}
+	return nil
+}
+
+// parsePathItem Convert data in route to openapi3
+func parsePathItem(path openapi3.Operation, routeMethod string) *openapi3.Operation {
+	_path := &openapi3.Operation{
+		ExtensionProps: path.ExtensionProps,
+		Tags:           path.Tags,
+		Summary:        path.Summary,
+		Description:    path.Description,
+		OperationID:    path.OperationID + routeMethod,
+		Parameters:     path.Parameters,
+		RequestBody:    path.RequestBody,
+		Responses:      path.Responses,
+		Callbacks:      path.Callbacks,
+		Deprecated:     path.Deprecated,
+		Security:       path.Security,
+		Servers:        path.Servers,
+		ExternalDocs:   path.ExternalDocs,
+	}
+	return _path
+}
+
+// parseRoutePlugins describes the auth and request-validation plugins of the
+// route with security requirements, parameters and the request body.
+func parseRoutePlugins(route *entity.Route, paramsRefs []*openapi3.ParameterRef, path *openapi3.Operation, secSchemas openapi3.SecuritySchemes, requestBody *openapi3.RequestBody) []*openapi3.ParameterRef {
+	if route.Plugins == nil {
+		return paramsRefs
+	}
+
+	secReq := &openapi3.SecurityRequirements{}
+
+	// analysis plugins
+	for key, value := range route.Plugins {
+		// analysis request-validation plugin
+		if key == "request-validation" {
+			if valueMap, ok := value.(map[string]any); ok {
+				if hsVal, ok := valueMap["header_schema"]; ok {
+					requestValidation := &entity.RequestValidation{}
+					reqBytes, _ := json.Marshal(&hsVal)
+					err := json.Unmarshal(reqBytes, requestValidation)
+					if err != nil {
+						log.Errorf("json marshal failed: %s", err)
+					}
+					properties, _ := requestValidation.Properties.(map[string]any)
+					for key1, value1 := range properties {
+						param := &openapi3.Parameter{In: "header"}
+						for _, arr := range requestValidation.Required {
+							if arr == key1 {
+								param.Required = true
+							}
+						}
+						param.Name = key1
+						typeStr, _ := value1.(map[string]any)
+						typ, _ := typeStr["type"].(string)
+						schema := &openapi3.Schema{Type: typ}
+						param.Schema = &openapi3.SchemaRef{Value: schema}
+						paramsRefs = append(paramsRefs, &openapi3.ParameterRef{Value: param})
+					}
+				}
+
+				if bsVal, ok := valueMap["body_schema"]; ok {
+					m := map[string]*openapi3.MediaType{}
+					reqBytes, _ := json.Marshal(&bsVal)
+					schema := &openapi3.Schema{}
+					err := json.Unmarshal(reqBytes, schema)
+					if err != nil {
+						log.Errorf("json marshal failed: %s", err)
+					}
+					// In the swagger format conversion, there are many cases of content type data format
+					// Such as (application/json, application/xml, text/xml) and more.
+					// There are many matching methods, such as equal, inclusive and so on.
+					// Therefore, the current processing method is to use "*/*" to match all
+					m["*/*"] = &openapi3.MediaType{Schema: &openapi3.SchemaRef{Value: schema}}
+					requestBody.Content = m
+				}
+			}
+			continue
+		}
+		// analysis security plugins
+		securityEnv := &openapi3.SecurityRequirement{}
+		switch key {
+		case keyAuth:
+			secSchemas["api_key"] = &openapi3.SecuritySchemeRef{Value: openapi3.NewCSRFSecurityScheme()}
+			securityEnv.Authenticate("api_key", " ")
+			secReq.With(*securityEnv)
+		case basicAuth:
+			secSchemas["basicAuth"] = &openapi3.SecuritySchemeRef{Value: &openapi3.SecurityScheme{
+				Type: "basicAuth",
+				Name: "basicAuth",
+				In:   "header",
+			}}
+			securityEnv.Authenticate("basicAuth", " ")
+			secReq.With(*securityEnv)
+		case jwtAuth:
+			secSchemas["bearerAuth"] = &openapi3.SecuritySchemeRef{Value: openapi3.NewJWTSecurityScheme()}
+			securityEnv.Authenticate("bearerAuth", " ")
+			secReq.With(*securityEnv)
+		}
+	}
+	path.Security = secReq
+
+	return paramsRefs
+}
+
+// mergeServicePlugins returns the plugins of the route merged with the ones
+// of its service, auth and request-validation plugins are described by the
+// operation instead.
+func mergeServicePlugins(route *entity.Route, service *entity.Service) (map[string]any, error) {
+	var servicePlugins map[string]any
+	if service != nil {
+		servicePlugins = service.Plugins
+	}
+	if route.Plugins == nil {
+		return servicePlugins, nil
+	}
+
+	plugins := make(map[string]any)
+	for key, value := range route.Plugins {
+		switch key {
+		case "request-validation", keyAuth, basicAuth, jwtAuth:
+			continue
+		}
+		plugins[key] = value
+	}
+
+	if service != nil && servicePlugins != nil {
+		_servicePlugins, err := json.Marshal(servicePlugins)
+		if err != nil {
+			log.Errorf("MapToJson err: ", err)
+			return nil, err
+		}
+		_plugins, err := json.Marshal(plugins)
+		if err != nil {
+			log.Errorf("MapToJson err: ", err)
+			return nil, err
+		}
+		bytePlugins, err := utils.MergeJson(_servicePlugins, _plugins)
+		if err != nil {
+			log.Errorf("Plugins MergeJson err: ", err)
+			return nil, err
+		}
+		err = json.Unmarshal(bytePlugins, &plugins)
+		if err != nil {
+			log.Errorf("JsonToMapDemo err: ", err)
+			return nil, err
+		}
+	}
+	return plugins, nil
+}
+
+// parseRouteUris The URI and URIs of route are converted to paths URI in openapi3
+func parseRouteUris(route *entity.Route, paths openapi3.Paths, paramsRefs []*openapi3.ParameterRef, pathItem *openapi3.PathItem, pathNumber int) []*openapi3.ParameterRef {
+	routeURIs := []string{}
+	if route.URI != "" {
+		routeURIs = append(routeURIs, route.URI)
+	}
+
+	if route.Uris != nil {
+		routeURIs = route.Uris
+	}
+
+	for _, uri := range routeURIs {
+		if strings.Contains(uri, "*") {
+			if _, ok := paths[strings.Split(uri, "*")[0]+"{params}"]; !ok {
+				paths[strings.Split(uri, "*")[0]+"{params}"] = pathItem
+			} else {
+				paths[strings.Split(uri, "*")[0]+"{params}"+repeatURISuffix+strconv.Itoa(pathNumber)] = pathItem
+			}
+			// add params introduce
+			paramsRefs = append(paramsRefs, &openapi3.ParameterRef{
+				Value: &openapi3.Parameter{
+					In:          "path",
+					Name:        "params",
+					Required:    true,
+					Description: "params in path",
+					Schema:      &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}}}})
+		} else {
+			if _, ok := paths[uri]; !ok {
+				paths[uri] = pathItem
+			} else {
+				paths[uri+repeatURISuffix+strconv.Itoa(pathNumber)] = pathItem
+			}
+		}
+	}
+	return paramsRefs
+}
+
+// routeUpstream Processing the upstream in service and route
+func (e *exporter) routeUpstream(route *entity.Route, service *entity.Service) (any, error) {
+	// The upstream data of route has the highest priority.
+	// If there is one, it will be used directly.
+	// If there is no route, the upstream data of service will be used.
+	// If there is no route, the upstream data of service will not be used normally.
+	if route.Upstream != nil {
+		return route.Upstream, nil
+	} else if route.UpstreamID != nil {
+		upstream, ok := e.upstreams[utils.InterfaceToString(route.UpstreamID)]
+		if !ok {
+			return nil, fmt.Errorf(consts.IDNotFound, "upstream", route.UpstreamID)
+		}
+		return upstream, nil
+	} else if service != nil {
+		if service.Upstream != nil {
+			return service.Upstream, nil
+		} else if service.UpstreamID != nil {
+			upstream, ok := e.upstreams[utils.InterfaceToString(service.UpstreamID)]
+			if !ok {
+				return nil, fmt.Errorf(consts.IDNotFound, "upstream", service.UpstreamID)
+			}
+			return upstream, nil
+		}
+	}
+	return nil, nil
 }
@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package openapi3
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+)
+
+func TestExportImport(t *testing.T) {
+	var data loader.DataSets
+	err := json.Unmarshal([]byte(`{
+		"Routes": [{
+			"id": "r1",
+			"name": "r1",
+			"desc": "route with references",
+			"uris": ["/r1", "/r1/*"],
+			"methods": ["GET", "POST"],
+			"hosts": ["foo.com"],
+			"priority": 10,
+			"status": 1,
+			"labels": {"env": "prod"},
+			"service_id": "s1",
+			"upstream_id": "u1",
+			"plugins": {
+				"key-auth": {"header": "apikey"},
+				"limit-count": {"count": 2, "time_window": 60, "rejected_code": 503},
+				"request-validation": {
+					"header_schema": {"type": "object", "properties": {"x-id": {"type": "string"}}, "required": ["x-id"]},
+					"body_schema": {"type": "object", "properties": {"name": {"type": "string"}}}
+				}
+			}
+		}, {
+			"id": "r2",
+			"name": "r2",
+			"uri": "/r1",
+			"upstream": {"type": "roundrobin", "nodes": [{"host": "127.0.0.1", "port": 1980, "weight": 1}]}
+		}],
+		"Services": [{"id": "s1", "name": "s1", "upstream_id": "u2", "plugins": {"prometheus": {}}}],
+		"Upstreams": [
+			{"id": "u1", "name": "u1", "type": "roundrobin", "nodes": [{"host": "10.0.0.1", "port": 80, "weight": 1}]},
+			{"id": "u2", "name": "u2", "type": "chash", "hash_on": "header", "key": "x-id", "nodes": [{"host": "10.0.0.2", "port": 80, "weight": 1}]}
+		]
+	}`), &data)
+	assert.Nil(t, err)
+
+	doc, err := Loader{}.Export(data)
+	assert.Nil(t, err)
+	raw, err := json.Marshal(doc)
+	assert.Nil(t, err)
+
+	for _, merge := range []bool{false, true} {
+		imported, err := Loader{MergeMethod: merge, TaskName: "test"}.Import(raw)
+		assert.Nil(t, err)
+
+		assert.Equal(t, toMaps(t, data.Upstreams), toMaps(t, imported.Upstreams))
+		assert.Equal(t, toMaps(t, data.Services), toMaps(t, imported.Services))
+		assert.ElementsMatch(t, toMaps(t, data.Routes), toMaps(t, imported.Routes))
+	}
+}
+
+func TestExportInline(t *testing.T) {
+	data := loader.DataSets{
+		Routes: []entity.Route{{
+			BaseInfo:  entity.BaseInfo{ID: "r1"},
+			URI:       "/hello",
+			Methods:   []string{"GET"},
+			ServiceID: "s1",
+			Plugins:   map[string]any{"jwt-auth": map[string]any{}},
+		}},
+		Services: []entity.Service{{
+			BaseInfo:   entity.BaseInfo{ID: "s1"},
+			UpstreamID: "u1",
+			Labels:     map[string]string{"env": "prod"},
+			Plugins:    map[string]any{"prometheus": map[string]any{}},
+		}},
+		Upstreams: []entity.Upstream{{
+			BaseInfo:    entity.BaseInfo{ID: "u1"},
+			UpstreamDef: entity.UpstreamDef{Type: "roundrobin"},
+		}},
+	}
+
+	doc, err := Loader{Inline: true}.Export(data)
+	assert.Nil(t, err)
+	raw, err := json.Marshal(doc)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"components": {
+			"securitySchemes": {
+				"bearerAuth": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}
+			}
+		},
+		"info": {"title": "RoutesExport", "version": "3.0.0"},
+		"openapi": "3.0.0",
+		"paths": {
+			"/hello": {
+				"get": {
+					"operationId": "GET",
+					"requestBody": {},
+					"responses": {"default": {"description": ""}},
+					"security": [{"bearerAuth": [" "]}],
+					"x-apisix-enable_websocket": false,
+					"x-apisix-id": "r1",
+					"x-apisix-labels": {"env": "prod"},
+					"x-apisix-plugins": {"prometheus": {}},
+					"x-apisix-priority": 0,
+					"x-apisix-status": 0,
+					"x-apisix-upstream": {"id": "u1", "type": "roundrobin"}
+				}
+			}
+		}
+	}`, string(raw))
+
+	// a referenced service has to be part of the data sets
+	data.Services = nil
+	_, err = Loader{Inline: true}.Export(data)
+	assert.EqualError(t, err, "service id: s1 not found")
+}
+
+func toMaps(t *testing.T, v any) []map[string]any {
+	bs, err := json.Marshal(v)
+	assert.Nil(t, err)
+	var ret []map[string]any
+	assert.Nil(t, json.Unmarshal(bs, &ret))
+	return ret
+}
@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package openapi3
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+const extensionPrefix = "x-apisix-"
+
+// extensionAliases maps the extension names used by older exports to route fields
+var extensionAliases = map[string]string{
+	"enableWebsocket": "enable_websocket",
+}
+
+// extensions returns the raw x-apisix-* extensions keyed by route field.
+func extensions(props openapi3.ExtensionProps) (map[string]json.RawMessage, error) {
+	ret := make(map[string]json.RawMessage)
+	for k, v := range props.Extensions {
+		if !strings.HasPrefix(k, extensionPrefix) {
+			continue
+		}
+
+		key := strings.TrimPrefix(k, extensionPrefix)
+		if alias, ok := extensionAliases[key]; ok {
+			key = alias
+		}
+
+		raw, ok := v.(json.RawMessage)
+		if !ok {
+			bs, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", k, err)
+			}
+			raw = bs
+		}
+		ret[key] = raw
+	}
+	return ret, nil
+}
+
+// applyExtensions sets the route fields from the extensions. Plugins and
+// labels are merged with the ones already on the route, an upstream replaces
+// the upstream reference, and the route ID is only taken from operations.
+func applyExtensions(route *entity.Route, ext map[string]json.RawMessage, where string, operation bool) error {
+	keys := make([]string, 0, len(ext))
+	for k := range ext {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		raw := ext[key]
+
+		var err error
+		switch key {
+		case "plugins":
+			plugins := make(map[string]any)
+			if err = json.Unmarshal(raw, &plugins); err == nil {
+				for name, conf := range plugins {
+					route.Plugins[name] = conf
+				}
+			}
+		case "labels":
+			labels := make(map[string]string)
+			if err = json.Unmarshal(raw, &labels); err == nil && len(labels) > 0 {
+				if route.Labels == nil {
+					route.Labels = make(map[string]string)
+				}
+				for k, v := range labels {
+					route.Labels[k] = v
+				}
+			}
+		case "upstream":
+			upstream := &entity.UpstreamDef{}
+			if err = json.Unmarshal(raw, upstream); err == nil {
+				route.Upstream = upstream
+				route.UpstreamID = nil
+			}
+		case "id":
+			if operation {
+				err = json.Unmarshal(raw, &route.ID)
+			}
+		case "uri", "uris":
+			// a route has either uri or uris
+			route.URI, route.Uris = "", nil
+			fallthrough
+		default:
+			var bs []byte
+			if bs, err = json.Marshal(map[string]json.RawMessage{key: raw}); err == nil {
+				err = json.Unmarshal(bs, route)
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid %s%s on %s: %s", extensionPrefix, key, where, err)
+		}
+	}
+	return nil
+}
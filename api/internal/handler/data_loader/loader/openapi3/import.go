@@ -17,8 +17,10 @@
 package openapi3
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 
 	"github.com/apisix/manager-api/internal/core/entity"
 	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/utils"
 	"github.com/apisix/manager-api/internal/utils/consts"
 )
 
@@ -46,6 +49,12 @@ func (o Loader) Import(input any) (*loader.DataSets, error) {
 		return nil, err
 	}
 
+	return o.ImportDocument(swagger)
+}
+
+// ImportDocument converts a loaded OAS3 document into entity data sets, it
+// is shared with the loaders of formats which are converted to OAS3 first.
+func (o Loader) ImportDocument(swagger *openapi3.Swagger) (*loader.DataSets, error) {
 	// no paths in OAS3 document
 	if len(swagger.Paths) <= 0 {
 		return nil, errors.Wrap(errors.New("OpenAPI documentation does not contain any paths"), consts.ErrImportFile.Error())
@@ -68,58 +77,252 @@ func (o Loader) convertToEntities(s *openapi3.Swagger) (*loader.DataSets, error)
 		// temporarily save the parsed data
 		data = &loader.DataSets{}
 		// global upstream ID
-		globalUpstreamID = o.TaskName
-		// global uri prefix
-		globalPath = ""
+		globalUpstreamID any
 	)
 
-	// create upstream when servers field not empty
-	if len(s.Servers) > 0 {
-		upstream := entity.Upstream{
-			BaseInfo: entity.BaseInfo{ID: globalUpstreamID},
-			UpstreamDef: entity.UpstreamDef{
-				Name: globalUpstreamID,
-				Type: "roundrobin",
-				Nodes: map[string]float64{
-					"0.0.0.0": 1,
-				},
-			},
+	docExt, err := extensions(s.ExtensionProps)
+	if err != nil {
+		return nil, err
+	}
+
+	// services and upstreams exported with the routes
+	if raw, ok := docExt["services"]; ok {
+		if err := json.Unmarshal(raw, &data.Services); err != nil {
+			return nil, fmt.Errorf("invalid %sservices on document: %s", extensionPrefix, err)
+		}
+		delete(docExt, "services")
+	}
+	if raw, ok := docExt["upstreams"]; ok {
+		if err := json.Unmarshal(raw, &data.Upstreams); err != nil {
+			return nil, fmt.Errorf("invalid %supstreams on document: %s", extensionPrefix, err)
+		}
+		delete(docExt, "upstreams")
+	}
+
+	// create upstream from the servers field, x-apisix-upstream on the document overrides it
+	globalUpstream, globalPath := upstreamFromServers(s.Servers)
+	if raw, ok := docExt["upstream"]; ok {
+		if globalUpstream == nil {
+			globalUpstream = &entity.UpstreamDef{Type: "roundrobin"}
 		}
-		data.Upstreams = append(data.Upstreams, upstream)
+		if err := json.Unmarshal(raw, globalUpstream); err != nil {
+			return nil, fmt.Errorf("invalid %supstream on document: %s", extensionPrefix, err)
+		}
+		delete(docExt, "upstream")
+	}
+	if globalUpstream != nil {
+		globalUpstream.Name = o.TaskName
+		data.Upstreams = append(data.Upstreams, entity.Upstream{
+			BaseInfo:    entity.BaseInfo{ID: o.TaskName},
+			UpstreamDef: *globalUpstream,
+		})
+		globalUpstreamID = o.TaskName
 	}
 
+	// routes exported with several uris or methods are imported once
+	routeIndex := make(map[string]int)
+	addRoute := func(route entity.Route) {
+		if route.ID == nil {
+			data.Routes = append(data.Routes, route)
+			return
+		}
+		id := utils.InterfaceToString(route.ID)
+		i, ok := routeIndex[id]
+		if !ok {
+			routeIndex[id] = len(data.Routes)
+			data.Routes = append(data.Routes, route)
+			return
+		}
+		data.Routes[i].Uris = appendMissing(data.Routes[i].Uris, route.Uris...)
+		if len(data.Routes[i].Methods) > 0 {
+			data.Routes[i].Methods = appendMissing(data.Routes[i].Methods, route.Methods...)
+		}
+	}
+
+	uris := make([]string, 0, len(s.Paths))
+	for uri := range s.Paths {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
 	// each one will correspond to a route
-	for uri, v := range s.Paths {
-		// replace parameter in uri to wildcard
-		realUri := regURIVar.ReplaceAllString(uri, "*")
+	for _, uri := range uris {
+		v := s.Paths[uri]
 		// generate route Name
 		routeName := o.TaskName + "_" + strings.TrimPrefix(uri, "/")
 
+		operations := v.Operations()
+		methods := make([]string, 0, len(operations))
+		for method := range operations {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		b := &routeBuilder{
+			swagger:    s,
+			uri:        uri,
+			item:       v,
+			docExt:     docExt,
+			upstreamID: globalUpstreamID,
+			basePath:   globalPath,
+		}
+
 		// decide whether to merge multi-method routes based on configuration
 		if o.MergeMethod {
-			// create a single route for each path, merge all methods
-			route := generateBaseRoute(routeName, v.Summary)
-			route.Uris = []string{globalPath + realUri}
-			route.UpstreamID = globalUpstreamID
-			for method := range v.Operations() {
-				route.Methods = append(route.Methods, strings.ToUpper(method))
+			// create a single route for each path, merge the methods with the
+			// same request schema
+			groups := b.validationGroups(methods)
+			for _, group := range groups {
+				name := routeName
+				if len(groups) > 1 {
+					name += "_" + strings.Join(group, "_")
+				}
+				route, err := b.build(name, v.Summary, group)
+				if err != nil {
+					return nil, err
+				}
+				addRoute(route)
 			}
-			data.Routes = append(data.Routes, route)
 		} else {
 			// create routes for each method of each path
-			for method, operation := range v.Operations() {
+			for _, method := range methods {
 				subRouteID := routeName + "_" + method
-				route := generateBaseRoute(subRouteID, operation.Summary)
-				route.Uris = []string{globalPath + realUri}
-				route.Methods = []string{strings.ToUpper(method)}
-				route.UpstreamID = globalUpstreamID
-				data.Routes = append(data.Routes, route)
+				route, err := b.build(subRouteID, operations[method].Summary, []string{method})
+				if err != nil {
+					return nil, err
+				}
+				addRoute(route)
 			}
 		}
 	}
 	return data, nil
 }
 
+// routeBuilder converts the operations of one path into a route.
+type routeBuilder struct {
+	swagger    *openapi3.Swagger
+	uri        string
+	item       *openapi3.PathItem
+	docExt     map[string]json.RawMessage
+	upstreamID any
+	basePath   string
+}
+
+// validationGroups groups the methods by the request-validation of their
+// operations, a route has one request-validation so methods with different
+// request schemas can't share a route.
+func (b *routeBuilder) validationGroups(methods []string) [][]string {
+	var (
+		groups [][]string
+		confs  []map[string]any
+	)
+	for _, method := range methods {
+		conf := requestValidation(b.item.Parameters, b.item.GetOperation(method))
+		found := false
+		for i := range groups {
+			if reflect.DeepEqual(confs[i], conf) {
+				groups[i] = append(groups[i], method)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, []string{method})
+			confs = append(confs, conf)
+		}
+	}
+	return groups
+}
+
+// build creates the route of the given methods. Settings are applied from
+// the least to the most specific level: servers, security schemes and
+// parameters first, then the x-apisix extensions of the document, the path
+// and the operations. When methods are merged, the operations are applied in
+// method order and the later ones win, except request-validation which is the
+// same for all of them.
+func (b *routeBuilder) build(name, desc string, methods []string) (entity.Route, error) {
+	route := generateBaseRoute(name, desc)
+	route.Methods = methods
+
+	operations := make([]*openapi3.Operation, 0, len(methods))
+	for _, method := range methods {
+		operations = append(operations, b.item.GetOperation(method))
+	}
+
+	// servers declared on the path or on an operation get an upstream of their own
+	servers := b.item.Servers
+	for _, op := range operations {
+		if op.Servers != nil && len(*op.Servers) > 0 {
+			servers = *op.Servers
+		}
+	}
+	basePath := b.basePath
+	if len(servers) > 0 {
+		var upstream *entity.UpstreamDef
+		upstream, basePath = upstreamFromServers(servers)
+		route.Upstream = upstream
+	}
+	if route.Upstream == nil {
+		route.UpstreamID = b.upstreamID
+	}
+
+	// replace parameter in uri to wildcard, paths exported more than once have a suffix
+	uri := regRepeatURI.ReplaceAllString(b.uri, "")
+	route.Uris = []string{basePath + regURIVar.ReplaceAllString(uri, "*")}
+
+	for _, op := range operations {
+		security := b.swagger.Security
+		if op.Security != nil {
+			security = *op.Security
+		}
+		for k, v := range securityPlugins(b.swagger, security) {
+			route.Plugins[k] = v
+		}
+		if conf := requestValidation(b.item.Parameters, op); conf != nil {
+			route.Plugins["request-validation"] = conf
+		}
+	}
+
+	where := "path " + b.uri
+	if err := applyExtensions(&route, b.docExt, "document", false); err != nil {
+		return route, err
+	}
+	pathExt, err := extensions(b.item.ExtensionProps)
+	if err != nil {
+		return route, err
+	}
+	if err := applyExtensions(&route, pathExt, where, false); err != nil {
+		return route, err
+	}
+	for i, op := range operations {
+		opExt, err := extensions(op.ExtensionProps)
+		if err != nil {
+			return route, err
+		}
+		if err := applyExtensions(&route, opExt, where+" "+methods[i], true); err != nil {
+			return route, err
+		}
+	}
+
+	return route, nil
+}
+
+func appendMissing(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, v := range list {
+			if v == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // Generate a base route for customize
 func generateBaseRoute(name string, desc string) entity.Route {
 	return entity.Route{
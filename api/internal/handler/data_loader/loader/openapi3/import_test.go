@@ -26,7 +26,8 @@ import (
 )
 
 var (
-	TestAPI101 = "../../../../../test/testdata/import/Postman-API101.yaml"
+	TestAPI101     = "../../../../../test/testdata/import/Postman-API101.yaml"
+	TestExtensions = "../../../../../test/testdata/import/openapi3-extensions.yaml"
 )
 
 // Test API 101 on no MergeMethod mode
@@ -89,7 +90,8 @@ func TestParseAPI101Merge(t *testing.T) {
 	data, err := l.Import(fileContent)
 	assert.NoError(t, err)
 
-	assert.Len(t, data.Routes, 3)
+	// the methods with a request body have a schema of their own
+	assert.Len(t, data.Routes, 5)
 	assert.Len(t, data.Upstreams, 1)
 
 	// Upstream
@@ -99,21 +101,207 @@ func TestParseAPI101Merge(t *testing.T) {
 	// Route
 	assert.Equal(t, data.Upstreams[0].ID, data.Routes[0].UpstreamID)
 	for _, route := range data.Routes {
+		assert.Equal(t, entity.Status(0), route.Status)
 		switch route.Name {
-		case "test_customer":
-			assert.Contains(t, route.Uris, "/customer")
-			assert.Contains(t, route.Methods, "GET", "GET")
-			assert.Equal(t, entity.Status(0), route.Status)
+		case "test_customer_GET":
+			assert.Equal(t, []string{"/customer"}, route.Uris)
+			assert.Equal(t, []string{"GET"}, route.Methods)
+		case "test_customer_POST":
+			assert.Equal(t, []string{"/customer"}, route.Uris)
+			assert.Equal(t, []string{"POST"}, route.Methods)
 		case "test_customers":
-			assert.Contains(t, route.Uris, "/customers")
-			assert.Contains(t, route.Methods, "GET")
-			assert.Equal(t, entity.Status(0), route.Status)
-		case "test_customer/{customer_id}":
-			assert.Contains(t, route.Uris, "/customer/*")
-			assert.Contains(t, route.Methods, "PUT", "DELETE")
-			assert.Equal(t, entity.Status(0), route.Status)
+			assert.Equal(t, []string{"/customers"}, route.Uris)
+			assert.Equal(t, []string{"GET"}, route.Methods)
+		case "test_customer/{customer_id}_DELETE":
+			assert.Equal(t, []string{"/customer/*"}, route.Uris)
+			assert.Equal(t, []string{"DELETE"}, route.Methods)
+		case "test_customer/{customer_id}_PUT":
+			assert.Equal(t, []string{"/customer/*"}, route.Uris)
+			assert.Equal(t, []string{"PUT"}, route.Methods)
 		default:
 			t.Fatal("bad route name exist")
 		}
 	}
 }
+
+// Test servers, security schemes, parameters and x-apisix extensions
+func TestParseExtensions(t *testing.T) {
+	fileContent, err := ioutil.ReadFile(TestExtensions)
+	assert.NoError(t, err)
+
+	l := &Loader{MergeMethod: false, TaskName: "test"}
+	data, err := l.Import(fileContent)
+	assert.NoError(t, err)
+
+	assert.Len(t, data.Routes, 4)
+	assert.Len(t, data.Upstreams, 1)
+
+	// Upstream
+	upstream := data.Upstreams[0]
+	assert.Equal(t, "test", upstream.Name)
+	assert.Equal(t, "chash", upstream.Type)
+	assert.Equal(t, "header", upstream.HashOn)
+	assert.Equal(t, "x-pet-id", upstream.Key)
+	assert.Equal(t, "https", upstream.Scheme)
+	assert.Equal(t, "node", upstream.PassHost)
+	assert.Equal(t, []*entity.Node{
+		{Host: "eu.petstore.example.com", Port: 8443, Weight: 1},
+		{Host: "10.0.0.2", Port: 8443, Weight: 1},
+	}, upstream.Nodes)
+
+	routes := make(map[string]entity.Route)
+	for _, route := range data.Routes {
+		assert.Equal(t, "pets", route.Labels["team"])
+		routes[route.Name] = route
+	}
+
+	route := routes["test_pets_GET"]
+	assert.Equal(t, []string{"/v1/pets"}, route.Uris)
+	assert.Equal(t, "test", route.UpstreamID)
+	assert.Equal(t, 10, route.Priority)
+	assert.Equal(t, []string{"petstore.example.com"}, route.Hosts)
+	assert.Equal(t, map[string]any{"header": "X-API-Key"}, route.Plugins["key-auth"])
+	assert.Equal(t, map[string]any{
+		"header_schema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"x-request-id": map[string]any{"type": "string"},
+			},
+		},
+	}, route.Plugins["request-validation"])
+
+	route = routes["test_pets_POST"]
+	assert.Equal(t, map[string]string{"team": "pets", "stage": "beta"}, route.Labels)
+	assert.Nil(t, route.Plugins["key-auth"])
+	assert.Equal(t, map[string]any{}, route.Plugins["basic-auth"])
+	assert.NotNil(t, route.Plugins["limit-count"])
+	conf := route.Plugins["request-validation"].(map[string]any)
+	assert.Equal(t, []string{"x-tenant"}, conf["header_schema"].(map[string]any)["required"])
+	body := conf["body_schema"].(map[string]any)
+	assert.Equal(t, "object", body["type"])
+	assert.Equal(t, []any{"name"}, body["required"])
+	props := body["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string"}, props["name"])
+	assert.Equal(t, map[string]any{"type": "string"}, props["tag"])
+	// the recursive reference is cut
+	assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{}}, props["children"])
+
+	route = routes["test_pets/{petId}_GET"]
+	assert.Equal(t, []string{"/api/pets/*"}, route.Uris)
+	assert.Nil(t, route.UpstreamID)
+	assert.Equal(t, "http", route.Upstream.Scheme)
+	assert.Equal(t, []*entity.Node{{Host: "pets-internal", Port: 8080, Weight: 1}}, route.Upstream.Nodes)
+	assert.Equal(t, map[string]any{}, route.Plugins["jwt-auth"])
+	assert.Equal(t, map[string]any{
+		"discovery":     "https://auth.example.com/.well-known/openid-configuration",
+		"client_id":     "",
+		"client_secret": "",
+		"bearer_only":   true,
+	}, route.Plugins["openid-connect"])
+
+	route = routes["test_pets/{petId}_DELETE"]
+	assert.Equal(t, "delete-pet", route.ID)
+	assert.Equal(t, entity.Status(1), route.Status)
+	assert.Len(t, route.Plugins, 0)
+	assert.Equal(t, []any{map[string]any{"host": "127.0.0.1", "port": float64(1980), "weight": float64(1)}}, route.Upstream.Nodes)
+}
+
+// Test invalid x-apisix extensions are reported
+func TestParseInvalidExtension(t *testing.T) {
+	doc := `
+openapi: 3.0.0
+info:
+  title: invalid
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      x-apisix-priority: high
+      responses:
+        '200':
+          description: ok
+`
+	l := &Loader{TaskName: "test"}
+	_, err := l.Import([]byte(doc))
+	assert.EqualError(t, err, "invalid x-apisix-priority on path /pets GET: json: cannot unmarshal string into Go struct field Route.priority of type int")
+}
+
+// Test methods with different request schemas are not merged
+func TestParseMergeValidation(t *testing.T) {
+	doc := `
+openapi: 3.0.0
+info:
+  title: merge
+  version: 1.0.0
+paths:
+  /items:
+    get:
+      responses:
+        '200':
+          description: ok
+    delete:
+      responses:
+        '200':
+          description: ok
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+      responses:
+        '200':
+          description: ok
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [id]
+      responses:
+        '200':
+          description: ok
+  /tags:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: string
+      responses:
+        '200':
+          description: ok
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: string
+      responses:
+        '200':
+          description: ok
+`
+	l := &Loader{MergeMethod: true, TaskName: "test"}
+	data, err := l.Import([]byte(doc))
+	assert.NoError(t, err)
+
+	routes := make(map[string]entity.Route)
+	for _, route := range data.Routes {
+		routes[route.Name] = route
+	}
+	assert.Len(t, routes, 4)
+	assert.Equal(t, []string{"DELETE", "GET"}, routes["test_items_DELETE_GET"].Methods)
+	assert.Nil(t, routes["test_items_DELETE_GET"].Plugins["request-validation"])
+	assert.Equal(t, map[string]any{
+		"body_schema": map[string]any{"type": "object", "required": []any{"name"}},
+	}, routes["test_items_POST"].Plugins["request-validation"])
+	assert.Equal(t, map[string]any{
+		"body_schema": map[string]any{"type": "object", "required": []any{"id"}},
+	}, routes["test_items_PUT"].Plugins["request-validation"])
+	assert.Equal(t, []string{"POST", "PUT"}, routes["test_tags"].Methods)
+	assert.Equal(t, map[string]any{
+		"body_schema": map[string]any{"type": "string"},
+	}, routes["test_tags"].Plugins["request-validation"])
+}
@@ -29,6 +29,11 @@ type Loader struct {
 	MergeMethod bool
 	// TaskName indicates the name of current import/export task
 	TaskName string
+	// Inline indicates whether to copy the upstream, plugins and labels of the
+	// referenced service and upstream into each exported route, so that the
+	// document does not depend on other objects. Otherwise routes keep their
+	// references and the services and upstreams are exported with the document.
+	Inline bool
 }
 
 type PathValue struct {
@@ -37,5 +42,6 @@ type PathValue struct {
 }
 
 var (
-	regURIVar = regexp.MustCompile(`{.*?}`)
+	regURIVar    = regexp.MustCompile(`{.*?}`)
+	regRepeatURI = regexp.MustCompile(repeatURISuffix + `\d+$`)
 )
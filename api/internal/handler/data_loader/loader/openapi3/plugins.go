@@ -0,0 +1,225 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package openapi3
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// openapiKeywords are the schema keywords of OpenAPI which are not part of
+// JSON Schema, they are dropped from the schemas given to request-validation.
+var openapiKeywords = []string{
+	"nullable", "readOnly", "writeOnly", "example", "deprecated", "xml", "externalDocs", "discriminator",
+}
+
+// securityPlugins returns the auth plugins of the security requirements.
+// Only the first requirement is used since APISIX has no alternative between
+// auth plugins, the schemes of that requirement are all enabled.
+func securityPlugins(s *openapi3.Swagger, requirements openapi3.SecurityRequirements) map[string]any {
+	plugins := make(map[string]any)
+	if len(requirements) == 0 {
+		return plugins
+	}
+
+	names := make([]string, 0, len(requirements[0]))
+	for name := range requirements[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ref := s.Components.SecuritySchemes[name]
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		if plugin, conf := authPlugin(ref.Value); plugin != "" {
+			plugins[plugin] = conf
+		}
+	}
+	return plugins
+}
+
+// authPlugin maps a security scheme to the auth plugin checking it, schemes
+// without an equivalent plugin return an empty name.
+func authPlugin(scheme *openapi3.SecurityScheme) (string, map[string]any) {
+	switch scheme.Type {
+	case "apiKey":
+		switch scheme.In {
+		case "header", "query":
+			return "key-auth", map[string]any{scheme.In: scheme.Name}
+		}
+	case "http":
+		switch strings.ToLower(scheme.Scheme) {
+		case "basic":
+			return "basic-auth", map[string]any{}
+		case "bearer":
+			return "jwt-auth", map[string]any{}
+		}
+	case "openIdConnect":
+		// the client credentials are not part of the document and have to be filled in after import
+		discovery := ""
+		if raw, ok := scheme.Extensions["openIdConnectUrl"].(json.RawMessage); ok {
+			_ = json.Unmarshal(raw, &discovery)
+		}
+		return "openid-connect", map[string]any{
+			"discovery":     discovery,
+			"client_id":     "",
+			"client_secret": "",
+			"bearer_only":   true,
+		}
+	}
+	return "", nil
+}
+
+// requestValidation returns the request-validation config checking the
+// header parameters and the request body of the operation, or nil when there
+// is nothing to check. Operation parameters override path parameters.
+func requestValidation(pathParams openapi3.Parameters, op *openapi3.Operation) map[string]any {
+	params := make(map[string]*openapi3.Parameter)
+	var names []string
+	for _, refs := range []openapi3.Parameters{pathParams, op.Parameters} {
+		for _, ref := range refs {
+			if ref == nil || ref.Value == nil || ref.Value.In != openapi3.ParameterInHeader {
+				continue
+			}
+			// nginx exposes header names in lower case
+			name := strings.ToLower(ref.Value.Name)
+			if _, ok := params[name]; !ok {
+				names = append(names, name)
+			}
+			params[name] = ref.Value
+		}
+	}
+	sort.Strings(names)
+
+	conf := make(map[string]any)
+	if len(names) > 0 {
+		properties := make(map[string]any)
+		required := make([]string, 0)
+		for _, name := range names {
+			param := params[name]
+			properties[name] = inlineSchema(paramSchema(param), nil)
+			if param.Required {
+				required = append(required, name)
+			}
+		}
+		header := map[string]any{
+			"type":       "object",
+			"properties": properties,
+		}
+		if len(required) > 0 {
+			header["required"] = required
+		}
+		conf["header_schema"] = header
+	}
+
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		if schema := bodySchema(op.RequestBody.Value.Content); schema != nil {
+			conf["body_schema"] = inlineSchema(schema, nil)
+		}
+	}
+
+	if len(conf) == 0 {
+		return nil
+	}
+	return conf
+}
+
+func paramSchema(param *openapi3.Parameter) *openapi3.SchemaRef {
+	if param.Schema != nil {
+		return param.Schema
+	}
+	return bodySchema(param.Content)
+}
+
+// bodySchema returns the JSON schema of the content, or of the first media
+// type with a schema when there is no JSON content.
+func bodySchema(content openapi3.Content) *openapi3.SchemaRef {
+	if mt := content.Get("application/json"); mt != nil && mt.Schema != nil {
+		return mt.Schema
+	}
+
+	types := make([]string, 0, len(content))
+	for k := range content {
+		types = append(types, k)
+	}
+	sort.Strings(types)
+	for _, k := range types {
+		if mt := content[k]; mt != nil && mt.Schema != nil {
+			return mt.Schema
+		}
+	}
+	return nil
+}
+
+// inlineSchema converts the schema into a JSON schema with all references
+// resolved, recursive references are replaced by an empty schema.
+func inlineSchema(ref *openapi3.SchemaRef, seen map[*openapi3.Schema]bool) map[string]any {
+	ret := make(map[string]any)
+	if ref == nil || ref.Value == nil || seen[ref.Value] {
+		return ret
+	}
+	if seen == nil {
+		seen = make(map[*openapi3.Schema]bool)
+	}
+	seen[ref.Value] = true
+	defer delete(seen, ref.Value)
+
+	s := *ref.Value
+	properties, items, not, additional := s.Properties, s.Items, s.Not, s.AdditionalProperties
+	subSchemas := map[string]openapi3.SchemaRefs{"allOf": s.AllOf, "anyOf": s.AnyOf, "oneOf": s.OneOf}
+	s.Properties, s.Items, s.Not, s.AdditionalProperties = nil, nil, nil, nil
+	s.AllOf, s.AnyOf, s.OneOf = nil, nil, nil
+
+	bs, _ := json.Marshal(&s)
+	_ = json.Unmarshal(bs, &ret)
+	for _, k := range openapiKeywords {
+		delete(ret, k)
+	}
+
+	if len(properties) > 0 {
+		props := make(map[string]any, len(properties))
+		for k, v := range properties {
+			props[k] = inlineSchema(v, seen)
+		}
+		ret["properties"] = props
+	}
+	if items != nil {
+		ret["items"] = inlineSchema(items, seen)
+	}
+	if not != nil {
+		ret["not"] = inlineSchema(not, seen)
+	}
+	if additional != nil {
+		ret["additionalProperties"] = inlineSchema(additional, seen)
+	}
+	for k, refs := range subSchemas {
+		if len(refs) == 0 {
+			continue
+		}
+		list := make([]any, 0, len(refs))
+		for _, v := range refs {
+			list = append(list, inlineSchema(v, seen))
+		}
+		ret[k] = list
+	}
+	return ret
+}
@@ -0,0 +1,141 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package openapi3
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+var (
+	regServerVar = regexp.MustCompile(`{([^{}]*)}`)
+
+	defaultPorts = map[string]int{
+		"http":  80,
+		"https": 443,
+	}
+)
+
+// upstreamFromServers creates an upstream with a node for each server and
+// returns it with the base path of the first usable server. An upstream has a
+// single scheme, so servers with another scheme than the first one are left
+// out, as are servers whose URL can not be resolved. The upstream is nil when
+// no server has a host.
+func upstreamFromServers(servers openapi3.Servers) (*entity.UpstreamDef, string) {
+	var (
+		upstream *entity.UpstreamDef
+		nodes    []*entity.Node
+		basePath string
+		found    bool
+	)
+
+	for _, server := range servers {
+		u, ok := serverURL(server)
+		if !ok {
+			continue
+		}
+		if !found {
+			basePath = strings.TrimRight(u.Path, "/")
+			found = true
+		}
+
+		// relative servers only contribute a base path
+		if u.Host == "" {
+			continue
+		}
+
+		port := defaultPorts[u.Scheme]
+		if p := u.Port(); p != "" {
+			port, _ = strconv.Atoi(p)
+		}
+		if port <= 0 {
+			continue
+		}
+
+		if upstream == nil {
+			upstream = &entity.UpstreamDef{
+				Type:   "roundrobin",
+				Scheme: u.Scheme,
+			}
+		} else if upstream.Scheme != u.Scheme {
+			continue
+		}
+
+		host := u.Hostname()
+		if net.ParseIP(host) == nil {
+			// backends behind a domain usually rely on the Host header
+			upstream.PassHost = "node"
+		}
+		if !containsNode(nodes, host, port) {
+			nodes = append(nodes, &entity.Node{Host: host, Port: port, Weight: 1})
+		}
+	}
+
+	if upstream != nil {
+		upstream.Nodes = nodes
+	}
+	return upstream, basePath
+}
+
+// serverURL resolves the variables of the server URL with their default
+// values, servers using an undefined variable are not usable.
+func serverURL(server *openapi3.Server) (*url.URL, bool) {
+	if server == nil {
+		return nil, false
+	}
+
+	resolved := true
+	raw := regServerVar.ReplaceAllStringFunc(server.URL, func(m string) string {
+		v, ok := server.Variables[m[1:len(m)-1]]
+		if !ok || v == nil || v.Default == nil {
+			resolved = false
+			return m
+		}
+		return fmt.Sprint(v.Default)
+	})
+	if !resolved {
+		return nil, false
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, false
+	}
+	if u.Host != "" {
+		if _, ok := defaultPorts[u.Scheme]; !ok && u.Port() == "" {
+			return nil, false
+		}
+	}
+	return u, true
+}
+
+func containsNode(nodes []*entity.Node, host string, port int) bool {
+	for _, node := range nodes {
+		if node.Host == host && node.Port == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/httpreq"
+	"github.com/apisix/manager-api/internal/utils/consts"
+)
+
+// Loader imports Postman v2.0 and v2.1 collections, the requests of a
+// folder are grouped into one service.
+type Loader struct {
+	// MergeMethod indicates whether to merge routes when multiple HTTP methods are on the same path
+	MergeMethod bool
+	// TaskName indicates the name of current import/export task
+	TaskName string
+}
+
+type collection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []item     `json:"item"`
+	Variable []variable `json:"variable"`
+}
+
+// item is a folder when it has no request
+type item struct {
+	Name    string          `json:"name"`
+	Item    []item          `json:"item"`
+	Request json.RawMessage `json:"request"`
+}
+
+// request is either an object or the URL of a GET request
+type request struct {
+	Method string          `json:"method"`
+	URL    json.RawMessage `json:"url"`
+}
+
+// requestURL is either an object or the raw URL
+type requestURL struct {
+	Raw      string          `json:"raw"`
+	Protocol string          `json:"protocol"`
+	Host     json.RawMessage `json:"host"`
+	Port     string          `json:"port"`
+	Path     json.RawMessage `json:"path"`
+}
+
+type variable struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+func (o Loader) Import(input any) (*loader.DataSets, error) {
+	if input == nil {
+		panic("input is nil")
+	}
+
+	d, ok := input.([]byte)
+	if !ok {
+		panic(fmt.Sprintf("input format error: expected []byte but it is %s", reflect.TypeOf(input).Kind().String()))
+	}
+
+	c := &collection{}
+	if err := json.Unmarshal(d, c); err != nil {
+		return nil, err
+	}
+	if !strings.Contains(c.Info.Schema, "/collection/v2.") {
+		return nil, errors.Wrap(fmt.Errorf("invalid Postman collection schema: %s", c.Info.Schema), consts.ErrImportFile.Error())
+	}
+
+	vars := make(map[string]string)
+	for _, v := range c.Variable {
+		if v.Key != "" && v.Value != nil {
+			vars[v.Key] = fmt.Sprint(v.Value)
+		}
+	}
+
+	var reqs []httpreq.Request
+	if err := collectRequests(&reqs, c.Item, "", vars); err != nil {
+		return nil, err
+	}
+	if len(reqs) == 0 {
+		return nil, errors.Wrap(errors.New("Postman collection does not contain any requests"), consts.ErrImportFile.Error())
+	}
+
+	if o.TaskName == "" {
+		o.TaskName = "postman_" + time.Now().Format("20060102150405")
+	}
+
+	cv := httpreq.Converter{MergeMethod: o.MergeMethod, TaskName: o.TaskName}
+	return cv.Convert(reqs)
+}
+
+func (Loader) Export(data loader.DataSets) (any, error) {
+	return nil, errors.New("export to Postman collection is not supported")
+}
+
+// collectRequests walks the folders, nested folders are joined with a slash.
+func collectRequests(reqs *[]httpreq.Request, items []item, folder string, vars map[string]string) error {
+	for _, it := range items {
+		if len(it.Request) == 0 || string(it.Request) == "null" {
+			group := it.Name
+			if folder != "" {
+				group = folder + "/" + it.Name
+			}
+			if err := collectRequests(reqs, it.Item, group, vars); err != nil {
+				return err
+			}
+			continue
+		}
+
+		req, err := parseRequest(it.Request)
+		if err != nil {
+			return errors.Wrap(fmt.Errorf("invalid request %s: %s", it.Name, err), consts.ErrImportFile.Error())
+		}
+		req.Group = folder
+		req.Name = it.Name
+		req.URL = substitute(req.URL, vars)
+		*reqs = append(*reqs, req)
+	}
+	return nil
+}
+
+func parseRequest(raw json.RawMessage) (httpreq.Request, error) {
+	var u string
+	if err := json.Unmarshal(raw, &u); err == nil {
+		return httpreq.Request{Method: "GET", URL: u}, nil
+	}
+
+	r := request{}
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return httpreq.Request{}, err
+	}
+	u, err := parseURL(r.URL)
+	if err != nil {
+		return httpreq.Request{}, err
+	}
+	return httpreq.Request{Method: r.Method, URL: u}, nil
+}
+
+// parseURL returns the raw URL, it is built from the parts when missing
+func parseURL(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var u string
+	if err := json.Unmarshal(raw, &u); err == nil {
+		return u, nil
+	}
+
+	ru := requestURL{}
+	if err := json.Unmarshal(raw, &ru); err != nil {
+		return "", err
+	}
+	if ru.Raw != "" {
+		return ru.Raw, nil
+	}
+
+	host, err := joinParts(ru.Host, ".")
+	if err != nil {
+		return "", err
+	}
+	path, err := joinParts(ru.Path, "/")
+	if err != nil {
+		return "", err
+	}
+	if ru.Port != "" {
+		host += ":" + ru.Port
+	}
+	if ru.Protocol != "" {
+		host = ru.Protocol + "://" + host
+	}
+	return host + "/" + strings.TrimPrefix(path, "/"), nil
+}
+
+// joinParts joins a list of parts, a single string is returned as is
+func joinParts(raw json.RawMessage, sep string) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	var parts []string
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", err
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// substitute replaces the collection variables, unknown variables are kept
+func substitute(s string, vars map[string]string) string {
+	for k, v := range vars {
+		s = strings.ReplaceAll(s, "{{"+k+"}}", v)
+	}
+	return s
+}
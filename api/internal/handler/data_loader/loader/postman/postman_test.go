@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package postman
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+var (
+	TestPostman = "../../../../../test/testdata/import/postman.json"
+)
+
+func TestParsePostman(t *testing.T) {
+	fileContent, err := ioutil.ReadFile(TestPostman)
+	assert.NoError(t, err)
+
+	l := &Loader{MergeMethod: false, TaskName: "test"}
+	data, err := l.Import(fileContent)
+	assert.NoError(t, err)
+
+	assert.Len(t, data.Routes, 5)
+	assert.Len(t, data.Services, 3)
+	assert.Len(t, data.Upstreams, 3)
+
+	// Upstreams
+	assert.Equal(t, "test_petstore.example.com_443", data.Upstreams[0].ID)
+	assert.Equal(t, "https", data.Upstreams[0].Scheme)
+	assert.Equal(t, "node", data.Upstreams[0].PassHost)
+	assert.Equal(t, []*entity.Node{{Host: "petstore.example.com", Port: 443, Weight: 1}}, data.Upstreams[0].Nodes)
+	assert.Equal(t, "test_10.0.0.1_8080", data.Upstreams[1].ID)
+	assert.Equal(t, "", data.Upstreams[1].PassHost)
+	assert.Equal(t, []*entity.Node{{Host: "10.0.0.1", Port: 8080, Weight: 1}}, data.Upstreams[1].Nodes)
+	assert.Equal(t, "test_status.example.com_80", data.Upstreams[2].ID)
+
+	// Services, one per folder and per host for requests without folder
+	assert.Equal(t, "test_pets", data.Services[0].ID)
+	assert.Equal(t, "test_petstore.example.com_443", data.Services[0].UpstreamID)
+	assert.Equal(t, "test_pets_admin", data.Services[1].ID)
+	assert.Equal(t, "test_pets/admin", data.Services[1].Name)
+	assert.Equal(t, "test_10.0.0.1_8080", data.Services[1].UpstreamID)
+	assert.Equal(t, "test_status.example.com", data.Services[2].ID)
+
+	for _, route := range data.Routes {
+		assert.Nil(t, route.UpstreamID)
+
+		switch route.Name {
+		case "test_v1/pets_GET":
+			assert.Equal(t, "List pets", route.Desc)
+			assert.Equal(t, []string{"/v1/pets"}, route.Uris)
+			assert.Equal(t, []string{"GET"}, route.Methods)
+			assert.Equal(t, "petstore.example.com", route.Host)
+			assert.Equal(t, "test_pets", route.ServiceID)
+		case "test_v1/pets_POST":
+			assert.Equal(t, []string{"/v1/pets"}, route.Uris)
+			assert.Equal(t, []string{"POST"}, route.Methods)
+		case "test_v1/pets/*_GET":
+			assert.Equal(t, []string{"/v1/pets/*"}, route.Uris)
+			assert.Equal(t, "test_pets", route.ServiceID)
+		case "test_admin/pets/*_DELETE":
+			assert.Equal(t, []string{"/admin/pets/*"}, route.Uris)
+			assert.Equal(t, "10.0.0.1", route.Host)
+			assert.Equal(t, "test_pets_admin", route.ServiceID)
+		case "test_health_GET":
+			assert.Equal(t, []string{"/health"}, route.Uris)
+			assert.Equal(t, "test_status.example.com", route.ServiceID)
+		default:
+			t.Fatal("bad route name exist")
+		}
+	}
+}
+
+func TestParsePostmanMergeMethod(t *testing.T) {
+	fileContent, err := ioutil.ReadFile(TestPostman)
+	assert.NoError(t, err)
+
+	l := &Loader{MergeMethod: true, TaskName: "test"}
+	data, err := l.Import(fileContent)
+	assert.NoError(t, err)
+
+	assert.Len(t, data.Routes, 4)
+	assert.Equal(t, "test_v1/pets", data.Routes[0].Name)
+	assert.Equal(t, []string{"GET", "POST"}, data.Routes[0].Methods)
+}
+
+func TestParsePostmanInvalid(t *testing.T) {
+	l := &Loader{TaskName: "test"}
+
+	_, err := l.Import([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`))
+	assert.EqualError(t, err, "empty or invalid imported file: invalid Postman collection schema: https://schema.getpostman.com/json/collection/v1.0.0/collection.json")
+
+	_, err = l.Import([]byte(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"}, "item": [{"name": "empty", "item": []}]}`))
+	assert.EqualError(t, err, "empty or invalid imported file: Postman collection does not contain any requests")
+}
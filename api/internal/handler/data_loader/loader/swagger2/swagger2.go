@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package swagger2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	oas3 "github.com/apisix/manager-api/internal/handler/data_loader/loader/openapi3"
+	"github.com/apisix/manager-api/internal/utils/consts"
+)
+
+// Loader imports Swagger 2.0 documents, they are converted to OpenAPI 3 and
+// imported like OpenAPI 3 documents.
+type Loader struct {
+	// MergeMethod indicates whether to merge routes when multiple HTTP methods are on the same path
+	MergeMethod bool
+	// TaskName indicates the name of current import/export task
+	TaskName string
+}
+
+// defaultSchemes is used when the document has no schemes
+var defaultSchemes = []string{"http"}
+
+// oauth2Flows are the OAuth 2 flows which can be converted to OpenAPI 3
+var oauth2Flows = map[string]bool{
+	"implicit":   true,
+	"accessCode": true,
+	"password":   true,
+}
+
+func (o Loader) Import(input any) (*loader.DataSets, error) {
+	if input == nil {
+		panic("input is nil")
+	}
+
+	d, ok := input.([]byte)
+	if !ok {
+		panic(fmt.Sprintf("input format error: expected []byte but it is %s", reflect.TypeOf(input).Kind().String()))
+	}
+
+	// load Swagger 2.0 document, YAML is a superset of JSON
+	d, err := yaml.YAMLToJSON(d)
+	if err != nil {
+		return nil, err
+	}
+	doc := &openapi2.Swagger{}
+	if err := json.Unmarshal(d, doc); err != nil {
+		return nil, err
+	}
+	if doc.Swagger != "2.0" {
+		return nil, errors.Wrap(fmt.Errorf("invalid swagger version: %s", doc.Swagger), consts.ErrImportFile.Error())
+	}
+
+	// no paths in Swagger 2.0 document
+	if len(doc.Paths) <= 0 {
+		return nil, errors.Wrap(errors.New("Swagger documentation does not contain any paths"), consts.ErrImportFile.Error())
+	}
+
+	swagger, err := toOpenAPI3(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.TaskName == "" {
+		o.TaskName = "swagger_" + time.Now().Format("20060102150405")
+	}
+
+	l := oas3.Loader{MergeMethod: o.MergeMethod, TaskName: o.TaskName}
+	return l.ImportDocument(swagger)
+}
+
+func (Loader) Export(data loader.DataSets) (any, error) {
+	return nil, errors.New("export to Swagger 2.0 is not supported")
+}
+
+// toOpenAPI3 converts the document, host, basePath and schemes become one
+// server per scheme.
+func toOpenAPI3(doc *openapi2.Swagger) (*openapi3.Swagger, error) {
+	host, basePath, schemes := doc.Host, doc.BasePath, doc.Schemes
+	if len(schemes) == 0 {
+		schemes = defaultSchemes
+	}
+	doc.Host = ""
+
+	// security definitions without an OpenAPI 3 equivalent are not checked by any plugin
+	for name, scheme := range doc.SecurityDefinitions {
+		if scheme != nil && scheme.Type == "oauth2" && !oauth2Flows[scheme.Flow] {
+			delete(doc.SecurityDefinitions, name)
+		}
+	}
+
+	swagger, err := openapi2conv.ToV3Swagger(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, consts.ErrImportFile.Error())
+	}
+
+	for _, scheme := range schemes {
+		u := url.URL{Scheme: scheme, Host: host, Path: basePath}
+		if host == "" {
+			// without host only the base path is known
+			u = url.URL{Path: basePath}
+		}
+		swagger.AddServer(&openapi3.Server{URL: u.String()})
+		if host == "" {
+			break
+		}
+	}
+	return swagger, nil
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package swagger2
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+)
+
+var (
+	TestSwagger2 = "../../../../../test/testdata/import/swagger2.yaml"
+)
+
+func TestParseSwagger2(t *testing.T) {
+	fileContent, err := ioutil.ReadFile(TestSwagger2)
+	assert.NoError(t, err)
+
+	l := &Loader{MergeMethod: false, TaskName: "test"}
+	data, err := l.Import(fileContent)
+	assert.NoError(t, err)
+
+	assert.Len(t, data.Routes, 3)
+	assert.Len(t, data.Upstreams, 1)
+
+	// Upstream, https is left out since an upstream has a single scheme
+	assert.Equal(t, "test", data.Upstreams[0].Name)
+	assert.Equal(t, "http", data.Upstreams[0].Scheme)
+	assert.Equal(t, []*entity.Node{{Host: "petstore.example.com", Port: 8080, Weight: 1}}, data.Upstreams[0].Nodes)
+
+	for _, route := range data.Routes {
+		assert.Equal(t, "test", route.UpstreamID)
+		assert.Equal(t, "pets", route.Labels["team"])
+		assert.Equal(t, entity.Status(0), route.Status)
+
+		switch route.Name {
+		case "test_pet_POST":
+			assert.Equal(t, []string{"/v2/pet"}, route.Uris)
+			assert.Equal(t, "Add a new pet", route.Desc)
+			assert.Equal(t, map[string]any{}, route.Plugins["basic-auth"])
+			assert.Nil(t, route.Plugins["key-auth"])
+			assert.Equal(t, map[string]any{
+				"header_schema": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"x-tenant": map[string]any{"type": "string"}},
+					"required":   []string{"x-tenant"},
+				},
+				"body_schema": map[string]any{
+					"type":       "object",
+					"required":   []any{"name"},
+					"properties": map[string]any{"name": map[string]any{"type": "string"}},
+				},
+			}, route.Plugins["request-validation"])
+		case "test_pet/{petId}_GET":
+			assert.Equal(t, []string{"/v2/pet/*"}, route.Uris)
+			assert.Equal(t, []string{"GET"}, route.Methods)
+			assert.Equal(t, 5, route.Priority)
+			assert.Equal(t, map[string]any{"header": "api_key"}, route.Plugins["key-auth"])
+		case "test_pet/{petId}_DELETE":
+			// the client credentials flow has no OpenAPI 3 equivalent
+			assert.Len(t, route.Plugins, 0)
+		default:
+			t.Fatal("bad route name exist")
+		}
+	}
+}
+
+func TestParseSwagger2Invalid(t *testing.T) {
+	l := &Loader{TaskName: "test"}
+
+	_, err := l.Import([]byte(`{"openapi": "3.0.0", "paths": {"/": {}}}`))
+	assert.EqualError(t, err, "empty or invalid imported file: invalid swagger version: ")
+
+	_, err = l.Import([]byte(`swagger: "2.0"`))
+	assert.EqualError(t, err, "empty or invalid imported file: Swagger documentation does not contain any paths")
+}
@@ -0,0 +1,376 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package data_loader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/matcher"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/handler/data_loader/reconcile"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+const (
+	StatusNew         = "new"
+	StatusIdentical   = "identical"
+	StatusChanged     = "changed"
+	StatusConflicting = "conflicting"
+
+	ActionOverwrite = "overwrite"
+	ActionSkip      = "skip"
+
+	ResultApplied   = "applied"
+	ResultSkipped   = "skipped"
+	ResultUnchanged = "unchanged"
+	ResultFailed    = "failed"
+
+	// previewTTL is how long an import session is kept for the apply
+	previewTTL = 30 * time.Minute
+)
+
+// PreviewItem is an imported object compared with the stored object of the
+// same ID. A conflicting route has no stored object of its ID but matches
+// the same requests as the stored route it conflicts with.
+type PreviewItem struct {
+	Key           string                `json:"key"`
+	Type          store.HubKey          `json:"type"`
+	ID            string                `json:"id,omitempty"`
+	Name          string                `json:"name,omitempty"`
+	Status        string                `json:"status"`
+	Diff          []reconcile.FieldDiff `json:"diff,omitempty"`
+	ConflictsWith string                `json:"conflicts_with,omitempty"`
+
+	object any
+}
+
+type ImportPreview struct {
+	SessionID string                    `json:"session_id"`
+	ExpireAt  int64                     `json:"expire_at"`
+	Items     []PreviewItem             `json:"items"`
+	Errors    map[store.HubKey][]string `json:"errors,omitempty"`
+}
+
+// importSessions keeps the previewed imports in memory until they are
+// applied, discarded or expired.
+type importSessions struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[string]*importSession
+}
+
+type importSession struct {
+	expireAt time.Time
+	items    []PreviewItem
+}
+
+func newImportSessions(ttl time.Duration) *importSessions {
+	return &importSessions{ttl: ttl, sessions: make(map[string]*importSession)}
+}
+
+func (s *importSessions) add(items []PreviewItem) (string, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range s.sessions {
+		if now.After(session.expireAt) {
+			delete(s.sessions, id)
+		}
+	}
+	id := uuid.NewV4().String()
+	s.sessions[id] = &importSession{expireAt: now.Add(s.ttl), items: items}
+	return id, s.sessions[id].expireAt
+}
+
+func (s *importSessions) get(id string) ([]PreviewItem, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || time.Now().After(session.expireAt) {
+		delete(s.sessions, id)
+		return nil, false
+	}
+	return session.items, true
+}
+
+func (s *importSessions) remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.sessions[id]
+	delete(s.sessions, id)
+	return ok
+}
+
+// Preview converts the uploaded file like Import and compares each object
+// with the stored objects without writing anything. The result is kept as
+// an import session, whose items are written by ApplyPreview.
+func (h *ImportHandler) Preview(c droplet.Context) (any, error) {
+	input := c.Input().(*ImportInput)
+
+	dataSets, err := h.load(input)
+	if err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+
+	items, err := h.previewItems(c.Context(), dataSets)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+
+	id, expireAt := h.sessions.add(items)
+	return &ImportPreview{
+		SessionID: id,
+		ExpireAt:  expireAt.Unix(),
+		Items:     items,
+		Errors:    dataSets.Errors,
+	}, nil
+}
+
+// previewItems returns the items in the order they are written, objects
+// come before the objects which use them.
+func (h *ImportHandler) previewItems(ctx context.Context, dataSets *loader.DataSets) ([]PreviewItem, error) {
+	items := []PreviewItem{}
+	for _, typ := range reconcile.Types {
+		s := h.graphStores[typ]
+		for i, obj := range reconcile.Objects(dataSets, typ) {
+			node, _ := graph.NodeOf(typ, obj)
+			item := PreviewItem{
+				Key:    fmt.Sprintf("%s/%d", typ, i),
+				Type:   typ,
+				ID:     node.ID,
+				Name:   node.Name,
+				Status: StatusNew,
+				object: obj,
+			}
+
+			if node.ID != "" && s != nil {
+				stored, err := s.Get(ctx, node.ID)
+				if err != nil && err != data.ErrNotFound {
+					return nil, err
+				}
+				if err == nil {
+					diff, err := reconcile.Diff(stored, obj)
+					if err != nil {
+						return nil, err
+					}
+					item.Status, item.Diff = StatusIdentical, diff
+					if len(diff) > 0 {
+						item.Status = StatusChanged
+					}
+				}
+			}
+
+			if route, ok := obj.(*entity.Route); ok && item.Status == StatusNew {
+				ret, err := h.routeStore.List(ctx, store.ListInput{
+					Predicate: func(obj any) bool {
+						return matcher.Duplicated(obj.(*entity.Route), route)
+					},
+				})
+				if err != nil {
+					return nil, err
+				}
+				if len(ret.Rows) > 0 {
+					stored := ret.Rows[0].(*entity.Route)
+					diff, err := reconcile.Diff(stored, withID(route, stored.ID))
+					if err != nil {
+						return nil, err
+					}
+					item.Status, item.Diff = StatusConflicting, diff
+					item.ConflictsWith = utils.InterfaceToString(stored.ID)
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// withID returns a copy of the route replacing the stored route with the id
+func withID(route *entity.Route, id any) *entity.Route {
+	r := *route
+	r.ID = id
+	return &r
+}
+
+type ImportSelection struct {
+	Key    string `json:"key"`
+	Action string `json:"action"`
+}
+
+type ApplyPreviewInput struct {
+	SessionID string            `auto_read:"session_id,path"`
+	Items     []ImportSelection `json:"items"`
+}
+
+type ApplyResult struct {
+	Key    string       `json:"key"`
+	Type   store.HubKey `json:"type"`
+	ID     string       `json:"id,omitempty"`
+	Action string       `json:"action"`
+	Result string       `json:"result"`
+	Error  string       `json:"error,omitempty"`
+}
+
+type ApplyPreviewOutput struct {
+	Items []ApplyResult `json:"items"`
+}
+
+// ApplyPreview writes the selected items of an import session. Overwrite
+// creates new items, replaces the stored object of changed items and the
+// stored route conflicting items conflict with, items which are not
+// selected are skipped. The session ends once the items are written.
+func (h *ImportHandler) ApplyPreview(c droplet.Context) (any, error) {
+	input := c.Input().(*ApplyPreviewInput)
+
+	items, ok := h.sessions.get(input.SessionID)
+	if !ok {
+		return &data.SpecCodeResponse{StatusCode: http.StatusNotFound},
+			fmt.Errorf("import session %s not found or expired", input.SessionID)
+	}
+	if len(input.Items) == 0 {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, fmt.Errorf("no item selected")
+	}
+
+	actions := make(map[string]string)
+	for _, sel := range input.Items {
+		if sel.Action != ActionOverwrite && sel.Action != ActionSkip {
+			return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest},
+				fmt.Errorf("invalid action %s of item %s, action should be overwrite or skip", sel.Action, sel.Key)
+		}
+		if _, ok := actions[sel.Key]; ok {
+			return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, fmt.Errorf("item %s is selected twice", sel.Key)
+		}
+		actions[sel.Key] = sel.Action
+	}
+	found := make(map[string]bool)
+	for _, item := range items {
+		found[item.Key] = true
+	}
+	for _, sel := range input.Items {
+		if !found[sel.Key] {
+			return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest},
+				fmt.Errorf("item %s not found in import session", sel.Key)
+		}
+	}
+
+	// the objects to write, conflicting routes replace the stored route
+	writes := make(map[string]any)
+	for _, item := range items {
+		if actions[item.Key] != ActionOverwrite || item.Status == StatusIdentical {
+			continue
+		}
+		obj := item.object
+		if item.Status == StatusConflicting {
+			obj = withID(obj.(*entity.Route), item.ConflictsWith)
+		}
+		writes[item.Key] = obj
+	}
+	if err := h.checkSelected(c.Context(), items, writes); err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+
+	ctx := c.Context()
+	output := &ApplyPreviewOutput{Items: []ApplyResult{}}
+	for _, item := range items {
+		ret := ApplyResult{Key: item.Key, Type: item.Type, ID: item.ID, Action: actions[item.Key], Result: ResultSkipped}
+		if ret.Action == "" {
+			ret.Action = ActionSkip
+		}
+		obj, ok := writes[item.Key]
+		switch {
+		case ret.Action == ActionOverwrite && item.Status == StatusIdentical:
+			ret.Result = ResultUnchanged
+		case ok:
+			var err error
+			if item.Status == StatusNew {
+				_, err = h.graphStores[item.Type].Create(ctx, obj)
+			} else {
+				_, err = h.graphStores[item.Type].Update(ctx, obj, false)
+			}
+			ret.Result = ResultApplied
+			if err != nil {
+				ret.Result, ret.Error = ResultFailed, err.Error()
+			}
+			if item.Status == StatusConflicting {
+				ret.ID = item.ConflictsWith
+			}
+		}
+		output.Items = append(output.Items, ret)
+	}
+
+	h.sessions.remove(input.SessionID)
+	return output, nil
+}
+
+// checkSelected makes sure the written objects only use objects which are
+// stored or written along with them.
+func (h *ImportHandler) checkSelected(ctx context.Context, items []PreviewItem, writes map[string]any) error {
+	g, err := graph.Build(ctx, h.graphStores)
+	if err != nil {
+		return err
+	}
+
+	written := make(map[string]bool)
+	for _, item := range items {
+		if obj, ok := writes[item.Key]; ok {
+			node, _ := graph.NodeOf(item.Type, obj)
+			written[string(node.Type)+"/"+node.ID] = true
+		}
+	}
+	for _, item := range items {
+		obj, ok := writes[item.Key]
+		if !ok {
+			continue
+		}
+		for _, ref := range graph.References(obj) {
+			if _, ok := g.Node(ref.Type, ref.ID); ok || written[string(ref.Type)+"/"+ref.ID] {
+				continue
+			}
+			return fmt.Errorf("item %s uses %s %s which is neither stored nor selected", item.Key, ref.Type, ref.ID)
+		}
+	}
+	return nil
+}
+
+type DiscardPreviewInput struct {
+	SessionID string `auto_read:"session_id,path"`
+}
+
+// DiscardPreview ends an import session without writing anything
+func (h *ImportHandler) DiscardPreview(c droplet.Context) (any, error) {
+	input := c.Input().(*DiscardPreviewInput)
+	if !h.sessions.remove(input.SessionID) {
+		return &data.SpecCodeResponse{StatusCode: http.StatusNotFound},
+			fmt.Errorf("import session %s not found or expired", input.SessionID)
+	}
+	return nil, nil
+}
@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package data_loader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/handler/data_loader/reconcile"
+)
+
+// previewStore returns a store holding the objects, the written objects are
+// recorded as "create <id>" or "update <id>".
+func previewStore(typ store.HubKey, written *[]string, objs ...any) *store.MockInterface {
+	s := store.NewMockStore(typ, objs...)
+	for _, obj := range objs {
+		node, _ := graph.NodeOf(typ, obj)
+		s.On("Get", node.ID).Return(obj, nil)
+	}
+	s.On("Get", mock.Anything).Return(nil, data.ErrNotFound)
+	s.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		node, _ := graph.NodeOf(typ, args.Get(1))
+		*written = append(*written, "create "+string(typ)+" "+node.ID)
+	}).Return(nil, nil)
+	s.On("Update", mock.Anything, mock.Anything, false).Run(func(args mock.Arguments) {
+		node, _ := graph.NodeOf(typ, args.Get(1))
+		*written = append(*written, "update "+string(typ)+" "+node.ID)
+	}).Return(nil, nil)
+	return s
+}
+
+func newPreviewHandler(written *[]string) *ImportHandler {
+	routeStore := previewStore(store.HubKeyRoute, written,
+		&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, Uris: []string{"/a"}, UpstreamID: "u1", Status: 1},
+		&entity.Route{BaseInfo: entity.BaseInfo{ID: "r3"}, Uris: []string{"/b"}, Methods: []string{"GET"}, UpstreamID: "u1", Status: 1},
+	)
+	upstreamStore := previewStore(store.HubKeyUpstream, written,
+		&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}, UpstreamDef: entity.UpstreamDef{
+			Type:  "roundrobin",
+			Nodes: []any{map[string]any{"host": "127.0.0.1", "port": float64(80), "weight": float64(1)}},
+		}},
+	)
+	return &ImportHandler{
+		routeStore:    routeStore,
+		upstreamStore: upstreamStore,
+		graphStores: graph.Stores{
+			store.HubKeyRoute:    routeStore,
+			store.HubKeyUpstream: upstreamStore,
+		},
+		sessions: newImportSessions(previewTTL),
+	}
+}
+
+func previewData() *loader.DataSets {
+	return &loader.DataSets{
+		Upstreams: []entity.Upstream{
+			{BaseInfo: entity.BaseInfo{ID: "u1"}, UpstreamDef: entity.UpstreamDef{
+				Type:  "roundrobin",
+				Nodes: []*entity.Node{{Host: "127.0.0.1", Port: 80, Weight: 2}},
+			}},
+			{BaseInfo: entity.BaseInfo{ID: "u2"}, UpstreamDef: entity.UpstreamDef{
+				Type:  "roundrobin",
+				Nodes: []*entity.Node{{Host: "127.0.0.2", Port: 80, Weight: 1}},
+			}},
+		},
+		Routes: []entity.Route{
+			{BaseInfo: entity.BaseInfo{ID: "r1"}, Uris: []string{"/a"}, UpstreamID: "u1", Status: 1},
+			{BaseInfo: entity.BaseInfo{ID: "r2"}, Uris: []string{"/b"}, Methods: []string{"GET"}, UpstreamID: "u2", Status: 1},
+			{BaseInfo: entity.BaseInfo{ID: "r4"}, Uris: []string{"/c"}, UpstreamID: "u2", Status: 1},
+		},
+	}
+}
+
+func TestPreviewItems(t *testing.T) {
+	h := newPreviewHandler(&[]string{})
+
+	items, err := h.previewItems(context.Background(), previewData())
+	assert.NoError(t, err)
+
+	var statuses []string
+	for _, item := range items {
+		statuses = append(statuses, item.Key+" "+item.ID+" "+item.Status)
+	}
+	assert.Equal(t, []string{
+		"upstream/0 u1 changed",
+		"upstream/1 u2 new",
+		"route/0 r1 identical",
+		"route/1 r2 conflicting",
+		"route/2 r4 new",
+	}, statuses)
+
+	assert.Equal(t, []reconcile.FieldDiff{{
+		Path:   "nodes",
+		Before: []any{map[string]any{"host": "127.0.0.1", "port": float64(80), "weight": float64(1)}},
+		After:  []any{map[string]any{"host": "127.0.0.1", "port": float64(80), "weight": float64(2)}},
+	}}, items[0].Diff)
+	assert.Equal(t, "r3", items[3].ConflictsWith)
+	assert.Equal(t, []reconcile.FieldDiff{{Path: "upstream_id", Before: "u1", After: "u2"}}, items[3].Diff)
+}
+
+func TestApplyPreview(t *testing.T) {
+	var written []string
+	h := newPreviewHandler(&written)
+	items, err := h.previewItems(context.Background(), previewData())
+	assert.NoError(t, err)
+	id, _ := h.sessions.add(items)
+
+	ctx := droplet.NewContext()
+	apply := func(sel ...ImportSelection) (any, error) {
+		ctx.SetInput(&ApplyPreviewInput{SessionID: id, Items: sel})
+		return h.ApplyPreview(ctx)
+	}
+
+	_, err = apply(ImportSelection{Key: "route/9", Action: ActionOverwrite})
+	assert.EqualError(t, err, "item route/9 not found in import session")
+	_, err = apply(ImportSelection{Key: "route/0", Action: "replace"})
+	assert.EqualError(t, err, "invalid action replace of item route/0, action should be overwrite or skip")
+
+	// the new upstream used by the routes must be selected as well
+	_, err = apply(
+		ImportSelection{Key: "route/1", Action: ActionOverwrite},
+		ImportSelection{Key: "route/2", Action: ActionOverwrite},
+	)
+	assert.EqualError(t, err, "item route/1 uses upstream u2 which is neither stored nor selected")
+	assert.Empty(t, written)
+
+	ret, err := apply(
+		ImportSelection{Key: "upstream/0", Action: ActionSkip},
+		ImportSelection{Key: "upstream/1", Action: ActionOverwrite},
+		ImportSelection{Key: "route/0", Action: ActionOverwrite},
+		ImportSelection{Key: "route/1", Action: ActionOverwrite},
+		ImportSelection{Key: "route/2", Action: ActionOverwrite},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, &ApplyPreviewOutput{Items: []ApplyResult{
+		{Key: "upstream/0", Type: store.HubKeyUpstream, ID: "u1", Action: ActionSkip, Result: ResultSkipped},
+		{Key: "upstream/1", Type: store.HubKeyUpstream, ID: "u2", Action: ActionOverwrite, Result: ResultApplied},
+		{Key: "route/0", Type: store.HubKeyRoute, ID: "r1", Action: ActionOverwrite, Result: ResultUnchanged},
+		{Key: "route/1", Type: store.HubKeyRoute, ID: "r3", Action: ActionOverwrite, Result: ResultApplied},
+		{Key: "route/2", Type: store.HubKeyRoute, ID: "r4", Action: ActionOverwrite, Result: ResultApplied},
+	}}, ret)
+	// the conflicting route replaces the stored route
+	assert.Equal(t, []string{"create upstream u2", "update route r3", "create route r4"}, written)
+
+	// the session ends with the apply
+	_, err = apply(ImportSelection{Key: "route/0", Action: ActionOverwrite})
+	assert.EqualError(t, err, "import session "+id+" not found or expired")
+}
+
+func TestPreview(t *testing.T) {
+	h := newPreviewHandler(&[]string{})
+	input := &ImportInput{
+		Type:     "kong",
+		TaskName: "test",
+		FileName: "kong.yaml",
+		FileContent: []byte(`
+_format_version: "3.0"
+upstreams:
+  - name: u1
+    targets:
+      - target: 127.0.0.1:80
+`),
+	}
+	ctx := droplet.NewContext()
+	ctx.SetInput(input)
+
+	ret, err := h.Preview(ctx)
+	assert.NoError(t, err)
+	preview := ret.(*ImportPreview)
+	assert.NotEmpty(t, preview.SessionID)
+	assert.Len(t, preview.Items, 1)
+	assert.Equal(t, StatusChanged, preview.Items[0].Status)
+
+	ctx.SetInput(&DiscardPreviewInput{SessionID: preview.SessionID})
+	_, err = h.DiscardPreview(ctx)
+	assert.NoError(t, err)
+	_, err = h.DiscardPreview(ctx)
+	assert.EqualError(t, err, "import session "+preview.SessionID+" not found or expired")
+}
@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apisix/manager-api/internal/core/graph"
+)
+
+// Apply writes the changes of the plan in order. When a change fails, the
+// changes written before are reverted in reverse order and the error of the
+// change is returned together with the errors of the rollback.
+func Apply(ctx context.Context, stores graph.Stores, plan *Plan) error {
+	for i, c := range plan.Changes {
+		err := apply(ctx, stores, c)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("%s %s %s failed: %w", c.Action, c.Type, c.ID, err)
+
+		var rollbackErrs []string
+		for j := i - 1; j >= 0; j-- {
+			if rerr := apply(ctx, stores, revert(plan.Changes[j])); rerr != nil {
+				rollbackErrs = append(rollbackErrs, fmt.Sprintf("%s %s: %s", plan.Changes[j].Type, plan.Changes[j].ID, rerr))
+			}
+		}
+		if len(rollbackErrs) > 0 {
+			return fmt.Errorf("%s, rollback failed: %s", err, strings.Join(rollbackErrs, "; "))
+		}
+		return err
+	}
+	return nil
+}
+
+func apply(ctx context.Context, stores graph.Stores, c Change) error {
+	s, ok := stores[c.Type]
+	if !ok || s == nil {
+		return fmt.Errorf("no store with key: %s", c.Type)
+	}
+
+	var err error
+	switch c.Action {
+	case ActionCreate:
+		_, err = s.Create(ctx, c.After)
+	case ActionUpdate:
+		_, err = s.Update(ctx, c.After, false)
+	case ActionDelete:
+		err = s.BatchDelete(ctx, []string{c.ID})
+	default:
+		err = fmt.Errorf("unsupported action: %s", c.Action)
+	}
+	return err
+}
+
+// revert returns the change undoing c
+func revert(c Change) Change {
+	switch c.Action {
+	case ActionCreate:
+		return Change{Action: ActionDelete, Type: c.Type, ID: c.ID, Before: c.After}
+	case ActionUpdate:
+		return Change{Action: ActionUpdate, Type: c.Type, ID: c.ID, Before: c.After, After: c.Before}
+	default:
+		return Change{Action: ActionCreate, Type: c.Type, ID: c.ID, After: c.Before}
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package reconcile
+
+import (
+	"reflect"
+	"sort"
+)
+
+// FieldDiff is a field which differs between two versions of an object, the
+// path joins the names of nested fields with dots.
+type FieldDiff struct {
+	Path   string `json:"path"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// Diff returns the fields which differ between the objects sorted by path,
+// nested objects are compared field by field and lists as a whole. The
+// timestamps set by the store are ignored.
+func Diff(before, after any) ([]FieldDiff, error) {
+	mb, err := toMap(before)
+	if err != nil {
+		return nil, err
+	}
+	ma, err := toMap(after)
+	if err != nil {
+		return nil, err
+	}
+	diffs := diff("", mb, ma)
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs, nil
+}
+
+func diff(prefix string, before, after map[string]any) []FieldDiff {
+	var diffs []FieldDiff
+	for k, b := range before {
+		a, ok := after[k]
+		if !ok {
+			diffs = append(diffs, FieldDiff{Path: prefix + k, Before: b})
+			continue
+		}
+		mb, okb := b.(map[string]any)
+		ma, oka := a.(map[string]any)
+		if okb && oka {
+			diffs = append(diffs, diff(prefix+k+".", mb, ma)...)
+			continue
+		}
+		if !reflect.DeepEqual(a, b) {
+			diffs = append(diffs, FieldDiff{Path: prefix + k, Before: b, After: a})
+		}
+	}
+	for k, a := range after {
+		if _, ok := before[k]; !ok {
+			diffs = append(diffs, FieldDiff{Path: prefix + k, After: a})
+		}
+	}
+	return diffs
+}
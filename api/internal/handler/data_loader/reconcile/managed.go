@@ -0,0 +1,171 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/shiningrush/droplet/data"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+// ManagedConfig is the name of the system config recording the objects
+// without labels which were created by a sync. Its payload holds the ids of
+// the objects of each selector, keyed by type.
+const ManagedConfig = "sync_managed"
+
+// managed is the record of the objects without labels owned by the sync of
+// a selector.
+type managed struct {
+	// stored is the stored record, nil when there is none
+	stored *entity.SystemConfig
+	key    string
+	ids    map[store.HubKey]map[string]struct{}
+}
+
+// selectorKey returns the selector in a canonical form
+func selectorKey(selector map[string]string) string {
+	labels := make([]string, 0, len(selector))
+	for k, v := range selector {
+		labels = append(labels, k+":"+v)
+	}
+	sort.Strings(labels)
+	return strings.Join(labels, ",")
+}
+
+// readManaged reads the objects without labels owned by the sync of the
+// selector. Without a system config store nothing is owned.
+func readManaged(ctx context.Context, stores graph.Stores, selector map[string]string) (*managed, error) {
+	m := &managed{key: selectorKey(selector), ids: map[store.HubKey]map[string]struct{}{}}
+	s, ok := stores[store.HubKeySystemConfig]
+	if !ok || s == nil {
+		return m, nil
+	}
+
+	obj, err := s.Get(ctx, ManagedConfig)
+	if err == data.ErrNotFound {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	stored, err := Clone(obj)
+	if err != nil {
+		return nil, err
+	}
+	m.stored = stored.(*entity.SystemConfig)
+
+	types, _ := m.stored.Payload[m.key].(map[string]any)
+	for typ, ids := range types {
+		list, _ := ids.([]any)
+		for _, id := range list {
+			if id, ok := id.(string); ok {
+				m.add(store.HubKey(typ), id)
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *managed) add(typ store.HubKey, id string) {
+	if m.ids[typ] == nil {
+		m.ids[typ] = map[string]struct{}{}
+	}
+	m.ids[typ][id] = struct{}{}
+}
+
+func (m *managed) has(typ store.HubKey, id string) bool {
+	_, ok := m.ids[typ][id]
+	return ok
+}
+
+// change returns the change recording the desired objects without labels as
+// the objects owned by the selector, nil when the record is up to date.
+func (m *managed) change(stores graph.Stores, desired map[store.HubKey][]string) (*Change, error) {
+	if s, ok := stores[store.HubKeySystemConfig]; !ok || s == nil {
+		return nil, nil
+	}
+
+	// the ids are kept in the form they are decoded in from etcd
+	types := map[string]any{}
+	for typ, ids := range desired {
+		sort.Strings(ids)
+		list := make([]any, 0, len(ids))
+		for _, id := range ids {
+			list = append(list, id)
+		}
+		types[string(typ)] = list
+	}
+
+	after := &entity.SystemConfig{
+		ConfigName: ManagedConfig,
+		Desc:       "objects without labels created by the declarative sync",
+	}
+	if m.stored != nil {
+		c, err := Clone(m.stored)
+		if err != nil {
+			return nil, err
+		}
+		after = c.(*entity.SystemConfig)
+	}
+	if after.Payload == nil {
+		after.Payload = map[string]any{}
+	}
+	old, recorded := after.Payload[m.key]
+	if len(types) == 0 {
+		if !recorded {
+			return nil, nil
+		}
+		delete(after.Payload, m.key)
+	} else {
+		if recorded && reflect.DeepEqual(old, any(types)) {
+			return nil, nil
+		}
+		after.Payload[m.key] = types
+	}
+
+	switch {
+	case len(after.Payload) == 0:
+		// the schema of the system configs requires a payload
+		return &Change{Action: ActionDelete, Type: store.HubKeySystemConfig, ID: ManagedConfig, Before: m.stored}, nil
+	case m.stored == nil:
+		return &Change{Action: ActionCreate, Type: store.HubKeySystemConfig, ID: ManagedConfig, After: after}, nil
+	default:
+		return &Change{Action: ActionUpdate, Type: store.HubKeySystemConfig, ID: ManagedConfig, Before: m.stored, After: after}, nil
+	}
+}
+
+// Clone returns a deep copy of a stored object, so that a change keeps the
+// object as it was when the change was planned.
+func Clone(obj any) (any, error) {
+	d, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	c := reflect.New(reflect.TypeOf(obj).Elem()).Interface()
+	if err := json.Unmarshal(d, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
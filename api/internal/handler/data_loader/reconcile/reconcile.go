@@ -0,0 +1,395 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package reconcile
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Types lists the types of objects which are synced, in the order they are
+// created: objects come before the objects which use them.
+var Types = []store.HubKey{
+	store.HubKeyUpstream,
+	store.HubKeyService,
+	store.HubKeyPluginConfig,
+	store.HubKeyProto,
+	store.HubKeySsl,
+	store.HubKeyConsumer,
+	store.HubKeyGlobalRule,
+	store.HubKeyRoute,
+	store.HubKeyStreamRoute,
+}
+
+// Change is a write to one object. Before is the stored object, After the
+// desired one.
+type Change struct {
+	Action Action       `json:"action"`
+	Type   store.HubKey `json:"type"`
+	ID     string       `json:"id"`
+	Name   string       `json:"name,omitempty"`
+	Before any          `json:"before,omitempty"`
+	After  any          `json:"after,omitempty"`
+}
+
+// Plan is the list of changes which make the stored objects selected by the
+// label selector equal to a desired state. Creates and updates come first
+// in dependency order, then the deletes with users before the objects they
+// use. The checksum identifies the plan, so that an apply can make sure it
+// applies the reviewed plan.
+type Plan struct {
+	Selector  map[string]string `json:"selector"`
+	Changes   []Change          `json:"changes"`
+	Unchanged int               `json:"unchanged"`
+	Checksum  string            `json:"checksum"`
+}
+
+// ParseSelector parses a label selector like "team:payments,env:prod", an
+// object is selected when it has all the labels.
+func ParseSelector(s string) (map[string]string, error) {
+	selector := make(map[string]string)
+	for _, l := range strings.Split(s, ",") {
+		if l = strings.TrimSpace(l); l == "" {
+			continue
+		}
+		kv := strings.Split(l, ":")
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, fmt.Errorf("invalid label selector: %s, labels should be key:value", s)
+		}
+		selector[kv[0]] = kv[1]
+	}
+	if len(selector) == 0 {
+		return nil, fmt.Errorf("label selector is required")
+	}
+	return selector, nil
+}
+
+// NewPlan compares the desired objects with the stored objects. Objects with
+// labels are owned when they are selected by the selector and the selector
+// labels are added to the desired objects. The types without labels have
+// their owned objects recorded in the ManagedConfig system config, which the
+// plan updates last. Owned objects which are not desired any more are
+// deleted. A desired object replacing a stored object which is not owned, or
+// a delete leaving a reference to the deleted object, fails the plan.
+func NewPlan(ctx context.Context, stores graph.Stores, desired *loader.DataSets, selector map[string]string) (*Plan, error) {
+	if len(selector) == 0 {
+		return nil, fmt.Errorf("label selector is required")
+	}
+
+	g, err := graph.Build(ctx, stores)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := readManaged(ctx, stores, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Selector: selector, Changes: []Change{}}
+	wanted := make(map[string]any)
+	unlabelled := make(map[store.HubKey][]string)
+	var deletes []Change
+	for _, typ := range Types {
+		live, err := list(ctx, stores, typ)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range Objects(desired, typ) {
+			node, _ := graph.NodeOf(typ, obj)
+			if node.ID == "" {
+				return nil, fmt.Errorf("%s without id can't be synced, id is required", typ)
+			}
+			key := string(typ) + "/" + node.ID
+			if _, ok := wanted[key]; ok {
+				return nil, fmt.Errorf("%s %s is duplicated", typ, node.ID)
+			}
+			wanted[key] = obj
+
+			if labels := labelsOf(obj); labels != nil {
+				merged := make(map[string]string, len(*labels)+len(selector))
+				for k, v := range *labels {
+					merged[k] = v
+				}
+				for k, v := range selector {
+					merged[k] = v
+				}
+				*labels = merged
+			} else {
+				unlabelled[typ] = append(unlabelled[typ], node.ID)
+			}
+
+			stored, ok := live[node.ID]
+			if !ok {
+				plan.Changes = append(plan.Changes, Change{
+					Action: ActionCreate, Type: typ, ID: node.ID, Name: node.Name, After: obj,
+				})
+				continue
+			}
+			if labelsOf(stored) != nil && !selected(stored, selector) {
+				return nil, fmt.Errorf("%s %s is conflicted: it exists and is not selected by the label selector", typ, node.ID)
+			}
+			if labelsOf(stored) == nil && !m.has(typ, node.ID) {
+				return nil, fmt.Errorf("%s %s is conflicted: it exists and was not created by a sync with the label selector", typ, node.ID)
+			}
+			if equal(stored, obj) {
+				plan.Unchanged++
+				continue
+			}
+			// the stored object is kept for a rollback, the store updates
+			// the cached one in place
+			before, err := Clone(stored)
+			if err != nil {
+				return nil, err
+			}
+			plan.Changes = append(plan.Changes, Change{
+				Action: ActionUpdate, Type: typ, ID: node.ID, Name: node.Name, Before: before, After: obj,
+			})
+		}
+
+		for id, stored := range live {
+			if _, ok := wanted[string(typ)+"/"+id]; ok || !owns(m, typ, id, stored, selector) {
+				continue
+			}
+			before, err := Clone(stored)
+			if err != nil {
+				return nil, err
+			}
+			node, _ := graph.NodeOf(typ, stored)
+			deletes = append(deletes, Change{
+				Action: ActionDelete, Type: typ, ID: id, Name: node.Name, Before: before,
+			})
+		}
+	}
+
+	deleted := make(map[string]Change)
+	nodes := make([]graph.Node, 0, len(deletes))
+	for _, c := range deletes {
+		deleted[string(c.Type)+"/"+c.ID] = c
+		nodes = append(nodes, graph.Node{Type: c.Type, ID: c.ID, Name: c.Name})
+	}
+	if err := checkReferences(g, desired, wanted, deleted, nodes); err != nil {
+		return nil, err
+	}
+
+	graph.SortNodes(nodes)
+	for _, n := range nodes {
+		plan.Changes = append(plan.Changes, deleted[string(n.Type)+"/"+n.ID])
+	}
+
+	record, err := m.change(stores, unlabelled)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		plan.Changes = append(plan.Changes, *record)
+	}
+
+	sum, err := checksum(plan)
+	if err != nil {
+		return nil, err
+	}
+	plan.Checksum = sum
+	return plan, nil
+}
+
+// checkReferences makes sure no object still uses a deleted object after the
+// plan is applied: desired objects must not refer to them and the stored
+// users must be deleted or replaced as well.
+func checkReferences(g *graph.Graph, desired *loader.DataSets, wanted map[string]any, deleted map[string]Change, nodes []graph.Node) error {
+	for _, typ := range Types {
+		for _, obj := range Objects(desired, typ) {
+			for _, ref := range graph.References(obj) {
+				if _, ok := deleted[string(ref.Type)+"/"+ref.ID]; ok {
+					node, _ := graph.NodeOf(typ, obj)
+					return fmt.Errorf("%s %s is conflicted: it uses %s %s which is deleted", typ, node.ID, ref.Type, ref.ID)
+				}
+			}
+		}
+	}
+
+	for _, n := range nodes {
+		for _, user := range g.UsedBy(n) {
+			key := string(user.Type) + "/" + user.ID
+			if _, ok := wanted[key]; ok {
+				continue
+			}
+			if _, ok := deleted[key]; ok {
+				continue
+			}
+			return fmt.Errorf("%s %s is conflicted: it is used by %s %s which is not synced", n.Type, n.ID, user.Type, user.ID)
+		}
+	}
+	return nil
+}
+
+func checksum(plan *Plan) (string, error) {
+	d, err := json.Marshal(plan.Changes)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(d)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// list returns the stored objects of the type keyed by ID
+func list(ctx context.Context, stores graph.Stores, typ store.HubKey) (map[string]any, error) {
+	objs := make(map[string]any)
+	s, ok := stores[typ]
+	if !ok || s == nil {
+		return objs, nil
+	}
+	ret, err := s.List(ctx, store.ListInput{})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range ret.Rows {
+		if node, ok := graph.NodeOf(typ, obj); ok {
+			objs[node.ID] = obj
+		}
+	}
+	return objs, nil
+}
+
+// Objects returns pointers to the objects of the type in the data sets
+func Objects(data *loader.DataSets, typ store.HubKey) []any {
+	var objs []any
+	switch typ {
+	case store.HubKeyUpstream:
+		for i := range data.Upstreams {
+			objs = append(objs, &data.Upstreams[i])
+		}
+	case store.HubKeyService:
+		for i := range data.Services {
+			objs = append(objs, &data.Services[i])
+		}
+	case store.HubKeyPluginConfig:
+		for i := range data.PluginConfigs {
+			objs = append(objs, &data.PluginConfigs[i])
+		}
+	case store.HubKeyProto:
+		for i := range data.Protos {
+			objs = append(objs, &data.Protos[i])
+		}
+	case store.HubKeySsl:
+		for i := range data.SSLs {
+			objs = append(objs, &data.SSLs[i])
+		}
+	case store.HubKeyConsumer:
+		for i := range data.Consumers {
+			objs = append(objs, &data.Consumers[i])
+		}
+	case store.HubKeyGlobalRule:
+		for i := range data.GlobalPlugins {
+			objs = append(objs, &data.GlobalPlugins[i])
+		}
+	case store.HubKeyRoute:
+		for i := range data.Routes {
+			objs = append(objs, &data.Routes[i])
+		}
+	case store.HubKeyStreamRoute:
+		for i := range data.StreamRoutes {
+			objs = append(objs, &data.StreamRoutes[i])
+		}
+	}
+	return objs
+}
+
+// labelsOf returns the labels of the object, nil when the type has none
+func labelsOf(obj any) *map[string]string {
+	switch o := obj.(type) {
+	case *entity.Route:
+		return &o.Labels
+	case *entity.Service:
+		return &o.Labels
+	case *entity.Upstream:
+		return &o.Labels
+	case *entity.Consumer:
+		return &o.Labels
+	case *entity.SSL:
+		return &o.Labels
+	case *entity.PluginConfig:
+		return &o.Labels
+	}
+	return nil
+}
+
+// owns reports whether the stored object belongs to the sync of the
+// selector: objects with labels must be selected by it, the objects of the
+// types without labels must have been created by it.
+func owns(m *managed, typ store.HubKey, id string, obj any, selector map[string]string) bool {
+	if labelsOf(obj) == nil {
+		return m.has(typ, id)
+	}
+	return selected(obj, selector)
+}
+
+func selected(obj any, selector map[string]string) bool {
+	labels := *labelsOf(obj)
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// equal compares the objects without the timestamps set by the store
+func equal(a, b any) bool {
+	ma, err := toMap(a)
+	if err != nil {
+		return false
+	}
+	mb, err := toMap(b)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(ma, mb)
+}
+
+func toMap(obj any) (map[string]any, error) {
+	d, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]any)
+	if err := json.Unmarshal(d, &m); err != nil {
+		return nil, err
+	}
+	delete(m, "create_time")
+	delete(m, "update_time")
+	return m, nil
+}
@@ -0,0 +1,268 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shiningrush/droplet/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+)
+
+var owned = map[string]string{"team": "payments"}
+
+func newStores() graph.Stores {
+	return graph.Stores{
+		store.HubKeyRoute: store.NewMockStore(store.HubKeyRoute,
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1", UpdateTime: 10}, URI: "/a", UpstreamID: "u1", Labels: owned},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "r2"}, URI: "/b", UpstreamID: "u2", Labels: owned},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "r3"}, URI: "/c", UpstreamID: "u3"},
+		),
+		store.HubKeyUpstream: store.NewMockStore(store.HubKeyUpstream,
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}, UpstreamDef: entity.UpstreamDef{Type: "roundrobin", Labels: owned}},
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u2"}, UpstreamDef: entity.UpstreamDef{Type: "roundrobin", Labels: owned}},
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u3"}, UpstreamDef: entity.UpstreamDef{Type: "roundrobin", Labels: owned}},
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "other"}, UpstreamDef: entity.UpstreamDef{Type: "chash"}},
+		),
+		store.HubKeyGlobalRule: store.NewMockStore(store.HubKeyGlobalRule),
+	}
+}
+
+func summary(plan *Plan) []string {
+	var ret []string
+	for _, c := range plan.Changes {
+		ret = append(ret, string(c.Action)+" "+string(c.Type)+"/"+c.ID)
+	}
+	return ret
+}
+
+func TestParseSelector(t *testing.T) {
+	selector, err := ParseSelector("team:payments, env:prod")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"team": "payments", "env": "prod"}, selector)
+
+	_, err = ParseSelector("")
+	assert.EqualError(t, err, "label selector is required")
+	_, err = ParseSelector("team")
+	assert.EqualError(t, err, "invalid label selector: team, labels should be key:value")
+}
+
+func TestNewPlan(t *testing.T) {
+	desired := &loader.DataSets{
+		Routes: []entity.Route{
+			{BaseInfo: entity.BaseInfo{ID: "r1"}, URI: "/a", UpstreamID: "u1"},
+			{BaseInfo: entity.BaseInfo{ID: "r4"}, URI: "/d", UpstreamID: "u4"},
+		},
+		Upstreams: []entity.Upstream{
+			{BaseInfo: entity.BaseInfo{ID: "u1"}, UpstreamDef: entity.UpstreamDef{Type: "chash"}},
+			{BaseInfo: entity.BaseInfo{ID: "u3"}, UpstreamDef: entity.UpstreamDef{Type: "roundrobin"}},
+			{BaseInfo: entity.BaseInfo{ID: "u4"}, UpstreamDef: entity.UpstreamDef{Type: "roundrobin"}},
+		},
+		GlobalPlugins: []entity.GlobalPlugins{
+			{BaseInfo: entity.BaseInfo{ID: "g1"}, Plugins: map[string]any{"prometheus": map[string]any{}}},
+		},
+	}
+
+	plan, err := NewPlan(context.Background(), newStores(), desired, owned)
+	assert.Nil(t, err)
+
+	// r1 and u3 are unchanged, the unmanaged route r3 and upstream other are left alone
+	assert.Equal(t, []string{
+		"update upstream/u1",
+		"create upstream/u4",
+		"create global_rule/g1",
+		"create route/r4",
+		"delete route/r2",
+		"delete upstream/u2",
+	}, summary(plan))
+	assert.Equal(t, 2, plan.Unchanged)
+	assert.Equal(t, owned, desired.Routes[1].Labels)
+	assert.Len(t, plan.Checksum, 64)
+
+	again, err := NewPlan(context.Background(), newStores(), desired, owned)
+	assert.Nil(t, err)
+	assert.Equal(t, plan.Checksum, again.Checksum)
+}
+
+func TestNewPlanConflict(t *testing.T) {
+	// the upstream exists but is not owned
+	desired := &loader.DataSets{
+		Upstreams: []entity.Upstream{{BaseInfo: entity.BaseInfo{ID: "other"}}},
+	}
+	_, err := NewPlan(context.Background(), newStores(), desired, owned)
+	assert.EqualError(t, err, "upstream other is conflicted: it exists and is not selected by the label selector")
+
+	// u3 is still used by the unmanaged route r3
+	desired = &loader.DataSets{
+		Routes: []entity.Route{
+			{BaseInfo: entity.BaseInfo{ID: "r1"}, URI: "/a", UpstreamID: "u1"},
+		},
+		Upstreams: []entity.Upstream{{BaseInfo: entity.BaseInfo{ID: "u1"}}},
+	}
+	_, err = NewPlan(context.Background(), newStores(), desired, owned)
+	assert.EqualError(t, err, "upstream u3 is conflicted: it is used by route r3 which is not synced")
+
+	// a desired route uses a deleted upstream
+	desired = &loader.DataSets{
+		Routes: []entity.Route{
+			{BaseInfo: entity.BaseInfo{ID: "r1"}, URI: "/a", UpstreamID: "u2"},
+		},
+		Upstreams: []entity.Upstream{{BaseInfo: entity.BaseInfo{ID: "u3"}}},
+	}
+	_, err = NewPlan(context.Background(), newStores(), desired, owned)
+	assert.EqualError(t, err, "route r1 is conflicted: it uses upstream u2 which is deleted")
+
+	desired = &loader.DataSets{Routes: []entity.Route{{URI: "/a"}}}
+	_, err = NewPlan(context.Background(), newStores(), desired, owned)
+	assert.EqualError(t, err, "route without id can't be synced, id is required")
+
+	_, err = NewPlan(context.Background(), newStores(), desired, nil)
+	assert.EqualError(t, err, "label selector is required")
+}
+
+func TestNewPlanManaged(t *testing.T) {
+	record := &entity.SystemConfig{ConfigName: ManagedConfig, Payload: map[string]any{
+		"team:payments": map[string]any{"proto": []any{"p1", "p2"}},
+		"team:search":   map[string]any{"proto": []any{"p4"}},
+	}}
+	p1 := &entity.Proto{BaseInfo: entity.BaseInfo{ID: "p1"}, Content: "v1"}
+	newStores := func() graph.Stores {
+		configs := &store.MockInterface{}
+		configs.On("Get", ManagedConfig).Return(record, nil)
+		return graph.Stores{
+			store.HubKeyProto: store.NewMockStore(store.HubKeyProto,
+				p1,
+				&entity.Proto{BaseInfo: entity.BaseInfo{ID: "p2"}, Content: "v1"},
+				&entity.Proto{BaseInfo: entity.BaseInfo{ID: "p3"}, Content: "v1"},
+				&entity.Proto{BaseInfo: entity.BaseInfo{ID: "p4"}, Content: "v1"},
+			),
+			store.HubKeySystemConfig: configs,
+		}
+	}
+
+	// p1 was created by the sync, p3 by someone else
+	desired := &loader.DataSets{Protos: []entity.Proto{
+		{BaseInfo: entity.BaseInfo{ID: "p1"}, Content: "v2"},
+		{BaseInfo: entity.BaseInfo{ID: "p5"}, Content: "v1"},
+	}}
+	plan, err := NewPlan(context.Background(), newStores(), desired, owned)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"update proto/p1",
+		"create proto/p5",
+		"delete proto/p2",
+		"update system_config/sync_managed",
+	}, summary(plan))
+
+	// the rollback restores a copy of the stored object
+	before := plan.Changes[0].Before.(*entity.Proto)
+	assert.NotSame(t, p1, before)
+	assert.Equal(t, "v1", before.Content)
+	assert.Equal(t, map[string]any{
+		"team:payments": map[string]any{"proto": []any{"p1", "p5"}},
+		"team:search":   map[string]any{"proto": []any{"p4"}},
+	}, plan.Changes[3].After.(*entity.SystemConfig).Payload)
+	assert.Len(t, record.Payload["team:payments"].(map[string]any)["proto"], 2)
+
+	desired = &loader.DataSets{Protos: []entity.Proto{
+		{BaseInfo: entity.BaseInfo{ID: "p3"}, Content: "v2"},
+	}}
+	_, err = NewPlan(context.Background(), newStores(), desired, owned)
+	assert.EqualError(t, err, "proto p3 is conflicted: it exists and was not created by a sync with the label selector")
+
+	// the objects of another selector are not owned either
+	desired = &loader.DataSets{Protos: []entity.Proto{
+		{BaseInfo: entity.BaseInfo{ID: "p4"}, Content: "v1"},
+	}}
+	_, err = NewPlan(context.Background(), newStores(), desired, owned)
+	assert.EqualError(t, err, "proto p4 is conflicted: it exists and was not created by a sync with the label selector")
+
+	// without a record nothing is owned and the record is created
+	stores := newStores()
+	configs := &store.MockInterface{}
+	configs.On("Get", ManagedConfig).Return(nil, data.ErrNotFound)
+	stores[store.HubKeySystemConfig] = configs
+	desired = &loader.DataSets{Protos: []entity.Proto{
+		{BaseInfo: entity.BaseInfo{ID: "p5"}, Content: "v1"},
+	}}
+	plan, err = NewPlan(context.Background(), stores, desired, owned)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"create proto/p5", "create system_config/sync_managed"}, summary(plan))
+}
+
+func TestApply(t *testing.T) {
+	stores := newStores()
+	var written []string
+	for typ, s := range stores {
+		typ, ms := typ, s.(*store.MockInterface)
+		ms.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			node, _ := graph.NodeOf(typ, args.Get(1))
+			written = append(written, "create "+string(typ)+"/"+node.ID)
+		}).Return(nil, nil)
+		ms.On("Update", mock.Anything, mock.Anything, false).Run(func(args mock.Arguments) {
+			node, _ := graph.NodeOf(typ, args.Get(1))
+			written = append(written, "update "+string(typ)+"/"+node.ID)
+		}).Return(nil, nil)
+	}
+	stores[store.HubKeyRoute].(*store.MockInterface).On("BatchDelete", mock.Anything, mock.Anything).
+		Return(errors.New("etcd is down"))
+
+	plan := &Plan{Changes: []Change{
+		{Action: ActionUpdate, Type: store.HubKeyUpstream, ID: "u1",
+			Before: &entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}},
+			After:  &entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}}},
+		{Action: ActionCreate, Type: store.HubKeyRoute, ID: "r4",
+			After: &entity.Route{BaseInfo: entity.BaseInfo{ID: "r4"}}},
+		{Action: ActionDelete, Type: store.HubKeyRoute, ID: "r2",
+			Before: &entity.Route{BaseInfo: entity.BaseInfo{ID: "r2"}}},
+	}}
+
+	// the failed delete reverts the create and the update
+	err := Apply(context.Background(), stores, plan)
+	assert.EqualError(t, err, "delete route r2 failed: etcd is down, rollback failed: route r4: etcd is down")
+	assert.Equal(t, []string{"update upstream/u1", "create route/r4", "update upstream/u1"}, written)
+
+	written = nil
+	assert.Nil(t, Apply(context.Background(), stores, &Plan{Changes: plan.Changes[:2]}))
+	assert.Equal(t, []string{"update upstream/u1", "create route/r4"}, written)
+}
+
+func TestDiff(t *testing.T) {
+	diff, err := Diff(
+		&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1", UpdateTime: 1}, URI: "/a", Plugins: map[string]any{
+			"limit-count": map[string]any{"count": 1, "time_window": 60},
+			"cors":        map[string]any{},
+		}},
+		&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1", UpdateTime: 2}, URI: "/a", Desc: "hello", Plugins: map[string]any{
+			"limit-count": map[string]any{"count": 2, "time_window": 60},
+		}},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, []FieldDiff{
+		{Path: "desc", After: "hello"},
+		{Path: "plugins.cors", Before: map[string]any{}},
+		{Path: "plugins.limit-count.count", Before: float64(1), After: float64(2)},
+	}, diff)
+}
@@ -17,14 +17,11 @@
 package data_loader
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"reflect"
-	"strconv"
 	"strings"
 
-	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 	"github.com/shiningrush/droplet"
 	"github.com/shiningrush/droplet/data"
@@ -34,24 +31,35 @@ import (
 	"github.com/apisix/manager-api/internal/core/entity"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
-	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/openapi3"
 	"github.com/apisix/manager-api/internal/utils"
 	"github.com/apisix/manager-api/internal/utils/consts"
 )
 
 type Handler struct {
-	routeStore    store.Interface
-	upstreamStore store.Interface
-	serviceStore  store.Interface
-	consumerStore store.Interface
+	routeStore        store.Interface
+	upstreamStore     store.Interface
+	serviceStore      store.Interface
+	consumerStore     store.Interface
+	sslStore          store.Interface
+	streamRouteStore  store.Interface
+	globalPluginStore store.Interface
+	pluginConfigStore store.Interface
+	protoStore        store.Interface
 }
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		routeStore:    store.GetStore(store.HubKeyRoute),
-		upstreamStore: store.GetStore(store.HubKeyUpstream),
-		serviceStore:  store.GetStore(store.HubKeyService),
-		consumerStore: store.GetStore(store.HubKeyConsumer),
+		routeStore:        store.NewContextStore(store.HubKeyRoute),
+		upstreamStore:     store.NewContextStore(store.HubKeyUpstream),
+		serviceStore:      store.NewContextStore(store.HubKeyService),
+		consumerStore:     store.NewContextStore(store.HubKeyConsumer),
+		sslStore:          store.NewContextStore(store.HubKeySsl),
+		streamRouteStore:  store.NewContextStore(store.HubKeyStreamRoute),
+		globalPluginStore: store.NewContextStore(store.HubKeyGlobalRule),
+		pluginConfigStore: store.NewContextStore(store.HubKeyPluginConfig),
+		protoStore:        store.NewContextStore(store.HubKeyProto),
 	}, nil
 }
 
@@ -59,6 +67,12 @@ func (h *Handler) ApplyRoute(r *gin.Engine) {
 	r.GET("/apisix/admin/export/routes/:ids", wgin.Wraps(h.ExportRoutes,
 		wrapper.InputType(reflect.TypeOf(ExportInput{}))))
 	r.GET("/apisix/admin/export/routes", wgin.Wraps(h.ExportAllRoutes))
+	r.GET("/apisix/admin/export/openapi3", wgin.Wraps(h.ExportOpenAPI3,
+		wrapper.InputType(reflect.TypeOf(ExportOpenAPI3Input{}))))
+	r.GET("/apisix/admin/export/declarative", wgin.Wraps(h.ExportDeclarative,
+		wrapper.InputType(reflect.TypeOf(ExportDeclarativeInput{}))))
+	r.GET("/apisix/admin/export/k8s", wgin.Wraps(h.ExportK8s,
+		wrapper.InputType(reflect.TypeOf(ExportK8sInput{}))))
 }
 
 type ExportInput struct {
@@ -87,30 +101,9 @@ func (h *Handler) ExportRoutes(c droplet.Context) (any, error) {
 		routes = append(routes, route.(*entity.Route))
 	}
 
-	swagger, err := h.RouteToOpenAPI3(c, routes)
-	if err != nil {
-		return nil, err
-	}
-	return swagger, nil
+	return h.routesToOpenAPI3(c.Context(), routes)
 }
 
-type AuthType string
-
-const (
-	BasicAuth AuthType = "basic-auth"
-	KeyAuth   AuthType = "key-auth"
-	JWTAuth   AuthType = "jwt-auth"
-)
-
-var (
-	openApi         = "3.0.0"
-	title           = "RoutesExport"
-	service         any
-	err             error
-	routeMethods    []string
-	_allHTTPMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch, http.MethodHead, http.MethodConnect, http.MethodTrace, http.MethodOptions}
-)
-
 // ExportAllRoutes All routes can be directly exported without passing parameters
 func (h *Handler) ExportAllRoutes(c droplet.Context) (any, error) {
 	routelist, err := h.routeStore.List(c.Context(), store.ListInput{})
@@ -129,383 +122,189 @@ func (h *Handler) ExportAllRoutes(c droplet.Context) (any, error) {
 		routes = append(routes, route.(*entity.Route))
 	}
 
-	swagger, err := h.RouteToOpenAPI3(c, routes)
-	if err != nil {
-		return nil, err
-	}
-	return swagger, nil
+	return h.routesToOpenAPI3(c.Context(), routes)
 }
 
-// RouteToOpenAPI3 Pass in route list parameter: []*entity.Route, convert route data to openapi3 and export processing function
-func (h *Handler) RouteToOpenAPI3(c droplet.Context, routes []*entity.Route) (*openapi3.Swagger, error) {
-	paths := openapi3.Paths{}
-	paramsRefs := []*openapi3.ParameterRef{}
-	requestBody := &openapi3.RequestBody{}
-	components := &openapi3.Components{}
-	secSchemas := openapi3.SecuritySchemes{}
-	_pathNumber := GetPathNumber()
-
+// routesToOpenAPI3 exports the routes as a self-contained OpenAPI 3 document,
+// the services and upstreams they use are copied into them.
+func (h *Handler) routesToOpenAPI3(ctx context.Context, routes []*entity.Route) (any, error) {
+	col := newCollector(h)
 	for _, route := range routes {
-		extensions := make(map[string]any)
-		servicePlugins := make(map[string]any)
-		plugins := make(map[string]any)
-		serviceLabels := make(map[string]string)
-
-		pathItem := &openapi3.PathItem{}
-		path := openapi3.Operation{}
-		path.Summary = route.Desc
-		path.OperationID = route.Name
-
-		if route.ServiceID != nil {
-			serviceID := utils.InterfaceToString(route.ServiceID)
-			service, err = h.serviceStore.Get(c.Context(), serviceID)
-			if err != nil {
-				if err == data.ErrNotFound {
-					return nil, fmt.Errorf(consts.IDNotFound, "service", route.ServiceID)
-				}
-				return nil, err
-			}
-
-			_service := service.(*entity.Service)
-			servicePlugins = _service.Plugins
-			serviceLabels = _service.Labels
-		}
-
-		//Parse upstream
-		_upstream, err := h.ParseRouteUpstream(c, route)
-
-		if err != nil {
-			log.Errorf("ParseRouteUpstream err: ", err)
+		if err := col.addRoute(ctx, route); err != nil {
 			return nil, err
-		} else if _upstream != nil {
-			extensions["x-apisix-upstream"] = _upstream
-		}
-
-		if route.Host != "" {
-			extensions["x-apisix-host"] = route.Host
-		}
-
-		if route.Hosts != nil {
-			extensions["x-apisix-hosts"] = route.Hosts
-		}
-
-		//Parse Labels
-		labels, err := ParseLabels(route, serviceLabels)
-		if err != nil {
-			log.Errorf("parseLabels err: ", err)
-			return nil, err
-		}
-
-		if labels != nil {
-			extensions["x-apisix-labels"] = labels
 		}
+	}
 
-		if route.RemoteAddr != "" {
-			extensions["x-apisix-remote_addr"] = route.RemoteAddr
-		}
+	l := &openapi3.Loader{Inline: true}
+	return l.Export(col.data)
+}
 
-		if route.RemoteAddrs != nil {
-			extensions["x-apisix-remote_addrs"] = route.RemoteAddrs
-		}
+type ExportOpenAPI3Input struct {
+	RouteIDs    string `auto_read:"route_ids,query"`
+	ServiceIDs  string `auto_read:"service_ids,query"`
+	UpstreamIDs string `auto_read:"upstream_ids,query"`
+}
 
-		if route.FilterFunc != "" {
-			extensions["x-apisix-filter_func"] = route.FilterFunc
-		}
+// ExportOpenAPI3 exports routes, services and upstreams as an OpenAPI 3
+// document which is imported again as the same objects. The services and
+// upstreams used by the exported routes and services are always exported,
+// without any ID everything is exported.
+func (h *Handler) ExportOpenAPI3(c droplet.Context) (any, error) {
+	input := c.Input().(*ExportOpenAPI3Input)
+	col := newCollector(h)
 
-		if route.Script != nil {
-			extensions["x-apisix-script"] = route.Script
+	if input.RouteIDs == "" && input.ServiceIDs == "" && input.UpstreamIDs == "" {
+		if err := col.addAll(c.Context()); err != nil {
+			return nil, err
 		}
-
-		if route.ServiceProtocol != "" {
-			extensions["x-apisix-service_protocol"] = route.ServiceProtocol
+	} else {
+		for _, id := range splitIDs(input.UpstreamIDs) {
+			if _, err := col.upstream(c.Context(), id); err != nil {
+				return nil, err
+			}
 		}
-
-		if route.Vars != nil {
-			extensions["x-apisix-vars"] = route.Vars
+		for _, id := range splitIDs(input.ServiceIDs) {
+			if _, err := col.service(c.Context(), id); err != nil {
+				return nil, err
+			}
 		}
-
-		if route.ID != nil {
-			extensions["x-apisix-id"] = route.ID
+		for _, id := range splitIDs(input.RouteIDs) {
+			route, err := h.routeStore.Get(c.Context(), id)
+			if err != nil {
+				if err == data.ErrNotFound {
+					return nil, fmt.Errorf(consts.IDNotFound, "route", id)
+				}
+				return nil, err
+			}
+			if err := col.addRoute(c.Context(), route.(*entity.Route)); err != nil {
+				return nil, err
+			}
 		}
+	}
 
-		// Parse Route URIs
-		paths, paramsRefs = ParseRouteUris(route, paths, paramsRefs, pathItem, _pathNumber())
-
-		//Parse Route Plugins
-		path, secSchemas, paramsRefs, plugins, err = ParseRoutePlugins(route, paramsRefs, plugins, path, servicePlugins, secSchemas, requestBody)
-
-		if err != nil {
-			log.Errorf("parseRoutePlugins err: ", err)
-			return nil, err
-		}
+	l := &openapi3.Loader{}
+	return l.Export(col.data)
+}
 
-		if len(plugins) > 0 {
-			extensions["x-apisix-plugins"] = plugins
+func splitIDs(ids string) []string {
+	var ret []string
+	for _, id := range strings.Split(ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ret = append(ret, id)
 		}
+	}
+	return ret
+}
 
-		extensions["x-apisix-priority"] = route.Priority
-		extensions["x-apisix-status"] = route.Status
-		extensions["x-apisix-enable_websocket"] = route.EnableWebsocket
-		path.Extensions = extensions
-		path.Parameters = paramsRefs
-		path.RequestBody = &openapi3.RequestBodyRef{Value: requestBody}
-		path.Responses = openapi3.NewResponses()
-
-		if route.Methods != nil && len(route.Methods) > 0 {
-			routeMethods = route.Methods
-		} else {
-			routeMethods = _allHTTPMethods
-		}
+// collector gathers the routes to export together with the services and
+// upstreams they use, each object is loaded once.
+type collector struct {
+	h         *Handler
+	data      loader.DataSets
+	services  map[string]*entity.Service
+	upstreams map[string]*entity.Upstream
+}
 
-		for i := range routeMethods {
-			switch strings.ToUpper(routeMethods[i]) {
-			case http.MethodGet:
-				pathItem.Get = ParsePathItem(path, http.MethodGet)
-			case http.MethodPost:
-				pathItem.Post = ParsePathItem(path, http.MethodPost)
-			case http.MethodPut:
-				pathItem.Put = ParsePathItem(path, http.MethodPut)
-			case http.MethodDelete:
-				pathItem.Delete = ParsePathItem(path, http.MethodDelete)
-			case http.MethodPatch:
-				pathItem.Patch = ParsePathItem(path, http.MethodPatch)
-			case http.MethodHead:
-				pathItem.Head = ParsePathItem(path, http.MethodHead)
-			case http.MethodConnect:
-				pathItem.Connect = ParsePathItem(path, http.MethodConnect)
-			case http.MethodTrace:
-				pathItem.Trace = ParsePathItem(path, http.MethodTrace)
-			case http.MethodOptions:
-				pathItem.Options = ParsePathItem(path, http.MethodOptions)
-			}
-		}
+func newCollector(h *Handler) *collector {
+	return &collector{
+		h:         h,
+		services:  make(map[string]*entity.Service),
+		upstreams: make(map[string]*entity.Upstream),
 	}
+}
 
-	components.SecuritySchemes = secSchemas
-	swagger := openapi3.Swagger{
-		OpenAPI:    openApi,
-		Info:       &openapi3.Info{Title: title, Version: openApi},
-		Paths:      paths,
-		Components: *components,
+func (c *collector) addAll(ctx context.Context) error {
+	upstreams, err := c.h.upstreamStore.List(ctx, store.ListInput{})
+	if err != nil {
+		return err
+	}
+	for _, row := range upstreams.Rows {
+		upstream := row.(*entity.Upstream)
+		c.upstreams[utils.InterfaceToString(upstream.ID)] = upstream
+		c.data.Upstreams = append(c.data.Upstreams, *upstream)
 	}
-	return &swagger, nil
-}
 
-// ParseLabels When service and route have labels at the same time, use route's label.
-// When route has no label, service sometimes uses service's label. This function is used to process this logic
-func ParseLabels(route *entity.Route, serviceLabels map[string]string) (map[string]string, error) {
-	if route.Labels != nil {
-		return route.Labels, nil
-	} else if route.ServiceID != nil {
-		return serviceLabels, nil
+	services, err := c.h.serviceStore.List(ctx, store.ListInput{})
+	if err != nil {
+		return err
+	}
+	for _, row := range services.Rows {
+		service := row.(*entity.Service)
+		c.services[utils.InterfaceToString(service.ID)] = service
+		c.data.Services = append(c.data.Services, *service)
 	}
-	return nil, nil
-}
 
-// ParsePathItem Convert data in route to openapi3
-func ParsePathItem(path openapi3.Operation, routeMethod string) *openapi3.Operation {
-	_path := &openapi3.Operation{
-		ExtensionProps: path.ExtensionProps,
-		Tags:           path.Tags,
-		Summary:        path.Summary,
-		Description:    path.Description,
-		OperationID:    path.OperationID + routeMethod,
-		Parameters:     path.Parameters,
-		RequestBody:    path.RequestBody,
-		Responses:      path.Responses,
-		Callbacks:      path.Callbacks,
-		Deprecated:     path.Deprecated,
-		Security:       path.Security,
-		Servers:        path.Servers,
-		ExternalDocs:   path.ExternalDocs,
+	routes, err := c.h.routeStore.List(ctx, store.ListInput{})
+	if err != nil {
+		return err
+	}
+	for _, row := range routes.Rows {
+		if err := c.addRoute(ctx, row.(*entity.Route)); err != nil {
+			return err
+		}
 	}
-	return _path
+	return nil
 }
 
-// ParseRoutePlugins Merge service with plugin in route
-func ParseRoutePlugins(route *entity.Route, paramsRefs []*openapi3.ParameterRef, plugins map[string]any, path openapi3.Operation, servicePlugins map[string]any, secSchemas openapi3.SecuritySchemes, requestBody *openapi3.RequestBody) (openapi3.Operation, openapi3.SecuritySchemes, []*openapi3.ParameterRef, map[string]any, error) {
-	if route.Plugins != nil {
-		param := &openapi3.Parameter{}
-		secReq := &openapi3.SecurityRequirements{}
-
-		// analysis plugins
-		for key, value := range route.Plugins {
-			// analysis request-validation plugin
-			if key == "request-validation" {
-				if valueMap, ok := value.(map[string]any); ok {
-					if hsVal, ok := valueMap["header_schema"]; ok {
-						param.In = "header"
-						requestValidation := &entity.RequestValidation{}
-						reqBytes, _ := json.Marshal(&hsVal)
-						err := json.Unmarshal(reqBytes, requestValidation)
-						if err != nil {
-							log.Errorf("json marshal failed: %s", err)
-						}
-						for key1, value1 := range requestValidation.Properties.(map[string]any) {
-							for _, arr := range requestValidation.Required {
-								if arr == key1 {
-									param.Required = true
-								}
-							}
-							param.Name = key1
-							typeStr := value1.(map[string]any)
-							schema := &openapi3.Schema{Type: typeStr["type"].(string)}
-							param.Schema = &openapi3.SchemaRef{Value: schema}
-							paramsRefs = append(paramsRefs, &openapi3.ParameterRef{Value: param})
-						}
-					}
-
-					if bsVal, ok := valueMap["body_schema"]; ok {
-						m := map[string]*openapi3.MediaType{}
-						reqBytes, _ := json.Marshal(&bsVal)
-						schema := &openapi3.Schema{}
-						err := json.Unmarshal(reqBytes, schema)
-						if err != nil {
-							log.Errorf("json marshal failed: %s", err)
-						}
-						// In the swagger format conversion, there are many cases of content type data format
-						// Such as (application/json, application/xml, text/xml) and more.
-						// There are many matching methods, such as equal, inclusive and so on.
-						// Therefore, the current processing method is to use "*/*" to match all
-						m["*/*"] = &openapi3.MediaType{Schema: &openapi3.SchemaRef{Value: schema}}
-						requestBody.Content = m
-					}
-				}
-				continue
-			}
-			// analysis security plugins
-			securityEnv := &openapi3.SecurityRequirement{}
-			switch key {
-			case string(KeyAuth):
-				secSchemas["api_key"] = &openapi3.SecuritySchemeRef{Value: openapi3.NewCSRFSecurityScheme()}
-				securityEnv.Authenticate("api_key", " ")
-				secReq.With(*securityEnv)
-				continue
-			case string(BasicAuth):
-				secSchemas["basicAuth"] = &openapi3.SecuritySchemeRef{Value: &openapi3.SecurityScheme{
-					Type: "basicAuth",
-					Name: "basicAuth",
-					In:   "header",
-				}}
-				securityEnv.Authenticate("basicAuth", " ")
-				secReq.With(*securityEnv)
-				continue
-			case string(JWTAuth):
-				secSchemas["bearerAuth"] = &openapi3.SecuritySchemeRef{Value: openapi3.NewJWTSecurityScheme()}
-				securityEnv.Authenticate("bearerAuth", " ")
-				secReq.With(*securityEnv)
-				continue
-			}
-			plugins[key] = value
+func (c *collector) addRoute(ctx context.Context, route *entity.Route) error {
+	var service *entity.Service
+	if route.ServiceID != nil {
+		var err error
+		service, err = c.service(ctx, utils.InterfaceToString(route.ServiceID))
+		if err != nil {
+			return err
 		}
-		path.Security = secReq
+	}
 
-		if route.ServiceID != nil && servicePlugins != nil {
-			_servicePlugins, err := json.Marshal(servicePlugins)
-			if err != nil {
-				log.Errorf("MapToJson err: ", err)
-				return path, nil, nil, nil, err
+	// the upstream of the service is only used when the route has none
+	if route.Upstream == nil {
+		if route.UpstreamID != nil {
+			if _, err := c.upstream(ctx, utils.InterfaceToString(route.UpstreamID)); err != nil {
+				return err
 			}
-			_plugins, err := json.Marshal(plugins)
-			if err != nil {
-				log.Errorf("MapToJson err: ", err)
-				return path, nil, nil, nil, err
-			}
-			bytePlugins, err := utils.MergeJson(_servicePlugins, _plugins)
-			if err != nil {
-				log.Errorf("Plugins MergeJson err: ", err)
-				return path, nil, nil, nil, err
-			}
-			err = json.Unmarshal(bytePlugins, &plugins)
-			if err != nil {
-				log.Errorf("JsonToMapDemo err: ", err)
-				return path, nil, nil, nil, err
+		} else if service != nil && service.Upstream == nil && service.UpstreamID != nil {
+			if _, err := c.upstream(ctx, utils.InterfaceToString(service.UpstreamID)); err != nil {
+				return err
 			}
 		}
-	} else if route.Plugins == nil && route.ServiceID != nil {
-		plugins = servicePlugins
 	}
-	return path, secSchemas, paramsRefs, plugins, nil
-}
 
-// ParseRouteUris The URI and URIs of route are converted to paths URI in openapi3
-func ParseRouteUris(route *entity.Route, paths openapi3.Paths, paramsRefs []*openapi3.ParameterRef, pathItem *openapi3.PathItem, _pathNumber int) (openapi3.Paths, []*openapi3.ParameterRef) {
-	routeURIs := []string{}
-	if route.URI != "" {
-		routeURIs = append(routeURIs, route.URI)
-	}
+	c.data.Routes = append(c.data.Routes, *route)
+	return nil
+}
 
-	if route.Uris != nil {
-		routeURIs = route.Uris
+func (c *collector) service(ctx context.Context, id string) (*entity.Service, error) {
+	if service, ok := c.services[id]; ok {
+		return service, nil
 	}
 
-	for _, uri := range routeURIs {
-		if strings.Contains(uri, "*") {
-			if _, ok := paths[strings.Split(uri, "*")[0]+"{params}"]; !ok {
-				paths[strings.Split(uri, "*")[0]+"{params}"] = pathItem
-			} else {
-				paths[strings.Split(uri, "*")[0]+"{params}"+"-APISIX-REPEAT-URI-"+strconv.Itoa(_pathNumber)] = pathItem
-			}
-			// add params introduce
-			paramsRefs = append(paramsRefs, &openapi3.ParameterRef{
-				Value: &openapi3.Parameter{
-					In:          "path",
-					Name:        "params",
-					Required:    true,
-					Description: "params in path",
-					Schema:      &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}}}})
-		} else {
-			if _, ok := paths[uri]; !ok {
-				paths[uri] = pathItem
-			} else {
-				paths[uri+"-APISIX-REPEAT-URI-"+strconv.Itoa(_pathNumber)] = pathItem
-			}
+	obj, err := c.h.serviceStore.Get(ctx, id)
+	if err != nil {
+		if err == data.ErrNotFound {
+			return nil, fmt.Errorf(consts.IDNotFound, "service", id)
 		}
+		return nil, err
 	}
-	return paths, paramsRefs
+	service := obj.(*entity.Service)
+	c.services[id] = service
+	c.data.Services = append(c.data.Services, *service)
+	return service, nil
 }
 
-// ParseRouteUpstream Processing the upstream in service and route
-func (h *Handler) ParseRouteUpstream(c droplet.Context, route *entity.Route) (any, error) {
-	// The upstream data of route has the highest priority.
-	// If there is one, it will be used directly.
-	// If there is no route, the upstream data of service will be used.
-	// If there is no route, the upstream data of service will not be used normally.
-	if route.Upstream != nil {
-		return route.Upstream, nil
-	} else if route.UpstreamID != nil && route.Upstream == nil {
-		upstreamID := utils.InterfaceToString(route.UpstreamID)
-		upstream, err := h.upstreamStore.Get(c.Context(), upstreamID)
-		if err != nil {
-			if err == data.ErrNotFound {
-				return nil, fmt.Errorf(consts.IDNotFound, "upstream", route.UpstreamID)
-			}
-			return nil, err
-		}
+func (c *collector) upstream(ctx context.Context, id string) (*entity.Upstream, error) {
+	if upstream, ok := c.upstreams[id]; ok {
 		return upstream, nil
-	} else if route.UpstreamID == nil && route.Upstream == nil && route.ServiceID != nil {
-		_service := service.(*entity.Service)
-		if _service.Upstream != nil {
-			return _service.Upstream, nil
-		} else if _service.Upstream == nil && _service.UpstreamID != nil {
-			upstreamID := utils.InterfaceToString(_service.UpstreamID)
-			upstream, err := h.upstreamStore.Get(c.Context(), upstreamID)
-			if err != nil {
-				if err == data.ErrNotFound {
-					return nil, fmt.Errorf(consts.IDNotFound, "upstream", _service.UpstreamID)
-				}
-				return nil, err
-			}
-			return upstream, nil
-		}
 	}
-	return nil, nil
-}
 
-func GetPathNumber() func() int {
-	i := 0
-	return func() int {
-		i++
-		return i
+	obj, err := c.h.upstreamStore.Get(ctx, id)
+	if err != nil {
+		if err == data.ErrNotFound {
+			return nil, fmt.Errorf(consts.IDNotFound, "upstream", id)
+		}
+		return nil, err
 	}
+	upstream := obj.(*entity.Upstream)
+	c.upstreams[id] = upstream
+	c.data.Upstreams = append(c.data.Upstreams, *upstream)
+	return upstream, nil
 }
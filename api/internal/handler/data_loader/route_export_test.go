@@ -25,7 +25,9 @@ import (
 
 	"github.com/apisix/manager-api/internal/core/entity"
 	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -901,10 +903,11 @@ func TestExportRoutesCreateByServiceIdNoUpstream(t *testing.T) {
 	}`
 
 	s := `{
+		"id": "s5",
 		"name": "testservice",
 		"desc": "testservice_desc",
 		"enable_websocket":true,
-		"upstream_id": "6"
+		"upstream_id": "u1"
 	}`
 
 	r := `{
@@ -1013,6 +1016,7 @@ func TestExportRoutesCreateByServiceIdNoUpstream(t *testing.T) {
 func TestExportRoutesCreateByLabel(t *testing.T) {
 	input := &ExportInput{IDs: "1"}
 	s := `{
+		"id": "s1",
 		"name": "testservice",
 		"desc": "testservice_desc",
 		"enable_websocket":true,
@@ -1107,6 +1111,7 @@ func TestExportRoutesCreateByLabel(t *testing.T) {
 func TestExportRoutesCreateByLabel2(t *testing.T) {
 	input := &ExportInput{IDs: "1"}
 	s := `{
+		"id": "s2",
 		"name": "testservice",
 		"desc": "testservice_desc",
 		"enable_websocket":true,
@@ -2398,6 +2403,44 @@ func TestExportRoutesMethodsFeildNil(t *testing.T) {
 	assert.NotNil(t, ret1)
 }
 
+func TestExportOpenAPI3(t *testing.T) {
+	route := &entity.Route{
+		BaseInfo:  entity.BaseInfo{ID: "r1"},
+		Name:      "r1",
+		URI:       "/hello",
+		Methods:   []string{"GET"},
+		ServiceID: "s1",
+	}
+	service := &entity.Service{BaseInfo: entity.BaseInfo{ID: "s1"}, UpstreamID: "u1"}
+	upstream := &entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}, UpstreamDef: entity.UpstreamDef{Type: "roundrobin"}}
+
+	routeStore := &store.MockInterface{}
+	routeStore.On("Get", "r1").Return(route, nil)
+	routeStore.On("Get", "r2").Return(nil, data.ErrNotFound)
+	serviceStore := &store.MockInterface{}
+	serviceStore.On("Get", "s1").Return(service, nil)
+	upstreamStore := &store.MockInterface{}
+	upstreamStore.On("Get", "u1").Return(upstream, nil)
+
+	h := Handler{routeStore: routeStore, serviceStore: serviceStore, upstreamStore: upstreamStore}
+	ctx := droplet.NewContext()
+	ctx.SetInput(&ExportOpenAPI3Input{RouteIDs: "r1"})
+
+	// the service and the upstream the route uses are exported with it
+	ret, err := h.ExportOpenAPI3(ctx)
+	assert.Nil(t, err)
+	swagger := ret.(*openapi3.Swagger)
+	assert.Equal(t, []entity.Service{*service}, swagger.Extensions["x-apisix-services"])
+	assert.Equal(t, []entity.Upstream{*upstream}, swagger.Extensions["x-apisix-upstreams"])
+	get := swagger.Paths["/hello"].Get
+	assert.Equal(t, json.RawMessage(`"s1"`), get.Extensions["x-apisix-service_id"])
+	assert.Nil(t, get.Extensions["x-apisix-upstream"])
+
+	ctx.SetInput(&ExportOpenAPI3Input{RouteIDs: "r2"})
+	_, err = h.ExportOpenAPI3(ctx)
+	assert.EqualError(t, err, "route id: r2 not found")
+}
+
 func replaceStr(str string) string {
 	str = strings.Replace(str, "\n", "", -1)
 	str = strings.Replace(str, "\t", "", -1)
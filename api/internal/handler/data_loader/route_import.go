@@ -24,7 +24,6 @@ import (
 	"reflect"
 
 	"github.com/gin-gonic/gin"
-	"github.com/juliangruber/go-intersect"
 	"github.com/pkg/errors"
 	"github.com/shiningrush/droplet"
 	"github.com/shiningrush/droplet/wrapper"
@@ -32,10 +31,17 @@ import (
 
 	"github.com/apisix/manager-api/internal/conf"
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/matcher"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	loader "github.com/apisix/manager-api/internal/handler/data_loader/loader"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/har"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/k8s"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/kong"
 	"github.com/apisix/manager-api/internal/handler/data_loader/loader/openapi3"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/postman"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/swagger2"
 )
 
 type ImportHandler struct {
@@ -48,25 +54,44 @@ type ImportHandler struct {
 	globalPluginStore store.Interface
 	pluginConfigStore store.Interface
 	protoStore        store.Interface
+	graphStores       graph.Stores
+	sessions          *importSessions
 }
 
 func NewImportHandler() (handler.RouteRegister, error) {
+	graphStores := graph.DefaultStores()
+	// the sync records the objects of the types without labels it owns
+	graphStores[store.HubKeySystemConfig] = store.NewContextStore(store.HubKeySystemConfig)
 	return &ImportHandler{
-		routeStore:        store.GetStore(store.HubKeyRoute),
-		upstreamStore:     store.GetStore(store.HubKeyUpstream),
-		serviceStore:      store.GetStore(store.HubKeyService),
-		consumerStore:     store.GetStore(store.HubKeyConsumer),
-		sslStore:          store.GetStore(store.HubKeySsl),
-		streamRouteStore:  store.GetStore(store.HubKeyStreamRoute),
-		globalPluginStore: store.GetStore(store.HubKeyGlobalRule),
-		pluginConfigStore: store.GetStore(store.HubKeyPluginConfig),
-		protoStore:        store.GetStore(store.HubKeyProto),
+		routeStore:        store.NewContextStore(store.HubKeyRoute),
+		upstreamStore:     store.NewContextStore(store.HubKeyUpstream),
+		serviceStore:      store.NewContextStore(store.HubKeyService),
+		consumerStore:     store.NewContextStore(store.HubKeyConsumer),
+		sslStore:          store.NewContextStore(store.HubKeySsl),
+		streamRouteStore:  store.NewContextStore(store.HubKeyStreamRoute),
+		globalPluginStore: store.NewContextStore(store.HubKeyGlobalRule),
+		pluginConfigStore: store.NewContextStore(store.HubKeyPluginConfig),
+		protoStore:        store.NewContextStore(store.HubKeyProto),
+		graphStores:       graphStores,
+		sessions:          newImportSessions(previewTTL),
 	}, nil
 }
 
 func (h *ImportHandler) ApplyRoute(r *gin.Engine) {
 	r.POST("/apisix/admin/import/routes", wgin.Wraps(h.Import,
 		wrapper.InputType(reflect.TypeOf(ImportInput{}))))
+	r.POST("/apisix/admin/import/declarative", wgin.Wraps(h.ImportDeclarative,
+		wrapper.InputType(reflect.TypeOf(ImportDeclarativeInput{}))))
+	r.POST("/apisix/admin/import/preview", wgin.Wraps(h.Preview,
+		wrapper.InputType(reflect.TypeOf(ImportInput{}))))
+	r.POST("/apisix/admin/import/preview/:session_id/apply", wgin.Wraps(h.ApplyPreview,
+		wrapper.InputType(reflect.TypeOf(ApplyPreviewInput{}))))
+	r.DELETE("/apisix/admin/import/preview/:session_id", wgin.Wraps(h.DiscardPreview,
+		wrapper.InputType(reflect.TypeOf(DiscardPreviewInput{}))))
+	r.POST("/apisix/admin/sync/plan", wgin.Wraps(h.SyncPlan,
+		wrapper.InputType(reflect.TypeOf(SyncInput{}))))
+	r.POST("/apisix/admin/sync/apply", wgin.Wraps(h.SyncApply,
+		wrapper.InputType(reflect.TypeOf(SyncInput{}))))
 }
 
 type ImportResult struct {
@@ -88,22 +113,45 @@ type ImportInput struct {
 
 const (
 	LoaderTypeOpenAPI3 LoaderType = "openapi3"
+	LoaderTypeSwagger2 LoaderType = "swagger2"
+	LoaderTypePostman  LoaderType = "postman"
+	LoaderTypeHAR      LoaderType = "har"
+	LoaderTypeKong     LoaderType = "kong"
+	LoaderTypeK8s      LoaderType = "k8s"
 )
 
 func (h *ImportHandler) Import(c droplet.Context) (any, error) {
 	input := c.Input().(*ImportInput)
 
+	dataSets, err := h.load(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pre-checking for route duplication
+	preCheckErrs := h.preCheck(c.Context(), dataSets)
+	if _, ok := preCheckErrs[store.HubKeyRoute]; ok && len(preCheckErrs[store.HubKeyRoute]) > 0 {
+		return h.convertToImportResult(dataSets, preCheckErrs), nil
+	}
+
+	// Create APISIX resources
+	createErrs := h.createEntities(c.Context(), dataSets)
+	return h.convertToImportResult(dataSets, createErrs), nil
+}
+
+// load checks the uploaded file and converts it with the loader of its type
+func (h *ImportHandler) load(input *ImportInput) (*loader.DataSets, error) {
 	// input file content check
 	suffix := path.Ext(input.FileName)
-	if suffix != ".json" && suffix != ".yaml" && suffix != ".yml" {
+	if LoaderType(input.Type) == LoaderTypeHAR {
+		if suffix != ".har" && suffix != ".json" {
+			return nil, errors.Errorf("required file type is .har or .json but got: %s", suffix)
+		}
+	} else if suffix != ".json" && suffix != ".yaml" && suffix != ".yml" {
 		return nil, errors.Errorf("required file type is .yaml, .yml or .json but got: %s", suffix)
 	}
-	contentLen := bytes.Count(input.FileContent, nil) - 1
-	if contentLen <= 0 {
-		return nil, errors.New("uploaded file is empty")
-	}
-	if contentLen > conf.ImportSizeLimit {
-		return nil, errors.Errorf("uploaded file size exceeds the limit, limit is %d", conf.ImportSizeLimit)
+	if err := checkFileContent(input.FileContent); err != nil {
+		return nil, err
 	}
 
 	var l loader.Loader
@@ -114,24 +162,43 @@ func (h *ImportHandler) Import(c droplet.Context) (any, error) {
 			TaskName:    input.TaskName,
 		}
 		break
+	case LoaderTypeSwagger2:
+		l = &swagger2.Loader{
+			MergeMethod: input.MergeMethod == "true",
+			TaskName:    input.TaskName,
+		}
+	case LoaderTypePostman:
+		l = &postman.Loader{
+			MergeMethod: input.MergeMethod == "true",
+			TaskName:    input.TaskName,
+		}
+	case LoaderTypeHAR:
+		l = &har.Loader{
+			MergeMethod: input.MergeMethod == "true",
+			TaskName:    input.TaskName,
+		}
+	case LoaderTypeKong:
+		l = &kong.Loader{
+			TaskName: input.TaskName,
+		}
+	case LoaderTypeK8s:
+		l = &k8s.Loader{}
 	default:
 		return nil, fmt.Errorf("unsupported data loader type: %s", input.Type)
 	}
 
-	dataSets, err := l.Import(input.FileContent)
-	if err != nil {
-		return nil, err
-	}
+	return l.Import(input.FileContent)
+}
 
-	// Pre-checking for route duplication
-	preCheckErrs := h.preCheck(c.Context(), dataSets)
-	if _, ok := preCheckErrs[store.HubKeyRoute]; ok && len(preCheckErrs[store.HubKeyRoute]) > 0 {
-		return h.convertToImportResult(dataSets, preCheckErrs), nil
+func checkFileContent(content []byte) error {
+	contentLen := bytes.Count(content, nil) - 1
+	if contentLen <= 0 {
+		return errors.New("uploaded file is empty")
 	}
-
-	// Create APISIX resources
-	createErrs := h.createEntities(c.Context(), dataSets)
-	return h.convertToImportResult(dataSets, createErrs), nil
+	if contentLen > conf.ImportSizeLimit {
+		return errors.Errorf("uploaded file size exceeds the limit, limit is %d", conf.ImportSizeLimit)
+	}
+	return nil
 }
 
 // Pre-check imported data for duplicates
@@ -140,35 +207,11 @@ func (h *ImportHandler) Import(c droplet.Context) (any, error) {
 // domain name and uri.
 func (h *ImportHandler) preCheck(ctx context.Context, data *loader.DataSets) map[store.HubKey][]string {
 	errs := make(map[store.HubKey][]string)
+	errs[store.HubKeyRoute] = make([]string, 0)
 	for _, route := range data.Routes {
-		errs[store.HubKeyRoute] = make([]string, 0)
 		o, err := h.routeStore.List(ctx, store.ListInput{
-			// The check logic here is that if when a duplicate HOST or URI
-			// has been found, the HTTP method is checked for overlap, and
-			// if there is overlap it is determined to be a duplicate route
-			// and the import is rejected.
 			Predicate: func(obj any) bool {
-				r := obj.(*entity.Route)
-
-				// Check URI and host duplication
-				isURIDuplicated := r.URI != "" && route.URI != "" && r.URI == route.URI
-				isURIsDuplicated := len(r.Uris) > 0 && len(route.Uris) > 0 &&
-					len(intersect.Hash(r.Uris, route.Uris)) > 0
-				isMethodDuplicated := len(intersect.Hash(r.Methods, route.Methods)) > 0
-
-				// First check for duplicate URIs
-				if isURIDuplicated || isURIsDuplicated {
-					// Then check if the host field exists, and if it does, check for duplicates
-					if r.Host != "" && route.Host != "" {
-						return r.Host == route.Host && isMethodDuplicated
-					} else if len(r.Hosts) > 0 && len(route.Hosts) > 0 {
-						return len(intersect.Hash(r.Hosts, route.Hosts)) > 0 && isMethodDuplicated
-					}
-					// If the host field does not exist, only the presence or absence
-					// of HTTP method duplication is returned by default.
-					return isMethodDuplicated
-				}
-				return false
+				return matcher.Duplicated(obj.(*entity.Route), &route)
 			},
 			PageSize:   0,
 			PageNumber: 0,
@@ -200,113 +243,91 @@ func (h *ImportHandler) preCheck(ctx context.Context, data *loader.DataSets) map
 
 // Create parsed resources
 func (h *ImportHandler) createEntities(ctx context.Context, data *loader.DataSets) map[store.HubKey][]string {
+	return h.writeEntities(data, func(s store.Interface, obj any) error {
+		_, err := s.Create(ctx, obj)
+		return err
+	})
+}
+
+// writeEntities writes each object with write, the objects are written
+// before the objects which use them.
+func (h *ImportHandler) writeEntities(data *loader.DataSets, write func(s store.Interface, obj any) error) map[store.HubKey][]string {
 	errs := make(map[store.HubKey][]string)
 
-	for _, route := range data.Routes {
-		_, err := h.routeStore.Create(ctx, &route)
-		if err != nil {
-			errs[store.HubKeyRoute] = append(errs[store.HubKeyRoute], err.Error())
-		}
-	}
 	for _, upstream := range data.Upstreams {
-		_, err := h.upstreamStore.Create(ctx, &upstream)
-		if err != nil {
+		if err := write(h.upstreamStore, &upstream); err != nil {
 			errs[store.HubKeyUpstream] = append(errs[store.HubKeyUpstream], err.Error())
 		}
 	}
 	for _, service := range data.Services {
-		_, err := h.serviceStore.Create(ctx, &service)
-		if err != nil {
+		if err := write(h.serviceStore, &service); err != nil {
 			errs[store.HubKeyService] = append(errs[store.HubKeyService], err.Error())
 		}
 	}
+	for _, config := range data.PluginConfigs {
+		if err := write(h.pluginConfigStore, &config); err != nil {
+			errs[store.HubKeyPluginConfig] = append(errs[store.HubKeyPluginConfig], err.Error())
+		}
+	}
+	for _, proto := range data.Protos {
+		if err := write(h.protoStore, &proto); err != nil {
+			errs[store.HubKeyProto] = append(errs[store.HubKeyProto], err.Error())
+		}
+	}
+	// routes are created after the objects they use
+	for _, route := range data.Routes {
+		if err := write(h.routeStore, &route); err != nil {
+			errs[store.HubKeyRoute] = append(errs[store.HubKeyRoute], err.Error())
+		}
+	}
 	for _, consumer := range data.Consumers {
-		_, err := h.consumerStore.Create(ctx, &consumer)
-		if err != nil {
+		if err := write(h.consumerStore, &consumer); err != nil {
 			errs[store.HubKeyConsumer] = append(errs[store.HubKeyConsumer], err.Error())
 		}
 	}
 	for _, ssl := range data.SSLs {
-		_, err := h.sslStore.Create(ctx, &ssl)
-		if err != nil {
+		if err := write(h.sslStore, &ssl); err != nil {
 			errs[store.HubKeySsl] = append(errs[store.HubKeySsl], err.Error())
 		}
 	}
 	for _, route := range data.StreamRoutes {
-		_, err := h.streamRouteStore.Create(ctx, &route)
-		if err != nil {
+		if err := write(h.streamRouteStore, &route); err != nil {
 			errs[store.HubKeyStreamRoute] = append(errs[store.HubKeyStreamRoute], err.Error())
 		}
 	}
 	for _, plugin := range data.GlobalPlugins {
-		_, err := h.globalPluginStore.Create(ctx, &plugin)
-		if err != nil {
+		if err := write(h.globalPluginStore, &plugin); err != nil {
 			errs[store.HubKeyGlobalRule] = append(errs[store.HubKeyGlobalRule], err.Error())
 		}
 	}
-	for _, config := range data.PluginConfigs {
-		_, err := h.pluginConfigStore.Create(ctx, &config)
-		if err != nil {
-			errs[store.HubKeyPluginConfig] = append(errs[store.HubKeyPluginConfig], err.Error())
-		}
-	}
-	for _, proto := range data.Protos {
-		_, err := h.protoStore.Create(ctx, &proto)
-		if err != nil {
-			errs[store.HubKeyProto] = append(errs[store.HubKeyProto], err.Error())
-		}
-	}
 
 	return errs
 }
 
-// Convert import errors to response result
+// Convert import errors to response result, the conversion errors of the
+// loader are reported before the errors of the failed objects.
 func (ImportHandler) convertToImportResult(data *loader.DataSets, errs map[store.HubKey][]string) map[store.HubKey]ImportResult {
+	result := func(key store.HubKey, total int) ImportResult {
+		list := errs[key]
+		if len(data.Errors[key]) > 0 {
+			list = append(append([]string{}, data.Errors[key]...), errs[key]...)
+		}
+		return ImportResult{
+			Total:  total,
+			Failed: len(errs[key]),
+			Errors: list,
+		}
+	}
+
 	return map[store.HubKey]ImportResult{
-		store.HubKeyRoute: {
-			Total:  len(data.Routes),
-			Failed: len(errs[store.HubKeyRoute]),
-			Errors: errs[store.HubKeyRoute],
-		},
-		store.HubKeyUpstream: {
-			Total:  len(data.Upstreams),
-			Failed: len(errs[store.HubKeyUpstream]),
-			Errors: errs[store.HubKeyUpstream],
-		},
-		store.HubKeyService: {
-			Total:  len(data.Services),
-			Failed: len(errs[store.HubKeyService]),
-			Errors: errs[store.HubKeyService],
-		},
-		store.HubKeyConsumer: {
-			Total:  len(data.Consumers),
-			Failed: len(errs[store.HubKeyConsumer]),
-			Errors: errs[store.HubKeyConsumer],
-		},
-		store.HubKeySsl: {
-			Total:  len(data.SSLs),
-			Failed: len(errs[store.HubKeySsl]),
-			Errors: errs[store.HubKeySsl],
-		},
-		store.HubKeyStreamRoute: {
-			Total:  len(data.StreamRoutes),
-			Failed: len(errs[store.HubKeyStreamRoute]),
-			Errors: errs[store.HubKeyStreamRoute],
-		},
-		store.HubKeyGlobalRule: {
-			Total:  len(data.GlobalPlugins),
-			Failed: len(errs[store.HubKeyGlobalRule]),
-			Errors: errs[store.HubKeyGlobalRule],
-		},
-		store.HubKeyPluginConfig: {
-			Total:  len(data.PluginConfigs),
-			Failed: len(errs[store.HubKeyPluginConfig]),
-			Errors: errs[store.HubKeyPluginConfig],
-		},
-		store.HubKeyProto: {
-			Total:  len(data.Protos),
-			Failed: len(errs[store.HubKeyProto]),
-			Errors: errs[store.HubKeyProto],
-		},
+		store.HubKeyRoute:        result(store.HubKeyRoute, len(data.Routes)),
+		store.HubKeyUpstream:     result(store.HubKeyUpstream, len(data.Upstreams)),
+		store.HubKeyService:      result(store.HubKeyService, len(data.Services)),
+		store.HubKeyConsumer:     result(store.HubKeyConsumer, len(data.Consumers)),
+		store.HubKeySsl:          result(store.HubKeySsl, len(data.SSLs)),
+		store.HubKeyStreamRoute:  result(store.HubKeyStreamRoute, len(data.StreamRoutes)),
+		store.HubKeyGlobalRule:   result(store.HubKeyGlobalRule, len(data.GlobalPlugins)),
+		store.HubKeyPluginConfig: result(store.HubKeyPluginConfig, len(data.PluginConfigs)),
+		store.HubKeyProto:        result(store.HubKeyProto, len(data.Protos)),
 	}
 }
@@ -17,10 +17,15 @@
 package data_loader
 
 import (
+	"io/ioutil"
 	"testing"
 
 	"github.com/shiningrush/droplet"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/store"
 )
 
 func TestImport_invalid_loader(t *testing.T) {
@@ -63,3 +68,109 @@ func TestImport_openapi3_invalid_content(t *testing.T) {
 	_, err := h.Import(ctx)
 	assert.EqualError(t, err, "empty or invalid imported file: OpenAPI documentation does not contain any paths")
 }
+
+func TestImport_har_invalid_file_type(t *testing.T) {
+	input := &ImportInput{}
+	input.Type = "har"
+	input.FileName = "file1.yaml"
+	input.FileContent = []byte("hello")
+
+	h := ImportHandler{}
+	ctx := droplet.NewContext()
+	ctx.SetInput(input)
+
+	_, err := h.Import(ctx)
+	assert.EqualError(t, err, "required file type is .har or .json but got: .yaml")
+}
+
+func TestImport_har_duplicated(t *testing.T) {
+	fileContent, err := ioutil.ReadFile("../../../test/testdata/import/har.har")
+	assert.NoError(t, err)
+
+	input := &ImportInput{}
+	input.Type = "har"
+	input.TaskName = "test"
+	input.MergeMethod = "true"
+	input.FileName = "file1.har"
+	input.FileContent = fileContent
+
+	existing := []any{
+		&entity.Route{Name: "users", Uris: []string{"/users"}, Methods: []string{"GET"}},
+		&entity.Route{Name: "health", Uris: []string{"/health"}, Methods: []string{"GET"}},
+	}
+	routeStore := &store.MockInterface{}
+	routeStore.On("List", mock.Anything).Return(func(input store.ListInput) *store.ListOutput {
+		var rows []any
+		for _, r := range existing {
+			if input.Predicate(r) {
+				rows = append(rows, r)
+			}
+		}
+		return &store.ListOutput{Rows: rows, TotalSize: len(rows)}
+	}, nil)
+
+	h := ImportHandler{routeStore: routeStore}
+	ctx := droplet.NewContext()
+	ctx.SetInput(input)
+
+	ret, err := h.Import(ctx)
+	assert.NoError(t, err)
+
+	// every duplicated route is reported and nothing is created
+	result := ret.(map[store.HubKey]ImportResult)
+	assert.Equal(t, ImportResult{
+		Total:  2,
+		Failed: 2,
+		Errors: []string{
+			"/users is duplicated with route users",
+			"/health is duplicated with route health",
+		},
+	}, result[store.HubKeyRoute])
+	assert.Equal(t, 2, result[store.HubKeyUpstream].Total)
+	assert.Equal(t, 0, result[store.HubKeyUpstream].Failed)
+	routeStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestImport_kong_errors(t *testing.T) {
+	input := &ImportInput{}
+	input.Type = "kong"
+	input.TaskName = "test"
+	input.FileName = "kong.yaml"
+	input.FileContent = []byte(`
+_format_version: "3.0"
+services:
+  - name: users
+    url: http://127.0.0.1:8080
+    plugins:
+      - name: prometheus
+    routes:
+      - name: users
+        paths:
+          - /users
+`)
+
+	newStore := func() *store.MockInterface {
+		s := &store.MockInterface{}
+		s.On("List", mock.Anything).Return(&store.ListOutput{}, nil)
+		s.On("Create", mock.Anything, mock.Anything).Return(nil, nil)
+		return s
+	}
+	h := ImportHandler{
+		routeStore:   newStore(),
+		serviceStore: newStore(),
+	}
+	ctx := droplet.NewContext()
+	ctx.SetInput(input)
+
+	ret, err := h.Import(ctx)
+	assert.NoError(t, err)
+
+	// unsupported plugins are reported without failing the service
+	result := ret.(map[store.HubKey]ImportResult)
+	assert.Equal(t, ImportResult{
+		Total:  1,
+		Failed: 0,
+		Errors: []string{"service users: plugin prometheus is not supported"},
+	}, result[store.HubKeyService])
+	assert.Equal(t, ImportResult{Total: 1}, result[store.HubKeyRoute])
+}
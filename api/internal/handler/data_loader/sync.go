@@ -0,0 +1,95 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package data_loader
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+
+	"github.com/apisix/manager-api/internal/handler"
+	"github.com/apisix/manager-api/internal/handler/data_loader/loader/declarative"
+	"github.com/apisix/manager-api/internal/handler/data_loader/reconcile"
+)
+
+type SyncInput struct {
+	FileName    string `auto_read:"_file"`
+	FileContent []byte `auto_read:"file"`
+	Selector    string `auto_read:"selector"`
+	Checksum    string `auto_read:"checksum"`
+}
+
+// SyncPlan returns the changes which make the objects selected by the label
+// selector equal to the desired state in the apisix.yaml file, nothing is
+// written.
+func (h *ImportHandler) SyncPlan(c droplet.Context) (any, error) {
+	return h.syncPlan(c)
+}
+
+// SyncApply computes the plan again and applies it. With the checksum of a
+// reviewed plan, the apply is rejected when the plan has changed since.
+func (h *ImportHandler) SyncApply(c droplet.Context) (any, error) {
+	input := c.Input().(*SyncInput)
+	ret, err := h.syncPlan(c)
+	if err != nil {
+		return ret, err
+	}
+
+	plan := ret.(*reconcile.Plan)
+	if input.Checksum != "" && input.Checksum != plan.Checksum {
+		return &data.SpecCodeResponse{StatusCode: http.StatusConflict},
+			fmt.Errorf("plan has changed since it was reviewed, the new checksum is %s", plan.Checksum)
+	}
+
+	if err := reconcile.Apply(c.Context(), h.graphStores, plan); err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+	return plan, nil
+}
+
+func (h *ImportHandler) syncPlan(c droplet.Context) (any, error) {
+	input := c.Input().(*SyncInput)
+
+	suffix := path.Ext(input.FileName)
+	if suffix != ".json" && suffix != ".yaml" && suffix != ".yml" {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest},
+			errors.Errorf("required file type is .yaml, .yml or .json but got: %s", suffix)
+	}
+	if err := checkFileContent(input.FileContent); err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+	selector, err := reconcile.ParseSelector(input.Selector)
+	if err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+
+	l := &declarative.Loader{}
+	desired, err := l.Import(input.FileContent)
+	if err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+
+	plan, err := reconcile.NewPlan(c.Context(), h.graphStores, desired, selector)
+	if err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+	return plan, nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package data_loader
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/reconcile"
+)
+
+func TestSync(t *testing.T) {
+	labels := map[string]string{"team": "payments"}
+	routeStore := &store.MockInterface{}
+	routeStore.On("List", mock.Anything).Return(&store.ListOutput{
+		Rows: []any{
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, URI: "/a", Labels: labels},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "r2"}, URI: "/b", Labels: labels},
+		},
+		TotalSize: 2,
+	}, nil)
+	var written []string
+	routeStore.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		written = append(written, "create "+args.Get(1).(*entity.Route).ID.(string))
+	}).Return(nil, nil)
+	routeStore.On("BatchDelete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		written = append(written, "delete "+args.Get(1).([]string)[0])
+	}).Return(nil)
+
+	h := ImportHandler{graphStores: graph.Stores{store.HubKeyRoute: routeStore}}
+	input := &SyncInput{
+		FileName: "apisix.yaml",
+		FileContent: []byte(`
+routes:
+  - id: r1
+    uri: /a
+  - id: r3
+    uri: /c
+`),
+		Selector: "team:payments",
+	}
+	ctx := droplet.NewContext()
+	ctx.SetInput(input)
+
+	ret, err := h.SyncPlan(ctx)
+	assert.NoError(t, err)
+	plan := ret.(*reconcile.Plan)
+	assert.Len(t, plan.Changes, 2)
+	assert.Equal(t, reconcile.ActionCreate, plan.Changes[0].Action)
+	assert.Equal(t, reconcile.ActionDelete, plan.Changes[1].Action)
+	assert.Equal(t, 1, plan.Unchanged)
+	assert.Nil(t, written)
+
+	// the reviewed plan does not match
+	input.Checksum = "0000"
+	ret, err = h.SyncApply(ctx)
+	assert.EqualError(t, err, "plan has changed since it was reviewed, the new checksum is "+plan.Checksum)
+	assert.Equal(t, &data.SpecCodeResponse{StatusCode: http.StatusConflict}, ret)
+	assert.Nil(t, written)
+
+	input.Checksum = plan.Checksum
+	_, err = h.SyncApply(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"create r3", "delete r2"}, written)
+
+	input.Selector = ""
+	ret, err = h.SyncPlan(ctx)
+	assert.EqualError(t, err, "label selector is required")
+	assert.Equal(t, &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, ret)
+}
@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package dependency
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+	"github.com/shiningrush/droplet/wrapper"
+	wgin "github.com/shiningrush/droplet/wrapper/gin"
+
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler"
+)
+
+// types maps the resource names of the admin API to the store types.
+var types = map[string]store.HubKey{
+	"routes":         store.HubKeyRoute,
+	"stream_routes":  store.HubKeyStreamRoute,
+	"global_rules":   store.HubKeyGlobalRule,
+	"plugin_configs": store.HubKeyPluginConfig,
+	"services":       store.HubKeyService,
+	"consumers":      store.HubKeyConsumer,
+	"upstreams":      store.HubKeyUpstream,
+	"ssl":            store.HubKeySsl,
+	"proto":          store.HubKeyProto,
+}
+
+type Handler struct {
+	graphStores graph.Stores
+}
+
+func NewHandler() (handler.RouteRegister, error) {
+	return &Handler{
+		graphStores: graph.DefaultStores(),
+	}, nil
+}
+
+func (h *Handler) ApplyRoute(r *gin.Engine) {
+	r.GET("/apisix/admin/dependencies/:type/:id", wgin.Wraps(h.Get,
+		wrapper.InputType(reflect.TypeOf(GetInput{}))))
+}
+
+type GetInput struct {
+	Type string `auto_read:"type,path" validate:"required"`
+	ID   string `auto_read:"id,path" validate:"required"`
+}
+
+type GetOutput struct {
+	Node   graph.Node   `json:"node"`
+	Uses   []graph.Node `json:"uses"`
+	UsedBy []graph.Node `json:"used_by"`
+	Impact []graph.Node `json:"impact"`
+}
+
+// swagger:operation GET /apisix/admin/dependencies/{type}/{id} getDependencies
+//
+// Return the objects an object uses, the objects using it and every object a cascading delete of it removes.
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: type
+//     in: path
+//     description: type of the object, such as routes, services or upstreams
+//     required: true
+//     type: string
+//   - name: id
+//     in: path
+//     description: id of the object
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'0':
+//	  description: dependencies of the object
+//	  schema:
+//	    "$ref": "#/definitions/Dependencies"
+//	default:
+//	  description: unexpected error
+//	  schema:
+//	    "$ref": "#/definitions/ApiError"
+func (h *Handler) Get(c droplet.Context) (any, error) {
+	input := c.Input().(*GetInput)
+
+	typ, ok := types[input.Type]
+	if !ok {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest},
+			fmt.Errorf("unsupported type: %s", input.Type)
+	}
+
+	g, err := graph.Build(c.Context(), h.graphStores)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+
+	node, ok := g.Node(typ, input.ID)
+	if !ok {
+		return &data.SpecCodeResponse{StatusCode: http.StatusNotFound}, data.ErrNotFound
+	}
+
+	plan, err := g.NewPlan(typ, []string{input.ID})
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+
+	return &GetOutput{
+		Node:   node,
+		Uses:   g.Uses(node),
+		UsedBy: g.UsedBy(node),
+		Impact: plan.Impact,
+	}, nil
+}
@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package dependency
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func TestDependency_Get(t *testing.T) {
+	h := Handler{graphStores: graph.Stores{
+		store.HubKeyRoute: store.NewMockStore(store.HubKeyRoute,
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, Name: "route1", ServiceID: "s1"},
+		),
+		store.HubKeyService: store.NewMockStore(store.HubKeyService,
+			&entity.Service{BaseInfo: entity.BaseInfo{ID: "s1"}, Name: "service1", UpstreamID: "u1"},
+		),
+		store.HubKeyUpstream: store.NewMockStore(store.HubKeyUpstream,
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}},
+		),
+	}}
+
+	ctx := droplet.NewContext()
+	ctx.SetInput(&GetInput{Type: "services", ID: "s1"})
+	ret, err := h.Get(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, &GetOutput{
+		Node:   graph.Node{Type: store.HubKeyService, ID: "s1", Name: "service1"},
+		Uses:   []graph.Node{{Type: store.HubKeyUpstream, ID: "u1"}},
+		UsedBy: []graph.Node{{Type: store.HubKeyRoute, ID: "r1", Name: "route1"}},
+		Impact: []graph.Node{{Type: store.HubKeyRoute, ID: "r1", Name: "route1"}},
+	}, ret)
+
+	ctx = droplet.NewContext()
+	ctx.SetInput(&GetInput{Type: "upstreams", ID: "u2"})
+	ret, err = h.Get(ctx)
+	assert.Equal(t, data.ErrNotFound, err)
+	assert.Equal(t, &data.SpecCodeResponse{StatusCode: http.StatusNotFound}, ret)
+
+	ctx = droplet.NewContext()
+	ctx.SetInput(&GetInput{Type: "users", ID: "u1"})
+	ret, err = h.Get(ctx)
+	assert.EqualError(t, err, "unsupported type: users")
+	assert.Equal(t, &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, ret)
+}
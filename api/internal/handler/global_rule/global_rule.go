@@ -28,6 +28,7 @@ import (
 	wgin "github.com/shiningrush/droplet/wrapper/gin"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/utils"
@@ -35,11 +36,13 @@ import (
 
 type Handler struct {
 	globalRuleStore store.Interface
+	graphStores     graph.Stores
 }
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		globalRuleStore: store.GetStore(store.HubKeyGlobalRule),
+		globalRuleStore: store.NewContextStore(store.HubKeyGlobalRule),
+		graphStores:     graph.DefaultStores(),
 	}, nil
 }
 
@@ -164,8 +167,7 @@ func (h *Handler) Patch(c droplet.Context) (any, error) {
 	ID := input.ID
 	subPath := input.SubPath
 
-	routeStore := store.GetStore(store.HubKeyGlobalRule)
-	stored, err := routeStore.Get(c.Context(), ID)
+	stored, err := h.globalRuleStore.Get(c.Context(), ID)
 	if err != nil {
 		return handler.SpecCodeResponse(err), err
 	}
@@ -181,7 +183,7 @@ func (h *Handler) Patch(c droplet.Context) (any, error) {
 		return handler.SpecCodeResponse(err), err
 	}
 
-	ret, err := routeStore.Update(c.Context(), &globalRule, false)
+	ret, err := h.globalRuleStore.Update(c.Context(), &globalRule, false)
 	if err != nil {
 		return handler.SpecCodeResponse(err), err
 	}
@@ -190,12 +192,17 @@ func (h *Handler) Patch(c droplet.Context) (any, error) {
 }
 
 type BatchDeleteInput struct {
-	ID string `auto_read:"id,path"`
+	ID      string `auto_read:"id,path"`
+	Cascade bool   `auto_read:"cascade,query"`
 }
 
 func (h *Handler) BatchDelete(c droplet.Context) (any, error) {
 	input := c.Input().(*BatchDeleteInput)
 
+	if input.Cascade {
+		return handler.CascadeDelete(c, h.graphStores, store.HubKeyGlobalRule, []string{input.ID})
+	}
+
 	if err := h.globalRuleStore.BatchDelete(c.Context(), []string{input.ID}); err != nil {
 		return handler.SpecCodeResponse(err), err
 	}
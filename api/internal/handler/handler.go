@@ -46,6 +46,7 @@ import (
 	"github.com/shiningrush/droplet/middleware"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/utils"
 )
@@ -156,3 +157,66 @@ func NameExistCheck(ctx context.Context, stg store.Interface, resource, name str
 
 	return nil, nil
 }
+
+// IsDryRun reports whether the request only asks to validate a change,
+// which is requested by the "dry_run=true" query parameter.
+func IsDryRun(c droplet.Context) bool {
+	req, ok := c.Get(middleware.KeyHttpRequest).(*http.Request)
+	if !ok || req == nil {
+		return false
+	}
+	return req.URL.Query().Get("dry_run") == "true"
+}
+
+// DryRunMiddleware turns the writes of a request with "dry_run=true" into
+// dry runs: the stores validate the objects and return them as they would
+// be stored, but nothing is written.
+type DryRunMiddleware struct {
+	middleware.BaseMiddleware
+}
+
+func (mw *DryRunMiddleware) Handle(ctx droplet.Context) error {
+	if IsDryRun(ctx) {
+		ctx.SetContext(store.WithDryRun(ctx.Context()))
+	}
+	return mw.BaseMiddleware.Handle(ctx)
+}
+
+// CascadeDelete deletes the objects together with every object using them
+// and returns the plan, with dry_run the plan is returned without deleting.
+func CascadeDelete(c droplet.Context, stores graph.Stores, typ store.HubKey, ids []string) (any, error) {
+	g, err := graph.Build(c.Context(), stores)
+	if err != nil {
+		return SpecCodeResponse(err), err
+	}
+
+	plan, err := g.NewPlan(typ, ids)
+	if err != nil {
+		return SpecCodeResponse(err), err
+	}
+
+	if store.IsDryRun(c.Context()) {
+		plan.DryRun = true
+		return plan, nil
+	}
+
+	if err := graph.Delete(c.Context(), stores, plan); err != nil {
+		return SpecCodeResponse(err), err
+	}
+
+	return plan, nil
+}
+
+// CheckUnused fails when one of the objects is still used by another object.
+func CheckUnused(c droplet.Context, stores graph.Stores, typ store.HubKey, ids []string) (any, error) {
+	g, err := graph.Build(c.Context(), stores)
+	if err != nil {
+		return SpecCodeResponse(err), err
+	}
+
+	if err := g.CheckUnused(typ, ids); err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+
+	return nil, nil
+}
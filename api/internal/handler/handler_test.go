@@ -19,10 +19,12 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/shiningrush/droplet"
 	"github.com/shiningrush/droplet/data"
+	"github.com/shiningrush/droplet/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
@@ -143,3 +145,20 @@ func TestNameExistCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestDryRunMiddleware(t *testing.T) {
+	for _, url := range []string{"/apisix/admin/routes?dry_run=true", "/apisix/admin/routes"} {
+		var dryRun bool
+		next := &droplet.MockMiddleware{}
+		next.On("Handle", mock.Anything).Run(func(args mock.Arguments) {
+			dryRun = store.IsDryRun(args.Get(0).(droplet.Context).Context())
+		}).Return(nil)
+
+		mw := &DryRunMiddleware{}
+		mw.SetNext(next)
+		ctx := droplet.NewContext()
+		ctx.Set(middleware.KeyHttpRequest, httptest.NewRequest(http.MethodPost, url, nil))
+		assert.Nil(t, mw.Handle(ctx))
+		assert.Equal(t, url == "/apisix/admin/routes?dry_run=true", dryRun, url)
+	}
+}
@@ -60,12 +60,12 @@ func (p Pair) MarshalJSON() ([]byte, error) {
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		routeStore:        store.GetStore(store.HubKeyRoute),
-		serviceStore:      store.GetStore(store.HubKeyService),
-		upstreamStore:     store.GetStore(store.HubKeyUpstream),
-		sslStore:          store.GetStore(store.HubKeySsl),
-		consumerStore:     store.GetStore(store.HubKeyConsumer),
-		pluginConfigStore: store.GetStore(store.HubKeyPluginConfig),
+		routeStore:        store.NewContextStore(store.HubKeyRoute),
+		serviceStore:      store.NewContextStore(store.HubKeyService),
+		upstreamStore:     store.NewContextStore(store.HubKeyUpstream),
+		sslStore:          store.NewContextStore(store.HubKeySsl),
+		consumerStore:     store.NewContextStore(store.HubKeyConsumer),
+		pluginConfigStore: store.NewContextStore(store.HubKeyPluginConfig),
 	}, nil
 }
 
@@ -17,23 +17,26 @@
 package migrate
 
 import (
-	"encoding/binary"
-	"hash/crc32"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"path"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shiningrush/droplet/data"
 
+	"github.com/apisix/manager-api/internal/core/backup"
 	"github.com/apisix/manager-api/internal/core/migrate"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils"
 	"github.com/apisix/manager-api/internal/utils/consts"
 )
 
 const (
-	exportFileName = "apisix-config.bak"
-	checksumLength = 4 // 4 bytes (uint32)
+	exportName     = "apisix-config"
+	exportFileName = exportName + ".bak"
 )
 
 type Handler struct{}
@@ -45,89 +48,229 @@ func NewHandler() (handler.RouteRegister, error) {
 func (h *Handler) ApplyRoute(r *gin.Engine) {
 	r.GET("/apisix/admin/migrate/export", h.ExportConfig)
 	r.POST("/apisix/admin/migrate/import", h.ImportConfig)
+	r.GET("/apisix/admin/migrate/backups", h.ListBackups)
+	r.POST("/apisix/admin/migrate/backups/:name/restore", h.RestoreBackup)
 }
 
 type ExportInput struct{}
 
+var streamContentTypes = map[migrate.Format]string{
+	migrate.FormatNDJSON: "application/x-ndjson",
+	migrate.FormatTar:    "application/x-tar",
+}
+
 func (h *Handler) ExportConfig(c *gin.Context) {
-	data, err := migrate.Export(c)
+	// the duration of an export depends on the size of the configuration
+	if err := utils.ClearDeadlines(c.Request.Context()); err != nil {
+		log.Warnf("clear deadlines of export: %s", err)
+	}
+	filter, err := migrate.ParseFilter(c.Query("types"), c.Query("ids"), c.Query("label"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+	if format := c.Query("format"); format != "" {
+		h.exportStream(c, format, filter)
+		return
+	}
+
+	data, err := migrate.Export(c, filter)
 	if err != nil {
 		log.Errorf("Export: %s", err)
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
-	// To check file integrity
-	// Add 4 byte(uint32) checksum at the end of file.
-	checksumUint32 := crc32.ChecksumIEEE(data)
-	checksum := make([]byte, checksumLength)
-	binary.BigEndian.PutUint32(checksum, checksumUint32)
-	fileBytes := append(data, checksum...)
-
 	c.Writer.WriteHeader(http.StatusOK)
 	c.Header("Content-Disposition", "attachment; filename="+exportFileName)
 	c.Header("Content-Type", "application/octet-stream")
 	c.Header("Content-Transfer-Encoding", "binary")
-	_, err = c.Writer.Write([]byte(fileBytes))
+	_, err = c.Writer.Write(data)
 	if err != nil {
 		log.Errorf("Write: %s", err)
 	}
 }
 
-type ImportOutput struct {
-	ConflictItems *migrate.DataSet
+// exportStream writes an NDJSON or tar export, the objects are written to
+// the response as they are read from the stores.
+func (h *Handler) exportStream(c *gin.Context, name string, filter *migrate.Filter) {
+	format, err := migrate.ParseFormat(name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+exportName+"."+string(format))
+	c.Header("Content-Type", streamContentTypes[format])
+	c.Status(http.StatusOK)
+	if err := migrate.ExportStream(c, c.Writer, format, migrate.DefaultStores(c), filter); err != nil {
+		// the response is sent partly, the import rejects it by the manifest
+		log.Errorf("Export stream: %s", err)
+	}
 }
 
 var modeMap = map[string]migrate.ConflictMode{
 	"return":    migrate.ModeReturn,
 	"overwrite": migrate.ModeOverwrite,
 	"skip":      migrate.ModeSkip,
+	"remap":     migrate.ModeRemap,
 }
 
 func (h *Handler) ImportConfig(c *gin.Context) {
-	paraMode := c.PostForm("mode")
-	mode := migrate.ModeReturn
-	if m, ok := modeMap[paraMode]; ok {
-		mode = m
+	// the upload and the import take as long as the file is large
+	if err := utils.ClearDeadlines(c.Request.Context()); err != nil {
+		log.Warnf("clear deadlines of import: %s", err)
 	}
-	file, _, err := c.Request.FormFile("file")
+	mode, filter, err := parseImportForm(c)
+	if err != nil {
+		c.JSON(http.StatusOK, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+	file, header, err := c.Request.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
+	defer file.Close()
+	if ext := path.Ext(header.Filename); ext == ".ndjson" || ext == ".tar" {
+		h.importStream(c, file, mode, filter)
+		return
+	}
 	content, err := ioutil.ReadAll(file)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, err)
 		return
 	}
-	// checksum uint32,4 bytes
-	importData := content[:len(content)-4]
-	checksum := binary.BigEndian.Uint32(content[len(content)-4:])
-	if checksum != crc32.ChecksumIEEE(importData) {
+	report, err := migrate.Import(c, content, mode, filter)
+	writeImportReport(c, report, err)
+}
+
+// parseImportForm reads the conflict mode and the filter of an import.
+func parseImportForm(c *gin.Context) (migrate.ConflictMode, *migrate.Filter, error) {
+	mode := migrate.ModeReturn
+	if m, ok := modeMap[c.PostForm("mode")]; ok {
+		mode = m
+	}
+	filter, err := migrate.ParseFilter(c.PostForm("types"), c.PostForm("ids"), c.PostForm("label"))
+	return mode, filter, err
+}
+
+func writeImportReport(c *gin.Context, report *migrate.ImportReport, err error) {
+	if err != nil {
+		message := err.Error()
+		if err == migrate.ErrConflict {
+			message = "Config conflict"
+		} else {
+			log.Errorf("Import failed: %s", err)
+		}
+		c.JSON(http.StatusOK, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: message,
+			Data:    report,
+		})
+		return
+	}
+	if report.Failed > 0 {
 		c.JSON(http.StatusOK, &data.BaseError{
 			Code:    consts.ErrBadRequest,
-			Message: "Checksum check failure,maybe file broken",
+			Message: fmt.Sprintf("%d of %d objects failed to import", report.Failed, report.Total),
+			Data:    report,
 		})
 		return
 	}
-	conflictData, err := migrate.Import(c, importData, mode)
+	c.JSON(http.StatusOK, &data.Response{Data: report})
+}
+
+// importStream imports an NDJSON or tar export. The uploaded file is kept on
+// disk by the multipart reader when it is large and read twice from there.
+func (h *Handler) importStream(c *gin.Context, file io.ReadSeeker, mode migrate.ConflictMode, filter *migrate.Filter) {
+	if mode == migrate.ModeRemap {
+		c.JSON(http.StatusBadRequest, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: "remap mode is not supported by streamed imports",
+		})
+		return
+	}
+	ret, err := migrate.ImportStream(c, file, mode, migrate.DefaultStores(c), filter)
 	if err != nil {
+		message := err.Error()
 		if err == migrate.ErrConflict {
-			c.JSON(http.StatusOK, &data.BaseError{
-				Code:    consts.ErrBadRequest,
-				Message: "Config conflict",
-				Data:    ImportOutput{ConflictItems: conflictData},
-			})
+			message = "Config conflict"
 		} else {
-			log.Errorf("Import failed: %s", err)
-			c.JSON(http.StatusOK, &data.BaseError{
-				Code:    consts.ErrBadRequest,
-				Message: err.Error(),
-				Data:    ImportOutput{ConflictItems: conflictData},
-			})
+			log.Errorf("Import stream failed: %s", err)
 		}
+		c.JSON(http.StatusOK, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: message,
+			Data:    ret,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, &data.Response{Data: ret})
+}
+
+func backupScheduler(c *gin.Context) *backup.Scheduler {
+	s := backup.Default()
+	if s == nil {
+		c.JSON(http.StatusBadRequest, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: "backup is not enabled",
+		})
+	}
+	return s
+}
+
+func (h *Handler) ListBackups(c *gin.Context) {
+	s := backupScheduler(c)
+	if s == nil {
+		return
+	}
+	backups, err := s.List(c)
+	if err != nil {
+		log.Errorf("List backups: %s", err)
+		c.JSON(http.StatusInternalServerError, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, &data.Response{Data: backups})
+}
+
+// RestoreBackup imports a backup of the scheduler like an uploaded one.
+func (h *Handler) RestoreBackup(c *gin.Context) {
+	s := backupScheduler(c)
+	if s == nil {
+		return
+	}
+	mode, filter, err := parseImportForm(c)
+	if err != nil {
+		c.JSON(http.StatusOK, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+	content, err := s.Get(c, c.Param("name"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err == backup.ErrNotFound {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, &data.BaseError{
+			Code:    consts.ErrBadRequest,
+			Message: err.Error(),
+		})
 		return
 	}
-	c.JSON(http.StatusOK, &data.Response{
-		Data: ImportOutput{ConflictItems: conflictData},
-	})
+	report, err := migrate.Import(c, content, mode, filter)
+	writeImportReport(c, report, err)
 }
@@ -30,6 +30,7 @@ import (
 	wgin "github.com/shiningrush/droplet/wrapper/gin"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/log"
@@ -39,12 +40,14 @@ import (
 type Handler struct {
 	pluginConfigStore store.Interface
 	routeStore        store.Interface
+	graphStores       graph.Stores
 }
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		pluginConfigStore: store.GetStore(store.HubKeyPluginConfig),
-		routeStore:        store.GetStore(store.HubKeyRoute),
+		pluginConfigStore: store.NewContextStore(store.HubKeyPluginConfig),
+		routeStore:        store.NewContextStore(store.HubKeyRoute),
+		graphStores:       graph.DefaultStores(),
 	}, nil
 }
 
@@ -191,12 +194,17 @@ func (h *Handler) Update(c droplet.Context) (any, error) {
 }
 
 type BatchDelete struct {
-	IDs string `auto_read:"ids,path"`
+	IDs     string `auto_read:"ids,path"`
+	Cascade bool   `auto_read:"cascade,query"`
 }
 
 func (h *Handler) BatchDelete(c droplet.Context) (any, error) {
 	input := c.Input().(*BatchDelete)
 
+	if input.Cascade {
+		return handler.CascadeDelete(c, h.graphStores, store.HubKeyPluginConfig, strings.Split(input.IDs, ","))
+	}
+
 	IDs := strings.Split(input.IDs, ",")
 	IDMap := map[string]bool{}
 	for _, id := range IDs {
@@ -222,6 +230,10 @@ func (h *Handler) BatchDelete(c droplet.Context) (any, error) {
 				ret.Rows[0].(*entity.Route).ID)
 	}
 
+	if ret, err := handler.CheckUnused(c, h.graphStores, store.HubKeyPluginConfig, IDs); err != nil {
+		return ret, err
+	}
+
 	if err := h.pluginConfigStore.BatchDelete(c.Context(), strings.Split(input.IDs, ",")); err != nil {
 		return handler.SpecCodeResponse(err), err
 	}
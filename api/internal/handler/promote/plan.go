@@ -0,0 +1,286 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package promote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/migrate"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/reconcile"
+)
+
+// Change is a change of the target cluster with the fields it changes.
+type Change struct {
+	reconcile.Change
+	Diff []reconcile.FieldDiff `json:"diff,omitempty"`
+}
+
+// Plan is the difference between the objects selected by the filter in the
+// current cluster and in a target cluster, as the changes which make the
+// target equal to the current cluster. Creates and updates come first in
+// dependency order, then the deletes with users before the objects they
+// use.
+type Plan struct {
+	Cluster   string   `json:"cluster"`
+	Changes   []Change `json:"changes"`
+	Unchanged int      `json:"unchanged"`
+	Checksum  string   `json:"checksum"`
+}
+
+// ChangeRef selects a change of a plan.
+type ChangeRef struct {
+	Type store.HubKey `json:"type"`
+	ID   string       `json:"id"`
+}
+
+func (r ChangeRef) key() string {
+	return string(r.Type) + "/" + r.ID
+}
+
+// NewPlan compares the objects selected by the filter of the source and the
+// target stores. The objects of the plan are copies, writing them to the
+// target doesn't change the source stores.
+func NewPlan(ctx context.Context, cluster string, source, target graph.Stores, filter *migrate.Filter) (*Plan, error) {
+	plan := &Plan{Cluster: cluster, Changes: []Change{}}
+	var deletes []Change
+	for _, typ := range reconcile.Types {
+		desired, err := list(ctx, source, typ, filter)
+		if err != nil {
+			return nil, err
+		}
+		live, err := list(ctx, target, typ, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range sortedKeys(desired) {
+			obj := desired[id]
+			node, _ := graph.NodeOf(typ, obj)
+			c := Change{Change: reconcile.Change{Type: typ, ID: id, Name: node.Name, After: obj}}
+			stored, ok := live[id]
+			if !ok {
+				c.Action = reconcile.ActionCreate
+				plan.Changes = append(plan.Changes, c)
+				continue
+			}
+			diff, err := reconcile.Diff(stored, obj)
+			if err != nil {
+				return nil, err
+			}
+			if len(diff) == 0 {
+				plan.Unchanged++
+				continue
+			}
+			c.Action = reconcile.ActionUpdate
+			c.Before = stored
+			c.Diff = diff
+			plan.Changes = append(plan.Changes, c)
+		}
+
+		for _, id := range sortedKeys(live) {
+			if _, ok := desired[id]; ok {
+				continue
+			}
+			node, _ := graph.NodeOf(typ, live[id])
+			deletes = append(deletes, Change{Change: reconcile.Change{
+				Action: reconcile.ActionDelete, Type: typ, ID: id, Name: node.Name, Before: live[id],
+			}})
+		}
+	}
+
+	nodes := make([]graph.Node, 0, len(deletes))
+	deleted := make(map[string]Change, len(deletes))
+	for _, c := range deletes {
+		nodes = append(nodes, graph.Node{Type: c.Type, ID: c.ID})
+		deleted[string(c.Type)+"/"+c.ID] = c
+	}
+	graph.SortNodes(nodes)
+	for _, n := range nodes {
+		plan.Changes = append(plan.Changes, deleted[string(n.Type)+"/"+n.ID])
+	}
+
+	d, err := json.Marshal(plan.Changes)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(d)
+	plan.Checksum = hex.EncodeToString(sum[:])
+	return plan, nil
+}
+
+// Select returns the changes of the plan selected by the refs, in the order
+// of the plan.
+func (p *Plan) Select(refs []ChangeRef) ([]Change, error) {
+	wanted := make(map[string]struct{}, len(refs))
+	for _, r := range refs {
+		wanted[r.key()] = struct{}{}
+	}
+	var changes []Change
+	for _, c := range p.Changes {
+		ref := ChangeRef{Type: c.Type, ID: c.ID}
+		if _, ok := wanted[ref.key()]; ok {
+			changes = append(changes, c)
+			delete(wanted, ref.key())
+		}
+	}
+	for _, r := range refs {
+		if _, ok := wanted[r.key()]; ok {
+			return nil, fmt.Errorf("%s %s has no change to promote", r.Type, r.ID)
+		}
+	}
+	return changes, nil
+}
+
+// Check validates the changes against the target stores like a write of
+// them would, and makes sure no object of the target uses a missing object
+// after they are applied: the objects used by the promoted objects must
+// exist in the target or be promoted along, and the users of deleted
+// objects must be deleted or no longer use them.
+func Check(ctx context.Context, target graph.Stores, changes []Change) error {
+	dryRun := store.WithDryRun(ctx)
+	written := make(map[string]any)
+	deleted := make(map[string]struct{})
+	for _, c := range changes {
+		key := string(c.Type) + "/" + c.ID
+		if c.Action == reconcile.ActionDelete {
+			deleted[key] = struct{}{}
+			continue
+		}
+		written[key] = c.After
+
+		s, ok := target[c.Type]
+		if !ok || s == nil {
+			return fmt.Errorf("no store with key: %s", c.Type)
+		}
+		// the stores set the timestamps of the objects they validate
+		obj, err := reconcile.Clone(c.After)
+		if err != nil {
+			return err
+		}
+		if c.Action == reconcile.ActionCreate {
+			_, err = s.Create(dryRun, obj)
+		} else {
+			_, err = s.Update(dryRun, obj, false)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s is invalid: %s", c.Type, c.ID, err)
+		}
+	}
+
+	g, err := graph.Build(ctx, target)
+	if err != nil {
+		return err
+	}
+	exists := func(n graph.Node) bool {
+		key := string(n.Type) + "/" + n.ID
+		if _, ok := written[key]; ok {
+			return true
+		}
+		if _, ok := deleted[key]; ok {
+			return false
+		}
+		_, ok := g.Node(n.Type, n.ID)
+		return ok
+	}
+
+	for _, c := range changes {
+		if c.Action == reconcile.ActionDelete {
+			n, ok := g.Node(c.Type, c.ID)
+			if !ok {
+				continue
+			}
+			for _, user := range g.UsedBy(n) {
+				key := string(user.Type) + "/" + user.ID
+				if _, ok := deleted[key]; ok {
+					continue
+				}
+				if obj, ok := written[key]; ok && !uses(obj, n) {
+					continue
+				}
+				return fmt.Errorf("%s %s can't be deleted: it is used by %s %s which is not promoted", c.Type, c.ID, user.Type, user.ID)
+			}
+			continue
+		}
+		for _, ref := range graph.References(c.After) {
+			if !exists(ref) {
+				return fmt.Errorf("%s %s uses %s %s which is neither in the target cluster nor promoted", c.Type, c.ID, ref.Type, ref.ID)
+			}
+		}
+	}
+	return nil
+}
+
+func uses(obj any, n graph.Node) bool {
+	for _, ref := range graph.References(obj) {
+		if ref.Type == n.Type && ref.ID == n.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply writes the changes to the target, the changes written before a
+// failed one are reverted.
+func Apply(ctx context.Context, target graph.Stores, changes []Change) error {
+	plan := &reconcile.Plan{Changes: make([]reconcile.Change, 0, len(changes))}
+	for _, c := range changes {
+		plan.Changes = append(plan.Changes, c.Change)
+	}
+	return reconcile.Apply(ctx, target, plan)
+}
+
+// list returns copies of the objects of the type selected by the filter,
+// keyed by ID.
+func list(ctx context.Context, stores graph.Stores, typ store.HubKey, filter *migrate.Filter) (map[string]any, error) {
+	objs := make(map[string]any)
+	s, ok := stores[typ]
+	if !ok || s == nil {
+		return objs, nil
+	}
+	ret, err := s.List(ctx, store.ListInput{})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range ret.Rows {
+		node, ok := graph.NodeOf(typ, obj)
+		if !ok || !filter.Match(typ, node.ID, obj) {
+			continue
+		}
+		c, err := reconcile.Clone(obj)
+		if err != nil {
+			return nil, err
+		}
+		objs[node.ID] = c
+	}
+	return objs, nil
+}
+
+func sortedKeys(objs map[string]any) []string {
+	keys := make([]string, 0, len(objs))
+	for k := range objs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
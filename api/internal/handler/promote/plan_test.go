@@ -0,0 +1,186 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package promote
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/migrate"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+var payments = map[string]string{"team": "payments"}
+
+// newSource returns the staging cluster, the payments team promotes its
+// routes to prod.
+func newSource() graph.Stores {
+	return graph.Stores{
+		store.HubKeyRoute: store.NewMockStore(store.HubKeyRoute,
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "checkout", UpdateTime: 10}, URI: "/checkout", UpstreamID: "payment-gw", Labels: payments},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "refund"}, URI: "/refund", UpstreamID: "refund-gw", Labels: payments},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "health"}, URI: "/health", UpstreamID: "payment-gw"},
+		),
+		store.HubKeyUpstream: store.NewMockStore(store.HubKeyUpstream,
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "payment-gw"}, UpstreamDef: entity.UpstreamDef{Type: "chash", Labels: payments}},
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "refund-gw"}, UpstreamDef: entity.UpstreamDef{Type: "roundrobin", Labels: payments}},
+		),
+	}
+}
+
+// newTarget returns the prod cluster, invoice was removed from staging and
+// status belongs to another team.
+func newTarget() graph.Stores {
+	return graph.Stores{
+		store.HubKeyRoute: store.NewMockStore(store.HubKeyRoute,
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "checkout", UpdateTime: 20}, URI: "/checkout", UpstreamID: "payment-gw", Labels: payments},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "invoice"}, URI: "/invoice", UpstreamID: "billing-gw", Labels: payments},
+			&entity.Route{BaseInfo: entity.BaseInfo{ID: "status"}, URI: "/status", UpstreamID: "billing-gw"},
+		),
+		store.HubKeyUpstream: store.NewMockStore(store.HubKeyUpstream,
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "payment-gw"}, UpstreamDef: entity.UpstreamDef{Type: "roundrobin", Labels: payments}},
+			&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "billing-gw"}, UpstreamDef: entity.UpstreamDef{Type: "roundrobin", Labels: payments}},
+		),
+	}
+}
+
+func summary(changes []Change) []string {
+	var ret []string
+	for _, c := range changes {
+		ret = append(ret, string(c.Action)+" "+string(c.Type)+"/"+c.ID)
+	}
+	return ret
+}
+
+func TestNewPlan(t *testing.T) {
+	source := newSource()
+	filter, err := migrate.ParseFilter("", "", "team:payments")
+	assert.Nil(t, err)
+	plan, err := NewPlan(context.Background(), "prod", source, newTarget(), filter)
+	assert.Nil(t, err)
+
+	// checkout only differs by its timestamps, health and status aren't selected
+	assert.Equal(t, []string{
+		"update upstream/payment-gw",
+		"create upstream/refund-gw",
+		"create route/refund",
+		"delete route/invoice",
+		"delete upstream/billing-gw",
+	}, summary(plan.Changes))
+	assert.Equal(t, 1, plan.Unchanged)
+	assert.Equal(t, "type", plan.Changes[0].Diff[0].Path)
+	assert.Equal(t, "roundrobin", plan.Changes[0].Diff[0].Before)
+	assert.Equal(t, "chash", plan.Changes[0].Diff[0].After)
+	assert.Len(t, plan.Checksum, 64)
+
+	// the plan holds copies of the source objects
+	plan.Changes[0].After.(*entity.Upstream).Type = "ewma"
+	ret, _ := source[store.HubKeyUpstream].List(context.Background(), store.ListInput{})
+	assert.Equal(t, "chash", ret.Rows[0].(*entity.Upstream).Type)
+
+	plan, err = NewPlan(context.Background(), "prod", newSource(), newTarget(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"update upstream/payment-gw",
+		"create upstream/refund-gw",
+		"create route/health",
+		"create route/refund",
+		"delete route/invoice",
+		"delete route/status",
+		"delete upstream/billing-gw",
+	}, summary(plan.Changes))
+}
+
+func TestPlan_Select(t *testing.T) {
+	plan, err := NewPlan(context.Background(), "prod", newSource(), newTarget(), nil)
+	assert.Nil(t, err)
+
+	changes, err := plan.Select([]ChangeRef{{Type: store.HubKeyRoute, ID: "refund"}, {Type: store.HubKeyUpstream, ID: "refund-gw"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"create upstream/refund-gw", "create route/refund"}, summary(changes))
+
+	_, err = plan.Select([]ChangeRef{{Type: store.HubKeyRoute, ID: "checkout"}})
+	assert.EqualError(t, err, "route checkout has no change to promote")
+}
+
+func TestCheck(t *testing.T) {
+	plan, err := NewPlan(context.Background(), "prod", newSource(), newTarget(), nil)
+	assert.Nil(t, err)
+	target := newTarget()
+	for _, s := range target {
+		s.(*store.MockInterface).On("Create", mock.Anything, mock.Anything).Return(nil, nil)
+		s.(*store.MockInterface).On("Update", mock.Anything, mock.Anything, false).Return(nil, nil)
+	}
+
+	tests := []struct {
+		name    string
+		refs    []ChangeRef
+		wantErr string
+	}{
+		{
+			name: "route with its upstream",
+			refs: []ChangeRef{{Type: store.HubKeyRoute, ID: "refund"}, {Type: store.HubKeyUpstream, ID: "refund-gw"}},
+		},
+		{
+			name:    "route without its upstream",
+			refs:    []ChangeRef{{Type: store.HubKeyRoute, ID: "refund"}},
+			wantErr: "route refund uses upstream refund-gw which is neither in the target cluster nor promoted",
+		},
+		{
+			name:    "upstream used by a route which is kept",
+			refs:    []ChangeRef{{Type: store.HubKeyRoute, ID: "invoice"}, {Type: store.HubKeyUpstream, ID: "billing-gw"}},
+			wantErr: "upstream billing-gw can't be deleted: it is used by route status which is not promoted",
+		},
+		{
+			name: "upstream with all its routes",
+			refs: []ChangeRef{
+				{Type: store.HubKeyRoute, ID: "invoice"}, {Type: store.HubKeyRoute, ID: "status"}, {Type: store.HubKeyUpstream, ID: "billing-gw"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := plan.Select(tt.refs)
+			assert.Nil(t, err)
+			err = Check(context.Background(), target, changes)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+
+	// the validation of the target store fails the promotion
+	dryRun := false
+	invalid := newTarget()
+	invalid[store.HubKeyUpstream].(*store.MockInterface).On("Update", mock.Anything, mock.Anything, false).
+		Run(func(args mock.Arguments) {
+			dryRun = store.IsDryRun(args.Get(0).(context.Context))
+		}).
+		Return(nil, errors.New("schema validate failed: type is invalid"))
+	changes, _ := plan.Select([]ChangeRef{{Type: store.HubKeyUpstream, ID: "payment-gw"}})
+	err = Check(context.Background(), invalid, changes)
+	assert.EqualError(t, err, "upstream payment-gw is invalid: schema validate failed: type is invalid")
+	assert.True(t, dryRun)
+}
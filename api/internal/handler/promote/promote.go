@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package promote
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+	"github.com/shiningrush/droplet/wrapper"
+	wgin "github.com/shiningrush/droplet/wrapper/gin"
+
+	"github.com/apisix/manager-api/internal/core/cluster"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/migrate"
+	"github.com/apisix/manager-api/internal/core/storage"
+	"github.com/apisix/manager-api/internal/handler"
+	"github.com/apisix/manager-api/internal/log"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+type Handler struct {
+	source  graph.Stores
+	open    func(name string) (graph.Stores, error)
+	records *records
+}
+
+func NewHandler() (handler.RouteRegister, error) {
+	return &Handler{
+		source:  graph.DefaultStores(),
+		open:    openCluster,
+		records: &records{stg: storage.GenEtcdStorage()},
+	}, nil
+}
+
+func (h *Handler) ApplyRoute(r *gin.Engine) {
+	r.GET("/apisix/admin/promote/clusters/:cluster/diff", wgin.Wraps(h.Diff,
+		wrapper.InputType(reflect.TypeOf(DiffInput{}))))
+	r.POST("/apisix/admin/promote/clusters/:cluster", wgin.Wraps(h.Promote,
+		wrapper.InputType(reflect.TypeOf(PromoteInput{}))))
+	r.GET("/apisix/admin/promote/records", wgin.Wraps(h.ListRecords,
+		wrapper.InputType(reflect.TypeOf(ListRecordsInput{}))))
+}
+
+// openCluster returns the stores of a cluster managed by manager-api
+func openCluster(name string) (graph.Stores, error) {
+	c, err := cluster.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return graph.HubStores(c.Hub), nil
+}
+
+type DiffInput struct {
+	Cluster string `auto_read:"cluster,path" validate:"required"`
+	Types   string `auto_read:"types,query"`
+	IDs     string `auto_read:"ids,query"`
+	Label   string `auto_read:"label,query"`
+}
+
+// Diff returns the changes which make the objects selected by the filter in
+// the target cluster equal to those of this cluster.
+func (h *Handler) Diff(c droplet.Context) (any, error) {
+	input := c.Input().(*DiffInput)
+	target, ret, err := h.openTarget(input.Cluster)
+	if err != nil {
+		return ret, err
+	}
+	return h.plan(c, input.Cluster, target, input.Types, input.IDs, input.Label)
+}
+
+func (h *Handler) openTarget(name string) (graph.Stores, any, error) {
+	target, err := h.open(name)
+	if err == cluster.ErrNotFound {
+		return nil, &data.SpecCodeResponse{StatusCode: http.StatusNotFound},
+			fmt.Errorf("cluster %s is not configured", name)
+	}
+	if err != nil {
+		return nil, &data.SpecCodeResponse{StatusCode: http.StatusServiceUnavailable}, err
+	}
+	return target, nil, nil
+}
+
+func (h *Handler) plan(c droplet.Context, cluster string, target graph.Stores, types, ids, label string) (any, error) {
+	filter, err := migrate.ParseFilter(types, ids, label)
+	if err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+	plan, err := NewPlan(c.Context(), cluster, h.source, target, filter)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+	return plan, nil
+}
+
+type PromoteInput struct {
+	Cluster  string      `auto_read:"cluster,path" validate:"required"`
+	Types    string      `json:"types"`
+	IDs      string      `json:"ids"`
+	Label    string      `json:"label"`
+	Changes  []ChangeRef `json:"changes" validate:"required,min=1"`
+	Checksum string      `json:"checksum"`
+}
+
+// Promote computes the diff again and applies the selected changes to the
+// target cluster after validating them. With the checksum of a reviewed
+// diff, the promotion is rejected when the diff has changed since. Applied
+// and failed promotions are recorded.
+func (h *Handler) Promote(c droplet.Context) (any, error) {
+	input := c.Input().(*PromoteInput)
+	target, ret, err := h.openTarget(input.Cluster)
+	if err != nil {
+		return ret, err
+	}
+
+	ret, err = h.plan(c, input.Cluster, target, input.Types, input.IDs, input.Label)
+	if err != nil {
+		return ret, err
+	}
+	plan := ret.(*Plan)
+	if input.Checksum != "" && input.Checksum != plan.Checksum {
+		return &data.SpecCodeResponse{StatusCode: http.StatusConflict},
+			fmt.Errorf("diff has changed since it was reviewed, the new checksum is %s", plan.Checksum)
+	}
+	changes, err := plan.Select(input.Changes)
+	if err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+	if err := Check(c.Context(), target, changes); err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest}, err
+	}
+
+	recChanges, err := newRecordChanges(changes)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+	rec := &Record{
+		ID:         utils.GetFlakeUidStr(),
+		Cluster:    input.Cluster,
+		User:       utils.Username(c.Context()),
+		CreateTime: time.Now().Unix(),
+		Changes:    recChanges,
+	}
+	applyErr := Apply(c.Context(), target, changes)
+	if applyErr != nil {
+		rec.Error = applyErr.Error()
+	}
+	if err := h.records.add(c.Context(), rec); err != nil {
+		log.Errorf("record promotion %s to %s failed: %s", rec.ID, rec.Cluster, err)
+	}
+	if applyErr != nil {
+		return handler.SpecCodeResponse(applyErr), applyErr
+	}
+	return rec, nil
+}
+
+type ListRecordsInput struct {
+	Cluster string `auto_read:"cluster,query"`
+}
+
+// ListRecords returns the promotions, the newest first.
+func (h *Handler) ListRecords(c droplet.Context) (any, error) {
+	input := c.Input().(*ListRecordsInput)
+	ret, err := h.records.list(c.Context(), input.Cluster)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+	return ret, nil
+}
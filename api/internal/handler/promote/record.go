@@ -0,0 +1,162 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package promote
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/storage"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/reconcile"
+)
+
+// Record is a promotion as it is kept in the audit log: who promoted which
+// changes to which cluster, and the error when it failed.
+type Record struct {
+	ID         string         `json:"id"`
+	Cluster    string         `json:"cluster"`
+	User       string         `json:"user"`
+	CreateTime int64          `json:"create_time"`
+	Changes    []RecordChange `json:"changes"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// RecordChange is a promoted change as it is recorded: the objects are left
+// out, only the fields it changes are kept with the secrets redacted.
+type RecordChange struct {
+	Action reconcile.Action      `json:"action"`
+	Type   store.HubKey          `json:"type"`
+	ID     string                `json:"id"`
+	Diff   []reconcile.FieldDiff `json:"diff,omitempty"`
+}
+
+const redacted = "******"
+
+// secretFields are the fields of the objects of a type which are redacted,
+// the fields of the plugins are redacted in the objects of all types.
+var secretFields = map[store.HubKey][]string{
+	store.HubKeySsl: {"key", "keys"},
+}
+
+var pluginSecretFields = []string{"key", "password", "secret", "secret_key", "private_key", "client_secret"}
+
+// newRecordChanges returns the changes to record, the fields of creates and
+// deletes are compared with an empty object.
+func newRecordChanges(changes []Change) ([]RecordChange, error) {
+	ret := make([]RecordChange, 0, len(changes))
+	for _, c := range changes {
+		diff, err := reconcile.Diff(c.Before, c.After)
+		if err != nil {
+			return nil, err
+		}
+		for i := range diff {
+			diff[i].Before = redact(c.Type, diff[i].Path, diff[i].Before)
+			diff[i].After = redact(c.Type, diff[i].Path, diff[i].After)
+		}
+		ret = append(ret, RecordChange{Action: c.Action, Type: c.Type, ID: c.ID, Diff: diff})
+	}
+	return ret, nil
+}
+
+// redact returns the value of the field at the path with the secrets it
+// holds replaced, the path is the one of a field diff like "plugins.key-auth".
+func redact(typ store.HubKey, path string, v any) any {
+	if v == nil {
+		return nil
+	}
+	fields := strings.Split(path, ".")
+	if len(fields) == 1 && contains(secretFields[typ], fields[0]) {
+		return redacted
+	}
+	// plugins.<name>.<field>
+	if len(fields) >= 3 && fields[0] == "plugins" && contains(pluginSecretFields, fields[2]) {
+		return redacted
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		ret := make(map[string]any, len(val))
+		for k, f := range val {
+			ret[k] = redact(typ, path+"."+k, f)
+		}
+		return ret
+	case []any:
+		ret := make([]any, len(val))
+		for i, f := range val {
+			ret[i] = redact(typ, path, f)
+		}
+		return ret
+	}
+	return v
+}
+
+func contains(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// records keeps the records in the etcd of manager-api, below a prefix of
+// its own, outside of the prefix which is watched by APISIX.
+type records struct {
+	stg storage.Interface
+}
+
+func (r *records) basePath() string {
+	return "/manager-api" + conf.ETCDConfig.Prefix + "/promotions"
+}
+
+func (r *records) add(ctx context.Context, rec *Record) error {
+	d, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return r.stg.Create(ctx, fmt.Sprintf("%s/%s", r.basePath(), rec.ID), string(d))
+}
+
+// list returns the records of the cluster, of all clusters when it is
+// empty, the newest first.
+func (r *records) list(ctx context.Context, cluster string) ([]Record, error) {
+	kvs, err := r.stg.List(ctx, r.basePath())
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]Record, 0, len(kvs))
+	for _, kv := range kvs {
+		var rec Record
+		if err := json.Unmarshal([]byte(kv.Value), &rec); err != nil {
+			return nil, fmt.Errorf("invalid promotion record %s: %s", kv.Key, err)
+		}
+		if cluster == "" || rec.Cluster == cluster {
+			ret = append(ret, rec)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].CreateTime != ret[j].CreateTime {
+			return ret[i].CreateTime > ret[j].CreateTime
+		}
+		return ret[i].ID > ret[j].ID
+	})
+	return ret, nil
+}
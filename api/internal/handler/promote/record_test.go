@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package promote
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/conf"
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/storage"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler/data_loader/reconcile"
+)
+
+func TestRecords(t *testing.T) {
+	stg := &storage.MockInterface{}
+	var kvs []storage.Keypair
+	stg.On("Create", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		kvs = append(kvs, storage.Keypair{Key: args.String(1), Value: args.String(2)})
+	}).Return(nil)
+	stg.On("List", mock.Anything, "/manager-api"+conf.ETCDConfig.Prefix+"/promotions").Return(func(ctx context.Context, key string) []storage.Keypair {
+		return kvs
+	}, nil)
+
+	r := &records{stg: stg}
+	for _, rec := range []*Record{
+		{ID: "1", Cluster: "prod", User: "admin", CreateTime: 100},
+		{ID: "2", Cluster: "staging", User: "admin", CreateTime: 200},
+		{ID: "3", Cluster: "prod", User: "user", CreateTime: 300, Error: "etcd is down"},
+	} {
+		assert.Nil(t, r.add(context.Background(), rec))
+	}
+	assert.Equal(t, "/manager-api"+conf.ETCDConfig.Prefix+"/promotions/1", kvs[0].Key)
+	var stored Record
+	assert.Nil(t, json.Unmarshal([]byte(kvs[0].Value), &stored))
+	assert.Equal(t, "admin", stored.User)
+
+	ret, err := r.list(context.Background(), "prod")
+	assert.Nil(t, err)
+	assert.Len(t, ret, 2)
+	assert.Equal(t, "3", ret[0].ID)
+	assert.Equal(t, "etcd is down", ret[0].Error)
+	assert.Equal(t, "1", ret[1].ID)
+
+	ret, err = r.list(context.Background(), "")
+	assert.Nil(t, err)
+	assert.Len(t, ret, 3)
+}
+
+func TestNewRecordChanges(t *testing.T) {
+	ssl := &entity.SSL{BaseInfo: entity.BaseInfo{ID: "1"}, Cert: "cert", Key: "private key",
+		Snis: []string{"a.com"}, Keys: []string{"private key"}, Status: 1}
+	jack := &entity.Consumer{Username: "jack", Plugins: map[string]any{
+		"key-auth":   map[string]any{"key": "auth-one"},
+		"basic-auth": map[string]any{"username": "jack", "password": "123456"},
+	}}
+	changed := &entity.Consumer{Username: "jack", Desc: "new", Plugins: map[string]any{
+		"key-auth":   map[string]any{"key": "auth-two"},
+		"basic-auth": map[string]any{"username": "jack", "password": "123456"},
+	}}
+
+	changes, err := newRecordChanges([]Change{
+		{Change: reconcile.Change{Action: reconcile.ActionCreate, Type: store.HubKeySsl, ID: "1", After: ssl}},
+		{Change: reconcile.Change{Action: reconcile.ActionUpdate, Type: store.HubKeyConsumer, ID: "jack",
+			Before: jack, After: changed}},
+		{Change: reconcile.Change{Action: reconcile.ActionDelete, Type: store.HubKeyConsumer, ID: "jack", Before: jack}},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, changes, 3)
+
+	d, err := json.Marshal(changes)
+	assert.Nil(t, err)
+	for _, secret := range []string{"private key", "auth-one", "auth-two", "123456"} {
+		assert.NotContains(t, string(d), secret)
+	}
+
+	assert.Equal(t, reconcile.ActionCreate, changes[0].Action)
+	assert.Equal(t, []reconcile.FieldDiff{
+		{Path: "cert", After: "cert"},
+		{Path: "id", After: "1"},
+		{Path: "key", After: redacted},
+		{Path: "keys", After: redacted},
+		{Path: "snis", After: []any{"a.com"}},
+		{Path: "status", After: float64(1)},
+	}, changes[0].Diff)
+
+	assert.Equal(t, []reconcile.FieldDiff{
+		{Path: "desc", After: "new"},
+		{Path: "plugins.key-auth.key", Before: redacted, After: redacted},
+	}, changes[1].Diff)
+
+	assert.Equal(t, []reconcile.FieldDiff{
+		{Path: "plugins", Before: map[string]any{
+			"key-auth":   map[string]any{"key": redacted},
+			"basic-auth": map[string]any{"username": "jack", "password": redacted},
+		}},
+		{Path: "username", Before: "jack"},
+	}, changes[2].Diff)
+}
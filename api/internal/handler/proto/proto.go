@@ -17,10 +17,8 @@
 package proto
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"reflect"
 	"strings"
@@ -32,28 +30,21 @@ import (
 	wgin "github.com/shiningrush/droplet/wrapper/gin"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/utils"
 )
 
 type Handler struct {
-	routeStore        store.Interface
-	serviceStore      store.Interface
-	consumerStore     store.Interface
-	pluginConfigStore store.Interface
-	globalRuleStore   store.Interface
-	protoStore        store.Interface
+	protoStore  store.Interface
+	graphStores graph.Stores
 }
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		routeStore:        store.GetStore(store.HubKeyRoute),
-		serviceStore:      store.GetStore(store.HubKeyService),
-		consumerStore:     store.GetStore(store.HubKeyConsumer),
-		pluginConfigStore: store.GetStore(store.HubKeyPluginConfig),
-		globalRuleStore:   store.GetStore(store.HubKeyGlobalRule),
-		protoStore:        store.GetStore(store.HubKeyProto),
+		protoStore:  store.NewContextStore(store.HubKeyProto),
+		graphStores: graph.DefaultStores(),
 	}, nil
 }
 
@@ -76,8 +67,6 @@ func (h *Handler) ApplyRoute(r *gin.Engine) {
 		wrapper.InputType(reflect.TypeOf(BatchDeleteInput{}))))
 }
 
-var plugins = []string{"grpc-transcode"}
-
 type GetInput struct {
 	ID string `auto_read:"id,path" validate:"required"`
 }
@@ -204,21 +193,20 @@ func (h *Handler) Patch(c droplet.Context) (any, error) {
 }
 
 type BatchDeleteInput struct {
-	IDs string `auto_read:"ids,path"`
+	IDs     string `auto_read:"ids,path"`
+	Cascade bool   `auto_read:"cascade,query"`
 }
 
 func (h *Handler) BatchDelete(c droplet.Context) (any, error) {
 	input := c.Input().(*BatchDeleteInput)
 
 	ids := strings.Split(input.IDs, ",")
-	checklist := []store.Interface{h.routeStore, h.consumerStore, h.serviceStore, h.pluginConfigStore, h.globalRuleStore}
+	if input.Cascade {
+		return handler.CascadeDelete(c, h.graphStores, store.HubKeyProto, ids)
+	}
 
-	for _, id := range ids {
-		for _, store := range checklist {
-			if err := h.checkProtoUsed(c.Context(), store, id); err != nil {
-				return handler.SpecCodeResponse(err), err
-			}
-		}
+	if ret, err := handler.CheckUnused(c, h.graphStores, store.HubKeyProto, ids); err != nil {
+		return ret, err
 	}
 
 	if err := h.protoStore.BatchDelete(c.Context(), ids); err != nil {
@@ -227,34 +215,3 @@ func (h *Handler) BatchDelete(c droplet.Context) (any, error) {
 
 	return nil, nil
 }
-
-func (h *Handler) checkProtoUsed(ctx context.Context, storeInterface store.Interface, key string) error {
-	ret, err := storeInterface.List(ctx, store.ListInput{
-		Predicate: func(obj any) bool {
-			record := obj.(entity.GetPlugins)
-			for _, plugin := range plugins {
-				if _, ok := record.GetPlugins()[plugin]; ok {
-					configs := record.GetPlugins()[plugin].(map[string]any)
-					protoId := utils.InterfaceToString(configs["proto_id"])
-					if protoId == key {
-						return true
-					}
-				}
-			}
-			return false
-		},
-		Format: func(obj any) any {
-			return obj.(entity.GetPlugins)
-		},
-		PageSize:   0,
-		PageNumber: 0,
-	})
-	if err != nil {
-		return err
-	}
-	if ret.TotalSize > 0 {
-		return fmt.Errorf("proto used check invalid: %s: %s is using this proto", storeInterface.Type(), ret.Rows[0].(entity.GetBaseInfo).GetBaseInfo().ID)
-	}
-	return nil
-
-}
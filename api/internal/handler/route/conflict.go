@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package route
+
+import (
+	"context"
+
+	"github.com/shiningrush/droplet"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/matcher"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+type ConflictsInput struct {
+	ID string `auto_read:"id,query"`
+}
+
+// swagger:operation GET /apisix/admin/routes/conflicts getRouteConflicts
+//
+// Return the routes which match the same requests, conflicts with an undefined match order come first.
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: query
+//     description: only report conflicts of the route with this id
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'0':
+//	  description: list of conflicts
+//	  schema:
+//	    type: array
+//	    items:
+//	      "$ref": "#/definitions/RouteConflict"
+//	default:
+//	  description: unexpected error
+//	  schema:
+//	    "$ref": "#/definitions/ApiError"
+func (h *Handler) Conflicts(c droplet.Context) (any, error) {
+	input := c.Input().(*ConflictsInput)
+
+	rules, err := h.routeRules(c.Context())
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+
+	var conflicts []matcher.Conflict
+	if input.ID == "" {
+		conflicts = matcher.Analyze(rules)
+	} else {
+		r, err := h.routeStore.Get(c.Context(), input.ID)
+		if err != nil {
+			return handler.SpecCodeResponse(err), err
+		}
+		conflicts = matcher.Check(h.routeRule(c.Context(), r.(*entity.Route)), rules)
+	}
+
+	ret := store.NewListOutput()
+	for _, conflict := range conflicts {
+		ret.Rows = append(ret.Rows, conflict)
+	}
+	ret.TotalSize = len(ret.Rows)
+
+	return ret, nil
+}
+
+// DryRunOutput is returned instead of the stored route when a write is
+// requested with dry_run, it lists the routes the new route overlaps with.
+type DryRunOutput struct {
+	Route     *entity.Route      `json:"route"`
+	Conflicts []matcher.Conflict `json:"conflicts"`
+}
+
+func (h *Handler) dryRun(ctx context.Context, route *entity.Route) (any, error) {
+	rules, err := h.routeRules(ctx)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+
+	conflicts := make([]matcher.Conflict, 0)
+	if matcher.RouteEnabled(route) {
+		conflicts = matcher.Check(h.routeRule(ctx, route), rules)
+	}
+
+	return &DryRunOutput{Route: route, Conflicts: conflicts}, nil
+}
+
+// routeRules returns the matching rules of all enabled routes.
+func (h *Handler) routeRules(ctx context.Context) ([]*matcher.Rule, error) {
+	ret, err := h.routeStore.List(ctx, store.ListInput{
+		Predicate: func(obj any) bool {
+			return matcher.RouteEnabled(obj.(*entity.Route))
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*matcher.Rule, 0, len(ret.Rows))
+	for _, row := range ret.Rows {
+		rules = append(rules, h.routeRule(ctx, row.(*entity.Route)))
+	}
+	return rules, nil
+}
+
+func (h *Handler) routeRule(ctx context.Context, route *entity.Route) *matcher.Rule {
+	var service *entity.Service
+	if route.ServiceID != nil {
+		if obj, err := h.svcStore.Get(ctx, utils.InterfaceToString(route.ServiceID)); err == nil {
+			service, _ = obj.(*entity.Service)
+		}
+	}
+	return matcher.NewRule(route, service)
+}
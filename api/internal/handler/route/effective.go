@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package route
+
+import (
+	"net/http"
+
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/handler"
+)
+
+// swagger:operation GET /apisix/admin/routes/{id}/effective getRouteEffective
+//
+// Return the route merged with its service, plugin config, upstream and global rules, every field and plugin is annotated with its source.
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: id
+//     in: path
+//     description: id of the route
+//     required: true
+//     type: string
+//
+// responses:
+//
+//	'0':
+//	  description: effective configuration of the route
+//	  schema:
+//	    "$ref": "#/definitions/RouteEffective"
+//	default:
+//	  description: unexpected error
+//	  schema:
+//	    "$ref": "#/definitions/ApiError"
+func (h *Handler) Effective(c droplet.Context) (any, error) {
+	input := c.Input().(*GetInput)
+
+	r, err := h.routeStore.Get(c.Context(), input.ID)
+	if err != nil {
+		return &data.SpecCodeResponse{StatusCode: http.StatusNotFound}, err
+	}
+
+	route := *r.(*entity.Route)
+	script, _ := h.scriptStore.Get(c.Context(), input.ID)
+	if script != nil {
+		route.Script = script.(*entity.Script).Script
+	}
+
+	ret, err := h.resolver.Resolve(c.Context(), &route, nil)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+
+	return ret, nil
+}
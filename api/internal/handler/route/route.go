@@ -35,6 +35,8 @@ import (
 
 	"github.com/apisix/manager-api/internal/conf"
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
+	"github.com/apisix/manager-api/internal/core/resolver"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/log"
@@ -47,20 +49,28 @@ type Handler struct {
 	svcStore      store.Interface
 	upstreamStore store.Interface
 	scriptStore   store.Interface
+	resolver      *resolver.Resolver
+	graphStores   graph.Stores
 }
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		routeStore:    store.GetStore(store.HubKeyRoute),
-		svcStore:      store.GetStore(store.HubKeyService),
-		upstreamStore: store.GetStore(store.HubKeyUpstream),
-		scriptStore:   store.GetStore(store.HubKeyScript),
+		routeStore:    store.NewContextStore(store.HubKeyRoute),
+		svcStore:      store.NewContextStore(store.HubKeyService),
+		upstreamStore: store.NewContextStore(store.HubKeyUpstream),
+		scriptStore:   store.NewContextStore(store.HubKeyScript),
+		resolver:      resolver.NewResolver(),
+		graphStores:   graph.DefaultStores(),
 	}, nil
 }
 
 func (h *Handler) ApplyRoute(r *gin.Engine) {
+	r.GET("/apisix/admin/routes/conflicts", wgin.Wraps(h.Conflicts,
+		wrapper.InputType(reflect.TypeOf(ConflictsInput{}))))
 	r.GET("/apisix/admin/routes/:id", wgin.Wraps(h.Get,
 		wrapper.InputType(reflect.TypeOf(GetInput{}))))
+	r.GET("/apisix/admin/routes/:id/effective", wgin.Wraps(h.Effective,
+		wrapper.InputType(reflect.TypeOf(GetInput{}))))
 	r.GET("/apisix/admin/routes", wgin.Wraps(h.List,
 		wrapper.InputType(reflect.TypeOf(ListInput{}))))
 	r.POST("/apisix/admin/routes", wgin.Wraps(h.Create,
@@ -410,6 +420,10 @@ func (h *Handler) Create(c droplet.Context) (any, error) {
 		return handler.SpecCodeResponse(err), err
 	}
 
+	if store.IsDryRun(c.Context()) {
+		return h.dryRun(c.Context(), res.(*entity.Route))
+	}
+
 	return res, nil
 }
 
@@ -527,16 +541,25 @@ func (h *Handler) Update(c droplet.Context) (any, error) {
 		return handler.SpecCodeResponse(err), err
 	}
 
+	if store.IsDryRun(c.Context()) {
+		return h.dryRun(c.Context(), res.(*entity.Route))
+	}
+
 	return res, nil
 }
 
 type BatchDelete struct {
-	IDs string `auto_read:"ids,path"`
+	IDs     string `auto_read:"ids,path"`
+	Cascade bool   `auto_read:"cascade,query"`
 }
 
 func (h *Handler) BatchDelete(c droplet.Context) (any, error) {
 	input := c.Input().(*BatchDelete)
 
+	if input.Cascade {
+		return handler.CascadeDelete(c, h.graphStores, store.HubKeyRoute, strings.Split(input.IDs, ","))
+	}
+
 	//delete route
 	if err := h.routeStore.BatchDelete(c.Context(), strings.Split(input.IDs, ",")); err != nil {
 		return handler.SpecCodeResponse(err), err
@@ -17,6 +17,7 @@
 package route
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,6 +30,8 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/matcher"
+	"github.com/apisix/manager-api/internal/core/resolver"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/utils/consts"
@@ -1665,3 +1668,140 @@ func TestRoute_Exist(t *testing.T) {
 		})
 	}
 }
+
+func TestRoute_Conflicts(t *testing.T) {
+	routes := []any{
+		&entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, Name: "r1", URI: "/hello", Status: 1},
+		&entity.Route{BaseInfo: entity.BaseInfo{ID: "r2"}, Name: "r2", Uris: []string{"/hello*"}, Status: 1},
+		&entity.Route{BaseInfo: entity.BaseInfo{ID: "r3"}, Name: "r3", URI: "/hello", Status: 0},
+	}
+
+	routeStore := &store.MockInterface{}
+	routeStore.On("List", mock.Anything).Return(func(input store.ListInput) *store.ListOutput {
+		ret := store.NewListOutput()
+		for _, r := range routes {
+			if input.Predicate(r) {
+				ret.Rows = append(ret.Rows, r)
+			}
+		}
+		ret.TotalSize = len(ret.Rows)
+		return ret
+	}, nil)
+	routeStore.On("Get", "r2").Return(routes[1], nil)
+
+	h := Handler{routeStore: routeStore, svcStore: &store.MockInterface{}}
+
+	ctx := droplet.NewContext()
+	ctx.SetInput(&ConflictsInput{})
+	ret, err := h.Conflicts(ctx)
+	assert.Nil(t, err)
+	out := ret.(*store.ListOutput)
+	assert.Equal(t, 1, out.TotalSize)
+	conflict := out.Rows[0].(matcher.Conflict)
+	assert.Equal(t, matcher.SeverityShadow, conflict.Severity)
+	assert.Equal(t, "r1", conflict.WinnerID)
+
+	ctx = droplet.NewContext()
+	ctx.SetInput(&ConflictsInput{ID: "r2"})
+	ret, err = h.Conflicts(ctx)
+	assert.Nil(t, err)
+	out = ret.(*store.ListOutput)
+	assert.Equal(t, 1, out.TotalSize)
+	assert.Equal(t, "r2", out.Rows[0].(matcher.Conflict).RouteID)
+}
+
+func TestRoute_CreateDryRun(t *testing.T) {
+	stored := &entity.Route{BaseInfo: entity.BaseInfo{ID: "r1"}, Name: "r1", URI: "/hello", Status: 1}
+
+	routeStore := &store.MockInterface{}
+	routeStore.On("List", mock.Anything).Return(func(input store.ListInput) *store.ListOutput {
+		ret := store.NewListOutput()
+		// the name check and the conflict check share the same mock
+		if input.Predicate(stored) {
+			ret.Rows = append(ret.Rows, stored)
+		}
+		ret.TotalSize = len(ret.Rows)
+		return ret
+	}, nil)
+
+	input := &entity.Route{Name: "r2", URI: "/hello", Status: 1}
+	// the store validates the route without writing it in a dry run
+	routeStore.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		assert.True(t, store.IsDryRun(args.Get(0).(context.Context)))
+	}).Return(input, nil)
+
+	h := Handler{routeStore: routeStore}
+
+	ctx := droplet.NewContext()
+	ctx.SetInput(input)
+	ctx.SetContext(store.WithDryRun(ctx.Context()))
+
+	ret, err := h.Create(ctx)
+	assert.Nil(t, err)
+	out := ret.(*DryRunOutput)
+	assert.Equal(t, input, out.Route)
+	assert.Len(t, out.Conflicts, 1)
+	assert.Equal(t, matcher.SeverityConflict, out.Conflicts[0].Severity)
+	assert.Equal(t, "r1", out.Conflicts[0].OtherID)
+}
+
+func TestRoute_Effective(t *testing.T) {
+	route := &entity.Route{
+		BaseInfo:  entity.BaseInfo{ID: "r1"},
+		URI:       "/hello",
+		Status:    1,
+		ServiceID: "s1",
+		Plugins:   map[string]any{"limit-count": map[string]any{"count": float64(1)}},
+	}
+	service := &entity.Service{
+		BaseInfo:   entity.BaseInfo{ID: "s1"},
+		Hosts:      []string{"foo.com"},
+		UpstreamID: "u1",
+		Plugins:    map[string]any{"limit-count": map[string]any{"count": float64(2)}},
+	}
+	upstream := &entity.Upstream{
+		BaseInfo:    entity.BaseInfo{ID: "u1"},
+		UpstreamDef: entity.UpstreamDef{Type: "roundrobin"},
+	}
+
+	routeStore := &store.MockInterface{}
+	routeStore.On("Get", "r1").Return(route, nil)
+	routeStore.On("Get", "r2").Return(nil, data.ErrNotFound)
+	scriptStore := &store.MockInterface{}
+	scriptStore.On("Get", mock.Anything).Return(nil, data.ErrNotFound)
+	svcStore := &store.MockInterface{}
+	svcStore.On("Get", "s1").Return(service, nil)
+	upstreamStore := &store.MockInterface{}
+	upstreamStore.On("Get", "u1").Return(upstream, nil)
+	globalRuleStore := &store.MockInterface{}
+	globalRuleStore.On("List", mock.Anything).Return(store.NewListOutput(), nil)
+
+	h := Handler{
+		routeStore:  routeStore,
+		scriptStore: scriptStore,
+		resolver: &resolver.Resolver{
+			ServiceStore:      svcStore,
+			UpstreamStore:     upstreamStore,
+			PluginConfigStore: &store.MockInterface{},
+			GlobalRuleStore:   globalRuleStore,
+		},
+	}
+
+	ctx := droplet.NewContext()
+	ctx.SetInput(&GetInput{ID: "r1"})
+	ret, err := h.Effective(ctx)
+	assert.Nil(t, err)
+	resolved := ret.(*resolver.Resolved)
+	assert.Empty(t, resolved.Errors)
+	assert.Equal(t, resolver.Origin{Source: resolver.SourceService, ID: "s1"}, resolved.Fields["hosts"].Origin)
+	assert.Equal(t, resolver.Origin{Source: resolver.SourceService, ID: "s1"}, resolved.Fields["upstream_id"].Origin)
+	assert.Equal(t, "u1", resolved.Upstream.UpstreamID)
+	assert.Len(t, resolved.Plugins, 1)
+	assert.Equal(t, resolver.SourceRoute, resolved.Plugins[0].Source)
+
+	ctx = droplet.NewContext()
+	ctx.SetInput(&GetInput{ID: "r2"})
+	ret, err = h.Effective(ctx)
+	assert.Equal(t, data.ErrNotFound, err)
+	assert.Equal(t, http.StatusNotFound, ret.(*data.SpecCodeResponse).StatusCode)
+}
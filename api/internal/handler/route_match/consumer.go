@@ -0,0 +1,154 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package route_match
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/shiningrush/droplet/data"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/matcher"
+	"github.com/apisix/manager-api/internal/core/resolver"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+// findConsumer returns the consumer of the request, either the one named in
+// the input or the one owning the credentials the request carries.
+func (h *Handler) findConsumer(ctx context.Context, input *MatchInput, plugins []resolver.Plugin) (*entity.Consumer, string, error) {
+	if input.Consumer != "" {
+		obj, err := h.consumerStore.Get(ctx, input.Consumer)
+		if err != nil {
+			if err == data.ErrNotFound {
+				return nil, fmt.Sprintf("consumer %s not found", input.Consumer), nil
+			}
+			return nil, "", err
+		}
+		return obj.(*entity.Consumer), "", nil
+	}
+
+	for _, plugin := range plugins {
+		conf, _ := plugin.Config.(map[string]any)
+		var lookup func(c *entity.Consumer) bool
+		switch plugin.Name {
+		case "key-auth":
+			key := keyAuthCredential(&input.Request, conf)
+			if key == "" {
+				continue
+			}
+			lookup = func(c *entity.Consumer) bool {
+				return pluginField(c, "key-auth", "key") == key
+			}
+		case "basic-auth":
+			username, password, ok := basicAuthCredential(&input.Request)
+			if !ok {
+				continue
+			}
+			lookup = func(c *entity.Consumer) bool {
+				return pluginField(c, "basic-auth", "username") == username &&
+					pluginField(c, "basic-auth", "password") == password
+			}
+		case "jwt-auth":
+			key := jwtAuthCredential(&input.Request, conf)
+			if key == "" {
+				continue
+			}
+			lookup = func(c *entity.Consumer) bool {
+				return pluginField(c, "jwt-auth", "key") == key
+			}
+		default:
+			continue
+		}
+
+		ret, err := h.consumerStore.List(ctx, store.ListInput{
+			Predicate: func(obj any) bool {
+				return lookup(obj.(*entity.Consumer))
+			},
+			Less: func(i, j any) bool {
+				return i.(*entity.Consumer).Username < j.(*entity.Consumer).Username
+			},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		if ret.TotalSize > 0 {
+			return ret.Rows[0].(*entity.Consumer), "", nil
+		}
+		return nil, fmt.Sprintf("no consumer matches the credential of %s", plugin.Name), nil
+	}
+
+	return nil, "", nil
+}
+
+func pluginField(c *entity.Consumer, plugin, field string) string {
+	conf, ok := c.Plugins[plugin].(map[string]any)
+	if !ok {
+		return ""
+	}
+	value, _ := conf[field].(string)
+	return value
+}
+
+func confString(conf map[string]any, field, def string) string {
+	if value, ok := conf[field].(string); ok && value != "" {
+		return value
+	}
+	return def
+}
+
+func keyAuthCredential(req *matcher.Request, conf map[string]any) string {
+	if key := req.Header(confString(conf, "header", "apikey")); key != "" {
+		return key
+	}
+	return req.Args[confString(conf, "query", "apikey")]
+}
+
+func basicAuthCredential(req *matcher.Request) (string, string, bool) {
+	auth := req.Header("Authorization")
+	if !strings.HasPrefix(auth, "Basic ") {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(auth, "Basic "))
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
+
+// jwtAuthCredential returns the "key" claim of the token, the signature is
+// not verified because only the consumer is looked up.
+func jwtAuthCredential(req *matcher.Request, conf map[string]any) string {
+	token := req.Header(confString(conf, "header", "authorization"))
+	token = strings.TrimPrefix(token, "Bearer ")
+	if token == "" {
+		token = req.Args[confString(conf, "query", "jwt")]
+	}
+	if token == "" {
+		return ""
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+		return ""
+	}
+	key, _ := claims["key"].(string)
+	return key
+}
@@ -0,0 +1,211 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package route_match
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/wrapper"
+	wgin "github.com/shiningrush/droplet/wrapper/gin"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/matcher"
+	"github.com/apisix/manager-api/internal/core/resolver"
+	"github.com/apisix/manager-api/internal/core/store"
+	"github.com/apisix/manager-api/internal/handler"
+	"github.com/apisix/manager-api/internal/utils"
+)
+
+type Handler struct {
+	routeStore    store.Interface
+	serviceStore  store.Interface
+	consumerStore store.Interface
+	resolver      *resolver.Resolver
+}
+
+func NewHandler() (handler.RouteRegister, error) {
+	return &Handler{
+		routeStore:    store.NewContextStore(store.HubKeyRoute),
+		serviceStore:  store.NewContextStore(store.HubKeyService),
+		consumerStore: store.NewContextStore(store.HubKeyConsumer),
+		resolver:      resolver.NewResolver(),
+	}, nil
+}
+
+func (h *Handler) ApplyRoute(r *gin.Engine) {
+	r.POST("/apisix/admin/debug/route_match", wgin.Wraps(h.Match,
+		wrapper.InputType(reflect.TypeOf(MatchInput{}))))
+}
+
+type MatchInput struct {
+	matcher.Request
+	// Consumer forces the consumer of the request, otherwise it is looked up
+	// from the credentials of the authentication plugins of the route.
+	Consumer string `json:"consumer,omitempty"`
+}
+
+type MatchOutput struct {
+	Matched     bool   `json:"matched"`
+	RouteID     string `json:"route_id,omitempty"`
+	MatchedURI  string `json:"matched_uri,omitempty"`
+	MatchedHost string `json:"matched_host,omitempty"`
+	// Ambiguous lists routes APISIX may select instead, see the route conflicts API
+	Ambiguous []string `json:"ambiguous,omitempty"`
+	// Candidates lists every route that matches the request in match order
+	Candidates []string           `json:"candidates"`
+	Warnings   []string           `json:"warnings,omitempty"`
+	Effective  *resolver.Resolved `json:"effective,omitempty"`
+}
+
+// swagger:operation POST /apisix/admin/debug/route_match matchRoute
+//
+// Return the route APISIX would select for the request together with its effective configuration, no running gateway is required.
+//
+// ---
+// produces:
+// - application/json
+// parameters:
+//   - name: path
+//     in: body
+//     description: request path, a query string is added to the args
+//     required: true
+//     type: string
+//   - name: method
+//     in: body
+//     description: request method, GET by default
+//     required: false
+//     type: string
+//   - name: host
+//     in: body
+//     description: request host
+//     required: false
+//     type: string
+//   - name: headers
+//     in: body
+//     description: request headers
+//     required: false
+//     type: object
+//   - name: client_ip
+//     in: body
+//     description: client address
+//     required: false
+//     type: string
+//   - name: args
+//     in: body
+//     description: query arguments
+//     required: false
+//     type: object
+//   - name: consumer
+//     in: body
+//     description: username of the consumer to use
+//     required: false
+//     type: string
+//
+// responses:
+//
+//	'0':
+//	  description: match result
+//	  schema:
+//	    "$ref": "#/definitions/RouteMatch"
+//	default:
+//	  description: unexpected error
+//	  schema:
+//	    "$ref": "#/definitions/ApiError"
+func (h *Handler) Match(c droplet.Context) (any, error) {
+	input := c.Input().(*MatchInput)
+	input.Request.Normalize()
+
+	rules, err := h.routeRules(c.Context())
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+
+	result := matcher.Match(rules, input.Request)
+	output := &MatchOutput{Candidates: make([]string, 0), Warnings: result.Warnings}
+	for _, candidate := range result.Candidates {
+		output.Candidates = append(output.Candidates, candidate.Rule.ID)
+	}
+	if result.Matched == nil {
+		return output, nil
+	}
+
+	output.Matched = true
+	output.RouteID = result.Matched.Rule.ID
+	output.MatchedURI = result.Matched.URI()
+	output.MatchedHost = result.Matched.Host()
+	for _, candidate := range result.Ambiguous {
+		output.Ambiguous = append(output.Ambiguous, candidate.Rule.ID)
+	}
+
+	obj, err := h.routeStore.Get(c.Context(), output.RouteID)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+	route := obj.(*entity.Route)
+
+	// the authentication plugins decide the consumer, so resolve once
+	// without consumer to know which plugins run
+	effective, err := h.resolver.Resolve(c.Context(), route, nil)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+
+	consumer, warning, err := h.findConsumer(c.Context(), input, effective.Plugins)
+	if err != nil {
+		return handler.SpecCodeResponse(err), err
+	}
+	if warning != "" {
+		output.Warnings = append(output.Warnings, warning)
+	}
+	if consumer != nil {
+		effective, err = h.resolver.Resolve(c.Context(), route, consumer)
+		if err != nil {
+			return handler.SpecCodeResponse(err), err
+		}
+	}
+	output.Effective = effective
+
+	return output, nil
+}
+
+// routeRules returns the matching rules of all enabled routes.
+func (h *Handler) routeRules(ctx context.Context) ([]*matcher.Rule, error) {
+	ret, err := h.routeStore.List(ctx, store.ListInput{
+		Predicate: func(obj any) bool {
+			return matcher.RouteEnabled(obj.(*entity.Route))
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*matcher.Rule, 0, len(ret.Rows))
+	for _, row := range ret.Rows {
+		route := row.(*entity.Route)
+		var service *entity.Service
+		if route.ServiceID != nil {
+			if obj, err := h.serviceStore.Get(ctx, utils.InterfaceToString(route.ServiceID)); err == nil {
+				service, _ = obj.(*entity.Service)
+			}
+		}
+		rules = append(rules, matcher.NewRule(route, service))
+	}
+	return rules, nil
+}
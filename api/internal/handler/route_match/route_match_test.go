@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package route_match
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/shiningrush/droplet"
+	"github.com/shiningrush/droplet/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/matcher"
+	"github.com/apisix/manager-api/internal/core/resolver"
+	"github.com/apisix/manager-api/internal/core/store"
+)
+
+func listMock(rows ...any) func(input store.ListInput) *store.ListOutput {
+	return func(input store.ListInput) *store.ListOutput {
+		ret := store.NewListOutput()
+		for _, row := range rows {
+			if input.Predicate == nil || input.Predicate(row) {
+				ret.Rows = append(ret.Rows, row)
+			}
+		}
+		ret.TotalSize = len(ret.Rows)
+		return ret
+	}
+}
+
+func TestHandler_Match(t *testing.T) {
+	route := &entity.Route{
+		BaseInfo: entity.BaseInfo{ID: "r1"},
+		URI:      "/hello/*",
+		Status:   1,
+		Plugins:  map[string]any{"basic-auth": map[string]any{}},
+		Upstream: &entity.UpstreamDef{Nodes: map[string]float64{"127.0.0.1:1980": 1}},
+	}
+	fallback := &entity.Route{BaseInfo: entity.BaseInfo{ID: "r2"}, URI: "/*", Status: 1}
+	consumer := &entity.Consumer{
+		Username: "jack",
+		Plugins: map[string]any{
+			"basic-auth":  map[string]any{"username": "jack", "password": "123456"},
+			"limit-count": map[string]any{"count": float64(1)},
+		},
+	}
+
+	routeStore := &store.MockInterface{}
+	routeStore.On("List", mock.Anything).Return(listMock(route, fallback), nil)
+	routeStore.On("Get", "r1").Return(route, nil)
+
+	consumerStore := &store.MockInterface{}
+	consumerStore.On("List", mock.Anything).Return(listMock(consumer), nil)
+
+	globalRuleStore := &store.MockInterface{}
+	globalRuleStore.On("List", mock.Anything).Return(listMock(), nil)
+
+	h := Handler{
+		routeStore:    routeStore,
+		serviceStore:  &store.MockInterface{},
+		consumerStore: consumerStore,
+		resolver:      &resolver.Resolver{GlobalRuleStore: globalRuleStore},
+	}
+
+	ctx := droplet.NewContext()
+	ctx.SetInput(&MatchInput{Request: matcher.Request{
+		Path:    "/hello/world",
+		Headers: map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("jack:123456"))},
+	}})
+	ret, err := h.Match(ctx)
+	assert.Nil(t, err)
+
+	output := ret.(*MatchOutput)
+	assert.True(t, output.Matched)
+	assert.Equal(t, "r1", output.RouteID)
+	assert.Equal(t, "/hello/*", output.MatchedURI)
+	assert.Equal(t, []string{"r1", "r2"}, output.Candidates)
+	assert.Equal(t, "jack", output.Effective.Consumer.Username)
+	assert.Len(t, output.Effective.Plugins, 2)
+	assert.Equal(t, resolver.SourceConsumer, output.Effective.Plugins[1].Source)
+	assert.Equal(t, []*entity.Node{{Host: "127.0.0.1", Port: 1980, Weight: 1}}, output.Effective.Upstream.Nodes)
+
+	// wrong password does not identify the consumer
+	ctx = droplet.NewContext()
+	ctx.SetInput(&MatchInput{Request: matcher.Request{
+		Path:    "/hello/world",
+		Headers: map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("jack:wrong"))},
+	}})
+	ret, err = h.Match(ctx)
+	assert.Nil(t, err)
+	output = ret.(*MatchOutput)
+	assert.Nil(t, output.Effective.Consumer)
+	assert.Equal(t, []string{"no consumer matches the credential of basic-auth"}, output.Warnings)
+}
+
+func TestHandler_MatchNothing(t *testing.T) {
+	routeStore := &store.MockInterface{}
+	routeStore.On("List", mock.Anything).Return(listMock(), nil)
+
+	h := Handler{routeStore: routeStore}
+
+	ctx := droplet.NewContext()
+	ctx.SetInput(&MatchInput{Request: matcher.Request{Path: "/hello"}})
+	ret, err := h.Match(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, &MatchOutput{Candidates: []string{}}, ret)
+}
+
+func TestHandler_MatchConsumerNotFound(t *testing.T) {
+	consumerStore := &store.MockInterface{}
+	consumerStore.On("Get", "rose").Return(nil, data.ErrNotFound)
+
+	h := Handler{consumerStore: consumerStore}
+	consumer, warning, err := h.findConsumer(droplet.NewContext().Context(), &MatchInput{Consumer: "rose"}, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, consumer)
+	assert.Equal(t, "consumer rose not found", warning)
+}
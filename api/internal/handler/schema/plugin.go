@@ -52,7 +52,7 @@ func (h *Handler) Plugins(c droplet.Context) (any, error) {
 		var res []map[string]any
 		list := plugins.Value().(map[string]any)
 		for name, schemaConfig := range list {
-			if enable, ok := conf.Plugins[name]; !ok || !enable {
+			if !conf.PluginEnabled(name) {
 				continue
 			}
 			plugin := schemaConfig.(map[string]any)
@@ -68,7 +68,7 @@ func (h *Handler) Plugins(c droplet.Context) (any, error) {
 	var ret []string
 	list := plugins.Map()
 	for pluginName := range list {
-		if enable, ok := conf.Plugins[pluginName]; !ok || !enable {
+		if !conf.PluginEnabled(pluginName) {
 			continue
 		}
 
@@ -36,7 +36,7 @@ type Handler struct {
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		serverInfoStore: store.GetStore(store.HubKeyServerInfo),
+		serverInfoStore: store.NewContextStore(store.HubKeyServerInfo),
 	}, nil
 }
 
@@ -30,6 +30,7 @@ import (
 	wgin "github.com/shiningrush/droplet/wrapper/gin"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/utils"
@@ -38,14 +39,14 @@ import (
 type Handler struct {
 	serviceStore  store.Interface
 	upstreamStore store.Interface
-	routeStore    store.Interface
+	graphStores   graph.Stores
 }
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		serviceStore:  store.GetStore(store.HubKeyService),
-		upstreamStore: store.GetStore(store.HubKeyUpstream),
-		routeStore:    store.GetStore(store.HubKeyRoute),
+		serviceStore:  store.NewContextStore(store.HubKeyService),
+		upstreamStore: store.NewContextStore(store.HubKeyUpstream),
+		graphStores:   graph.DefaultStores(),
 	}, nil
 }
 
@@ -241,36 +242,19 @@ func (h *Handler) Update(c droplet.Context) (any, error) {
 }
 
 type BatchDelete struct {
-	IDs string `auto_read:"ids,path"`
+	IDs     string `auto_read:"ids,path"`
+	Cascade bool   `auto_read:"cascade,query"`
 }
 
 func (h *Handler) BatchDelete(c droplet.Context) (any, error) {
 	input := c.Input().(*BatchDelete)
 	ids := strings.Split(input.IDs, ",")
-	mp := make(map[string]struct{})
-	for _, id := range ids {
-		mp[id] = struct{}{}
+	if input.Cascade {
+		return handler.CascadeDelete(c, h.graphStores, store.HubKeyService, ids)
 	}
 
-	ret, err := h.routeStore.List(c.Context(), store.ListInput{
-		Predicate: func(obj any) bool {
-			route := obj.(*entity.Route)
-			if _, exist := mp[utils.InterfaceToString(route.ServiceID)]; exist {
-				return true
-			}
-
-			return false
-		},
-		PageSize:   0,
-		PageNumber: 0,
-	})
-	if err != nil {
-		return handler.SpecCodeResponse(err), err
-	}
-
-	if ret.TotalSize > 0 {
-		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest},
-			fmt.Errorf("route: %s is using this service", ret.Rows[0].(*entity.Route).Name)
+	if ret, err := handler.CheckUnused(c, h.graphStores, store.HubKeyService, ids); err != nil {
+		return ret, err
 	}
 
 	if err := h.serviceStore.BatchDelete(c.Context(), ids); err != nil {
@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 )
@@ -909,27 +910,23 @@ func TestServices_Delete(t *testing.T) {
 				assert.Equal(t, tc.wantInput, input)
 			}).Return(tc.giveErr)
 
-			routeStore := &store.MockInterface{}
-			routeStore.On("List", mock.Anything).Return(func(input store.ListInput) *store.ListOutput {
-				var returnData []any
-				for _, c := range tc.routeMockData {
-					if input.Predicate(c) {
-						if input.Format == nil {
-							returnData = append(returnData, c)
-							continue
-						}
-
-						returnData = append(returnData, input.Format(c))
-					}
-				}
+			serviceStore.On("List", mock.Anything).Return(&store.ListOutput{
+				Rows: []any{
+					&entity.Service{BaseInfo: entity.BaseInfo{ID: "s1"}},
+					&entity.Service{BaseInfo: entity.BaseInfo{ID: "s2"}},
+				},
+				TotalSize: 2,
+			}, nil)
 
-				return &store.ListOutput{
-					Rows:      returnData,
-					TotalSize: len(returnData),
-				}
-			}, tc.routeMockErr)
+			var routes []any
+			for _, r := range tc.routeMockData {
+				routes = append(routes, r)
+			}
+			routeStore := &store.MockInterface{}
+			routeStore.On("List", mock.Anything).Return(&store.ListOutput{Rows: routes, TotalSize: len(routes)}, tc.routeMockErr)
 
-			h := Handler{serviceStore: serviceStore, routeStore: routeStore}
+			graphStores := graph.Stores{store.HubKeyService: serviceStore, store.HubKeyRoute: routeStore}
+			h := Handler{serviceStore: serviceStore, graphStores: graphStores}
 			ctx := droplet.NewContext()
 			ctx.SetInput(tc.giveInput)
 			ret, err := h.BatchDelete(ctx)
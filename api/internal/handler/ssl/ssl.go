@@ -46,7 +46,7 @@ type Handler struct {
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		sslStore: store.GetStore(store.HubKeySsl),
+		sslStore: store.NewContextStore(store.HubKeySsl),
 	}, nil
 }
 
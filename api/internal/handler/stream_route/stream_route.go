@@ -29,6 +29,7 @@ import (
 	wgin "github.com/shiningrush/droplet/wrapper/gin"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/utils"
@@ -37,12 +38,14 @@ import (
 type Handler struct {
 	streamRouteStore store.Interface
 	upstreamStore    store.Interface
+	graphStores      graph.Stores
 }
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		streamRouteStore: store.GetStore(store.HubKeyStreamRoute),
-		upstreamStore:    store.GetStore(store.HubKeyUpstream),
+		streamRouteStore: store.NewContextStore(store.HubKeyStreamRoute),
+		upstreamStore:    store.NewContextStore(store.HubKeyUpstream),
+		graphStores:      graph.DefaultStores(),
 	}, nil
 }
 
@@ -172,12 +175,17 @@ func (h *Handler) Update(c droplet.Context) (any, error) {
 }
 
 type BatchDelete struct {
-	IDs string `auto_read:"ids,path"`
+	IDs     string `auto_read:"ids,path"`
+	Cascade bool   `auto_read:"cascade,query"`
 }
 
 func (h *Handler) BatchDelete(c droplet.Context) (any, error) {
 	input := c.Input().(*BatchDelete)
 
+	if input.Cascade {
+		return handler.CascadeDelete(c, h.graphStores, store.HubKeyStreamRoute, strings.Split(input.IDs, ","))
+	}
+
 	if err := h.streamRouteStore.BatchDelete(c.Context(), strings.Split(input.IDs, ",")); err != nil {
 		return handler.SpecCodeResponse(err), err
 	}
@@ -36,7 +36,7 @@ type Handler struct {
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		systemConfig: store.GetStore(store.HubKeySystemConfig),
+		systemConfig: store.NewContextStore(store.HubKeySystemConfig),
 	}, nil
 }
 
@@ -18,7 +18,6 @@ package upstream
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"reflect"
 	"strings"
@@ -30,6 +29,7 @@ import (
 	wgin "github.com/shiningrush/droplet/wrapper/gin"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/utils"
@@ -37,18 +37,14 @@ import (
 )
 
 type Handler struct {
-	upstreamStore    store.Interface
-	routeStore       store.Interface
-	serviceStore     store.Interface
-	streamRouteStore store.Interface
+	upstreamStore store.Interface
+	graphStores   graph.Stores
 }
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		upstreamStore:    store.GetStore(store.HubKeyUpstream),
-		routeStore:       store.GetStore(store.HubKeyRoute),
-		serviceStore:     store.GetStore(store.HubKeyService),
-		streamRouteStore: store.GetStore(store.HubKeyStreamRoute),
+		upstreamStore: store.NewContextStore(store.HubKeyUpstream),
+		graphStores:   graph.DefaultStores(),
 	}, nil
 }
 
@@ -219,82 +215,23 @@ func (h *Handler) Update(c droplet.Context) (any, error) {
 }
 
 type BatchDelete struct {
-	IDs string `auto_read:"ids,path"`
+	IDs     string `auto_read:"ids,path"`
+	Cascade bool   `auto_read:"cascade,query"`
 }
 
 func (h *Handler) BatchDelete(c droplet.Context) (any, error) {
 	input := c.Input().(*BatchDelete)
 
 	ids := strings.Split(input.IDs, ",")
-	mp := make(map[string]struct{})
-	for _, id := range ids {
-		mp[id] = struct{}{}
+	if input.Cascade {
+		return handler.CascadeDelete(c, h.graphStores, store.HubKeyUpstream, ids)
 	}
 
-	ret, err := h.routeStore.List(c.Context(), store.ListInput{
-		Predicate: func(obj any) bool {
-			route := obj.(*entity.Route)
-			if _, exist := mp[utils.InterfaceToString(route.UpstreamID)]; exist {
-				return true
-			}
-
-			return false
-		},
-		PageSize:   0,
-		PageNumber: 0,
-	})
-	if err != nil {
-		return handler.SpecCodeResponse(err), err
-	}
-
-	if ret.TotalSize > 0 {
-		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest},
-			fmt.Errorf("route: %s is using this upstream", ret.Rows[0].(*entity.Route).Name)
-	}
-
-	ret, err = h.serviceStore.List(c.Context(), store.ListInput{
-		Predicate: func(obj any) bool {
-			service := obj.(*entity.Service)
-			if _, exist := mp[utils.InterfaceToString(service.UpstreamID)]; exist {
-				return true
-			}
-
-			return false
-		},
-		PageSize:   0,
-		PageNumber: 0,
-	})
-	if err != nil {
-		return handler.SpecCodeResponse(err), err
-	}
-
-	if ret.TotalSize > 0 {
-		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest},
-			fmt.Errorf("service: %s is using this upstream", ret.Rows[0].(*entity.Service).Name)
-	}
-
-	ret, err = h.streamRouteStore.List(c.Context(), store.ListInput{
-		Predicate: func(obj any) bool {
-			streamRoute := obj.(*entity.StreamRoute)
-			if _, exist := mp[utils.InterfaceToString(streamRoute.UpstreamID)]; exist {
-				return true
-			}
-
-			return false
-		},
-		PageSize:   0,
-		PageNumber: 0,
-	})
-	if err != nil {
-		return handler.SpecCodeResponse(err), err
-	}
-
-	if ret.TotalSize > 0 {
-		return &data.SpecCodeResponse{StatusCode: http.StatusBadRequest},
-			fmt.Errorf("stream route: %s is using this upstream", ret.Rows[0].(*entity.StreamRoute).ID)
+	if ret, err := handler.CheckUnused(c, h.graphStores, store.HubKeyUpstream, ids); err != nil {
+		return ret, err
 	}
 
-	if err = h.upstreamStore.BatchDelete(c.Context(), ids); err != nil {
+	if err := h.upstreamStore.BatchDelete(c.Context(), ids); err != nil {
 		return handler.SpecCodeResponse(err), err
 	}
 
@@ -30,6 +30,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"github.com/apisix/manager-api/internal/core/entity"
+	"github.com/apisix/manager-api/internal/core/graph"
 	"github.com/apisix/manager-api/internal/core/store"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/utils/consts"
@@ -1505,7 +1506,7 @@ func TestUpstreams_Delete(t *testing.T) {
 		routeMockErr        error
 		serviceMockData     []*entity.Service
 		serviceMockErr      error
-		streamRouteMockData []*entity.Service
+		streamRouteMockData []*entity.StreamRoute
 		streamRouteMockErr  error
 		getCalled           bool
 	}{
@@ -1606,6 +1607,18 @@ func TestUpstreams_Delete(t *testing.T) {
 			wantErr:        errors.New("service list error"),
 			getCalled:      false,
 		},
+		{
+			caseDesc: "delete failed, stream route is using",
+			giveInput: &BatchDelete{
+				IDs: "u1,u2",
+			},
+			streamRouteMockData: []*entity.StreamRoute{
+				{BaseInfo: entity.BaseInfo{ID: "sr1"}, UpstreamID: "u2"},
+			},
+			getCalled: false,
+			wantRet:   &data.SpecCodeResponse{StatusCode: 400},
+			wantErr:   errors.New("stream_route: sr1 is using this upstream"),
+		},
 	}
 
 	for _, tc := range tests {
@@ -1618,67 +1631,37 @@ func TestUpstreams_Delete(t *testing.T) {
 				assert.Equal(t, tc.wantInput, input)
 			}).Return(tc.giveErr)
 
-			routeStore := &store.MockInterface{}
-			routeStore.On("List", mock.Anything).Return(func(input store.ListInput) *store.ListOutput {
-				var returnData []any
-				for _, c := range tc.routeMockData {
-					if input.Predicate(c) {
-						if input.Format == nil {
-							returnData = append(returnData, c)
-							continue
-						}
-
-						returnData = append(returnData, input.Format(c))
-					}
-				}
-
-				return &store.ListOutput{
-					Rows:      returnData,
-					TotalSize: len(returnData),
-				}
-			}, tc.routeMockErr)
+			upstreamStore.On("List", mock.Anything).Return(&store.ListOutput{
+				Rows: []any{
+					&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u1"}},
+					&entity.Upstream{BaseInfo: entity.BaseInfo{ID: "u2"}},
+				},
+				TotalSize: 2,
+			}, nil)
 
+			var routes, services, streamRoutes []any
+			for _, r := range tc.routeMockData {
+				routes = append(routes, r)
+			}
+			for _, s := range tc.serviceMockData {
+				services = append(services, s)
+			}
+			for _, r := range tc.streamRouteMockData {
+				streamRoutes = append(streamRoutes, r)
+			}
+			routeStore := &store.MockInterface{}
+			routeStore.On("List", mock.Anything).Return(&store.ListOutput{Rows: routes, TotalSize: len(routes)}, tc.routeMockErr)
 			serviceStore := &store.MockInterface{}
-			serviceStore.On("List", mock.Anything).Return(func(input store.ListInput) *store.ListOutput {
-				var returnData []any
-				for _, c := range tc.serviceMockData {
-					if input.Predicate(c) {
-						if input.Format == nil {
-							returnData = append(returnData, c)
-							continue
-						}
-
-						returnData = append(returnData, input.Format(c))
-					}
-				}
-
-				return &store.ListOutput{
-					Rows:      returnData,
-					TotalSize: len(returnData),
-				}
-			}, tc.serviceMockErr)
-
+			serviceStore.On("List", mock.Anything).Return(&store.ListOutput{Rows: services, TotalSize: len(services)}, tc.serviceMockErr)
 			streamRouteStore := &store.MockInterface{}
-			streamRouteStore.On("List", mock.Anything).Return(func(input store.ListInput) *store.ListOutput {
-				var returnData []any
-				for _, c := range tc.streamRouteMockData {
-					if input.Predicate(c) {
-						if input.Format == nil {
-							returnData = append(returnData, c)
-							continue
-						}
-
-						returnData = append(returnData, input.Format(c))
-					}
-				}
-
-				return &store.ListOutput{
-					Rows:      returnData,
-					TotalSize: len(returnData),
-				}
-			}, tc.streamRouteMockErr)
+			streamRouteStore.On("List", mock.Anything).Return(&store.ListOutput{Rows: streamRoutes, TotalSize: len(streamRoutes)}, tc.streamRouteMockErr)
 
-			h := Handler{upstreamStore: upstreamStore, routeStore: routeStore, serviceStore: serviceStore, streamRouteStore: streamRouteStore}
+			h := Handler{upstreamStore: upstreamStore, graphStores: graph.Stores{
+				store.HubKeyUpstream:    upstreamStore,
+				store.HubKeyRoute:       routeStore,
+				store.HubKeyService:     serviceStore,
+				store.HubKeyStreamRoute: streamRouteStore,
+			}}
 			ctx := droplet.NewContext()
 			ctx.SetInput(tc.giveInput)
 			ret, err := h.BatchDelete(ctx)
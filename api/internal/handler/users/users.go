@@ -22,7 +22,7 @@ type Handler struct {
 
 func NewHandler() (handler.RouteRegister, error) {
 	return &Handler{
-		userStore: store.GetStore(store.HubKeyServerInfo),
+		userStore: store.NewContextStore(store.HubKeyServerInfo),
 	}, nil
 }
 
@@ -26,7 +26,12 @@ import (
 	"github.com/apisix/manager-api/internal/conf"
 )
 
-var logger *zap.SugaredLogger
+var (
+	logger *zap.SugaredLogger
+	// errorLevel is the level of the error log, it changes when the
+	// configuration is reloaded.
+	errorLevel = zap.NewAtomicLevel()
+)
 
 // TODO: we should no longer use init() function after remove all handler's integration tests
 // ENV=test is for integration tests only, other ENV should call "InitLogger" explicitly
@@ -46,7 +51,8 @@ func GetLogger(logType Type) *zap.SugaredLogger {
 	skip := 2
 	writeSyncer := fileWriter(logType)
 	encoder := getEncoder(logType)
-	logLevel := getLogLevel()
+	ReloadLevel()
+	var logLevel zapcore.LevelEnabler = errorLevel
 	if logType == AccessLog {
 		logLevel = zapcore.InfoLevel
 		skip = 0
@@ -59,9 +65,14 @@ func GetLogger(logType Type) *zap.SugaredLogger {
 	return zapLogger.Sugar()
 }
 
-func getLogLevel() zapcore.LevelEnabler {
+// ReloadLevel sets the level of the error log from the configuration
+func ReloadLevel() {
+	errorLevel.SetLevel(getLogLevel())
+}
+
+func getLogLevel() zapcore.Level {
 	level := zapcore.WarnLevel
-	switch conf.ErrorLogLevel {
+	switch conf.GetErrorLogLevel() {
 	case "debug":
 		level = zapcore.DebugLevel
 	case "info":
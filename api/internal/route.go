@@ -29,15 +29,19 @@ import (
 	"github.com/apisix/manager-api/internal/filter"
 	"github.com/apisix/manager-api/internal/handler"
 	"github.com/apisix/manager-api/internal/handler/authentication"
+	"github.com/apisix/manager-api/internal/handler/cluster"
 	"github.com/apisix/manager-api/internal/handler/consumer"
 	"github.com/apisix/manager-api/internal/handler/data_loader"
+	"github.com/apisix/manager-api/internal/handler/dependency"
 	"github.com/apisix/manager-api/internal/handler/global_rule"
 	"github.com/apisix/manager-api/internal/handler/healthz"
 	"github.com/apisix/manager-api/internal/handler/label"
 	"github.com/apisix/manager-api/internal/handler/migrate"
 	"github.com/apisix/manager-api/internal/handler/plugin_config"
+	"github.com/apisix/manager-api/internal/handler/promote"
 	"github.com/apisix/manager-api/internal/handler/proto"
 	"github.com/apisix/manager-api/internal/handler/route"
+	"github.com/apisix/manager-api/internal/handler/route_match"
 	"github.com/apisix/manager-api/internal/handler/schema"
 	"github.com/apisix/manager-api/internal/handler/server_info"
 	"github.com/apisix/manager-api/internal/handler/service"
@@ -56,6 +60,9 @@ func SetUpRouter() *gin.Engine {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	r := gin.New()
+	// the gin context resolves the request context, which holds the stores
+	// of the cluster the request is served by
+	r.ContextWithFallback = true
 	logger := log.GetLogger(log.AccessLog)
 	// security
 	r.Use(filter.RequestLogHandler(logger), filter.IPFilter(), filter.InvalidRequest())
@@ -68,6 +75,7 @@ func SetUpRouter() *gin.Engine {
 		r.Use(filter.Oidc())
 	}
 	r.Use(filter.Authentication())
+	r.Use(filter.Cluster())
 
 	// misc
 	r.Use(gzip.Gzip(gzip.DefaultCompression), filter.CORS(), filter.RequestId(), filter.SchemaCheck(), filter.RecoverHandler())
@@ -97,6 +105,10 @@ func SetUpRouter() *gin.Engine {
 		proto.NewHandler,
 		stream_route.NewHandler,
 		system_config.NewHandler,
+		route_match.NewHandler,
+		dependency.NewHandler,
+		promote.NewHandler,
+		cluster.NewHandler,
 	}
 
 	for i := range factories {
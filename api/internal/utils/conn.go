@@ -0,0 +1,43 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package utils
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+type connKey struct{}
+
+// WithConn returns a context of the requests read from the connection, it
+// is the ConnContext of the servers.
+func WithConn(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// ClearDeadlines lifts the read and write timeouts of the server from the
+// connection of the request of ctx, for requests whose duration depends on
+// the size of the configuration such as imports and exports. The server
+// sets the timeouts again for the next request of the connection.
+func ClearDeadlines(ctx context.Context) error {
+	c, ok := ctx.Value(connKey{}).(net.Conn)
+	if !ok {
+		return nil
+	}
+	return c.SetDeadline(time.Time{})
+}
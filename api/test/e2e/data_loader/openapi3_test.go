@@ -107,8 +107,9 @@ var _ = Describe("OpenAPI 3", func() {
 
 			r = r.Get("data")
 			for s, result := range r.Map() {
+				// the methods with a request body are not merged, their schemas differ
 				if s == "route" {
-					Expect(result.Get("total").Uint()).To(Equal(uint64(3)))
+					Expect(result.Get("total").Uint()).To(Equal(uint64(5)))
 					Expect(result.Get("failed").Uint()).To(Equal(uint64(0)))
 				}
 				if s == "upstream" {
@@ -19,7 +19,9 @@ package migrate_test
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"hash/crc32"
 	"net/http"
@@ -35,25 +37,30 @@ const (
 	checksumLength = 4 // 4 bytes (uint32)
 )
 
-type AllData struct {
-	Consumers     []any
-	Routes        []any
-	Services      []any
-	SSLs          []any
-	Upstreams     []any
-	Scripts       []any
-	GlobalPlugins []any
-	PluginConfigs []any
+type conflict struct {
+	Type   string `json:"type"`
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
 }
 
 type response struct {
 	Code    int    `json:"Code"`
 	Message string `json:"Message"`
 	Data    struct {
-		ConflictItems AllData `json:"ConflictItems"`
+		Conflicts []conflict `json:"conflicts"`
 	} `json:"Data"`
 }
 
+func conflictCount(rsp *response, typ string) int {
+	count := 0
+	for _, c := range rsp.Data.Conflicts {
+		if c.Type == typ && c.Reason == "key" {
+			count++
+		}
+	}
+	return count
+}
+
 var _ = Describe("Migrate", func() {
 	var exportData []byte
 
@@ -151,9 +158,42 @@ var _ = Describe("Migrate", func() {
 		resp := req.Expect()
 		resp.Status(http.StatusOK)
 		exportData = []byte(resp.Body().Raw())
-		data := exportData[:len(exportData)-checksumLength]
-		checksum := binary.BigEndian.Uint32(exportData[len(exportData)-checksumLength:])
-		Expect(checksum).Should(Equal(crc32.ChecksumIEEE(data)))
+		backup := &struct {
+			Header struct {
+				FormatVersion int            `json:"format_version"`
+				Counts        map[string]int `json:"counts"`
+				SHA256        string         `json:"sha256"`
+			} `json:"header"`
+			Data json.RawMessage `json:"data"`
+		}{}
+		err := json.Unmarshal(exportData, backup)
+		Expect(err).Should(BeNil())
+		Expect(backup.Header.FormatVersion).Should(Equal(1))
+		Expect(backup.Header.Counts["route"]).Should(Equal(1))
+		sum := sha256.Sum256(backup.Data)
+		Expect(backup.Header.SHA256).Should(Equal(hex.EncodeToString(sum[:])))
+	})
+
+	It("import legacy config with checksum", func() {
+		backup := &struct {
+			Data json.RawMessage `json:"data"`
+		}{}
+		err := json.Unmarshal(exportData, backup)
+		Expect(err).Should(BeNil())
+		checksum := make([]byte, checksumLength)
+		binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(backup.Data))
+		legacyData := append([]byte(backup.Data), checksum...)
+
+		req := base.ManagerApiExpect().POST("/apisix/admin/migrate/import")
+		req.WithMultipart().WithForm(map[string]string{"mode": "skip"})
+		req.WithMultipart().WithFile("file", "apisix-config.bak", bytes.NewBuffer(legacyData))
+		req.WithHeader("Authorization", base.GetToken())
+		resp := req.Expect()
+		resp.Status(http.StatusOK)
+		rsp := &response{}
+		err = json.Unmarshal([]byte(resp.Body().Raw()), rsp)
+		Expect(err).Should(BeNil())
+		Expect(rsp.Code).Should(Equal(0))
 	})
 
 	It("import config conflict and return", func() {
@@ -168,9 +208,9 @@ var _ = Describe("Migrate", func() {
 		err := json.Unmarshal([]byte(resp.Body().Raw()), rsp)
 		Expect(err).Should(BeNil())
 		Expect(rsp.Code).Should(Equal(20001))
-		Expect(len(rsp.Data.ConflictItems.Routes)).Should(Equal(1))
-		Expect(len(rsp.Data.ConflictItems.Upstreams)).Should(Equal(1))
-		Expect(len(rsp.Data.ConflictItems.Services)).Should(Equal(1))
+		Expect(conflictCount(rsp, "route")).Should(Equal(1))
+		Expect(conflictCount(rsp, "upstream")).Should(Equal(1))
+		Expect(conflictCount(rsp, "service")).Should(Equal(1))
 	})
 
 	It("import config conflict and skip", func() {
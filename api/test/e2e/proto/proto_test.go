@@ -237,7 +237,7 @@ var _ = Describe("Proto", func() {
 				Method:       http.MethodDelete,
 				Path:         "/apisix/admin/proto/1",
 				Headers:      map[string]string{"Authorization": base.GetToken()},
-				ExpectBody:   "proto used check invalid: route",
+				ExpectBody:   "route: test_route is using this proto",
 				ExpectStatus: http.StatusBadRequest,
 			})
 		}),
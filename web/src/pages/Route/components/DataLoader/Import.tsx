@@ -42,7 +42,7 @@ type Props = {
   onClose: (finish: boolean) => void;
 };
 
-type ImportType = 'openapi3' | 'openapi_legacy';
+type ImportType = 'openapi3' | 'swagger2' | 'postman' | 'har' | 'kong' | 'k8s' | 'openapi_legacy';
 type ImportState = 'import' | 'result';
 type ImportResult = {
   success: boolean;
@@ -73,8 +73,13 @@ const Option: React.FC<{
 }> = ({ type }) => {
   switch (type) {
     case 'openapi_legacy':
+    case 'kong':
+    case 'k8s':
       return <></>;
     case 'openapi3':
+    case 'swagger2':
+    case 'postman':
+    case 'har':
     default:
       return <OpenAPI3 />;
   }
@@ -169,6 +174,21 @@ const DataLoaderImport: React.FC<Props> = (props) => {
                   <Select.Option value="openapi3">
                     {formatMessage({ id: 'page.route.data_loader.types.openapi3' })}
                   </Select.Option>
+                  <Select.Option value="swagger2">
+                    {formatMessage({ id: 'page.route.data_loader.types.swagger2' })}
+                  </Select.Option>
+                  <Select.Option value="postman">
+                    {formatMessage({ id: 'page.route.data_loader.types.postman' })}
+                  </Select.Option>
+                  <Select.Option value="har">
+                    {formatMessage({ id: 'page.route.data_loader.types.har' })}
+                  </Select.Option>
+                  <Select.Option value="kong">
+                    {formatMessage({ id: 'page.route.data_loader.types.kong' })}
+                  </Select.Option>
+                  <Select.Option value="k8s">
+                    {formatMessage({ id: 'page.route.data_loader.types.k8s' })}
+                  </Select.Option>
                   <Select.Option value="openapi_legacy" disabled>
                     {formatMessage({ id: 'page.route.data_loader.types.openapi_legacy' })}
                   </Select.Option>
@@ -208,6 +208,11 @@ export default {
   'page.route.data_loader.import': 'İçeri Aktar',
   'page.route.data_loader.import_panel': 'Veriyi içe Aktar',
   'page.route.data_loader.types.openapi3': 'OpenAPI 3',
+  'page.route.data_loader.types.swagger2': 'Swagger 2.0',
+  'page.route.data_loader.types.postman': 'Postman Collection',
+  'page.route.data_loader.types.har': 'HAR',
+  'page.route.data_loader.types.kong': 'Kong (decK)',
+  'page.route.data_loader.types.k8s': 'Kubernetes CRD',
   'page.route.data_loader.types.openapi_legacy': 'OpenAPI 3 Eski Sürüm',
   'page.route.data_loader.labels.loader_type': 'Veri Yükleyici Tipi',
   'page.route.data_loader.labels.task_name': 'İş Adı',
@@ -200,6 +200,11 @@ export default {
   'page.route.data_loader.import': '导入',
   'page.route.data_loader.import_panel': '导入路由',
   'page.route.data_loader.types.openapi3': 'OpenAPI 3',
+  'page.route.data_loader.types.swagger2': 'Swagger 2.0',
+  'page.route.data_loader.types.postman': 'Postman Collection',
+  'page.route.data_loader.types.har': 'HAR',
+  'page.route.data_loader.types.kong': 'Kong (decK)',
+  'page.route.data_loader.types.k8s': 'Kubernetes CRD',
   'page.route.data_loader.types.openapi_legacy': 'OpenAPI 3 旧版',
   'page.route.data_loader.labels.loader_type': '数据加载器类型',
   'page.route.data_loader.labels.task_name': '导入任务名称',